@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/analyzer"
+	"github.com/k8smed/k8smed/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+// agentRetryPeriod is how long the agent waits before starting again after it failed
+// to connect to the cluster
+const agentRetryPeriod = 10 * time.Second
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run in the cluster, scanning periodically and whenever pods change",
+	Long: `Run as a long-lived controller inside the cluster. The agent scans the selected namespaces
+every --interval, like the scan command, and analyzes pods again as soon as they or their events
+change. Findings are logged as they appear (+) and are resolved (-).
+
+Replicas elect a leader through a Lease, so only one of them scans at a time. /healthz and /readyz
+are served on --health-addr for the liveness and readiness probes.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		options, err := agentOptionsFromFlags(cmd)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		engine, names, err := newEngine(ctx, cmd)
+		if err != nil {
+			fmt.Printf("Error loading analyzers: %v\n", err)
+			os.Exit(1)
+		}
+
+		state := &agentState{}
+		server, err := startHealthServer(options.healthAddr, state)
+		if err != nil {
+			fmt.Printf("Error starting health server: %v\n", err)
+			os.Exit(1)
+		}
+
+		lead := func(ctx context.Context) {
+			state.terms.Add(1)
+			defer state.terms.Add(-1)
+			state.scanned.Store(false)
+			runAgent(ctx, engine, names, options, state)
+		}
+		if options.leaderElection == nil {
+			lead(ctx)
+		} else {
+			fmt.Printf("Waiting to acquire lease %s/%s as %s\n", options.leaderElection.Namespace,
+				options.leaderElection.Name, options.leaderElection.Identity)
+			if err := collector.RunWithLeaderElection(ctx, cfg.KubeConfig, *options.leaderElection, lead); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to stop health server: %v\n", err)
+		}
+		fmt.Println("Agent stopped")
+	},
+}
+
+// agentOptions is the scope and schedule of the agent
+type agentOptions struct {
+	namespaces     []string
+	kinds          []string
+	interval       time.Duration
+	debounce       time.Duration
+	metrics        bool // read pod usage from metrics-server
+	healthAddr     string
+	leaderElection *collector.LeaderElectionOptions // nil without leader election
+}
+
+// agentOptionsFromFlags reads the agent options from the command's flags
+func agentOptionsFromFlags(cmd *cobra.Command) (agentOptions, error) {
+	namespaces, _ := cmd.Flags().GetStringSlice("namespace")
+	kindArgs, _ := cmd.Flags().GetStringSlice("kinds")
+	kinds, err := parseScanKinds(kindArgs)
+	if err != nil {
+		return agentOptions{}, err
+	}
+
+	options := agentOptions{namespaces: namespaces, kinds: kinds}
+	options.interval, _ = cmd.Flags().GetDuration("interval")
+	options.debounce, _ = cmd.Flags().GetDuration("debounce")
+	options.metrics, _ = cmd.Flags().GetBool("metrics")
+	options.healthAddr, _ = cmd.Flags().GetString("health-addr")
+	if options.interval <= 0 {
+		return agentOptions{}, fmt.Errorf("--interval must be positive")
+	}
+
+	if leaderElect, _ := cmd.Flags().GetBool("leader-elect"); !leaderElect {
+		return options, nil
+	}
+	leaseNamespace, _ := cmd.Flags().GetString("lease-namespace")
+	if leaseNamespace == "" {
+		leaseNamespace = os.Getenv("POD_NAMESPACE")
+	}
+	if leaseNamespace == "" {
+		leaseNamespace = "k8smed-system"
+	}
+	leaseName, _ := cmd.Flags().GetString("lease-name")
+	// The hostname of a pod is its name
+	identity, err := os.Hostname()
+	if err != nil {
+		return agentOptions{}, fmt.Errorf("failed to get the hostname for leader election: %w", err)
+	}
+	options.leaderElection = &collector.LeaderElectionOptions{
+		Namespace: leaseNamespace,
+		Name:      leaseName,
+		Identity:  identity,
+	}
+	return options, nil
+}
+
+// agentState is what the health endpoints report
+type agentState struct {
+	// terms counts the leadership terms running, which overlap briefly when the lease
+	// is lost and acquired again
+	terms atomic.Int32
+	// scanned is set once the leader has completed its first scan
+	scanned atomic.Bool
+}
+
+// startHealthServer serves /healthz, which answers while the process runs, and
+// /readyz, which fails while the leader has not completed its first scan. Replicas
+// waiting for the lease are ready.
+func startHealthServer(addr string, state *agentState) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		if state.terms.Load() > 0 && !state.scanned.Load() {
+			http.Error(w, "waiting for the first scan", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Warning: health server stopped: %v\n", err)
+		}
+	}()
+	return server, nil
+}
+
+// runAgent scans until ctx is done, starting again after agentRetryPeriod when it
+// cannot connect to the cluster
+func runAgent(ctx context.Context, engine *analyzer.Engine, names []string, options agentOptions, state *agentState) {
+	for {
+		err := runAgentOnce(ctx, engine, names, options, state)
+		if err == nil || ctx.Err() != nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v; retrying in %s\n", err, agentRetryPeriod)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(agentRetryPeriod):
+		}
+	}
+}
+
+// runAgentOnce scans the namespaces every interval and analyzes the pods that changed
+// once per debounce interval, until ctx is done
+func runAgentOnce(ctx context.Context, engine *analyzer.Engine, names []string, options agentOptions, state *agentState) error {
+	// The cache is limited to a namespace only when a single one is scanned
+	cacheNamespace := ""
+	if len(options.namespaces) == 1 {
+		cacheNamespace = options.namespaces[0]
+	}
+	k8sCollector, err := collector.NewCachedCollector(ctx, cfg.KubeConfig, collector.CacheOptions{Namespace: cacheNamespace})
+	if err != nil {
+		return fmt.Errorf("failed to create collector: %w", err)
+	}
+
+	pending := newPendingChanges()
+	if slices.Contains(options.kinds, "Pod") {
+		err = k8sCollector.WatchPods(func(change collector.ResourceChange) {
+			if len(options.namespaces) == 0 || slices.Contains(options.namespaces, change.Namespace) {
+				pending.add(change)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("failed to watch pods: %w", err)
+		}
+	}
+
+	tracker := analyzer.NewFindingTracker()
+	scan := func() {
+		if scanFindings(ctx, k8sCollector, engine, names, tracker, options) {
+			state.scanned.Store(true)
+		}
+	}
+
+	scan()
+	ticker := time.NewTicker(options.interval)
+	defer ticker.Stop()
+
+	watchFindings(ctx, k8sCollector, engine, names, tracker, pending, options.debounce, options.metrics, ticker.C, scan)
+	return nil
+}
+
+// scanFindings scans the agent's namespaces, prints how the findings changed and reports
+// whether the scan was analyzed. Healthy resources are listed but not collected, which
+// resolves their findings; changed pods are judged the same way by analyzeChanges.
+func scanFindings(ctx context.Context, source resourceSource, engine *analyzer.Engine, names []string,
+	tracker *analyzer.FindingTracker, options agentOptions) bool {
+	resources, scanned := scanResources(ctx, source, options.namespaces, options.kinds, options.metrics)
+	report, err := engine.Run(ctx, &analyzer.AnalysisContext{Resources: resources}, names...)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to analyze scanned resources: %v\n", err)
+		}
+		return false
+	}
+	printFindingChanges(tracker.Update(scanned, report.Details))
+	fmt.Printf("%s scanned %d resources, %d unhealthy, %d finding(s)\n", time.Now().Format("15:04:05"),
+		len(scanned), len(resources), tracker.Count())
+	return true
+}
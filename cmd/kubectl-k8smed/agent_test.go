@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/analyzer"
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// stubSource serves pod summaries and collected pods, recording which pods are collected
+type stubSource struct {
+	pods      []collector.ResourceSummary
+	collected []string
+}
+
+func (s *stubSource) Summarize(ctx context.Context, kind, namespace string) ([]collector.ResourceSummary, error) {
+	if kind != "Pod" {
+		return nil, fmt.Errorf("unexpected kind %s", kind)
+	}
+	return s.pods, nil
+}
+
+// CollectResource returns every pod in CrashLoopBackOff, so a healthy pod that is
+// collected anyway shows up as a finding
+func (s *stubSource) CollectResource(ctx context.Context, resourceType collector.ResourceType, options collector.CollectionOptions) (*collector.ResourceData, error) {
+	s.collected = append(s.collected, options.Namespace+"/"+options.ResourceName)
+	return &collector.ResourceData{
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: options.ResourceName, Namespace: options.Namespace},
+		Status: map[string]string{
+			"phase":                    "Running",
+			"container.0.name":         "app",
+			"container.0.state":        "waiting",
+			"container.0.reason":       "CrashLoopBackOff",
+			"container.0.ready":        "false",
+			"container.0.restartCount": "5",
+		},
+	}, nil
+}
+
+func TestAgent_ScanThenChange(t *testing.T) {
+	ctx := context.Background()
+	web := collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "shop"}
+	api := collector.ResourceInfo{Kind: "Pod", Name: "api", Namespace: "shop"}
+	source := &stubSource{pods: []collector.ResourceSummary{
+		{ResourceInfo: web, Healthy: true},
+		{ResourceInfo: api, Healthy: false},
+	}}
+	engine := analyzer.NewEngine(analyzer.NewRegistry(), analyzer.EngineOptions{})
+	names := []string{"PodAnalyzer"}
+	tracker := analyzer.NewFindingTracker()
+	options := agentOptions{namespaces: []string{"shop"}, kinds: []string{"Pod"}}
+
+	if !scanFindings(ctx, source, engine, names, tracker, options) {
+		t.Fatal("Expected the scan to be analyzed")
+	}
+	findings := tracker.Count()
+	if findings == 0 || len(source.collected) != 1 || source.collected[0] != "shop/api" {
+		t.Fatalf("Expected only api to be collected and flagged, got %d finding(s) from %v", findings, source.collected)
+	}
+
+	// A change to the healthy pod is judged like the scan did, so nothing flaps
+	source.collected = nil
+	appeared, resolved := analyzeChanges(ctx, source, engine, names, tracker, map[string]collector.ResourceChange{
+		"shop/web": {ResourceInfo: web, Healthy: true},
+		"shop/api": {ResourceInfo: api},
+	}, false)
+	if len(appeared) != 0 || len(resolved) != 0 || tracker.Count() != findings {
+		t.Errorf("Expected no finding to change, got appeared %+v, resolved %+v", appeared, resolved)
+	}
+	if len(source.collected) != 1 || source.collected[0] != "shop/api" {
+		t.Errorf("Expected only the unhealthy pod to be collected, got %v", source.collected)
+	}
+
+	// api recovers, which resolves its findings
+	_, resolved = analyzeChanges(ctx, source, engine, names, tracker, map[string]collector.ResourceChange{
+		"shop/api": {ResourceInfo: api, Healthy: true},
+	}, false)
+	if len(resolved) != findings || tracker.Count() != 0 {
+		t.Errorf("Expected the findings of api to be resolved, got %d resolved, %d tracked", len(resolved), tracker.Count())
+	}
+}
+
+func TestPendingChanges(t *testing.T) {
+	pending := newPendingChanges()
+
+	// More changes than any buffer would hold are added without a reader
+	for i := 0; i < 1000; i++ {
+		pending.add(collector.ResourceChange{ResourceInfo: collector.ResourceInfo{
+			Kind: "Pod", Name: fmt.Sprintf("web-%d", i%300), Namespace: "shop",
+		}, Healthy: i < 700})
+	}
+	if len(pending.notify) != 1 {
+		t.Errorf("Expected a single pending notification, got %d", len(pending.notify))
+	}
+
+	changes := pending.take()
+	if len(changes) != 300 {
+		t.Fatalf("Expected the changes to be coalesced by pod, got %d", len(changes))
+	}
+	// web-0 last changed at i=900, after it became unhealthy
+	if changes["shop/web-0"].Healthy {
+		t.Errorf("Expected the latest change of web-0 to be kept, got %+v", changes["shop/web-0"])
+	}
+	if len(pending.take()) != 0 {
+		t.Errorf("Expected take to start gathering anew")
+	}
+}
+
+func TestWatchFindings(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	source := &stubSource{}
+	engine := analyzer.NewEngine(analyzer.NewRegistry(), analyzer.EngineOptions{})
+	tracker := analyzer.NewFindingTracker()
+
+	pending := newPendingChanges()
+	pending.add(collector.ResourceChange{ResourceInfo: collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "shop"}, Healthy: true})
+	pending.add(collector.ResourceChange{ResourceInfo: collector.ResourceInfo{Kind: "Pod", Name: "api", Namespace: "shop"}})
+
+	scans := 0
+	ticks := make(chan time.Time, 1)
+	ticks <- time.Now()
+	watchFindings(ctx, source, engine, []string{"PodAnalyzer"}, tracker, pending, 10*time.Millisecond, false, ticks, func() { scans++ })
+
+	if scans != 1 {
+		t.Errorf("Expected the tick to scan once, got %d", scans)
+	}
+	if len(source.collected) != 1 || source.collected[0] != "shop/api" || tracker.Count() == 0 {
+		t.Errorf("Expected the changed unhealthy pod to be analyzed once, got %v and %d finding(s)", source.collected, tracker.Count())
+	}
+}
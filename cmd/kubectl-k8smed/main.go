@@ -5,11 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/k8smed/k8smed/pkg/ai/anonymizer"
 	"github.com/k8smed/k8smed/pkg/ai/llm"
+	"github.com/k8smed/k8smed/pkg/analyzer"
+	"github.com/k8smed/k8smed/pkg/analyzer/rules"
+	"github.com/k8smed/k8smed/pkg/collector"
 	"github.com/k8smed/k8smed/pkg/config"
+	"github.com/k8smed/k8smed/pkg/snapshot"
 	"github.com/spf13/cobra"
 )
 
@@ -65,13 +75,32 @@ The analysis will include diagnostic information and potential remediation steps
 		// Create a context with a reasonable timeout
 		ctx := context.Background()
 
-		// If anonymize is enabled, anonymize the query
+		// Run the analyzers on the named resources first so the LLM can build on their findings
+		findings := ""
+		resourceArgs, _ := cmd.Flags().GetStringSlice("resource")
+		if snapshotPath, _ := cmd.Flags().GetString("from-snapshot"); len(resourceArgs) > 0 || snapshotPath != "" {
+			analysisCtx, report, err := runAnalyzers(ctx, cmd, query, resourceArgs)
+			if err != nil {
+				fmt.Printf("Error analyzing resources: %v\n", err)
+				os.Exit(1)
+			}
+			printReport(analysisCtx.Resources, report)
+			findings = summarizeFindings(analysisCtx.Resources, report.RootCauses)
+		}
+
+		// If anonymize is enabled, anonymize the query and the findings
 		if anonymize {
 			anon := anonymizer.NewAnonymizer()
 			query = anon.Anonymize(query)
+			findings = anon.Anonymize(findings)
 			fmt.Printf("Anonymized query: %s\n", query)
 		}
 
+		content := query
+		if findings != "" {
+			content += "\n\nFindings of the k8smed analyzers:\n" + findings
+		}
+
 		// Create a simple completion request
 		req := llm.CompletionRequest{
 			Model: cfg.AIModel,
@@ -84,7 +113,7 @@ Provide clear explanations and suggest remediation steps or commands when possib
 				},
 				{
 					Role:    "user",
-					Content: query,
+					Content: content,
 				},
 			},
 			MaxTokens:   500,
@@ -109,6 +138,152 @@ Provide clear explanations and suggest remediation steps or commands when possib
 	},
 }
 
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save collected resources to a tarball for offline analysis",
+	Long: `Collect resources with their manifests, events and logs and save them to a gzipped tarball.
+The snapshot can be analyzed later, without access to the cluster, with analyze --from-snapshot.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		resources, err := snapshotResources(ctx, cmd)
+		if err != nil {
+			fmt.Printf("Error collecting resources: %v\n", err)
+			os.Exit(1)
+		}
+
+		output, _ := cmd.Flags().GetString("output")
+		if output == "" {
+			output = fmt.Sprintf("k8smed-snapshot-%s.tar.gz", time.Now().Format("20060102-150405"))
+		}
+		if err := snapshot.Write(output, snapshot.New(collector.ContextName(cfg.KubeConfig), resources)); err != nil {
+			fmt.Printf("Error writing snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %d resources to %s\n", len(resources), output)
+	},
+}
+
+var scanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Scan namespaces for unhealthy resources and summarize their findings",
+	Long: `Scan pods, workloads, services, persistent volume claims and nodes across namespaces.
+Healthy resources are skipped from their listed status; the others are collected and analyzed,
+and their findings are printed as a table ordered by severity. With --top, the LLM explains
+the most important issues.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+
+		namespaces, _ := cmd.Flags().GetStringSlice("namespace")
+		kindArgs, _ := cmd.Flags().GetStringSlice("kinds")
+		kinds, err := parseScanKinds(kindArgs)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		engine, names, err := newEngine(ctx, cmd)
+		if err != nil {
+			fmt.Printf("Error loading analyzers: %v\n", err)
+			os.Exit(1)
+		}
+
+		// The cache is limited to a namespace only when a single one is scanned
+		cacheNamespace := ""
+		if len(namespaces) == 1 {
+			cacheNamespace = namespaces[0]
+		}
+		k8sCollector, err := newCollector(ctx, cmd, cacheNamespace)
+		if err != nil {
+			fmt.Printf("Error creating collector: %v\n", err)
+			os.Exit(1)
+		}
+
+		metrics, _ := cmd.Flags().GetBool("metrics")
+		resources, scanned := scanResources(ctx, k8sCollector, namespaces, kinds, metrics)
+		fmt.Printf("Scanned %d resources, %d unhealthy\n", len(scanned), len(resources))
+
+		analysisCtx := &analyzer.AnalysisContext{Resources: resources}
+		report, err := engine.Run(ctx, analysisCtx, names...)
+		if err != nil {
+			fmt.Printf("Error analyzing resources: %v\n", err)
+			os.Exit(1)
+		}
+
+		groups := analyzer.PrioritizeGroups(analyzer.GroupFindings(resources, report.Details))
+		if err := printScanTable(groups); err != nil {
+			fmt.Printf("Error writing output: %v\n", err)
+			os.Exit(1)
+		}
+		if len(report.Suppressed) > 0 {
+			fmt.Printf("(%d finding(s) suppressed)\n", len(report.Suppressed))
+		}
+		for _, result := range report.Results {
+			if result.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: analyzer %s failed: %v\n", result.Analyzer, result.Err)
+			}
+		}
+
+		top, _ := cmd.Flags().GetInt("top")
+		if top <= 0 || len(groups) == 0 {
+			return
+		}
+		anonymizeFlag, _ := cmd.Flags().GetBool("anonymize")
+		if err := explainGroups(ctx, groups[:min(top, len(groups))], cfg.AnonymizeByDefault || anonymizeFlag); err != nil {
+			fmt.Printf("Error explaining findings: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Stream new and resolved findings as pods and events change",
+	Long: `Watch the pods of a namespace and the events recorded for them, and analyze again only the
+pods that changed. Findings are printed as they appear (+) and when they are resolved (-), so an
+incident can be followed live without re-running analyze. Changes are batched over --debounce.
+Pods that are running and ready are not analyzed, as in a scan, which resolves their findings.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		namespace, _ := cmd.Flags().GetString("namespace")
+		if allNamespaces, _ := cmd.Flags().GetBool("all-namespaces"); allNamespaces {
+			namespace = ""
+		}
+		debounce, _ := cmd.Flags().GetDuration("debounce")
+		metrics, _ := cmd.Flags().GetBool("metrics")
+
+		engine, names, err := newEngine(ctx, cmd)
+		if err != nil {
+			fmt.Printf("Error loading analyzers: %v\n", err)
+			os.Exit(1)
+		}
+
+		k8sCollector, err := collector.NewCachedCollector(ctx, cfg.KubeConfig, collector.CacheOptions{Namespace: namespace})
+		if err != nil {
+			fmt.Printf("Error creating collector: %v\n", err)
+			os.Exit(1)
+		}
+
+		pending := newPendingChanges()
+		if err := k8sCollector.WatchPods(pending.add); err != nil {
+			fmt.Printf("Error watching pods: %v\n", err)
+			os.Exit(1)
+		}
+
+		if namespace == "" {
+			fmt.Println("Watching pods in all namespaces; press Ctrl+C to stop")
+		} else {
+			fmt.Printf("Watching pods in namespace %s; press Ctrl+C to stop\n", namespace)
+		}
+		watchFindings(ctx, k8sCollector, engine, names, analyzer.NewFindingTracker(), pending, debounce, metrics, nil, nil)
+	},
+}
+
 var interactiveCmd = &cobra.Command{
 	Use:   "interactive",
 	Short: "Start an interactive troubleshooting session",
@@ -143,6 +318,76 @@ var configCmd = &cobra.Command{
 	},
 }
 
+var analyzersCmd = &cobra.Command{
+	Use:   "analyzers",
+	Short: "Work with the registered analyzers",
+}
+
+var analyzersListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in analyzers, custom rules and plugins",
+	Run: func(cmd *cobra.Command, args []string) {
+		registry, err := buildRegistry(context.Background(), cmd)
+		if err != nil {
+			fmt.Printf("Error loading analyzers: %v\n", err)
+			os.Exit(1)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "NAME\tDESCRIPTION")
+		for _, registered := range registry.GetAll() {
+			fmt.Fprintf(writer, "%s\t%s\n", registered.Name(), registered.Description())
+		}
+		if err := writer.Flush(); err != nil {
+			fmt.Printf("Error writing output: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Work with custom analyzer rules",
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test [file or directory...]",
+	Short: "Compile custom rules and run their test fixtures",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		failed := 0
+		for _, path := range args {
+			loaded, err := loadRules(path)
+			if err != nil {
+				fmt.Printf("Error loading rules: %v\n", err)
+				os.Exit(1)
+			}
+
+			for _, rule := range loaded {
+				results, err := rules.RunTests(rule)
+				if err != nil {
+					fmt.Printf("FAIL %v\n", err)
+					failed++
+					continue
+				}
+				for _, result := range results {
+					if result.Passed {
+						fmt.Printf("ok   %s: %s\n", result.Rule, result.Test)
+						continue
+					}
+					fmt.Printf("FAIL %s: %s: %s\n", result.Rule, result.Test, result.Message)
+					failed++
+				}
+			}
+		}
+
+		if failed > 0 {
+			fmt.Printf("%d rule test(s) failed\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
 func init() {
 	// Initialize configuration
 	cobra.OnInitialize(initConfig)
@@ -150,22 +395,98 @@ func init() {
 	// Add flags to analyze command
 	analyzeCmd.Flags().BoolP("explain", "e", false, "Provide detailed explanations for the analysis")
 	analyzeCmd.Flags().BoolP("anonymize", "a", false, "Anonymize sensitive information in queries")
+	analyzeCmd.Flags().StringSlice("resource", nil, "Resource to collect and run the analyzers on, as kind/name (e.g. pod/web-0 or rollouts.argoproj.io/web); repeatable")
+	analyzeCmd.Flags().StringP("namespace", "n", "default", "Namespace of the resources")
+	analyzeCmd.Flags().StringSlice("analyzers", nil, "Run only these analyzers (comma separated)")
+	analyzeCmd.Flags().StringSlice("skip-analyzers", nil, "Do not run these analyzers (comma separated)")
+	analyzeCmd.Flags().String("suppressions", "", "YAML file of accepted findings to hide (default from K8SMED_SUPPRESSIONS_FILE)")
+	analyzeCmd.Flags().String("from-snapshot", "", "Analyze a snapshot or a kubectl cluster-info dump directory instead of the cluster; --resource narrows it down")
+	addCollectorFlags(analyzeCmd)
+
+	snapshotCmd.Flags().StringP("output", "o", "", "Snapshot file to write (default k8smed-snapshot-<time>.tar.gz)")
+	snapshotCmd.Flags().StringP("namespace", "n", "default", "Namespace of the resources")
+	snapshotCmd.Flags().BoolP("all-namespaces", "A", false, "Collect resources in all namespaces")
+	snapshotCmd.Flags().StringSlice("resource", nil, "Resource to collect, as kind/name; repeatable (default every pod, PodDisruptionBudget and Ingress)")
+	addCollectorFlags(snapshotCmd)
+	addRegistryFlags(analyzeCmd, true)
+	addRegistryFlags(analyzersListCmd, true)
+
+	scanCmd.Flags().StringSliceP("namespace", "n", nil, "Namespace to scan; repeatable (default all namespaces)")
+	scanCmd.Flags().StringSlice("kinds", nil, "Kinds to scan, such as pods,deploy,svc (default pods, deployments, statefulsets, daemonsets, services, pvcs and nodes)")
+	scanCmd.Flags().Int("top", 0, "Ask the LLM to explain this many of the most important findings")
+	scanCmd.Flags().BoolP("anonymize", "a", false, "Anonymize sensitive information sent to the LLM")
+	scanCmd.Flags().StringSlice("analyzers", nil, "Run only these analyzers (comma separated)")
+	scanCmd.Flags().StringSlice("skip-analyzers", nil, "Do not run these analyzers (comma separated)")
+	scanCmd.Flags().String("suppressions", "", "YAML file of accepted findings to hide (default from K8SMED_SUPPRESSIONS_FILE)")
+	addCollectorFlags(scanCmd)
+	addRegistryFlags(scanCmd, true)
+
+	watchCmd.Flags().StringP("namespace", "n", "default", "Namespace to watch")
+	watchCmd.Flags().BoolP("all-namespaces", "A", false, "Watch pods in all namespaces")
+	watchCmd.Flags().Duration("debounce", 2*time.Second, "How long to gather changes before analyzing the changed pods")
+	watchCmd.Flags().StringSlice("analyzers", nil, "Run only these analyzers (comma separated)")
+	watchCmd.Flags().StringSlice("skip-analyzers", nil, "Do not run these analyzers (comma separated)")
+	watchCmd.Flags().String("suppressions", "", "YAML file of accepted findings to hide (default from K8SMED_SUPPRESSIONS_FILE)")
+	addMetricsFlag(watchCmd)
+	addRegistryFlags(watchCmd, true)
+
+	agentCmd.Flags().StringSliceP("namespace", "n", nil, "Namespace to scan; repeatable (default all namespaces)")
+	agentCmd.Flags().StringSlice("kinds", nil, "Kinds to scan, such as pods,deploy,svc (default pods, deployments, statefulsets, daemonsets, services, pvcs and nodes)")
+	agentCmd.Flags().Duration("interval", 5*time.Minute, "How often to scan the namespaces")
+	agentCmd.Flags().Duration("debounce", 10*time.Second, "How long to gather pod changes before analyzing the changed pods")
+	agentCmd.Flags().String("health-addr", ":8080", "Address serving /healthz and /readyz")
+	agentCmd.Flags().Bool("leader-elect", true, "Elect a leader among replicas so only one scans at a time")
+	agentCmd.Flags().String("lease-namespace", "", "Namespace of the leader election Lease (default $POD_NAMESPACE, or k8smed-system)")
+	agentCmd.Flags().String("lease-name", "k8smed-agent", "Name of the leader election Lease")
+	agentCmd.Flags().StringSlice("analyzers", nil, "Run only these analyzers (comma separated)")
+	agentCmd.Flags().StringSlice("skip-analyzers", nil, "Do not run these analyzers (comma separated)")
+	agentCmd.Flags().String("suppressions", "", "YAML file of accepted findings to hide (default from K8SMED_SUPPRESSIONS_FILE)")
+	addMetricsFlag(agentCmd)
+	// A long-running agent only runs the plugins it is pointed at
+	addRegistryFlags(agentCmd, false)
 
 	// Add commands to root command
 	rootCmd.AddCommand(analyzeCmd)
+	rootCmd.AddCommand(snapshotCmd)
+	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(watchCmd)
+	rootCmd.AddCommand(agentCmd)
 	rootCmd.AddCommand(interactiveCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(configCmd)
+
+	analyzersCmd.AddCommand(analyzersListCmd)
+	rootCmd.AddCommand(analyzersCmd)
+
+	rulesCmd.AddCommand(rulesTestCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+// addRegistryFlags adds the flags that load custom rules and plugins into the registry;
+// searchPath is the default of --plugins
+func addRegistryFlags(cmd *cobra.Command, searchPath bool) {
+	cmd.Flags().StringSlice("rules", nil, "Rule file or directory to load custom analyzers from; repeatable")
+	cmd.Flags().StringSlice("rules-configmap", nil, "ConfigMap, as namespace/name, whose YAML keys hold custom rules; repeatable")
+	cmd.Flags().StringSlice("plugin-dir", nil, "Directory searched for k8smed-analyzer-* plugins before PATH; repeatable")
+	cmd.Flags().Bool("plugins", searchPath, "Search PATH for k8smed-analyzer-* plugins; --plugin-dir directories are searched regardless")
+	cmd.Flags().Int("cert-expiry-days", 0, "Report certificates expiring within this many days (default from K8SMED_CERT_EXPIRY_DAYS, or 30)")
 }
 
 func initConfig() {
-	// Skip config validation for version command which doesn't require API keys
-	if len(os.Args) > 1 && (os.Args[1] == "version" || os.Args[1] == "--version" || os.Args[1] == "-v") {
-		// Use default config without validation for version command
+	// Skip config validation for commands which don't require API keys
+	if len(os.Args) > 1 && (os.Args[1] == "version" || os.Args[1] == "--version" || os.Args[1] == "-v" || os.Args[1] == "rules" || os.Args[1] == "analyzers") {
+		// Use default config without validation
 		cfg = config.DefaultConfig()
 		return
 	}
 
+	// A scan only calls the LLM with --top, so the AI provider is checked when it does;
+	// watch and agent never call it
+	if len(os.Args) > 1 && (os.Args[1] == "scan" || os.Args[1] == "watch" || os.Args[1] == "agent") {
+		cfg = config.ReadConfig()
+		return
+	}
+
 	// Load and validate configuration for other commands
 	var err error
 	cfg, err = config.LoadConfig()
@@ -206,6 +527,656 @@ func createLLMClient(cfg *config.Config) (llm.Client, error) {
 	return llm.NewClient(cfg.AIProvider, options)
 }
 
+// buildRegistry returns the built-in analyzers plus the custom rules and plugins
+// selected by the command's flags. Plugins that fail to load are reported and skipped.
+func buildRegistry(ctx context.Context, cmd *cobra.Command) (*analyzer.Registry, error) {
+	registry := analyzer.NewRegistry()
+
+	expiryDays, _ := cmd.Flags().GetInt("cert-expiry-days")
+	if expiryDays < 0 {
+		return nil, fmt.Errorf("--cert-expiry-days must not be negative")
+	}
+	if expiryDays == 0 {
+		expiryDays = cfg.CertExpiryWarningDays
+	}
+	// Replaces the built-in TLS analyzer in place
+	registry.Register(&analyzer.TLSAnalyzer{ExpiryWarningDays: expiryDays})
+
+	rulePaths, _ := cmd.Flags().GetStringSlice("rules")
+	for _, path := range rulePaths {
+		loaded, err := loadRules(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := rules.Register(registry, loaded); err != nil {
+			return nil, err
+		}
+	}
+
+	configMaps, _ := cmd.Flags().GetStringSlice("rules-configmap")
+	if len(configMaps) > 0 {
+		clientset, err := collector.NewClientset(cfg.KubeConfig)
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range configMaps {
+			namespace, name, ok := strings.Cut(ref, "/")
+			if !ok || namespace == "" || name == "" {
+				return nil, fmt.Errorf("--rules-configmap %q must be namespace/name", ref)
+			}
+			loaded, err := rules.LoadFromCluster(ctx, clientset, namespace, name)
+			if err != nil {
+				return nil, err
+			}
+			if err := rules.Register(registry, loaded); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	pluginDirs, _ := cmd.Flags().GetStringSlice("plugin-dir")
+	searchPath, _ := cmd.Flags().GetBool("plugins")
+	for _, err := range registry.RegisterPlugins(ctx, analyzer.PluginOptions{Dirs: pluginDirs, SkipPath: !searchPath}) {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	return registry, nil
+}
+
+// addCollectorFlags adds the flags choosing how resources are read from the cluster
+func addCollectorFlags(cmd *cobra.Command) {
+	cmd.Flags().Bool("cached", false, "Read pods, events, nodes, services and workloads from informer caches synced once at startup")
+	addMetricsFlag(cmd)
+}
+
+// addMetricsFlag adds the flag choosing whether pod usage is read from metrics-server
+func addMetricsFlag(cmd *cobra.Command) {
+	cmd.Flags().Bool("metrics", true, "Query metrics-server for the live CPU and memory usage of pods and their nodes")
+}
+
+// newCollector creates a one-shot collector, or a cached one limited to the namespace
+// when --cached is set
+func newCollector(ctx context.Context, cmd *cobra.Command, namespace string) (*collector.Collector, error) {
+	if cached, _ := cmd.Flags().GetBool("cached"); cached {
+		return collector.NewCachedCollector(ctx, cfg.KubeConfig, collector.CacheOptions{Namespace: namespace})
+	}
+	return collector.NewCollector(cfg.KubeConfig)
+}
+
+// collectResources collects the kind/name resources from the cluster or, with
+// --from-snapshot, reads them from a snapshot, where no names means every resource
+func collectResources(ctx context.Context, cmd *cobra.Command, namespace string, resourceArgs []string) ([]collector.ResourceData, error) {
+	if snapshotPath, _ := cmd.Flags().GetString("from-snapshot"); snapshotPath != "" {
+		loaded, err := snapshot.Load(ctx, snapshotPath)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Analyzing snapshot of %s taken at %s\n", loaded.Source, loaded.CreatedAt.Format(time.RFC3339))
+		// The namespace only narrows a snapshot down when it is given explicitly
+		if !cmd.Flags().Changed("namespace") {
+			namespace = ""
+		}
+		return selectSnapshotResources(loaded.Resources, namespace, resourceArgs)
+	}
+
+	k8sCollector, err := newCollector(ctx, cmd, namespace)
+	if err != nil {
+		return nil, err
+	}
+	metrics, _ := cmd.Flags().GetBool("metrics")
+
+	resources := make([]collector.ResourceData, 0, len(resourceArgs))
+	for _, arg := range resourceArgs {
+		kind, name, err := parseResourceArg(arg)
+		if err != nil {
+			return nil, err
+		}
+		data, err := k8sCollector.CollectResource(ctx, collector.ResourceType(strings.ToLower(kind)), collector.CollectionOptions{
+			Namespace:      namespace,
+			ResourceName:   name,
+			IncludeEvents:  true,
+			IncludeLogs:    true,
+			IncludeMetrics: metrics,
+			TailLines:      200,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect %s: %w", arg, err)
+		}
+		resources = append(resources, *data)
+	}
+	return resources, nil
+}
+
+// selectSnapshotResources returns the snapshot resources named by the kind/name
+// arguments, or all of them when there are none, limited to the namespace if set
+func selectSnapshotResources(resources []collector.ResourceData, namespace string, resourceArgs []string) ([]collector.ResourceData, error) {
+	selected := make([]collector.ResourceData, 0)
+	for _, resource := range resources {
+		if namespace != "" && resource.Resource.Namespace != namespace {
+			continue
+		}
+		if len(resourceArgs) == 0 {
+			selected = append(selected, resource)
+			continue
+		}
+		for _, arg := range resourceArgs {
+			kind, name, err := parseResourceArg(arg)
+			if err != nil {
+				return nil, err
+			}
+			// Generic resources are also named by their resource, such as certificates.cert-manager.io
+			kindMatches := strings.EqualFold(resource.Resource.Kind, kind) || strings.EqualFold(resource.Status["resource"], kind)
+			if kindMatches && resource.Resource.Name == name {
+				selected = append(selected, resource)
+				break
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no matching resources in the snapshot")
+	}
+	return selected, nil
+}
+
+// snapshotResources collects the kind/name resources or, when none are named, every
+// pod, PodDisruptionBudget and Ingress in the namespace
+func snapshotResources(ctx context.Context, cmd *cobra.Command) ([]collector.ResourceData, error) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+	if allNamespaces, _ := cmd.Flags().GetBool("all-namespaces"); allNamespaces {
+		namespace = ""
+	}
+	if resourceArgs, _ := cmd.Flags().GetStringSlice("resource"); len(resourceArgs) > 0 {
+		return collectResources(ctx, cmd, namespace, resourceArgs)
+	}
+
+	k8sCollector, err := newCollector(ctx, cmd, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics, _ := cmd.Flags().GetBool("metrics")
+	options := collector.CollectionOptions{
+		Namespace:      namespace,
+		IncludeEvents:  true,
+		IncludeLogs:    true,
+		IncludeMetrics: metrics,
+		TailLines:      200,
+	}
+	resources := make([]collector.ResourceData, 0)
+	for _, resourceType := range []collector.ResourceType{collector.ResourceTypePod, collector.ResourceTypePDB, collector.ResourceTypeIngress} {
+		collected, err := k8sCollector.CollectResources(ctx, resourceType, options)
+		if err != nil {
+			if resourceType == collector.ResourceTypePod {
+				return nil, err
+			}
+			fmt.Fprintf(os.Stderr, "Warning: failed to collect %ss: %v\n", resourceType, err)
+			continue
+		}
+		for _, data := range collected {
+			resources = append(resources, *data)
+		}
+	}
+	return resources, nil
+}
+
+// parseResourceArg splits a kind/name resource argument
+func parseResourceArg(arg string) (kind, name string, err error) {
+	kind, name, ok := strings.Cut(arg, "/")
+	if !ok || kind == "" || name == "" {
+		return "", "", fmt.Errorf("resource %q must be kind/name, such as pod/web-0", arg)
+	}
+	return kind, name, nil
+}
+
+// newEngine returns an engine with the suppressions selected by the command's flags and
+// the names of the analyzers it should run
+func newEngine(ctx context.Context, cmd *cobra.Command) (*analyzer.Engine, []string, error) {
+	only, _ := cmd.Flags().GetStringSlice("analyzers")
+	skip, _ := cmd.Flags().GetStringSlice("skip-analyzers")
+
+	registry, err := buildRegistry(ctx, cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	names, err := registry.Select(only, skip)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	suppressionsFile, _ := cmd.Flags().GetString("suppressions")
+	if suppressionsFile == "" {
+		suppressionsFile = cfg.SuppressionsFile
+	}
+	var suppressions []analyzer.Suppression
+	if suppressionsFile != "" {
+		if suppressions, err = analyzer.LoadSuppressions(suppressionsFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return analyzer.NewEngine(registry, analyzer.EngineOptions{Suppressions: suppressions}), names, nil
+}
+
+// runAnalyzers collects the kind/name resources and runs the selected analyzers on them
+func runAnalyzers(ctx context.Context, cmd *cobra.Command, query string, resourceArgs []string) (*analyzer.AnalysisContext, *analyzer.Report, error) {
+	namespace, _ := cmd.Flags().GetString("namespace")
+
+	engine, names, err := newEngine(ctx, cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resources, err := collectResources(ctx, cmd, namespace, resourceArgs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	analysisCtx := &analyzer.AnalysisContext{Query: query, Resources: resources}
+	report, err := engine.Run(ctx, analysisCtx, names...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return analysisCtx, report, nil
+}
+
+// scanKinds maps the kind names and aliases accepted by scan --kinds to kinds
+var scanKinds = map[string]string{
+	"pod": "Pod", "pods": "Pod", "po": "Pod",
+	"deployment": "Deployment", "deployments": "Deployment", "deploy": "Deployment",
+	"statefulset": "StatefulSet", "statefulsets": "StatefulSet", "sts": "StatefulSet",
+	"daemonset": "DaemonSet", "daemonsets": "DaemonSet", "ds": "DaemonSet",
+	"service": "Service", "services": "Service", "svc": "Service",
+	"persistentvolumeclaim": "PersistentVolumeClaim", "persistentvolumeclaims": "PersistentVolumeClaim", "pvc": "PersistentVolumeClaim",
+	"node": "Node", "nodes": "Node", "no": "Node",
+}
+
+// scanResourceTypes are the resource types collected for each scanned kind
+var scanResourceTypes = map[string]collector.ResourceType{
+	"Pod":                   collector.ResourceTypePod,
+	"Deployment":            collector.ResourceTypeDeployment,
+	"StatefulSet":           collector.ResourceTypeStatefulSet,
+	"DaemonSet":             collector.ResourceTypeDaemonSet,
+	"Service":               collector.ResourceTypeService,
+	"PersistentVolumeClaim": collector.ResourceTypePVC,
+	"Node":                  collector.ResourceTypeNode,
+}
+
+// parseScanKinds resolves the --kinds arguments, or returns every kind when there are none
+func parseScanKinds(kindArgs []string) ([]string, error) {
+	if len(kindArgs) == 0 {
+		return collector.SummaryKinds, nil
+	}
+
+	kinds := make([]string, 0, len(kindArgs))
+	seen := make(map[string]bool)
+	for _, arg := range kindArgs {
+		kind, ok := scanKinds[strings.ToLower(arg)]
+		if !ok {
+			return nil, fmt.Errorf("cannot scan %q; supported kinds are %s", arg, strings.Join(collector.SummaryKinds, ", "))
+		}
+		if !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds, nil
+}
+
+// resourceSource lists and collects resources; *collector.Collector implements it
+type resourceSource interface {
+	Summarize(ctx context.Context, kind, namespace string) ([]collector.ResourceSummary, error)
+	CollectResource(ctx context.Context, resourceType collector.ResourceType, options collector.CollectionOptions) (*collector.ResourceData, error)
+}
+
+// scanResources lists the kinds in each namespace, or in all namespaces when none are
+// given, and collects the resources that are not healthy. It returns them with every
+// resource listed; kinds that cannot be listed are reported and skipped. The usage of
+// pods is read from metrics-server when metrics is set.
+func scanResources(ctx context.Context, source resourceSource, namespaces, kinds []string, metrics bool) ([]collector.ResourceData, []collector.ResourceInfo) {
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+
+	resources := make([]collector.ResourceData, 0)
+	scanned := make([]collector.ResourceInfo, 0)
+	for _, kind := range kinds {
+		kindNamespaces := namespaces
+		if kind == "Node" {
+			kindNamespaces = []string{""}
+		}
+
+		for _, namespace := range kindNamespaces {
+			summaries, err := source.Summarize(ctx, kind, namespace)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				continue
+			}
+			for _, summary := range summaries {
+				scanned = append(scanned, summary.ResourceInfo)
+				if summary.Healthy {
+					continue
+				}
+				data, err := source.CollectResource(ctx, scanResourceTypes[kind], collector.CollectionOptions{
+					Namespace:      summary.Namespace,
+					ResourceName:   summary.Name,
+					IncludeEvents:  true,
+					IncludeLogs:    kind == "Pod",
+					IncludeMetrics: metrics && kind == "Pod",
+					TailLines:      200,
+				})
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to collect %s: %v\n", resourceName(summary.ResourceInfo), err)
+					continue
+				}
+				resources = append(resources, *data)
+			}
+		}
+	}
+	return resources, scanned
+}
+
+// printScanTable prints one row per group of findings, most important first
+func printScanTable(groups []analyzer.FindingGroup) error {
+	if len(groups) == 0 {
+		fmt.Println("No issues found")
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "#\tSEVERITY\tFINDING\tRESOURCE\tAFFECTED\tTITLE")
+	for i, group := range groups {
+		detail := group.Detail
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%d\t%s\n", i+1, detail.Severity, detail.ID,
+			resourceName(detail.Resource), group.Count(), detail.Title)
+	}
+	return writer.Flush()
+}
+
+// explainGroups asks the LLM to explain each group of findings, one request per group
+func explainGroups(ctx context.Context, groups []analyzer.FindingGroup, anonymize bool) error {
+	llmClient, err := createLLMClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	anon := anonymizer.NewAnonymizer()
+	for i, group := range groups {
+		detail := group.Detail
+		var builder strings.Builder
+		fmt.Fprintf(&builder, "[%s] %s on %s%s\n%s\n", detail.Severity, detail.Title,
+			resourceName(detail.Resource), affectedString(group), detail.Description)
+		for _, evidence := range detail.Evidence {
+			fmt.Fprintf(&builder, "- %s %s: %s\n", evidence.Kind, evidence.Key, evidence.Value)
+		}
+		content := builder.String()
+		if anonymize {
+			content = anon.Anonymize(content)
+		}
+
+		resp, err := llmClient.Complete(ctx, llm.CompletionRequest{
+			Model: cfg.AIModel,
+			Messages: []llm.Message{
+				{
+					Role: "system",
+					Content: `You are K8sMed, an AI-powered Kubernetes troubleshooting assistant.
+Explain the likely cause of the finding below, which was reported by a cluster scan, and how to fix it.
+Keep the answer short and suggest commands when possible.`,
+				},
+				{
+					Role:    "user",
+					Content: content,
+				},
+			},
+			MaxTokens:   300,
+			Temperature: 0.3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n%d. %s %s\n%s\n", i+1, detail.ID, resourceName(detail.Resource), resp.Content)
+	}
+	return nil
+}
+
+// pendingChanges gathers the pods that changed since they were last analyzed. Only the
+// latest change to a pod matters, so adding never blocks the informer that reports them,
+// however many pods change at once.
+type pendingChanges struct {
+	mu      sync.Mutex
+	changes map[string]collector.ResourceChange
+	// notify holds a signal while changes are pending
+	notify chan struct{}
+}
+
+func newPendingChanges() *pendingChanges {
+	return &pendingChanges{
+		changes: make(map[string]collector.ResourceChange),
+		notify:  make(chan struct{}, 1),
+	}
+}
+
+// add records a change, replacing an earlier one to the same pod
+func (p *pendingChanges) add(change collector.ResourceChange) {
+	p.mu.Lock()
+	p.changes[change.Namespace+"/"+change.Name] = change
+	p.mu.Unlock()
+
+	select {
+	case p.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the pending changes and starts gathering anew
+func (p *pendingChanges) take() map[string]collector.ResourceChange {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	changes := p.changes
+	p.changes = make(map[string]collector.ResourceChange)
+	return changes
+}
+
+// watchFindings analyzes the pods that changed, once per debounce interval, and prints
+// the findings that appeared or were resolved until ctx is done. When tick is not nil,
+// scan also runs on each tick, sharing the tracker with the changes.
+func watchFindings(ctx context.Context, source resourceSource, engine *analyzer.Engine, names []string,
+	tracker *analyzer.FindingTracker, pending *pendingChanges, debounce time.Duration, metrics bool, tick <-chan time.Time, scan func()) {
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+			scan()
+		case <-pending.notify:
+			if timer == nil {
+				timer = time.After(debounce)
+			}
+		case <-timer:
+			timer = nil
+			if changes := pending.take(); len(changes) > 0 {
+				printFindingChanges(analyzeChanges(ctx, source, engine, names, tracker, changes, metrics))
+			}
+		}
+	}
+}
+
+// analyzeChanges collects and analyzes the changed pods and returns how their findings
+// changed. Deleted pods resolve their findings, and so do healthy pods, which are not
+// collected, as in a scan; pods that fail to collect keep theirs. The usage of the pods
+// is read from metrics-server when metrics is set.
+func analyzeChanges(ctx context.Context, source resourceSource, engine *analyzer.Engine, names []string,
+	tracker *analyzer.FindingTracker, pending map[string]collector.ResourceChange, metrics bool) (appeared, resolved []analyzer.AnalysisDetail) {
+	keys := make([]string, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	analyzed := make([]collector.ResourceInfo, 0, len(keys))
+	resources := make([]collector.ResourceData, 0, len(keys))
+	for _, key := range keys {
+		change := pending[key]
+		if !change.Deleted && !change.Healthy {
+			data, err := source.CollectResource(ctx, collector.ResourceTypePod, collector.CollectionOptions{
+				Namespace:      change.Namespace,
+				ResourceName:   change.Name,
+				IncludeEvents:  true,
+				IncludeLogs:    true,
+				IncludeMetrics: metrics,
+				TailLines:      200,
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to collect %s: %v\n", resourceName(change.ResourceInfo), err)
+				}
+				continue
+			}
+			resources = append(resources, *data)
+		}
+		analyzed = append(analyzed, change.ResourceInfo)
+	}
+
+	report, err := engine.Run(ctx, &analyzer.AnalysisContext{Resources: resources}, names...)
+	if err != nil {
+		if ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to analyze changed pods: %v\n", err)
+		}
+		return nil, nil
+	}
+	for _, result := range report.Results {
+		if result.Err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: analyzer %s failed: %v\n", result.Analyzer, result.Err)
+		}
+	}
+
+	return tracker.Update(analyzed, report.Details)
+}
+
+// printFindingChanges prints the findings that appeared (+) and were resolved (-)
+func printFindingChanges(appeared, resolved []analyzer.AnalysisDetail) {
+	now := time.Now().Format("15:04:05")
+	for _, detail := range appeared {
+		fmt.Printf("%s + [%s] %s %s: %s\n", now, detail.Severity, detail.ID, resourceName(detail.Resource), detail.Title)
+	}
+	for _, detail := range resolved {
+		fmt.Printf("%s - [%s] %s %s: resolved\n", now, detail.Severity, detail.ID, resourceName(detail.Resource))
+	}
+}
+
+// printReport prints the findings of an analysis, grouped by probable root cause with
+// the same issue on several replicas merged, and the analyzers that failed
+func printReport(resources []collector.ResourceData, report *analyzer.Report) {
+	fmt.Printf("\nFindings (%d):\n", len(report.Details))
+	for i, rootCause := range report.RootCauses {
+		fmt.Printf("  %d. Probable root cause: %s\n", i+1, resourceName(rootCause.Resource))
+		for _, group := range analyzer.GroupFindings(resources, rootCause.Findings) {
+			detail := group.Detail
+			fmt.Printf("     [%s] %s %s: %s%s\n", detail.Severity, detail.ID, resourceName(detail.Resource), detail.Title, affectedString(group))
+		}
+		for _, symptom := range groupSymptoms(resources, rootCause.Symptoms) {
+			detail := symptom.group.Detail
+			fmt.Printf("       symptom [%s] %s %s: %s%s\n", detail.Severity, detail.ID,
+				resourceName(detail.Resource), detail.Title, affectedString(symptom.group))
+			fmt.Printf("         via %s\n", chainString(symptom.chain))
+		}
+	}
+	if len(report.Suppressed) > 0 {
+		fmt.Printf("  (%d finding(s) suppressed)\n", len(report.Suppressed))
+	}
+	for _, result := range report.Results {
+		if result.Err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: analyzer %s failed: %v\n", result.Analyzer, result.Err)
+		}
+	}
+}
+
+// summarizeFindings renders the root causes and their grouped symptoms for the LLM prompt
+func summarizeFindings(resources []collector.ResourceData, rootCauses []analyzer.RootCause) string {
+	var builder strings.Builder
+	for _, rootCause := range rootCauses {
+		fmt.Fprintf(&builder, "Probable root cause in %s:\n", resourceName(rootCause.Resource))
+		for _, group := range analyzer.GroupFindings(resources, rootCause.Findings) {
+			detail := group.Detail
+			fmt.Fprintf(&builder, "- [%s] %s (%s%s): %s\n", detail.Severity, detail.Title,
+				resourceName(detail.Resource), affectedString(group), detail.Description)
+		}
+		for _, symptom := range groupSymptoms(resources, rootCause.Symptoms) {
+			detail := symptom.group.Detail
+			fmt.Fprintf(&builder, "  - symptom [%s] %s (%s%s, via %s)\n", detail.Severity, detail.Title,
+				resourceName(detail.Resource), affectedString(symptom.group), chainString(symptom.chain))
+		}
+	}
+	return builder.String()
+}
+
+// symptomGroup is a group of symptoms with the chain of its first symptom
+type symptomGroup struct {
+	group analyzer.FindingGroup
+	chain []collector.ResourceInfo
+}
+
+// groupSymptoms merges the symptoms that report the same issue on several replicas
+func groupSymptoms(resources []collector.ResourceData, symptoms []analyzer.Symptom) []symptomGroup {
+	details := make([]analyzer.AnalysisDetail, 0, len(symptoms))
+	for _, symptom := range symptoms {
+		details = append(details, symptom.Detail)
+	}
+
+	groups := make([]symptomGroup, 0)
+	for _, group := range analyzer.GroupFindings(resources, details) {
+		for _, symptom := range symptoms {
+			if symptom.Detail.ID == group.Detail.ID && symptom.Detail.Resource.Name == group.Detail.Resource.Name {
+				groups = append(groups, symptomGroup{group: group, chain: symptom.Chain})
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// affectedString lists the resources of a group, or returns "" for a single finding
+func affectedString(group analyzer.FindingGroup) string {
+	if group.Count() < 2 {
+		return ""
+	}
+	names := make([]string, 0, group.Count())
+	for _, resource := range group.Resources {
+		names = append(names, resource.Name)
+	}
+	return fmt.Sprintf(" [%d resources: %s]", group.Count(), strings.Join(names, ", "))
+}
+
+// resourceName formats a resource as "Kind namespace/name"
+func resourceName(resource collector.ResourceInfo) string {
+	if resource.Namespace == "" {
+		return resource.Kind + " " + resource.Name
+	}
+	return resource.Kind + " " + resource.Namespace + "/" + resource.Name
+}
+
+// chainString formats a chain of resources from cause to symptom
+func chainString(chain []collector.ResourceInfo) string {
+	hops := make([]string, 0, len(chain))
+	for _, resource := range chain {
+		hops = append(hops, resource.Kind+"/"+resource.Name)
+	}
+	return strings.Join(hops, " -> ")
+}
+
+// loadRules reads the rules in a file or in every YAML file of a directory
+func loadRules(path string) ([]rules.Rule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return rules.LoadDir(path)
+	}
+	return rules.LoadFile(path)
+}
+
 func main() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
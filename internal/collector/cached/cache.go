@@ -0,0 +1,146 @@
+package cached
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k8smed/k8smed/internal/collector"
+	"github.com/k8smed/k8smed/internal/collector/health"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Cache keeps the resources that collectors read most, pods, events, nodes, services
+// and workloads, in shared informers
+type Cache struct {
+	client    kubernetes.Interface
+	factory   informers.SharedInformerFactory
+	namespace string
+
+	pods         cache.SharedIndexInformer
+	events       cache.SharedIndexInformer
+	nodes        cache.SharedIndexInformer
+	services     cache.SharedIndexInformer
+	replicaSets  cache.SharedIndexInformer
+	deployments  cache.SharedIndexInformer
+	statefulSets cache.SharedIndexInformer
+	daemonSets   cache.SharedIndexInformer
+	jobs         cache.SharedIndexInformer
+}
+
+// New creates a cache over the client. A namespace limits the cached namespaced
+// resources to it; reads in other namespaces go to the API server.
+func New(client kubernetes.Interface, resync time.Duration, namespace string) *Cache {
+	factory := informers.NewSharedInformerFactoryWithOptions(client, resync,
+		informers.WithNamespace(namespace),
+		informers.WithTransform(stripManagedFields),
+	)
+
+	return &Cache{
+		client:       client,
+		factory:      factory,
+		namespace:    namespace,
+		pods:         factory.Core().V1().Pods().Informer(),
+		events:       factory.Core().V1().Events().Informer(),
+		nodes:        factory.Core().V1().Nodes().Informer(),
+		services:     factory.Core().V1().Services().Informer(),
+		replicaSets:  factory.Apps().V1().ReplicaSets().Informer(),
+		deployments:  factory.Apps().V1().Deployments().Informer(),
+		statefulSets: factory.Apps().V1().StatefulSets().Informer(),
+		daemonSets:   factory.Apps().V1().DaemonSets().Informer(),
+		jobs:         factory.Batch().V1().Jobs().Informer(),
+	}
+}
+
+// Start starts the informers, which run until ctx is done, and waits for their first sync
+func (c *Cache) Start(ctx context.Context) error {
+	c.factory.Start(ctx.Done())
+	for informerType, synced := range c.factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("cache for %v did not sync: %w", informerType, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// Client returns a client that serves Get and List of the cached resources from the
+// cache and sends every other request to the API server
+func (c *Cache) Client() kubernetes.Interface {
+	return NewClient(c.client, Indexers{
+		Nodes:        c.nodes.GetIndexer(),
+		Pods:         c.pods.GetIndexer(),
+		Events:       c.events.GetIndexer(),
+		Services:     c.services.GetIndexer(),
+		ReplicaSets:  c.replicaSets.GetIndexer(),
+		Deployments:  c.deployments.GetIndexer(),
+		StatefulSets: c.statefulSets.GetIndexer(),
+		DaemonSets:   c.daemonSets.GetIndexer(),
+		Jobs:         c.jobs.GetIndexer(),
+	}, c.namespace)
+}
+
+// Change is a change to a cached pod: the pod was added, updated or deleted, or an
+// event was recorded for it
+type Change struct {
+	collector.ResourceInfo
+	Deleted bool
+	// Healthy is whether the pod is healthy the way a scan judges it
+	Healthy bool
+}
+
+// OnPodChange calls handler for every change to a cached pod, starting with an add for
+// each pod already cached. Events count as a change to the pod they are about when
+// that pod is cached. The handler is called from the informers' goroutines.
+func (c *Cache) OnPodChange(handler func(Change)) error {
+	podChange := func(object interface{}, deleted bool) {
+		if tombstone, ok := object.(cache.DeletedFinalStateUnknown); ok {
+			object = tombstone.Obj
+		}
+		if pod, ok := object.(*corev1.Pod); ok {
+			handler(Change{
+				ResourceInfo: collector.ResourceInfo{Kind: "Pod", Name: pod.Name, Namespace: pod.Namespace, Labels: pod.Labels},
+				Deleted:      deleted,
+				Healthy:      !deleted && health.PodHealthy(pod, time.Now()),
+			})
+		}
+	}
+	if _, err := c.pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(object interface{}) { podChange(object, false) },
+		UpdateFunc: func(_, object interface{}) { podChange(object, false) },
+		DeleteFunc: func(object interface{}) { podChange(object, true) },
+	}); err != nil {
+		return fmt.Errorf("failed to watch pods: %w", err)
+	}
+
+	eventChange := func(object interface{}) {
+		event, ok := object.(*corev1.Event)
+		if !ok || event.InvolvedObject.Kind != "Pod" {
+			return
+		}
+		// Events outlive their pods, which are then no longer cached
+		pod, exists, err := c.pods.GetStore().GetByKey(event.InvolvedObject.Namespace + "/" + event.InvolvedObject.Name)
+		if err == nil && exists {
+			podChange(pod, false)
+		}
+	}
+	if _, err := c.events.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    eventChange,
+		UpdateFunc: func(_, object interface{}) { eventChange(object) },
+	}); err != nil {
+		return fmt.Errorf("failed to watch events: %w", err)
+	}
+	return nil
+}
+
+// stripManagedFields drops managed fields, which no collector reads, to save memory
+func stripManagedFields(object interface{}) (interface{}, error) {
+	if accessor, err := meta.Accessor(object); err == nil {
+		accessor.SetManagedFields(nil)
+	}
+	return object, nil
+}
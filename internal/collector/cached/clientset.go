@@ -0,0 +1,506 @@
+package cached
+
+import (
+	"context"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	typedappsv1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	typedbatchv1 "k8s.io/client-go/kubernetes/typed/batch/v1"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Indexers holds the objects a client created by NewClient serves reads from. A nil
+// indexer leaves the reads of its resource to the wrapped client.
+type Indexers struct {
+	Namespaces   cache.Indexer
+	Nodes        cache.Indexer
+	Pods         cache.Indexer
+	Events       cache.Indexer
+	Services     cache.Indexer
+	ReplicaSets  cache.Indexer
+	Deployments  cache.Indexer
+	StatefulSets cache.Indexer
+	DaemonSets   cache.Indexer
+	Jobs         cache.Indexer
+}
+
+// NewIndexer creates an empty indexer keyed and indexed the way NewClient reads it
+func NewIndexer() cache.Indexer {
+	return cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+// NewClient returns a client that serves Get and List of the indexed resources from
+// the indexers and sends every other request to client. A namespace limits the
+// indexed namespaced resources to it; reads in other namespaces go to client.
+func NewClient(client kubernetes.Interface, indexers Indexers, namespace string) kubernetes.Interface {
+	return &clientset{Interface: client, indexers: indexers, namespace: namespace}
+}
+
+// clientset overrides the groups of the indexed resources and passes every other call
+// to the wrapped client
+type clientset struct {
+	kubernetes.Interface
+	indexers  Indexers
+	namespace string
+}
+
+// CoreV1 serves namespaces, nodes, pods, events and services from the indexers
+func (c *clientset) CoreV1() typedcorev1.CoreV1Interface {
+	return &coreV1{CoreV1Interface: c.Interface.CoreV1(), client: c}
+}
+
+// AppsV1 serves replica sets, deployments, stateful sets and daemon sets from the indexers
+func (c *clientset) AppsV1() typedappsv1.AppsV1Interface {
+	return &appsV1{AppsV1Interface: c.Interface.AppsV1(), client: c}
+}
+
+// BatchV1 serves jobs from the indexers
+func (c *clientset) BatchV1() typedbatchv1.BatchV1Interface {
+	return &batchV1{BatchV1Interface: c.Interface.BatchV1(), client: c}
+}
+
+// covers reports whether reads in the namespace can be served from the indexers
+func (c *clientset) covers(namespace string) bool {
+	return c.namespace == "" || c.namespace == namespace
+}
+
+type coreV1 struct {
+	typedcorev1.CoreV1Interface
+	client *clientset
+}
+
+func (c *coreV1) Namespaces() typedcorev1.NamespaceInterface {
+	return &namespaces{
+		NamespaceInterface: c.CoreV1Interface.Namespaces(),
+		reader:             newClusterReader[corev1.Namespace](c.client.indexers.Namespaces, "namespaces"),
+	}
+}
+
+func (c *coreV1) Nodes() typedcorev1.NodeInterface {
+	return &nodes{
+		NodeInterface: c.CoreV1Interface.Nodes(),
+		reader:        newClusterReader[corev1.Node](c.client.indexers.Nodes, "nodes"),
+	}
+}
+
+func (c *coreV1) Pods(namespace string) typedcorev1.PodInterface {
+	return &pods{
+		PodInterface: c.CoreV1Interface.Pods(namespace),
+		reader:       newReader[corev1.Pod](c.client, c.client.indexers.Pods, "pods", namespace, podFields),
+	}
+}
+
+func (c *coreV1) Events(namespace string) typedcorev1.EventInterface {
+	return &events{
+		EventInterface: c.CoreV1Interface.Events(namespace),
+		reader:         newReader[corev1.Event](c.client, c.client.indexers.Events, "events", namespace, eventFields),
+	}
+}
+
+func (c *coreV1) Services(namespace string) typedcorev1.ServiceInterface {
+	return &services{
+		ServiceInterface: c.CoreV1Interface.Services(namespace),
+		reader:           newReader[corev1.Service](c.client, c.client.indexers.Services, "services", namespace, metadataFields[*corev1.Service]),
+	}
+}
+
+type appsV1 struct {
+	typedappsv1.AppsV1Interface
+	client *clientset
+}
+
+func (c *appsV1) ReplicaSets(namespace string) typedappsv1.ReplicaSetInterface {
+	return &replicaSets{
+		ReplicaSetInterface: c.AppsV1Interface.ReplicaSets(namespace),
+		reader:              newReader[appsv1.ReplicaSet](c.client, c.client.indexers.ReplicaSets, "replicasets", namespace, metadataFields[*appsv1.ReplicaSet]),
+	}
+}
+
+func (c *appsV1) Deployments(namespace string) typedappsv1.DeploymentInterface {
+	return &deployments{
+		DeploymentInterface: c.AppsV1Interface.Deployments(namespace),
+		reader:              newReader[appsv1.Deployment](c.client, c.client.indexers.Deployments, "deployments", namespace, metadataFields[*appsv1.Deployment]),
+	}
+}
+
+func (c *appsV1) StatefulSets(namespace string) typedappsv1.StatefulSetInterface {
+	return &statefulSets{
+		StatefulSetInterface: c.AppsV1Interface.StatefulSets(namespace),
+		reader:               newReader[appsv1.StatefulSet](c.client, c.client.indexers.StatefulSets, "statefulsets", namespace, metadataFields[*appsv1.StatefulSet]),
+	}
+}
+
+func (c *appsV1) DaemonSets(namespace string) typedappsv1.DaemonSetInterface {
+	return &daemonSets{
+		DaemonSetInterface: c.AppsV1Interface.DaemonSets(namespace),
+		reader:             newReader[appsv1.DaemonSet](c.client, c.client.indexers.DaemonSets, "daemonsets", namespace, metadataFields[*appsv1.DaemonSet]),
+	}
+}
+
+type batchV1 struct {
+	typedbatchv1.BatchV1Interface
+	client *clientset
+}
+
+func (c *batchV1) Jobs(namespace string) typedbatchv1.JobInterface {
+	return &jobs{
+		JobInterface: c.BatchV1Interface.Jobs(namespace),
+		reader:       newReader[batchv1.Job](c.client, c.client.indexers.Jobs, "jobs", namespace, metadataFields[*batchv1.Job]),
+	}
+}
+
+// object is the pointer type of a cached resource
+type object[T any] interface {
+	*T
+	runtime.Object
+	metav1.Object
+}
+
+// reader serves Get and List of one resource in one namespace from its indexer
+type reader[T any, P object[T]] struct {
+	indexer   cache.Indexer
+	resource  schema.GroupResource
+	namespace string
+	covered   bool
+	fields    func(P) fields.Set
+}
+
+// newReader creates the reader of a namespaced resource. Namespaces outside the
+// client's are not covered and their reads go to the wrapped client.
+func newReader[T any, P object[T]](c *clientset, indexer cache.Indexer, resource, namespace string, fieldSet func(P) fields.Set) reader[T, P] {
+	return reader[T, P]{
+		indexer:   indexer,
+		resource:  schema.GroupResource{Resource: resource},
+		namespace: namespace,
+		covered:   indexer != nil && c.covers(namespace),
+		fields:    fieldSet,
+	}
+}
+
+// newClusterReader creates the reader of a cluster-scoped resource
+func newClusterReader[T any, P object[T]](indexer cache.Indexer, resource string) reader[T, P] {
+	return reader[T, P]{
+		indexer:  indexer,
+		resource: schema.GroupResource{Resource: resource},
+		covered:  indexer != nil,
+		fields:   metadataFields[P],
+	}
+}
+
+// get returns a copy of the named object; ok is false when the indexer cannot serve the read
+func (r reader[T, P]) get(name string) (object P, ok bool, err error) {
+	if !r.covered {
+		return nil, false, nil
+	}
+
+	key := name
+	if r.namespace != "" {
+		key = r.namespace + "/" + name
+	}
+	item, exists, err := r.indexer.GetByKey(key)
+	if err != nil {
+		return nil, true, err
+	}
+	if !exists {
+		return nil, true, apierrors.NewNotFound(r.resource, name)
+	}
+	return item.(P).DeepCopyObject().(P), true, nil
+}
+
+// list returns copies of the objects matching the options; ok is false when the indexer
+// cannot serve the read, such as for paging or an unsupported field selector
+func (r reader[T, P]) list(options metav1.ListOptions) (items []T, ok bool, err error) {
+	if !r.covered || options.Continue != "" || options.ResourceVersion != "" {
+		return nil, false, nil
+	}
+
+	labelSelector := labels.Everything()
+	if options.LabelSelector != "" {
+		if labelSelector, err = labels.Parse(options.LabelSelector); err != nil {
+			return nil, true, apierrors.NewBadRequest(err.Error())
+		}
+	}
+	fieldSelector := fields.Everything()
+	if options.FieldSelector != "" {
+		if fieldSelector, err = fields.ParseSelector(options.FieldSelector); err != nil {
+			return nil, true, apierrors.NewBadRequest(err.Error())
+		}
+	}
+
+	matched := make([]P, 0)
+	var unsupported bool
+	add := func(item interface{}) {
+		object := item.(P)
+		if fieldSelector.Empty() {
+			matched = append(matched, object)
+			return
+		}
+		set := r.fields(object)
+		for _, requirement := range fieldSelector.Requirements() {
+			if _, known := set[requirement.Field]; !known {
+				unsupported = true
+				return
+			}
+		}
+		if fieldSelector.Matches(set) {
+			matched = append(matched, object)
+		}
+	}
+	if r.namespace == "" {
+		err = cache.ListAll(r.indexer, labelSelector, add)
+	} else {
+		err = cache.ListAllByNamespace(r.indexer, r.namespace, labelSelector, add)
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	if unsupported {
+		return nil, false, nil
+	}
+
+	// The API server returns objects ordered by namespace and name
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].GetNamespace() != matched[j].GetNamespace() {
+			return matched[i].GetNamespace() < matched[j].GetNamespace()
+		}
+		return matched[i].GetName() < matched[j].GetName()
+	})
+	if options.Limit > 0 && int64(len(matched)) > options.Limit {
+		matched = matched[:options.Limit]
+	}
+
+	items = make([]T, 0, len(matched))
+	for _, object := range matched {
+		items = append(items, *object.DeepCopyObject().(P))
+	}
+	return items, true, nil
+}
+
+// metadataFields returns the field selectors every resource supports
+func metadataFields[P metav1.Object](object P) fields.Set {
+	return fields.Set{
+		"metadata.name":      object.GetName(),
+		"metadata.namespace": object.GetNamespace(),
+	}
+}
+
+// podFields returns the field selectors supported for pods
+func podFields(pod *corev1.Pod) fields.Set {
+	set := metadataFields(pod)
+	set["spec.nodeName"] = pod.Spec.NodeName
+	set["status.phase"] = string(pod.Status.Phase)
+	return set
+}
+
+// eventFields returns the field selectors supported for events
+func eventFields(event *corev1.Event) fields.Set {
+	set := metadataFields(event)
+	set["involvedObject.kind"] = event.InvolvedObject.Kind
+	set["involvedObject.name"] = event.InvolvedObject.Name
+	set["involvedObject.namespace"] = event.InvolvedObject.Namespace
+	set["involvedObject.uid"] = string(event.InvolvedObject.UID)
+	set["reason"] = event.Reason
+	set["type"] = event.Type
+	return set
+}
+
+type pods struct {
+	typedcorev1.PodInterface
+	reader reader[corev1.Pod, *corev1.Pod]
+}
+
+func (p *pods) Get(ctx context.Context, name string, options metav1.GetOptions) (*corev1.Pod, error) {
+	if pod, ok, err := p.reader.get(name); ok {
+		return pod, err
+	}
+	return p.PodInterface.Get(ctx, name, options)
+}
+
+func (p *pods) List(ctx context.Context, options metav1.ListOptions) (*corev1.PodList, error) {
+	if items, ok, err := p.reader.list(options); ok {
+		return &corev1.PodList{Items: items}, err
+	}
+	return p.PodInterface.List(ctx, options)
+}
+
+type events struct {
+	typedcorev1.EventInterface
+	reader reader[corev1.Event, *corev1.Event]
+}
+
+func (e *events) Get(ctx context.Context, name string, options metav1.GetOptions) (*corev1.Event, error) {
+	if event, ok, err := e.reader.get(name); ok {
+		return event, err
+	}
+	return e.EventInterface.Get(ctx, name, options)
+}
+
+func (e *events) List(ctx context.Context, options metav1.ListOptions) (*corev1.EventList, error) {
+	if items, ok, err := e.reader.list(options); ok {
+		return &corev1.EventList{Items: items}, err
+	}
+	return e.EventInterface.List(ctx, options)
+}
+
+type namespaces struct {
+	typedcorev1.NamespaceInterface
+	reader reader[corev1.Namespace, *corev1.Namespace]
+}
+
+func (n *namespaces) Get(ctx context.Context, name string, options metav1.GetOptions) (*corev1.Namespace, error) {
+	if namespace, ok, err := n.reader.get(name); ok {
+		return namespace, err
+	}
+	return n.NamespaceInterface.Get(ctx, name, options)
+}
+
+func (n *namespaces) List(ctx context.Context, options metav1.ListOptions) (*corev1.NamespaceList, error) {
+	if items, ok, err := n.reader.list(options); ok {
+		return &corev1.NamespaceList{Items: items}, err
+	}
+	return n.NamespaceInterface.List(ctx, options)
+}
+
+type nodes struct {
+	typedcorev1.NodeInterface
+	reader reader[corev1.Node, *corev1.Node]
+}
+
+func (n *nodes) Get(ctx context.Context, name string, options metav1.GetOptions) (*corev1.Node, error) {
+	if node, ok, err := n.reader.get(name); ok {
+		return node, err
+	}
+	return n.NodeInterface.Get(ctx, name, options)
+}
+
+func (n *nodes) List(ctx context.Context, options metav1.ListOptions) (*corev1.NodeList, error) {
+	if items, ok, err := n.reader.list(options); ok {
+		return &corev1.NodeList{Items: items}, err
+	}
+	return n.NodeInterface.List(ctx, options)
+}
+
+type services struct {
+	typedcorev1.ServiceInterface
+	reader reader[corev1.Service, *corev1.Service]
+}
+
+func (s *services) Get(ctx context.Context, name string, options metav1.GetOptions) (*corev1.Service, error) {
+	if service, ok, err := s.reader.get(name); ok {
+		return service, err
+	}
+	return s.ServiceInterface.Get(ctx, name, options)
+}
+
+func (s *services) List(ctx context.Context, options metav1.ListOptions) (*corev1.ServiceList, error) {
+	if items, ok, err := s.reader.list(options); ok {
+		return &corev1.ServiceList{Items: items}, err
+	}
+	return s.ServiceInterface.List(ctx, options)
+}
+
+type replicaSets struct {
+	typedappsv1.ReplicaSetInterface
+	reader reader[appsv1.ReplicaSet, *appsv1.ReplicaSet]
+}
+
+func (r *replicaSets) Get(ctx context.Context, name string, options metav1.GetOptions) (*appsv1.ReplicaSet, error) {
+	if rs, ok, err := r.reader.get(name); ok {
+		return rs, err
+	}
+	return r.ReplicaSetInterface.Get(ctx, name, options)
+}
+
+func (r *replicaSets) List(ctx context.Context, options metav1.ListOptions) (*appsv1.ReplicaSetList, error) {
+	if items, ok, err := r.reader.list(options); ok {
+		return &appsv1.ReplicaSetList{Items: items}, err
+	}
+	return r.ReplicaSetInterface.List(ctx, options)
+}
+
+type deployments struct {
+	typedappsv1.DeploymentInterface
+	reader reader[appsv1.Deployment, *appsv1.Deployment]
+}
+
+func (d *deployments) Get(ctx context.Context, name string, options metav1.GetOptions) (*appsv1.Deployment, error) {
+	if deployment, ok, err := d.reader.get(name); ok {
+		return deployment, err
+	}
+	return d.DeploymentInterface.Get(ctx, name, options)
+}
+
+func (d *deployments) List(ctx context.Context, options metav1.ListOptions) (*appsv1.DeploymentList, error) {
+	if items, ok, err := d.reader.list(options); ok {
+		return &appsv1.DeploymentList{Items: items}, err
+	}
+	return d.DeploymentInterface.List(ctx, options)
+}
+
+type statefulSets struct {
+	typedappsv1.StatefulSetInterface
+	reader reader[appsv1.StatefulSet, *appsv1.StatefulSet]
+}
+
+func (s *statefulSets) Get(ctx context.Context, name string, options metav1.GetOptions) (*appsv1.StatefulSet, error) {
+	if sts, ok, err := s.reader.get(name); ok {
+		return sts, err
+	}
+	return s.StatefulSetInterface.Get(ctx, name, options)
+}
+
+func (s *statefulSets) List(ctx context.Context, options metav1.ListOptions) (*appsv1.StatefulSetList, error) {
+	if items, ok, err := s.reader.list(options); ok {
+		return &appsv1.StatefulSetList{Items: items}, err
+	}
+	return s.StatefulSetInterface.List(ctx, options)
+}
+
+type daemonSets struct {
+	typedappsv1.DaemonSetInterface
+	reader reader[appsv1.DaemonSet, *appsv1.DaemonSet]
+}
+
+func (d *daemonSets) Get(ctx context.Context, name string, options metav1.GetOptions) (*appsv1.DaemonSet, error) {
+	if ds, ok, err := d.reader.get(name); ok {
+		return ds, err
+	}
+	return d.DaemonSetInterface.Get(ctx, name, options)
+}
+
+func (d *daemonSets) List(ctx context.Context, options metav1.ListOptions) (*appsv1.DaemonSetList, error) {
+	if items, ok, err := d.reader.list(options); ok {
+		return &appsv1.DaemonSetList{Items: items}, err
+	}
+	return d.DaemonSetInterface.List(ctx, options)
+}
+
+type jobs struct {
+	typedbatchv1.JobInterface
+	reader reader[batchv1.Job, *batchv1.Job]
+}
+
+func (j *jobs) Get(ctx context.Context, name string, options metav1.GetOptions) (*batchv1.Job, error) {
+	if job, ok, err := j.reader.get(name); ok {
+		return job, err
+	}
+	return j.JobInterface.Get(ctx, name, options)
+}
+
+func (j *jobs) List(ctx context.Context, options metav1.ListOptions) (*batchv1.JobList, error) {
+	if items, ok, err := j.reader.list(options); ok {
+		return &batchv1.JobList{Items: items}, err
+	}
+	return j.JobInterface.List(ctx, options)
+}
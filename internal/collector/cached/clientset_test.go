@@ -0,0 +1,112 @@
+package cached
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// startCache starts a cache limited to the namespace over the fake clientset and clears
+// the informers' own list and watch calls, so only reads that missed the cache remain
+func startCache(t *testing.T, clientset *fake.Clientset, namespace string) *Cache {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	informerCache := New(clientset, 0, namespace)
+	if err := informerCache.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	clientset.ClearActions()
+	return informerCache
+}
+
+// apiLists returns the resources listed through the API server
+func apiLists(clientset *fake.Clientset) []string {
+	lists := make([]string, 0)
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "list" {
+			lists = append(lists, action.GetNamespace()+"/"+action.GetResource().Resource)
+		}
+	}
+	return lists
+}
+
+func event(name, namespace, podName string) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: namespace},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: podName, Namespace: namespace, UID: types.UID("uid-" + podName)},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+	}
+}
+
+func TestList_EventSelector(t *testing.T) {
+	clientset := fake.NewClientset(
+		event("web.1", "shop", "web"),
+		event("web.2", "shop", "web"),
+		event("api.1", "shop", "api"),
+	)
+	client := startCache(t, clientset, "shop").Client()
+
+	list, err := client.CoreV1().Events("shop").List(context.Background(), metav1.ListOptions{
+		FieldSelector: "involvedObject.kind=Pod,involvedObject.uid=uid-web",
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list.Items) != 2 || list.Items[0].Name != "web.1" || list.Items[1].Name != "web.2" {
+		t.Errorf("Expected the two events of web in name order, got %+v", list.Items)
+	}
+	if lists := apiLists(clientset); len(lists) != 0 {
+		t.Errorf("Expected the list to be served from the cache, got API lists %v", lists)
+	}
+}
+
+func TestList_UnsupportedSelector(t *testing.T) {
+	clientset := fake.NewClientset(
+		event("web.1", "shop", "web"),
+	)
+	client := startCache(t, clientset, "shop").Client()
+
+	if _, err := client.CoreV1().Events("shop").List(context.Background(), metav1.ListOptions{
+		FieldSelector: "source=kubelet",
+	}); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if lists := apiLists(clientset); len(lists) != 1 || lists[0] != "shop/events" {
+		t.Errorf("Expected an unsupported selector to list through the API, got %v", lists)
+	}
+}
+
+func TestList_NamespaceOutsideCache(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "coredns", Namespace: "kube-system"}},
+	)
+	client := startCache(t, clientset, "shop").Client()
+
+	list, err := client.CoreV1().Pods("kube-system").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Name != "coredns" {
+		t.Errorf("Expected the pod of kube-system, got %+v", list.Items)
+	}
+	if lists := apiLists(clientset); len(lists) != 1 || lists[0] != "kube-system/pods" {
+		t.Errorf("Expected a namespace outside the cache to list through the API, got %v", lists)
+	}
+
+	// Reads in the cached namespace still come from the cache
+	clientset.ClearActions()
+	if _, err := client.CoreV1().Pods("shop").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if len(clientset.Actions()) != 0 {
+		t.Errorf("Expected the get to be served from the cache, got %v", clientset.Actions())
+	}
+}
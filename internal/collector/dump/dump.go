@@ -0,0 +1,410 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k8smed/k8smed/internal/collector/cached"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+)
+
+// dumpHost is the host of the requests the dump client answers itself
+const dumpHost = "cluster-info-dump"
+
+// dumpFile is a list kubectl cluster-info dump writes per namespace
+type dumpFile struct {
+	name    string
+	kind    schema.GroupVersionKind
+	indexer func(*cached.Indexers) cache.Indexer
+}
+
+// dumpFiles are the per-namespace files read into the indexers
+var dumpFiles = []dumpFile{
+	{"pods", corev1.SchemeGroupVersion.WithKind("PodList"), func(i *cached.Indexers) cache.Indexer { return i.Pods }},
+	{"events", corev1.SchemeGroupVersion.WithKind("EventList"), func(i *cached.Indexers) cache.Indexer { return i.Events }},
+	{"services", corev1.SchemeGroupVersion.WithKind("ServiceList"), func(i *cached.Indexers) cache.Indexer { return i.Services }},
+	{"deployments", appsv1.SchemeGroupVersion.WithKind("DeploymentList"), func(i *cached.Indexers) cache.Indexer { return i.Deployments }},
+	{"replicasets", appsv1.SchemeGroupVersion.WithKind("ReplicaSetList"), func(i *cached.Indexers) cache.Indexer { return i.ReplicaSets }},
+	{"daemonsets", appsv1.SchemeGroupVersion.WithKind("DaemonSetList"), func(i *cached.Indexers) cache.Indexer { return i.DaemonSets }},
+}
+
+// servedResource is a resource of the dump that the dynamic client reads
+type servedResource struct {
+	kind     schema.GroupVersionKind
+	resource string
+	scope    meta.RESTScope
+	indexer  func(*cached.Indexers) cache.Indexer
+}
+
+// servedResources are the resources of the dump the generic collector can read
+var servedResources = []servedResource{
+	{corev1.SchemeGroupVersion.WithKind("Node"), "nodes", meta.RESTScopeRoot, func(i *cached.Indexers) cache.Indexer { return i.Nodes }},
+	{corev1.SchemeGroupVersion.WithKind("Pod"), "pods", meta.RESTScopeNamespace, func(i *cached.Indexers) cache.Indexer { return i.Pods }},
+	{corev1.SchemeGroupVersion.WithKind("Service"), "services", meta.RESTScopeNamespace, func(i *cached.Indexers) cache.Indexer { return i.Services }},
+	{appsv1.SchemeGroupVersion.WithKind("Deployment"), "deployments", meta.RESTScopeNamespace, func(i *cached.Indexers) cache.Indexer { return i.Deployments }},
+	{appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), "replicasets", meta.RESTScopeNamespace, func(i *cached.Indexers) cache.Indexer { return i.ReplicaSets }},
+	{appsv1.SchemeGroupVersion.WithKind("StatefulSet"), "statefulsets", meta.RESTScopeNamespace, func(i *cached.Indexers) cache.Indexer { return i.StatefulSets }},
+	{appsv1.SchemeGroupVersion.WithKind("DaemonSet"), "daemonsets", meta.RESTScopeNamespace, func(i *cached.Indexers) cache.Indexer { return i.DaemonSets }},
+}
+
+// logHeader is the line kubectl cluster-info dump writes before each container's logs
+var logHeader = regexp.MustCompile(`^==== START logs for container (\S+) of pod (\S+)/(\S+) ====$`)
+
+// logPath matches the path of a container log request
+var logPath = regexp.MustCompile(`^/api/v1/namespaces/([^/]+)/pods/([^/]+)/log$`)
+
+// objectPath matches the path of a request for the objects of a resource: the group,
+// empty for the core group, the version, the namespace, the resource and the name
+var objectPath = regexp.MustCompile(`^/(?:api|apis/([^/]+))/([^/]+)(?:/namespaces/([^/]+))?/([^/]+)(?:/([^/]+))?$`)
+
+// Dump serves the contents of a `kubectl cluster-info dump --output-directory`
+// directory through a client, so the collectors can read it as if it were a cluster
+type Dump struct {
+	indexers cached.Indexers
+	logs     map[string]string // namespace/pod/container -> logs
+}
+
+// Load reads a cluster-info dump directory
+func Load(dir string) (*Dump, error) {
+	if _, err := os.Stat(filepath.Join(dir, "nodes.json")); err != nil {
+		return nil, fmt.Errorf("%s is not a cluster-info dump directory: %w", dir, err)
+	}
+
+	// The dump has no stateful sets or jobs, which are then listed as empty
+	d := &Dump{
+		indexers: cached.Indexers{
+			Namespaces:   cached.NewIndexer(),
+			Nodes:        cached.NewIndexer(),
+			Pods:         cached.NewIndexer(),
+			Events:       cached.NewIndexer(),
+			Services:     cached.NewIndexer(),
+			ReplicaSets:  cached.NewIndexer(),
+			Deployments:  cached.NewIndexer(),
+			StatefulSets: cached.NewIndexer(),
+			DaemonSets:   cached.NewIndexer(),
+			Jobs:         cached.NewIndexer(),
+		},
+		logs: make(map[string]string),
+	}
+
+	if err := addFile(d.indexers.Nodes, filepath.Join(dir, "nodes.json"), corev1.SchemeGroupVersion.WithKind("NodeList")); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dump directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		namespace := entry.Name()
+		if err := d.indexers.Namespaces.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}}); err != nil {
+			return nil, err
+		}
+		for _, file := range dumpFiles {
+			path := filepath.Join(dir, namespace, file.name+".json")
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			if err := addFile(file.indexer(&d.indexers), path, file.kind); err != nil {
+				return nil, err
+			}
+		}
+		if err := d.loadLogs(filepath.Join(dir, namespace)); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// Client returns a client reading the dump. Reads of resources the dump does not hold,
+// such as ConfigMaps or Secrets, fail with an error other than not found, so the
+// collectors record them as unknown rather than missing.
+func (d *Dump) Client() (kubernetes.Interface, error) {
+	client, err := kubernetes.NewForConfigAndClient(d.config(), &http.Client{Transport: d})
+	if err != nil {
+		return nil, err
+	}
+	return cached.NewClient(client, d.indexers, ""), nil
+}
+
+// DynamicClient returns a dynamic client reading the nodes, pods, services and workloads
+// of the dump. Reads of other resources fail as they do through Client.
+func (d *Dump) DynamicClient() (dynamic.Interface, error) {
+	return dynamic.NewForConfigAndClient(d.config(), &http.Client{Transport: d})
+}
+
+// RESTMapper maps the resources DynamicClient reads, so resource names resolve without
+// the discovery API the dump does not have
+func (d *Dump) RESTMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	for _, served := range servedResources {
+		mapper.Add(served.kind, served.scope)
+	}
+	return mapper
+}
+
+// config is the client configuration of requests answered by RoundTrip
+func (d *Dump) config() *rest.Config {
+	// Requests are answered in memory, so they are not rate limited
+	return &rest.Config{Host: "http://" + dumpHost, QPS: -1}
+}
+
+// RoundTrip answers the requests the indexers do not serve: container logs are read
+// from the dump, the served resources are read from the indexers for the dynamic client
+// and everything else fails
+func (d *Dump) RoundTrip(request *http.Request) (*http.Response, error) {
+	if m := logPath.FindStringSubmatch(request.URL.Path); m != nil && request.Method == http.MethodGet {
+		query := request.URL.Query()
+		logs := d.containerLogs(m[1], m[2], query.Get("container"), query.Get("tailLines"))
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"text/plain"}},
+			Body:       io.NopCloser(strings.NewReader(logs)),
+			Request:    request,
+		}, nil
+	}
+	if m := objectPath.FindStringSubmatch(request.URL.Path); m != nil && request.Method == http.MethodGet {
+		for _, served := range servedResources {
+			if served.kind.Group == m[1] && served.kind.Version == m[2] && served.resource == m[4] {
+				return d.serveObjects(request, served, m[3], m[5])
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s are not included in the cluster-info dump", requestResource(request.URL.Path))
+}
+
+// serveObjects answers a get of the named object, or a list of the objects in the
+// namespace matching the request's label selector, as JSON
+func (d *Dump) serveObjects(request *http.Request, served servedResource, namespace, name string) (*http.Response, error) {
+	indexer := served.indexer(&d.indexers)
+
+	if name != "" {
+		key := name
+		if namespace != "" {
+			key = namespace + "/" + name
+		}
+		item, exists, err := indexer.GetByKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			status := apierrors.NewNotFound(schema.GroupResource{Group: served.kind.Group, Resource: served.resource}, name).ErrStatus
+			status.APIVersion, status.Kind = "v1", "Status"
+			return jsonResponse(request, http.StatusNotFound, status)
+		}
+		object, err := unstructuredObject(item, served.kind)
+		if err != nil {
+			return nil, err
+		}
+		return jsonResponse(request, http.StatusOK, object)
+	}
+
+	selector, err := labels.Parse(request.URL.Query().Get("labelSelector"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+	items := indexer.List()
+	if namespace != "" {
+		if items, err = indexer.ByIndex(cache.NamespaceIndex, namespace); err != nil {
+			return nil, err
+		}
+	}
+
+	// Indexers list in no particular order; the API lists by namespace and name
+	sort.Slice(items, func(i, j int) bool {
+		first, _ := cache.MetaNamespaceKeyFunc(items[i])
+		second, _ := cache.MetaNamespaceKeyFunc(items[j])
+		return first < second
+	})
+
+	objects := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		accessor, err := meta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		if !selector.Matches(labels.Set(accessor.GetLabels())) {
+			continue
+		}
+		object, err := unstructuredObject(item, served.kind)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+
+	return jsonResponse(request, http.StatusOK, map[string]interface{}{
+		"apiVersion": served.kind.GroupVersion().String(),
+		"kind":       served.kind.Kind + "List",
+		"metadata":   map[string]interface{}{},
+		"items":      objects,
+	})
+}
+
+// unstructuredObject converts an indexed object to its JSON form, with the apiVersion
+// and kind the decoded dump files leave empty
+func unstructuredObject(item interface{}, kind schema.GroupVersionKind) (map[string]interface{}, error) {
+	object, ok := item.(runtime.Object)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %T in the %s indexer", item, kind.Kind)
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	content["apiVersion"] = kind.GroupVersion().String()
+	content["kind"] = kind.Kind
+	return content, nil
+}
+
+// jsonResponse answers a request with a JSON body
+func jsonResponse(request *http.Request, statusCode int, body interface{}) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+		Request:    request,
+	}, nil
+}
+
+// addFile adds the objects of a dumped list to the indexer
+func addFile(indexer cache.Indexer, path string, kind schema.GroupVersionKind) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// Lists printed by kubectl may omit their kind
+	object, _, err := scheme.Codecs.UniversalDeserializer().Decode(data, &kind, nil)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	items, err := meta.ExtractList(object)
+	if err != nil {
+		return fmt.Errorf("failed to read items of %s: %w", path, err)
+	}
+	for _, item := range items {
+		if err := indexer.Add(item); err != nil {
+			return fmt.Errorf("failed to load %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// loadLogs reads the <pod>/logs.txt files of a namespace, which hold the logs of each
+// container of the pod between START and END lines
+func (d *Dump) loadLogs(namespaceDir string) error {
+	entries, err := os.ReadDir(namespaceDir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", namespaceDir, err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		file, err := os.Open(filepath.Join(namespaceDir, entry.Name(), "logs.txt"))
+		if err != nil {
+			continue
+		}
+
+		var key string
+		var logs strings.Builder
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if match := logHeader.FindStringSubmatch(line); match != nil {
+				key = match[2] + "/" + match[3] + "/" + match[1]
+				logs.Reset()
+				continue
+			}
+			if strings.HasPrefix(line, "==== END logs for container ") {
+				if key != "" {
+					d.logs[key] = logs.String()
+				}
+				key = ""
+				continue
+			}
+			if key != "" {
+				logs.WriteString(line + "\n")
+			}
+		}
+		err = scanner.Err()
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read logs of pod %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// containerLogs returns the dumped logs of a container, limited to the tail lines
+func (d *Dump) containerLogs(namespace, pod, container, tailLines string) string {
+	logs := d.logs[namespace+"/"+pod+"/"+container]
+	tail, err := strconv.Atoi(tailLines)
+	if err != nil || tail < 0 {
+		return logs
+	}
+
+	// Each line keeps its newline; logs ending in one leave an empty last element
+	lines := strings.SplitAfter(logs, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > tail {
+		lines = lines[len(lines)-tail:]
+	}
+	return strings.Join(lines, "")
+}
+
+// requestResource returns the resource an API path refers to, such as "configmaps"
+// for /api/v1/namespaces/shop/configmaps/settings
+func requestResource(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	// Skip the prefix and version: api/v1 or apis/<group>/<version>
+	switch {
+	case len(parts) > 2 && parts[0] == "api":
+		parts = parts[2:]
+	case len(parts) > 3 && parts[0] == "apis":
+		parts = parts[3:]
+	default:
+		return path
+	}
+	if len(parts) > 2 && parts[0] == "namespaces" {
+		parts = parts[2:]
+	}
+	return parts[0]
+}
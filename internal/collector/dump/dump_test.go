@@ -0,0 +1,168 @@
+package dump
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+func loadClient(t *testing.T) kubernetes.Interface {
+	t.Helper()
+	clusterDump, err := Load("testdata/cluster-info")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	client, err := clusterDump.Client()
+	if err != nil {
+		t.Fatalf("Client() error = %v", err)
+	}
+	return client
+}
+
+// readLogs reads the dumped logs of a container of web-7d9f
+func readLogs(t *testing.T, client kubernetes.Interface, options *corev1.PodLogOptions) string {
+	t.Helper()
+	stream, err := client.CoreV1().Pods("shop").GetLogs("web-7d9f", options).Stream(context.Background())
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		t.Fatalf("Failed to read logs: %v", err)
+	}
+	return string(logs)
+}
+
+func TestDump_Resources(t *testing.T) {
+	ctx := context.Background()
+	client := loadClient(t)
+
+	pods, err := client.CoreV1().Pods("shop").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("List pods error = %v", err)
+	}
+	if len(pods.Items) != 1 || pods.Items[0].Name != "web-7d9f" || len(pods.Items[0].Spec.Containers) != 2 {
+		t.Fatalf("Expected pod web-7d9f with two containers, got %+v", pods.Items)
+	}
+
+	events, err := client.CoreV1().Events("shop").List(ctx, metav1.ListOptions{
+		FieldSelector: "involvedObject.uid=" + string(pods.Items[0].UID),
+	})
+	if err != nil {
+		t.Fatalf("List events error = %v", err)
+	}
+	if len(events.Items) != 1 || events.Items[0].Reason != "BackOff" {
+		t.Errorf("Expected only the pod's BackOff event, got %+v", events.Items)
+	}
+
+	if _, err := client.CoreV1().Nodes().Get(ctx, "node-1", metav1.GetOptions{}); err != nil {
+		t.Errorf("Get node error = %v", err)
+	}
+	if _, err := client.CoreV1().Namespaces().Get(ctx, "shop", metav1.GetOptions{}); err != nil {
+		t.Errorf("Get namespace error = %v", err)
+	}
+
+	// The dump has no stateful sets, which list as empty
+	statefulSets, err := client.AppsV1().StatefulSets("shop").List(ctx, metav1.ListOptions{})
+	if err != nil || len(statefulSets.Items) != 0 {
+		t.Errorf("Expected no stateful sets, got %v, %v", statefulSets, err)
+	}
+
+	// Resources outside the dump are unknown rather than missing
+	_, err = client.CoreV1().ConfigMaps("shop").Get(ctx, "settings", metav1.GetOptions{})
+	if err == nil || apierrors.IsNotFound(err) || !strings.Contains(err.Error(), "configmaps are not included in the cluster-info dump") {
+		t.Errorf("Expected a configmap read to fail as not dumped, got %v", err)
+	}
+}
+
+func TestDump_Logs(t *testing.T) {
+	client := loadClient(t)
+
+	logs := readLogs(t, client, &corev1.PodLogOptions{Container: "app"})
+	want := "starting web 1.4.2\nconnecting to postgres at db.shop.svc:5432\nFATAL: password authentication failed for user \"web\"\n"
+	if logs != want {
+		t.Errorf("Unexpected logs of app:\n%s", logs)
+	}
+
+	tail := int64(1)
+	if logs := readLogs(t, client, &corev1.PodLogOptions{Container: "app", TailLines: &tail}); logs != "FATAL: password authentication failed for user \"web\"\n" {
+		t.Errorf("Expected the last line of app, got %q", logs)
+	}
+
+	if logs := readLogs(t, client, &corev1.PodLogOptions{Container: "proxy"}); logs != "[info] envoy started\n" {
+		t.Errorf("Unexpected logs of proxy: %q", logs)
+	}
+	if logs := readLogs(t, client, &corev1.PodLogOptions{Container: "missing"}); logs != "" {
+		t.Errorf("Expected no logs for an unknown container, got %q", logs)
+	}
+}
+
+func TestRequestResource(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/namespaces/shop/configmaps/settings":                           "configmaps",
+		"/api/v1/persistentvolumes":                                             "persistentvolumes",
+		"/apis/networking.k8s.io/v1/namespaces/shop/ingresses":                  "ingresses",
+		"/apis/admissionregistration.k8s.io/v1/validatingwebhookconfigurations": "validatingwebhookconfigurations",
+	}
+	for path, want := range tests {
+		if got := requestResource(path); got != want {
+			t.Errorf("requestResource(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestDump_DynamicClient(t *testing.T) {
+	ctx := context.Background()
+	clusterDump, err := Load("testdata/cluster-info")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	client, err := clusterDump.DynamicClient()
+	if err != nil {
+		t.Fatalf("DynamicClient() error = %v", err)
+	}
+	mapper := clusterDump.RESTMapper()
+
+	gvr, err := mapper.ResourceFor(schema.GroupVersionResource{Resource: "deployment"})
+	if err != nil {
+		t.Fatalf("ResourceFor() error = %v", err)
+	}
+	deployment, err := client.Resource(gvr).Namespace("shop").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Get deployment error = %v", err)
+	}
+	if deployment.GetKind() != "Deployment" || deployment.GetAPIVersion() != "apps/v1" {
+		t.Errorf("Expected the deployment's kind to be set, got %s %s", deployment.GetAPIVersion(), deployment.GetKind())
+	}
+
+	nodes, err := client.Resource(corev1.SchemeGroupVersion.WithResource("nodes")).List(ctx, metav1.ListOptions{})
+	if err != nil || len(nodes.Items) != 1 || nodes.Items[0].GetName() != "node-1" {
+		t.Errorf("Expected node-1, got %v, %v", nodes, err)
+	}
+
+	services := corev1.SchemeGroupVersion.WithResource("services")
+	selected, err := client.Resource(services).Namespace("shop").List(ctx, metav1.ListOptions{LabelSelector: "app=db"})
+	if err != nil || len(selected.Items) != 0 {
+		t.Errorf("Expected the label selector to match no service, got %v, %v", selected, err)
+	}
+	if _, err := client.Resource(services).Namespace("shop").Get(ctx, "db", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected a missing service to be not found, got %v", err)
+	}
+
+	// Resources outside the dump cannot be mapped or read
+	if _, err := mapper.ResourceFor(schema.GroupVersionResource{Resource: "configmaps"}); err == nil {
+		t.Errorf("Expected configmaps not to be mapped")
+	}
+	_, err = client.Resource(corev1.SchemeGroupVersion.WithResource("configmaps")).Namespace("shop").Get(ctx, "settings", metav1.GetOptions{})
+	if err == nil || apierrors.IsNotFound(err) {
+		t.Errorf("Expected a configmap read to fail as not dumped, got %v", err)
+	}
+}
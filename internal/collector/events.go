@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// eventTimeFormat is the layout of the time that starts every formatted event
+const eventTimeFormat = "2006-01-02 15:04:05"
+
+// ListEvents lists the events in the namespace that match the field selector, such as
+// "involvedObject.uid=<uid>", formatted with FormatEvent
+func ListEvents(ctx context.Context, clientset kubernetes.Interface, namespace, fieldSelector string) ([]string, error) {
+	events, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	formatted := make([]string, 0, len(events.Items))
+	for i := range events.Items {
+		formatted = append(formatted, FormatEvent(&events.Items[i]))
+	}
+	return formatted, nil
+}
+
+// FormatEvent formats an event as "[time] Type Reason: Message (count: N)", the form
+// the analyzers and custom rules parse
+func FormatEvent(event *corev1.Event) string {
+	return fmt.Sprintf("[%s] %s %s: %s (count: %d)",
+		eventTime(event).Format(eventTimeFormat),
+		event.Type,
+		event.Reason,
+		event.Message,
+		event.Count,
+	)
+}
+
+// FormatEventWithObject formats an event like FormatEvent with the object it involves
+// after the reason, "[time] Type Reason Kind namespace/name: Message (count: N)", for
+// events collected on behalf of another resource
+func FormatEventWithObject(event *corev1.Event) string {
+	return fmt.Sprintf("[%s] %s %s %s %s/%s: %s (count: %d)",
+		eventTime(event).Format(eventTimeFormat),
+		event.Type,
+		event.Reason,
+		event.InvolvedObject.Kind,
+		event.InvolvedObject.Namespace,
+		event.InvolvedObject.Name,
+		event.Message,
+		event.Count,
+	)
+}
+
+// eventTime returns when the event was last seen, or created for events without one
+func eventTime(event *corev1.Event) metav1.Time {
+	if event.LastTimestamp.IsZero() {
+		return event.CreationTimestamp
+	}
+	return event.LastTimestamp
+}
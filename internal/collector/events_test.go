@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFormatEvent(t *testing.T) {
+	lastSeen := metav1.NewTime(time.Date(2026, 10, 18, 10, 0, 0, 0, time.Local))
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "shop"},
+		InvolvedObject: corev1.ObjectReference{Kind: "ReplicaSet", Name: "web-7d9f", Namespace: "shop"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FailedCreate",
+		Message:        "Error creating: pods is forbidden",
+		Count:          3,
+		LastTimestamp:  lastSeen,
+	}
+
+	if got := FormatEvent(event); got != "[2026-10-18 10:00:00] Warning FailedCreate: Error creating: pods is forbidden (count: 3)" {
+		t.Errorf("FormatEvent() = %q", got)
+	}
+	if got := FormatEventWithObject(event); got != "[2026-10-18 10:00:00] Warning FailedCreate ReplicaSet shop/web-7d9f: Error creating: pods is forbidden (count: 3)" {
+		t.Errorf("FormatEventWithObject() = %q", got)
+	}
+
+	// Events without a last timestamp fall back to their creation time
+	event.LastTimestamp = metav1.Time{}
+	event.CreationTimestamp = metav1.NewTime(lastSeen.Add(time.Minute))
+	if got := FormatEvent(event); got[:21] != "[2026-10-18 10:01:00]" {
+		t.Errorf("FormatEvent() = %q", got)
+	}
+}
+
+func TestListEvents(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "shop"},
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+			Message:        "Successfully pulled image",
+			Count:          1,
+		},
+	)
+
+	events, err := ListEvents(context.Background(), clientset, "shop", "")
+	if err != nil {
+		t.Fatalf("ListEvents() error = %v", err)
+	}
+	if len(events) != 1 || events[0][22:] != "Normal Pulled: Successfully pulled image (count: 1)" {
+		t.Errorf("ListEvents() = %q", events)
+	}
+}
@@ -0,0 +1,205 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Collector implements collection of any resource, including custom resources, through
+// the dynamic client
+type Collector struct {
+	client    dynamic.Interface
+	clientset kubernetes.Interface
+	mapper    meta.RESTMapper
+}
+
+// NewCollector creates a new generic collector. The mapper resolves resource names
+// through the discovery API.
+func NewCollector(client dynamic.Interface, clientset kubernetes.Interface, mapper meta.RESTMapper) *Collector {
+	return &Collector{
+		client:    client,
+		clientset: clientset,
+		mapper:    mapper,
+	}
+}
+
+// Collect gathers data about a single object of the resource, which is named the way
+// kubectl accepts it: "rollout", "rollouts.argoproj.io", "certificate.v1.cert-manager.io"
+// or a short name such as "cert"
+func (c *Collector) Collect(ctx context.Context, resource string, options collector.CollectionOptions) (*collector.ResourceData, error) {
+	mapping, err := c.resolve(resource)
+	if err != nil {
+		return nil, err
+	}
+	client := c.resourceClient(mapping, options.Namespace)
+
+	var object *unstructured.Unstructured
+	if options.ResourceName != "" {
+		object, err = client.Get(ctx, options.ResourceName, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s %s: %w", mapping.GroupVersionKind.Kind, options.ResourceName, err)
+		}
+	} else if options.LabelSelector != "" {
+		list, err := client.List(ctx, metav1.ListOptions{LabelSelector: options.LabelSelector, Limit: options.Limit})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s with selector %s: %w", mapping.Resource.Resource, options.LabelSelector, err)
+		}
+		if len(list.Items) == 0 {
+			return nil, fmt.Errorf("no %s found with selector %s", mapping.Resource.Resource, options.LabelSelector)
+		}
+		// Use the first object for detailed collection
+		object = &list.Items[0]
+	} else {
+		return nil, fmt.Errorf("either a name or a label selector is required")
+	}
+
+	return c.collectObject(ctx, mapping, object, options), nil
+}
+
+// CollectAll gathers data about every object of the resource in the namespace, or in
+// all namespaces when no namespace is set
+func (c *Collector) CollectAll(ctx context.Context, resource string, options collector.CollectionOptions) ([]*collector.ResourceData, error) {
+	mapping, err := c.resolve(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := c.resourceClient(mapping, options.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: options.LabelSelector,
+		Limit:         options.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", mapping.Resource.Resource, err)
+	}
+
+	results := make([]*collector.ResourceData, 0, len(list.Items))
+	for i := range list.Items {
+		results = append(results, c.collectObject(ctx, mapping, &list.Items[i], options))
+	}
+	return results, nil
+}
+
+// resolve maps a resource name to its preferred version and kind
+func (c *Collector) resolve(resource string) (*meta.RESTMapping, error) {
+	fullySpecified, groupResource := schema.ParseResourceArg(resource)
+
+	var gvr schema.GroupVersionResource
+	var err error
+	if fullySpecified != nil {
+		gvr, err = c.mapper.ResourceFor(*fullySpecified)
+	}
+	if fullySpecified == nil || err != nil {
+		gvr, err = c.mapper.ResourceFor(groupResource.WithVersion(""))
+		if err != nil {
+			return nil, fmt.Errorf("unknown resource type %s: %w", resource, err)
+		}
+	}
+
+	gvk, err := c.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, fmt.Errorf("unknown resource type %s: %w", resource, err)
+	}
+	return c.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+// resourceClient returns the client for the resource, scoped to the namespace when the
+// resource is namespaced
+func (c *Collector) resourceClient(mapping *meta.RESTMapping, namespace string) dynamic.ResourceInterface {
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return c.client.Resource(mapping.Resource).Namespace(namespace)
+	}
+	return c.client.Resource(mapping.Resource)
+}
+
+// collectObject builds the resource data for an object, including its conditions,
+// manifest, owners and events
+func (c *Collector) collectObject(ctx context.Context, mapping *meta.RESTMapping, object *unstructured.Unstructured, options collector.CollectionOptions) *collector.ResourceData {
+	resourceData := &collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      object.GetKind(),
+			Name:      object.GetName(),
+			Namespace: object.GetNamespace(),
+			Labels:    object.GetLabels(),
+		},
+		Status:  extractStatus(object, mapping.Resource),
+		Related: []collector.RelatedResource{},
+	}
+
+	resourceData.Manifest = collector.FormatManifest(object, object.GroupVersionKind())
+
+	for _, owner := range object.GetOwnerReferences() {
+		resourceData.Related = append(resourceData.Related, collector.RelatedResource{
+			ResourceInfo: collector.ResourceInfo{
+				Kind:      owner.Kind,
+				Name:      owner.Name,
+				Namespace: object.GetNamespace(),
+			},
+			Relation: collector.RelationOwner,
+		})
+	}
+
+	// Collect events if requested
+	if options.IncludeEvents {
+		events, err := c.collectEvents(ctx, object)
+		if err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to collect events: %v\n", err)
+		} else {
+			resourceData.Events = events
+		}
+	}
+
+	return resourceData
+}
+
+// extractStatus flattens the fields of an object's status that most controllers share:
+// its scalar fields, such as the phase and the observed generation, and the conditions
+func extractStatus(object *unstructured.Unstructured, gvr schema.GroupVersionResource) map[string]string {
+	status := make(map[string]string)
+
+	// Scalar status fields, such as the phase, observedGeneration or the replica counts
+	// of workloads, are recorded under their own name
+	fields, _, _ := unstructured.NestedMap(object.Object, "status")
+	for field, value := range fields {
+		switch value.(type) {
+		case string, bool, int64, float64:
+			status[field] = fmt.Sprintf("%v", value)
+		}
+	}
+	if replicas, found, _ := unstructured.NestedFieldNoCopy(object.Object, "spec", "replicas"); found {
+		status["spec.replicas"] = fmt.Sprintf("%v", replicas)
+	}
+	status["apiVersion"] = object.GetAPIVersion()
+	status["resource"] = gvr.GroupResource().String()
+	status["generation"] = fmt.Sprintf("%d", object.GetGeneration())
+
+	conditions, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for i, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		prefix := fmt.Sprintf("condition.%d.", i)
+		for _, field := range []string{"type", "status", "reason", "message", "lastTransitionTime"} {
+			if value, ok := condition[field]; ok && value != nil {
+				status[prefix+field] = fmt.Sprintf("%v", value)
+			}
+		}
+	}
+
+	return status
+}
+
+// collectEvents gathers the events involving the object
+func (c *Collector) collectEvents(ctx context.Context, object *unstructured.Unstructured) ([]string, error) {
+	return collector.ListEvents(ctx, c.clientset, object.GetNamespace(), fmt.Sprintf("involvedObject.uid=%s", object.GetUID()))
+}
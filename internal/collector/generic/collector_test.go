@@ -0,0 +1,129 @@
+package generic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// rollout returns an Argo Rollout with the status
+func rollout(name string, status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata": map[string]interface{}{
+			"name":       name,
+			"namespace":  "shop",
+			"generation": int64(4),
+			"uid":        name + "-uid",
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": "argoproj.io/v1alpha1", "kind": "Experiment", "name": "canary", "uid": "experiment-uid",
+			}},
+		},
+		"spec":   map[string]interface{}{"replicas": int64(3)},
+		"status": status,
+	}}
+}
+
+func TestCollectAll(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "RolloutList"},
+		rollout("web", map[string]interface{}{
+			"phase":              "Degraded",
+			"message":            "ProgressDeadlineExceeded",
+			"observedGeneration": "4",
+			"availableReplicas":  int64(1),
+			"paused":             false,
+			// Nested fields are left to the conditions and the manifest
+			"canary": map[string]interface{}{"weights": map[string]interface{}{}},
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": "False", "reason": "AvailableReason", "message": "Rollout does not have minimum availability"},
+				map[string]interface{}{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded", "lastTransitionTime": "2024-01-01T10:00:00Z"},
+				"not a condition",
+			},
+		}),
+		rollout("api", nil),
+	)
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "argoproj.io", Version: "v1alpha1", Kind: "Rollout"}, meta.RESTScopeNamespace)
+
+	collected, err := NewCollector(client, fake.NewClientset(), mapper).CollectAll(context.Background(), "rollout", collector.CollectionOptions{Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("CollectAll() error = %v", err)
+	}
+	resources := make(map[string]*collector.ResourceData)
+	for _, data := range collected {
+		resources[data.Resource.Name] = data
+	}
+
+	tests := []struct {
+		name   string
+		want   map[string]string
+		absent []string
+	}{
+		{
+			name: "web",
+			want: map[string]string{
+				"phase":                          "Degraded",
+				"message":                        "ProgressDeadlineExceeded",
+				"availableReplicas":              "1",
+				"paused":                         "false",
+				"spec.replicas":                  "3",
+				"generation":                     "4",
+				"apiVersion":                     "argoproj.io/v1alpha1",
+				"resource":                       "rollouts.argoproj.io",
+				"condition.0.type":               "Available",
+				"condition.0.status":             "False",
+				"condition.0.message":            "Rollout does not have minimum availability",
+				"condition.1.reason":             "ProgressDeadlineExceeded",
+				"condition.1.lastTransitionTime": "2024-01-01T10:00:00Z",
+			},
+			absent: []string{"canary", "condition.1.message", "condition.2.type"},
+		},
+		{
+			name:   "api",
+			want:   map[string]string{"spec.replicas": "3", "resource": "rollouts.argoproj.io"},
+			absent: []string{"phase", "condition.0.type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, ok := resources[tt.name]
+			if !ok {
+				t.Fatalf("Expected %s to be collected", tt.name)
+			}
+			if data.Resource.Kind != "Rollout" || data.Manifest == "" {
+				t.Errorf("Expected the Rollout's kind and manifest, got %s", data.Resource.Kind)
+			}
+			for key, want := range tt.want {
+				if got := data.Status[key]; got != want {
+					t.Errorf("Expected %s to be %q, got %q", key, want, got)
+				}
+			}
+			for _, key := range tt.absent {
+				if value, ok := data.Status[key]; ok {
+					t.Errorf("Expected no %s, got %q", key, value)
+				}
+			}
+			if len(data.Related) != 1 || data.Related[0].Kind != "Experiment" || data.Related[0].Relation != collector.RelationOwner {
+				t.Errorf("Expected the owning Experiment to be related, got %+v", data.Related)
+			}
+		})
+	}
+
+	if _, err := NewCollector(client, fake.NewClientset(), mapper).Collect(context.Background(), "widgets", collector.CollectionOptions{
+		Namespace: "shop", ResourceName: "web",
+	}); err == nil {
+		t.Errorf("Expected an unknown resource type to fail")
+	}
+}
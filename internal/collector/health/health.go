@@ -0,0 +1,224 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// recentRestart is how long after a container last terminated its pod still counts as
+// unhealthy, so pods that crash and recover are not skipped
+const recentRestart = time.Hour
+
+// Summary is a listed resource with a verdict on its health, judged from the listed
+// object alone
+type Summary struct {
+	collector.ResourceInfo
+	Healthy bool
+}
+
+// Summarizer lists resources and judges their health without collecting them, so that
+// a scan collects only the resources worth analyzing
+type Summarizer struct {
+	clientset kubernetes.Interface
+}
+
+// NewSummarizer creates a new summarizer
+func NewSummarizer(clientset kubernetes.Interface) *Summarizer {
+	return &Summarizer{
+		clientset: clientset,
+	}
+}
+
+// Summarize lists the objects of the kind in the namespace, or in all namespaces when no
+// namespace is set. Kind is one of Pod, Deployment, StatefulSet, DaemonSet, Service,
+// PersistentVolumeClaim and Node; nodes ignore the namespace.
+func (s *Summarizer) Summarize(ctx context.Context, kind, namespace string) ([]Summary, error) {
+	listOptions := metav1.ListOptions{}
+	summaries := make([]Summary, 0)
+	add := func(object metav1.Object, healthy bool) {
+		summaries = append(summaries, Summary{
+			ResourceInfo: collector.ResourceInfo{
+				Kind:      kind,
+				Name:      object.GetName(),
+				Namespace: object.GetNamespace(),
+				Labels:    object.GetLabels(),
+			},
+			Healthy: healthy,
+		})
+	}
+
+	switch kind {
+	case "Pod":
+		pods, err := s.clientset.CoreV1().Pods(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+		now := time.Now()
+		for i := range pods.Items {
+			add(&pods.Items[i], PodHealthy(&pods.Items[i], now))
+		}
+	case "Deployment":
+		deployments, err := s.clientset.AppsV1().Deployments(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		for i := range deployments.Items {
+			add(&deployments.Items[i], deploymentHealthy(&deployments.Items[i]))
+		}
+	case "StatefulSet":
+		statefulSets, err := s.clientset.AppsV1().StatefulSets(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list stateful sets: %w", err)
+		}
+		for i := range statefulSets.Items {
+			add(&statefulSets.Items[i], statefulSetHealthy(&statefulSets.Items[i]))
+		}
+	case "DaemonSet":
+		daemonSets, err := s.clientset.AppsV1().DaemonSets(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list daemon sets: %w", err)
+		}
+		for i := range daemonSets.Items {
+			add(&daemonSets.Items[i], daemonSetHealthy(&daemonSets.Items[i]))
+		}
+	case "Service":
+		services, err := s.clientset.CoreV1().Services(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services: %w", err)
+		}
+		endpoints, err := s.clientset.CoreV1().Endpoints(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list endpoints: %w", err)
+		}
+		ready := make(map[string]bool)
+		for _, endpoint := range endpoints.Items {
+			for _, subset := range endpoint.Subsets {
+				if len(subset.Addresses) > 0 {
+					ready[endpoint.Namespace+"/"+endpoint.Name] = true
+				}
+			}
+		}
+		for i := range services.Items {
+			service := &services.Items[i]
+			add(service, serviceHealthy(service, ready[service.Namespace+"/"+service.Name]))
+		}
+	case "PersistentVolumeClaim":
+		claims, err := s.clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+		}
+		for i := range claims.Items {
+			add(&claims.Items[i], claims.Items[i].Status.Phase == corev1.ClaimBound)
+		}
+	case "Node":
+		nodes, err := s.clientset.CoreV1().Nodes().List(ctx, listOptions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		for i := range nodes.Items {
+			add(&nodes.Items[i], nodeHealthy(&nodes.Items[i]))
+		}
+	default:
+		return nil, fmt.Errorf("cannot summarize %s resources", kind)
+	}
+
+	return summaries, nil
+}
+
+// PodHealthy reports whether a pod has completed, or runs with every container ready
+// and none terminated recently
+func PodHealthy(pod *corev1.Pod, now time.Time) bool {
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return true
+	case corev1.PodRunning:
+	default:
+		return false
+	}
+
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if !containerStatus.Ready {
+			return false
+		}
+		if last := containerStatus.LastTerminationState.Terminated; last != nil && now.Sub(last.FinishedAt.Time) < recentRestart {
+			return false
+		}
+	}
+	return true
+}
+
+// deploymentHealthy reports whether a deployment has rolled out all of its replicas
+func deploymentHealthy(deployment *appsv1.Deployment) bool {
+	desired := replicas(deployment.Spec.Replicas)
+	status := deployment.Status
+	if status.ObservedGeneration < deployment.Generation {
+		return false
+	}
+	for _, condition := range status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse {
+			return false
+		}
+		if condition.Type == appsv1.DeploymentReplicaFailure && condition.Status == corev1.ConditionTrue {
+			return false
+		}
+	}
+	return status.ReadyReplicas >= desired && status.UpdatedReplicas >= desired && status.AvailableReplicas >= desired
+}
+
+// statefulSetHealthy reports whether a stateful set has all of its replicas ready
+func statefulSetHealthy(statefulSet *appsv1.StatefulSet) bool {
+	desired := replicas(statefulSet.Spec.Replicas)
+	status := statefulSet.Status
+	return status.ObservedGeneration >= statefulSet.Generation && status.ReadyReplicas >= desired && status.UpdatedReplicas >= desired
+}
+
+// daemonSetHealthy reports whether a daemon set runs a ready, current pod on every node
+// it should and on no other
+func daemonSetHealthy(daemonSet *appsv1.DaemonSet) bool {
+	status := daemonSet.Status
+	return status.ObservedGeneration >= daemonSet.Generation &&
+		status.NumberReady >= status.DesiredNumberScheduled &&
+		status.UpdatedNumberScheduled >= status.DesiredNumberScheduled &&
+		status.NumberMisscheduled == 0
+}
+
+// serviceHealthy reports whether a service has ready endpoints, when its endpoints are
+// managed through a selector
+func serviceHealthy(service *corev1.Service, hasReadyEndpoints bool) bool {
+	if service.Spec.Type == corev1.ServiceTypeExternalName || len(service.Spec.Selector) == 0 {
+		return true
+	}
+	return hasReadyEndpoints
+}
+
+// nodeHealthy reports whether a node is Ready and under no resource pressure
+func nodeHealthy(node *corev1.Node) bool {
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		switch condition.Type {
+		case corev1.NodeReady:
+			ready = condition.Status == corev1.ConditionTrue
+		case corev1.NodeMemoryPressure, corev1.NodeDiskPressure, corev1.NodePIDPressure, corev1.NodeNetworkUnavailable:
+			if condition.Status == corev1.ConditionTrue {
+				return false
+			}
+		}
+	}
+	return ready
+}
+
+// replicas returns the desired replicas of a workload, which default to one
+func replicas(specReplicas *int32) int32 {
+	if specReplicas == nil {
+		return 1
+	}
+	return *specReplicas
+}
@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodHealthy(t *testing.T) {
+	now := time.Now()
+	container := func(ready bool, lastFinished time.Time) corev1.ContainerStatus {
+		status := corev1.ContainerStatus{Name: "app", Ready: ready}
+		if !lastFinished.IsZero() {
+			status.LastTerminationState.Terminated = &corev1.ContainerStateTerminated{FinishedAt: metav1.NewTime(lastFinished)}
+		}
+		return status
+	}
+
+	tests := []struct {
+		name       string
+		phase      corev1.PodPhase
+		containers []corev1.ContainerStatus
+		want       bool
+	}{
+		{name: "running and ready", phase: corev1.PodRunning, containers: []corev1.ContainerStatus{container(true, time.Time{})}, want: true},
+		{name: "completed", phase: corev1.PodSucceeded, containers: []corev1.ContainerStatus{container(false, time.Time{})}, want: true},
+		{name: "pending", phase: corev1.PodPending, want: false},
+		{name: "failed", phase: corev1.PodFailed, want: false},
+		{name: "container not ready", phase: corev1.PodRunning, containers: []corev1.ContainerStatus{container(true, time.Time{}), container(false, time.Time{})}, want: false},
+		// A pod that crashed and came back is still worth analyzing for a while
+		{name: "restarted recently", phase: corev1.PodRunning, containers: []corev1.ContainerStatus{container(true, now.Add(-10*time.Minute))}, want: false},
+		{name: "restarted long ago", phase: corev1.PodRunning, containers: []corev1.ContainerStatus{container(true, now.Add(-2*recentRestart))}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{Phase: tt.phase, ContainerStatuses: tt.containers}}
+			if got := PodHealthy(pod, now); got != tt.want {
+				t.Errorf("PodHealthy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	three := int32(3)
+	selector := map[string]string{"app": "web"}
+	clientset := fake.NewClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "rolled-out", Namespace: "shop", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &three},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "stalled", Namespace: "shop", Generation: 2},
+			Spec:       appsv1.DeploymentSpec{Replicas: &three},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: 2, ReadyReplicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3,
+				Conditions: []appsv1.DeploymentCondition{{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "not-observed", Namespace: "shop", Generation: 3},
+			Status:     appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "served", Namespace: "shop"}, Spec: corev1.ServiceSpec{Selector: selector}},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "served", Namespace: "shop"},
+			Subsets:    []corev1.EndpointSubset{{Addresses: []corev1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "unserved", Namespace: "shop"}, Spec: corev1.ServiceSpec{Selector: selector}},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "unserved", Namespace: "shop"},
+			Subsets:    []corev1.EndpointSubset{{NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}}}},
+		},
+		// Endpoints of Services without a selector are managed by hand
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "shop"}},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "external", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db.example.com", Selector: selector},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "ready"},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "pressured"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue},
+			}},
+		},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "shop"}, Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound}},
+		&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "shop"}, Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending}},
+	)
+
+	tests := []struct {
+		kind string
+		want map[string]bool
+	}{
+		{kind: "Deployment", want: map[string]bool{"rolled-out": true, "stalled": false, "not-observed": false}},
+		{kind: "Service", want: map[string]bool{"served": true, "unserved": false, "manual": true, "external": true}},
+		{kind: "Node", want: map[string]bool{"ready": true, "pressured": false, "unknown": false}},
+		{kind: "PersistentVolumeClaim", want: map[string]bool{"bound": true, "pending": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			summaries, err := NewSummarizer(clientset).Summarize(context.Background(), tt.kind, "shop")
+			if err != nil {
+				t.Fatalf("Summarize() error = %v", err)
+			}
+			if len(summaries) != len(tt.want) {
+				t.Fatalf("Expected %d %s resources, got %+v", len(tt.want), tt.kind, summaries)
+			}
+			for _, summary := range summaries {
+				if summary.Kind != tt.kind {
+					t.Errorf("Expected kind %s, got %s", tt.kind, summary.Kind)
+				}
+				if want, ok := tt.want[summary.Name]; !ok || summary.Healthy != want {
+					t.Errorf("Expected %s healthy: %v, got %v", summary.Name, want, summary.Healthy)
+				}
+			}
+		})
+	}
+
+	if _, err := NewSummarizer(clientset).Summarize(context.Background(), "Ingress", "shop"); err == nil {
+		t.Errorf("Expected an unsupported kind to fail")
+	}
+}
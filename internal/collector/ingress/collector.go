@@ -0,0 +1,166 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8smed/k8smed/internal/collector"
+	"github.com/k8smed/k8smed/internal/collector/tlscert"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Collector implements Ingress data collection
+type Collector struct {
+	clientset kubernetes.Interface
+}
+
+// NewCollector creates a new Ingress collector
+func NewCollector(clientset kubernetes.Interface) *Collector {
+	return &Collector{
+		clientset: clientset,
+	}
+}
+
+// Collect gathers data about a single Ingress
+func (c *Collector) Collect(ctx context.Context, options collector.CollectionOptions) (*collector.ResourceData, error) {
+	// Validate options
+	if options.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if options.ResourceName == "" {
+		return nil, fmt.Errorf("ingress name is required")
+	}
+
+	ingress, err := c.clientset.NetworkingV1().Ingresses(options.Namespace).Get(ctx, options.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ingress %s: %w", options.ResourceName, err)
+	}
+
+	return c.collectIngress(ctx, ingress, options), nil
+}
+
+// CollectAll gathers data about every Ingress in the namespace, or in all namespaces
+// when no namespace is set
+func (c *Collector) CollectAll(ctx context.Context, options collector.CollectionOptions) ([]*collector.ResourceData, error) {
+	ingresses, err := c.clientset.NetworkingV1().Ingresses(options.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: options.LabelSelector,
+		Limit:         options.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ingresses: %w", err)
+	}
+
+	results := make([]*collector.ResourceData, 0, len(ingresses.Items))
+	for i := range ingresses.Items {
+		results = append(results, c.collectIngress(ctx, &ingresses.Items[i], options))
+	}
+
+	return results, nil
+}
+
+// collectIngress builds the resource data for an Ingress, including the certificates
+// of the TLS secrets it serves
+func (c *Collector) collectIngress(ctx context.Context, ingress *networkingv1.Ingress, options collector.CollectionOptions) *collector.ResourceData {
+	resourceData := &collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Ingress",
+			Name:      ingress.Name,
+			Namespace: ingress.Namespace,
+			Labels:    ingress.Labels,
+		},
+		Manifest: collector.FormatManifest(ingress, networkingv1.SchemeGroupVersion.WithKind("Ingress")),
+		Status:   extractIngressStatus(ingress),
+		Related:  []collector.RelatedResource{},
+	}
+
+	for i, entry := range ingress.Spec.TLS {
+		prefix := fmt.Sprintf("tls.%d.", i)
+		resourceData.Status[prefix+"secret"] = entry.SecretName
+		resourceData.Status[prefix+"hosts"] = strings.Join(entry.Hosts, ",")
+
+		// Without a secret name the controller serves its default certificate
+		if entry.SecretName == "" {
+			continue
+		}
+
+		resourceData.Related = append(resourceData.Related, collector.RelatedResource{
+			ResourceInfo: collector.ResourceInfo{
+				Kind:      "Secret",
+				Name:      entry.SecretName,
+				Namespace: ingress.Namespace,
+			},
+			Relation: collector.RelationReferences,
+		})
+
+		secret, err := c.clientset.CoreV1().Secrets(ingress.Namespace).Get(ctx, entry.SecretName, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				resourceData.Status[prefix+"exists"] = "false"
+			} else {
+				resourceData.Status[prefix+"error"] = fmt.Sprintf("failed to get secret %s: %v", entry.SecretName, err)
+			}
+			continue
+		}
+
+		resourceData.Status[prefix+"exists"] = "true"
+		tlscert.DescribeSecret(resourceData.Status, prefix, secret, corev1.TLSCertKey)
+	}
+
+	// Collect events if requested
+	if options.IncludeEvents {
+		events, err := c.collectEvents(ctx, ingress)
+		if err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to collect events: %v\n", err)
+		} else {
+			resourceData.Events = events
+		}
+	}
+
+	return resourceData
+}
+
+// collectEvents gathers events related to the Ingress
+func (c *Collector) collectEvents(ctx context.Context, ingress *networkingv1.Ingress) ([]string, error) {
+	fieldSelector := fmt.Sprintf("involvedObject.name=%s,involvedObject.namespace=%s,involvedObject.kind=Ingress",
+		ingress.Name, ingress.Namespace)
+
+	return collector.ListEvents(ctx, c.clientset, ingress.Namespace, fieldSelector)
+}
+
+// extractIngressStatus extracts the Ingress class, hosts and load balancer addresses
+func extractIngressStatus(ingress *networkingv1.Ingress) map[string]string {
+	status := make(map[string]string)
+
+	if ingress.Spec.IngressClassName != nil {
+		status["ingressClassName"] = *ingress.Spec.IngressClassName
+	}
+
+	hosts := make([]string, 0, len(ingress.Spec.Rules))
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	sort.Strings(hosts)
+	status["hosts"] = strings.Join(hosts, ",")
+
+	addresses := make([]string, 0, len(ingress.Status.LoadBalancer.Ingress))
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			addresses = append(addresses, lb.IP)
+		} else if lb.Hostname != "" {
+			addresses = append(addresses, lb.Hostname)
+		}
+	}
+	status["loadBalancer"] = strings.Join(addresses, ",")
+
+	return status
+}
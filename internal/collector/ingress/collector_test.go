@@ -0,0 +1,75 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCollect(t *testing.T) {
+	className := "nginx"
+	clientset := fake.NewClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "shop"},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte("not a certificate")},
+		},
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: &className,
+				Rules:            []networkingv1.IngressRule{{Host: "www.example.com"}, {Host: "api.example.com"}, {}},
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{"www.example.com"}, SecretName: "web-tls"},
+					{Hosts: []string{"api.example.com"}, SecretName: "api-tls"},
+					// Served with the controller's default certificate
+					{Hosts: []string{"default.example.com"}},
+				},
+			},
+			Status: networkingv1.IngressStatus{LoadBalancer: networkingv1.IngressLoadBalancerStatus{
+				Ingress: []networkingv1.IngressLoadBalancerIngress{{IP: "203.0.113.10"}, {Hostname: "lb.example.com"}},
+			}},
+		},
+	)
+
+	data, err := NewCollector(clientset).Collect(context.Background(), collector.CollectionOptions{Namespace: "shop", ResourceName: "web"})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"ingressClassName", "nginx"},
+		{"hosts", "api.example.com,www.example.com"},
+		{"loadBalancer", "203.0.113.10,lb.example.com"},
+		{"tls.0.secret", "web-tls"},
+		{"tls.0.hosts", "www.example.com"},
+		{"tls.0.exists", "true"},
+		{"tls.0.type", "kubernetes.io/tls"},
+		{"tls.0.parseError", "no PEM encoded certificate found"},
+		{"tls.1.exists", "false"},
+		{"tls.2.secret", ""},
+		{"tls.2.exists", ""},
+	}
+	for _, tt := range tests {
+		if got := data.Status[tt.key]; got != tt.want {
+			t.Errorf("Expected %s to be %q, got %q", tt.key, tt.want, got)
+		}
+	}
+
+	// Both named secrets are related, the default certificate is not
+	if len(data.Related) != 2 || data.Related[0].Name != "web-tls" || data.Related[1].Name != "api-tls" {
+		t.Errorf("Expected the two TLS secrets to be related, got %+v", data.Related)
+	}
+	if data.Manifest == "" {
+		t.Errorf("Expected the manifest to be collected")
+	}
+}
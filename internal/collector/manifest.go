@@ -0,0 +1,27 @@
+package collector
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// FormatManifest renders an object as YAML for ResourceData.Manifest. Typed objects read
+// through a clientset carry no apiVersion and kind, so they are set from gvk. Managed
+// fields are bookkeeping that would drown the rest of the manifest and are left out.
+func FormatManifest(object runtime.Object, gvk schema.GroupVersionKind) string {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return ""
+	}
+	manifest := &unstructured.Unstructured{Object: content}
+	manifest.SetGroupVersionKind(gvk)
+	unstructured.RemoveNestedField(manifest.Object, "metadata", "managedFields")
+
+	data, err := yaml.Marshal(manifest.Object)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
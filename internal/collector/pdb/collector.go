@@ -0,0 +1,231 @@
+package pdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Collector implements PodDisruptionBudget data collection
+type Collector struct {
+	clientset kubernetes.Interface
+}
+
+// NewCollector creates a new PodDisruptionBudget collector
+func NewCollector(clientset kubernetes.Interface) *Collector {
+	return &Collector{
+		clientset: clientset,
+	}
+}
+
+// Collect gathers data about a single PodDisruptionBudget
+func (c *Collector) Collect(ctx context.Context, options collector.CollectionOptions) (*collector.ResourceData, error) {
+	// Validate options
+	if options.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if options.ResourceName == "" {
+		return nil, fmt.Errorf("pod disruption budget name is required")
+	}
+
+	pdb, err := c.clientset.PolicyV1().PodDisruptionBudgets(options.Namespace).Get(ctx, options.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod disruption budget %s: %w", options.ResourceName, err)
+	}
+
+	return c.collectPDB(ctx, pdb, options, newNodeCache(c.clientset))
+}
+
+// CollectAll gathers data about every PodDisruptionBudget in the namespace,
+// or in all namespaces when no namespace is set
+func (c *Collector) CollectAll(ctx context.Context, options collector.CollectionOptions) ([]*collector.ResourceData, error) {
+	pdbs, err := c.clientset.PolicyV1().PodDisruptionBudgets(options.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: options.LabelSelector,
+		Limit:         options.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pod disruption budgets: %w", err)
+	}
+
+	nodes := newNodeCache(c.clientset)
+	results := make([]*collector.ResourceData, 0, len(pdbs.Items))
+	for i := range pdbs.Items {
+		data, err := c.collectPDB(ctx, &pdbs.Items[i], options, nodes)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, data)
+	}
+
+	return results, nil
+}
+
+// collectPDB builds the resource data for a PodDisruptionBudget, including the pods it
+// covers, the workloads owning them and the cordon state of their nodes
+func (c *Collector) collectPDB(ctx context.Context, pdb *policyv1.PodDisruptionBudget, options collector.CollectionOptions, nodes *nodeCache) (*collector.ResourceData, error) {
+	resourceData := &collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "PodDisruptionBudget",
+			Name:      pdb.Name,
+			Namespace: pdb.Namespace,
+			Labels:    pdb.Labels,
+		},
+		Manifest: collector.FormatManifest(pdb, policyv1.SchemeGroupVersion.WithKind("PodDisruptionBudget")),
+		Status:   extractPDBStatus(pdb),
+		Related:  []collector.RelatedResource{},
+	}
+
+	pods, err := c.coveredPods(ctx, pdb)
+	if err != nil {
+		return nil, err
+	}
+	resourceData.Status["matchedPods"] = fmt.Sprintf("%d", len(pods))
+
+	workloads := newWorkloadResolver(c.clientset)
+	workloadIndex := 0
+	for i, pod := range pods {
+		prefix := fmt.Sprintf("pod.%d.", i)
+		resourceData.Status[prefix+"name"] = pod.Name
+		resourceData.Status[prefix+"node"] = pod.Spec.NodeName
+		resourceData.Status[prefix+"ready"] = fmt.Sprintf("%v", podReady(&pod))
+
+		if pod.Spec.NodeName != "" {
+			unschedulable, err := nodes.unschedulable(ctx, pod.Spec.NodeName)
+			if err != nil {
+				// Log the error but continue
+				fmt.Printf("Warning: failed to get node %s: %v\n", pod.Spec.NodeName, err)
+			} else {
+				resourceData.Status[prefix+"nodeUnschedulable"] = fmt.Sprintf("%v", unschedulable)
+			}
+		}
+
+		resourceData.Related = append(resourceData.Related, collector.RelatedResource{
+			ResourceInfo: collector.ResourceInfo{
+				Kind:      "Pod",
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+				Labels:    pod.Labels,
+			},
+			Relation: collector.RelationSelects,
+		})
+
+		workload, isNew, err := workloads.resolve(ctx, &pod)
+		if err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to resolve owner of pod %s: %v\n", pod.Name, err)
+			continue
+		}
+		if workload == nil || !isNew {
+			continue
+		}
+
+		workloadPrefix := fmt.Sprintf("workload.%d.", workloadIndex)
+		resourceData.Status[workloadPrefix+"kind"] = workload.kind
+		resourceData.Status[workloadPrefix+"name"] = workload.name
+		if workload.replicas != nil {
+			resourceData.Status[workloadPrefix+"replicas"] = fmt.Sprintf("%d", *workload.replicas)
+		}
+		resourceData.Related = append(resourceData.Related, collector.RelatedResource{
+			ResourceInfo: collector.ResourceInfo{
+				Kind:      workload.kind,
+				Name:      workload.name,
+				Namespace: pdb.Namespace,
+			},
+			Relation: collector.RelationSelects,
+		})
+		workloadIndex++
+	}
+
+	// Collect events if requested
+	if options.IncludeEvents {
+		events, err := c.collectEvents(ctx, pdb)
+		if err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to collect events: %v\n", err)
+		} else {
+			resourceData.Events = events
+		}
+	}
+
+	return resourceData, nil
+}
+
+// coveredPods lists the pods selected by the budget
+func (c *Collector) coveredPods(ctx context.Context, pdb *policyv1.PodDisruptionBudget) ([]corev1.Pod, error) {
+	// A nil selector matches nothing, while an empty one matches every pod in the namespace
+	if pdb.Spec.Selector == nil {
+		return nil, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector on pod disruption budget %s: %w", pdb.Name, err)
+	}
+
+	pods, err := c.clientset.CoreV1().Pods(pdb.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods for pod disruption budget %s: %w", pdb.Name, err)
+	}
+
+	return pods.Items, nil
+}
+
+// collectEvents gathers events related to the budget
+func (c *Collector) collectEvents(ctx context.Context, pdb *policyv1.PodDisruptionBudget) ([]string, error) {
+	fieldSelector := fmt.Sprintf("involvedObject.name=%s,involvedObject.namespace=%s,involvedObject.kind=PodDisruptionBudget",
+		pdb.Name, pdb.Namespace)
+
+	return collector.ListEvents(ctx, c.clientset, pdb.Namespace, fieldSelector)
+}
+
+// extractPDBStatus extracts the budget's spec and status
+func extractPDBStatus(pdb *policyv1.PodDisruptionBudget) map[string]string {
+	status := make(map[string]string)
+
+	if pdb.Spec.MinAvailable != nil {
+		status["minAvailable"] = pdb.Spec.MinAvailable.String()
+	}
+	if pdb.Spec.MaxUnavailable != nil {
+		status["maxUnavailable"] = pdb.Spec.MaxUnavailable.String()
+	}
+	if pdb.Spec.Selector != nil {
+		status["selector"] = metav1.FormatLabelSelector(pdb.Spec.Selector)
+	}
+	if pdb.Spec.UnhealthyPodEvictionPolicy != nil {
+		status["unhealthyPodEvictionPolicy"] = string(*pdb.Spec.UnhealthyPodEvictionPolicy)
+	}
+
+	status["currentHealthy"] = fmt.Sprintf("%d", pdb.Status.CurrentHealthy)
+	status["desiredHealthy"] = fmt.Sprintf("%d", pdb.Status.DesiredHealthy)
+	status["expectedPods"] = fmt.Sprintf("%d", pdb.Status.ExpectedPods)
+	status["disruptionsAllowed"] = fmt.Sprintf("%d", pdb.Status.DisruptionsAllowed)
+
+	// Add conditions
+	for i, condition := range pdb.Status.Conditions {
+		prefix := fmt.Sprintf("condition.%d.", i)
+		status[prefix+"type"] = condition.Type
+		status[prefix+"status"] = string(condition.Status)
+		status[prefix+"reason"] = condition.Reason
+		status[prefix+"message"] = condition.Message
+	}
+
+	return status
+}
+
+// podReady reports whether the pod's Ready condition is true
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package pdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// ptr returns a pointer to the value
+func ptr[T any](value T) *T {
+	return &value
+}
+
+// ownedPod returns a ready pod of the app running on the node, controlled by owner
+func ownedPod(name, app, node string, owner metav1.OwnerReference) *corev1.Pod {
+	owner.Controller = ptr(true)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "shop",
+			Labels:          map[string]string{"app": app},
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{
+			Type:   corev1.PodReady,
+			Status: corev1.ConditionTrue,
+		}}},
+	}
+}
+
+func TestCollectAll(t *testing.T) {
+	replicaSet := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7d9f"}
+	statefulSet := metav1.OwnerReference{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"}
+	clientset := fake.NewClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1"}, Spec: corev1.NodeSpec{Unschedulable: true}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec:       appsv1.DeploymentSpec{Replicas: ptr(int32(2))},
+		},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name:      "web-7d9f",
+			Namespace: "shop",
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: ptr(true),
+			}},
+		}},
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: ptr(int32(1))},
+		},
+		ownedPod("web-7d9f-abcde", "web", "worker-1", replicaSet),
+		ownedPod("web-7d9f-fghij", "web", "worker-2", replicaSet),
+		ownedPod("db-0", "db", "worker-1", statefulSet),
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: ptr(intstr.FromInt32(2)),
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: ptr(intstr.FromString("0%")),
+				Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			},
+		},
+		// A nil selector matches no pods
+		&policyv1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: "orphan", Namespace: "shop"}},
+	)
+
+	collected, err := NewCollector(clientset).CollectAll(context.Background(), collector.CollectionOptions{Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("CollectAll() error = %v", err)
+	}
+	budgets := make(map[string]map[string]string)
+	for _, data := range collected {
+		budgets[data.Resource.Name] = data.Status
+		if data.Manifest == "" {
+			t.Errorf("Expected the manifest of %s", data.Resource.Name)
+		}
+	}
+
+	tests := []struct {
+		budget string
+		want   map[string]string
+	}{
+		{
+			budget: "web",
+			want: map[string]string{
+				"minAvailable":            "2",
+				"selector":                "app=web",
+				"matchedPods":             "2",
+				"pod.0.name":              "web-7d9f-abcde",
+				"pod.0.ready":             "true",
+				"pod.0.nodeUnschedulable": "true",
+				"pod.1.nodeUnschedulable": "false",
+				// The ReplicaSet is followed up to its Deployment, which is recorded once
+				"workload.0.kind":     "Deployment",
+				"workload.0.name":     "web",
+				"workload.0.replicas": "2",
+			},
+		},
+		{
+			budget: "db",
+			want: map[string]string{
+				"maxUnavailable":          "0%",
+				"matchedPods":             "1",
+				"pod.0.nodeUnschedulable": "true",
+				"workload.0.kind":         "StatefulSet",
+				"workload.0.replicas":     "1",
+			},
+		},
+		{
+			budget: "orphan",
+			want:   map[string]string{"matchedPods": "0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.budget, func(t *testing.T) {
+			status, ok := budgets[tt.budget]
+			if !ok {
+				t.Fatalf("Expected budget %s to be collected", tt.budget)
+			}
+			for key, want := range tt.want {
+				if status[key] != want {
+					t.Errorf("Expected %s to be %q, got %q", key, want, status[key])
+				}
+			}
+		})
+	}
+	if _, ok := budgets["web"]["workload.1.kind"]; ok {
+		t.Errorf("Expected the Deployment of both pods to be recorded once, got %v", budgets["web"])
+	}
+}
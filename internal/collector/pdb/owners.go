@@ -0,0 +1,110 @@
+package pdb
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// workload is the top level controller of a pod
+type workload struct {
+	kind     string
+	name     string
+	replicas *int32
+}
+
+// workloadResolver walks pod owner references up to their workload, caching lookups
+type workloadResolver struct {
+	clientset kubernetes.Interface
+	seen      map[string]*workload
+}
+
+// newWorkloadResolver creates a resolver for a single collection
+func newWorkloadResolver(clientset kubernetes.Interface) *workloadResolver {
+	return &workloadResolver{
+		clientset: clientset,
+		seen:      make(map[string]*workload),
+	}
+}
+
+// resolve returns the pod's workload and whether it was seen for the first time
+func (r *workloadResolver) resolve(ctx context.Context, pod *corev1.Pod) (*workload, bool, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return nil, false, nil
+	}
+
+	key := owner.Kind + "/" + owner.Name
+	if w, ok := r.seen[key]; ok {
+		return w, false, nil
+	}
+
+	w, err := r.lookup(ctx, pod.Namespace, owner)
+	if err != nil {
+		return nil, false, err
+	}
+
+	r.seen[key] = w
+	return w, true, nil
+}
+
+// lookup fetches the owner, following ReplicaSets up to their Deployment
+func (r *workloadResolver) lookup(ctx context.Context, namespace string, owner *metav1.OwnerReference) (*workload, error) {
+	switch owner.Kind {
+	case "ReplicaSet":
+		rs, err := r.clientset.AppsV1().ReplicaSets(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get replica set %s: %w", owner.Name, err)
+		}
+		if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil && rsOwner.Kind == "Deployment" {
+			deployment, err := r.clientset.AppsV1().Deployments(namespace).Get(ctx, rsOwner.Name, metav1.GetOptions{})
+			if err != nil {
+				return nil, fmt.Errorf("failed to get deployment %s: %w", rsOwner.Name, err)
+			}
+			return &workload{kind: "Deployment", name: deployment.Name, replicas: deployment.Spec.Replicas}, nil
+		}
+		return &workload{kind: "ReplicaSet", name: rs.Name, replicas: rs.Spec.Replicas}, nil
+
+	case "StatefulSet":
+		sts, err := r.clientset.AppsV1().StatefulSets(namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stateful set %s: %w", owner.Name, err)
+		}
+		return &workload{kind: "StatefulSet", name: sts.Name, replicas: sts.Spec.Replicas}, nil
+
+	default:
+		return &workload{kind: owner.Kind, name: owner.Name}, nil
+	}
+}
+
+// nodeCache remembers the cordon state of nodes across budgets
+type nodeCache struct {
+	clientset kubernetes.Interface
+	cordoned  map[string]bool
+}
+
+// newNodeCache creates an empty node cache
+func newNodeCache(clientset kubernetes.Interface) *nodeCache {
+	return &nodeCache{
+		clientset: clientset,
+		cordoned:  make(map[string]bool),
+	}
+}
+
+// unschedulable reports whether the node is cordoned
+func (n *nodeCache) unschedulable(ctx context.Context, name string) (bool, error) {
+	if value, ok := n.cordoned[name]; ok {
+		return value, nil
+	}
+
+	node, err := n.clientset.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	n.cordoned[name] = node.Spec.Unschedulable
+	return node.Spec.Unschedulable, nil
+}
@@ -0,0 +1,60 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8smed/k8smed/internal/collector/tlscert"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// certificateKeys are the secret keys that hold certificates the pod serves or trusts
+var certificateKeys = []string{corev1.TLSCertKey, corev1.ServiceAccountRootCAKey}
+
+// collectMountedCertificates records the certificates in the secrets mounted by the pod,
+// so that certificate errors in the logs can be traced to a secret. It is only called
+// when the logs contain x509 failures.
+func (c *Collector) collectMountedCertificates(ctx context.Context, pod *corev1.Pod, status map[string]string) {
+	index := 0
+	seen := make(map[string]bool)
+	for _, volume := range pod.Spec.Volumes {
+		secretNames := make([]string, 0)
+		if volume.Secret != nil {
+			secretNames = append(secretNames, volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					secretNames = append(secretNames, source.Secret.Name)
+				}
+			}
+		}
+
+		for _, name := range secretNames {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			secret, err := c.clientset.CoreV1().Secrets(pod.Namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				// Missing secrets are reported by the config reference checks
+				continue
+			}
+
+			for _, key := range certificateKeys {
+				if _, ok := secret.Data[key]; !ok {
+					continue
+				}
+				prefix := fmt.Sprintf("tlsSecret.%d.", index)
+				status[prefix+"name"] = name
+				status[prefix+"key"] = key
+				status[prefix+"volume"] = volume.Name
+				tlscert.DescribeSecret(status, prefix, secret, key)
+				index++
+			}
+		}
+	}
+}
@@ -4,21 +4,32 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/k8smed/k8smed/internal/collector"
+	"github.com/k8smed/k8smed/internal/logscan"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
-// Collector implements pod data collection
+// Collector implements pod data collection. A Collector remembers what it listed to
+// explain scheduling failures and whether metrics-server answers, so collections should
+// share one.
 type Collector struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
+
+	mu                 sync.Mutex
+	load               *nodeLoad // nodes and their requests, listed for unschedulable pods
+	metricsUnavailable error     // why the metrics API is not served, nil when it is
+	metricsCheckedAt   time.Time // when the metrics API was last probed
 }
 
 // NewCollector creates a new pod collector
-func NewCollector(clientset *kubernetes.Clientset) *Collector {
+func NewCollector(clientset kubernetes.Interface) *Collector {
 	return &Collector{
 		clientset: clientset,
 	}
@@ -59,6 +70,30 @@ func (c *Collector) Collect(ctx context.Context, options collector.CollectionOpt
 		return nil, fmt.Errorf("either pod name or label selector is required")
 	}
 
+	return c.collectPod(ctx, pod, options), nil
+}
+
+// CollectAll gathers data about every pod in the namespace, or in all namespaces when
+// no namespace is set
+func (c *Collector) CollectAll(ctx context.Context, options collector.CollectionOptions) ([]*collector.ResourceData, error) {
+	pods, err := c.clientset.CoreV1().Pods(options.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: options.LabelSelector,
+		Limit:         options.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	results := make([]*collector.ResourceData, 0, len(pods.Items))
+	for i := range pods.Items {
+		results = append(results, c.collectPod(ctx, &pods.Items[i], options))
+	}
+	return results, nil
+}
+
+// collectPod builds the resource data for a pod, including the context its analyzers
+// need and, as requested, its events, logs and metrics
+func (c *Collector) collectPod(ctx context.Context, pod *corev1.Pod, options collector.CollectionOptions) *collector.ResourceData {
 	// Create resource info
 	resourceInfo := collector.ResourceInfo{
 		Kind:      "Pod",
@@ -70,8 +105,25 @@ func (c *Collector) Collect(ctx context.Context, options collector.CollectionOpt
 	// Collect pod data
 	resourceData := &collector.ResourceData{
 		Resource: resourceInfo,
+		Manifest: collector.FormatManifest(pod, corev1.SchemeGroupVersion.WithKind("Pod")),
 		Status:   extractPodStatus(pod),
-		Related:  []collector.ResourceInfo{},
+	}
+
+	// Record the owners, mounted and referenced objects, selecting Services and node
+	resourceData.Related = c.collectRelated(ctx, pod)
+
+	// Resolve ConfigMap and Secret references from the pod spec
+	c.collectConfigReferences(ctx, pod, resourceData.Status)
+
+	// Check the image pull secrets the pod relies on
+	c.collectImagePullSecrets(ctx, pod, resourceData.Status)
+
+	// Gather node state to explain why the scheduler rejected the pod
+	if isUnschedulable(pod) {
+		if err := c.collectSchedulingContext(ctx, pod, resourceData.Status); err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to collect scheduling context: %v\n", err)
+		}
 	}
 
 	// Collect events if requested
@@ -96,7 +148,35 @@ func (c *Collector) Collect(ctx context.Context, options collector.CollectionOpt
 		}
 	}
 
-	return resourceData, nil
+	// Compare live usage with requests and limits when metrics-server is installed
+	if options.IncludeMetrics {
+		c.collectMetrics(ctx, pod, resourceData.Status)
+	}
+
+	// Look up the names the pod failed to resolve and the health of cluster DNS
+	if failures := logscan.ExtractDNSFailures(strings.Join(resourceData.Logs, "\n")); len(failures) > 0 {
+		if err := c.collectDNSContext(ctx, pod, failures, resourceData.Status); err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to collect DNS context: %v\n", err)
+		}
+	}
+
+	// Inspect mounted certificates when the logs show certificate verification failures
+	if len(logscan.ExtractX509Failures(strings.Join(resourceData.Logs, "\n"))) > 0 {
+		c.collectMountedCertificates(ctx, pod, resourceData.Status)
+	}
+
+	// Collect the service account's RBAC rules when there are permission denials
+	observed := append(append([]string{}, resourceData.Events...), resourceData.Logs...)
+	if hasForbiddenErrors(observed) {
+		if err := c.collectRBACRules(ctx, pod, observed, resourceData.Status); err != nil {
+			// Log the error but continue; the rules stay marked incomplete
+			resourceData.Status["rbac.error"] = err.Error()
+			fmt.Printf("Warning: failed to collect RBAC rules: %v\n", err)
+		}
+	}
+
+	return resourceData
 }
 
 // collectEvents gathers events related to the pod
@@ -105,32 +185,7 @@ func (c *Collector) collectEvents(ctx context.Context, pod *corev1.Pod) ([]strin
 	fieldSelector := fmt.Sprintf("involvedObject.name=%s,involvedObject.namespace=%s,involvedObject.kind=Pod",
 		pod.Name, pod.Namespace)
 
-	events, err := c.clientset.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
-		FieldSelector: fieldSelector,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list events: %w", err)
-	}
-
-	// Format events as strings
-	eventStrings := make([]string, 0, len(events.Items))
-	for _, event := range events.Items {
-		eventTime := event.LastTimestamp.Time
-		if eventTime.IsZero() {
-			eventTime = event.CreationTimestamp.Time
-		}
-
-		eventStr := fmt.Sprintf("[%s] %s %s: %s (count: %d)",
-			eventTime.Format("2006-01-02 15:04:05"),
-			event.Type,
-			event.Reason,
-			event.Message,
-			event.Count,
-		)
-		eventStrings = append(eventStrings, eventStr)
-	}
-
-	return eventStrings, nil
+	return collector.ListEvents(ctx, c.clientset, pod.Namespace, fieldSelector)
 }
 
 // collectLogs gathers logs from the pod's containers
@@ -191,7 +246,10 @@ func extractPodStatus(pod *corev1.Pod) map[string]string {
 	status["phase"] = string(pod.Status.Phase)
 	status["hostIP"] = pod.Status.HostIP
 	status["podIP"] = pod.Status.PodIP
-	status["startTime"] = pod.Status.StartTime.String()
+	// Pods the scheduler has not placed yet have no start time
+	if pod.Status.StartTime != nil {
+		status["startTime"] = pod.Status.StartTime.String()
+	}
 
 	// Add container statuses
 	for i, containerStatus := range pod.Status.ContainerStatuses {
@@ -200,21 +258,44 @@ func extractPodStatus(pod *corev1.Pod) map[string]string {
 		status[prefix+"ready"] = fmt.Sprintf("%v", containerStatus.Ready)
 		status[prefix+"restartCount"] = fmt.Sprintf("%d", containerStatus.RestartCount)
 
+		// Record the image as requested in the spec, along with its pull policy
+		for _, container := range pod.Spec.Containers {
+			if container.Name == containerStatus.Name {
+				status[prefix+"image"] = container.Image
+				status[prefix+"imagePullPolicy"] = string(container.ImagePullPolicy)
+				break
+			}
+		}
+
 		// Check for container state details
-		if containerStatus.State.Waiting != nil {
-			status[prefix+"state"] = "waiting"
-			status[prefix+"reason"] = containerStatus.State.Waiting.Reason
-			status[prefix+"message"] = containerStatus.State.Waiting.Message
-		} else if containerStatus.State.Running != nil {
-			status[prefix+"state"] = "running"
-			status[prefix+"startedAt"] = containerStatus.State.Running.StartedAt.String()
-		} else if containerStatus.State.Terminated != nil {
-			status[prefix+"state"] = "terminated"
-			status[prefix+"reason"] = containerStatus.State.Terminated.Reason
-			status[prefix+"exitCode"] = fmt.Sprintf("%d", containerStatus.State.Terminated.ExitCode)
-			status[prefix+"message"] = containerStatus.State.Terminated.Message
+		extractContainerState(containerStatus, prefix, status)
+	}
+
+	// Add init container statuses; they run in spec order before the app containers
+	for i, containerStatus := range pod.Status.InitContainerStatuses {
+		prefix := fmt.Sprintf("initContainer.%d.", i)
+		status[prefix+"name"] = containerStatus.Name
+		status[prefix+"ready"] = fmt.Sprintf("%v", containerStatus.Ready)
+		status[prefix+"restartCount"] = fmt.Sprintf("%d", containerStatus.RestartCount)
+		if containerStatus.Started != nil {
+			status[prefix+"started"] = fmt.Sprintf("%v", *containerStatus.Started)
+		}
+
+		// Native sidecars are init containers with restartPolicy Always
+		for _, container := range pod.Spec.InitContainers {
+			if container.Name == containerStatus.Name {
+				status[prefix+"image"] = container.Image
+				status[prefix+"imagePullPolicy"] = string(container.ImagePullPolicy)
+				if container.RestartPolicy != nil {
+					status[prefix+"restartPolicy"] = string(*container.RestartPolicy)
+				}
+				break
+			}
 		}
+
+		extractContainerState(containerStatus, prefix, status)
 	}
+	status["initContainers"] = fmt.Sprintf("%d", len(pod.Spec.InitContainers))
 
 	// Add conditions
 	for i, condition := range pod.Status.Conditions {
@@ -227,3 +308,26 @@ func extractPodStatus(pod *corev1.Pod) map[string]string {
 
 	return status
 }
+
+// extractContainerState records the current state of a container under prefix
+func extractContainerState(containerStatus corev1.ContainerStatus, prefix string, status map[string]string) {
+	if containerStatus.State.Waiting != nil {
+		status[prefix+"state"] = "waiting"
+		status[prefix+"reason"] = containerStatus.State.Waiting.Reason
+		status[prefix+"message"] = containerStatus.State.Waiting.Message
+	} else if containerStatus.State.Running != nil {
+		status[prefix+"state"] = "running"
+		status[prefix+"startedAt"] = containerStatus.State.Running.StartedAt.String()
+	} else if containerStatus.State.Terminated != nil {
+		status[prefix+"state"] = "terminated"
+		status[prefix+"reason"] = containerStatus.State.Terminated.Reason
+		status[prefix+"exitCode"] = fmt.Sprintf("%d", containerStatus.State.Terminated.ExitCode)
+		status[prefix+"message"] = containerStatus.State.Terminated.Message
+	}
+
+	// The last termination explains crash loops while the container waits to restart
+	if last := containerStatus.LastTerminationState.Terminated; last != nil {
+		status[prefix+"lastExitCode"] = fmt.Sprintf("%d", last.ExitCode)
+		status[prefix+"lastReason"] = last.Reason
+	}
+}
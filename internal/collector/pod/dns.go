@@ -0,0 +1,151 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8smed/k8smed/internal/logscan"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// collectDNSContext records what the cluster knows about the host names the pod failed
+// to resolve, plus the health of CoreDNS. It is only called when the logs contain DNS
+// failures.
+func (c *Collector) collectDNSContext(ctx context.Context, pod *corev1.Pod, failures []logscan.DNSFailure, status map[string]string) error {
+	namespaces, err := c.clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+
+	namespaceNames := make([]string, 0, len(namespaces.Items))
+	existing := make(map[string]bool)
+	for _, namespace := range namespaces.Items {
+		namespaceNames = append(namespaceNames, namespace.Name)
+		existing[namespace.Name] = true
+	}
+	sort.Strings(namespaceNames)
+	status["dns.namespaces"] = strings.Join(namespaceNames, ",")
+
+	// Services are listed once per namespace, however many hosts point into it
+	servicesByNamespace := make(map[string][]string)
+	listServices := func(namespace string) ([]string, error) {
+		if names, ok := servicesByNamespace[namespace]; ok {
+			return names, nil
+		}
+		services, err := c.clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list services in namespace %s: %w", namespace, err)
+		}
+		names := make([]string, 0, len(services.Items))
+		for _, service := range services.Items {
+			names = append(names, service.Name)
+		}
+		sort.Strings(names)
+		servicesByNamespace[namespace] = names
+		return names, nil
+	}
+
+	seen := make(map[string]bool)
+	index := 0
+	for _, failure := range failures {
+		if failure.Host == "" || seen[failure.Host] {
+			continue
+		}
+		seen[failure.Host] = true
+
+		prefix := fmt.Sprintf("dns.host.%d.", index)
+		status[prefix+"name"] = failure.Host
+		index++
+
+		service, namespace := logscan.SplitServiceHost(failure.Host, pod.Namespace)
+		if service == "" {
+			continue
+		}
+
+		// Record the services of the namespace the name points into, for typo detection
+		if existing[namespace] {
+			names, err := listServices(namespace)
+			if err != nil {
+				return err
+			}
+			status[prefix+"namespaceServices"] = strings.Join(names, ",")
+		}
+
+		// Find the namespaces that do have a service with this name
+		matches, err := c.clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{
+			FieldSelector: "metadata.name=" + service,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to look up service %s: %w", service, err)
+		}
+		found := make([]string, 0, len(matches.Items))
+		for _, match := range matches.Items {
+			found = append(found, match.Namespace)
+		}
+		sort.Strings(found)
+		status[prefix+"serviceNamespaces"] = strings.Join(found, ",")
+	}
+
+	return c.collectCoreDNSHealth(ctx, status)
+}
+
+// collectCoreDNSHealth records the state of the cluster DNS pods and service in kube-system
+func (c *Collector) collectCoreDNSHealth(ctx context.Context, status map[string]string) error {
+	pods, err := c.clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
+		LabelSelector: "k8s-app=kube-dns",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list CoreDNS pods: %w", err)
+	}
+
+	ready := 0
+	for i, dnsPod := range pods.Items {
+		prefix := fmt.Sprintf("dns.coredns.pod.%d.", i)
+		status[prefix+"name"] = dnsPod.Name
+		status[prefix+"phase"] = string(dnsPod.Status.Phase)
+		status[prefix+"node"] = dnsPod.Spec.NodeName
+
+		restarts := int32(0)
+		for _, containerStatus := range dnsPod.Status.ContainerStatuses {
+			restarts += containerStatus.RestartCount
+		}
+		status[prefix+"restartCount"] = fmt.Sprintf("%d", restarts)
+
+		isReady := false
+		for _, condition := range dnsPod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				isReady = true
+			}
+		}
+		status[prefix+"ready"] = fmt.Sprintf("%v", isReady)
+		if isReady {
+			ready++
+		}
+	}
+	status["dns.coredns.pods"] = fmt.Sprintf("%d", len(pods.Items))
+	status["dns.coredns.readyPods"] = fmt.Sprintf("%d", ready)
+
+	service, err := c.clientset.CoreV1().Services("kube-system").Get(ctx, "kube-dns", metav1.GetOptions{})
+	if err != nil {
+		status["dns.service.error"] = err.Error()
+		return nil
+	}
+	status["dns.service.clusterIP"] = service.Spec.ClusterIP
+
+	endpoints, err := c.clientset.CoreV1().Endpoints("kube-system").Get(ctx, "kube-dns", metav1.GetOptions{})
+	if err != nil {
+		status["dns.service.error"] = err.Error()
+		return nil
+	}
+	addresses := 0
+	for _, subset := range endpoints.Subsets {
+		addresses += len(subset.Addresses)
+	}
+	status["dns.service.endpoints"] = fmt.Sprintf("%d", addresses)
+
+	return nil
+}
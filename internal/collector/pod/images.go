@@ -0,0 +1,65 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// collectImagePullSecrets records whether each of the pod's image pull secrets exists
+// and which registry hosts it has credentials for. Credentials are never recorded.
+func (c *Collector) collectImagePullSecrets(ctx context.Context, pod *corev1.Pod, status map[string]string) {
+	for i, ref := range pod.Spec.ImagePullSecrets {
+		prefix := fmt.Sprintf("imagePullSecret.%d.", i)
+		status[prefix+"name"] = ref.Name
+
+		secret, err := c.clientset.CoreV1().Secrets(pod.Namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				status[prefix+"exists"] = "false"
+			} else {
+				status[prefix+"error"] = fmt.Sprintf("failed to get secret %s: %v", ref.Name, err)
+			}
+			continue
+		}
+
+		status[prefix+"exists"] = "true"
+		status[prefix+"type"] = string(secret.Type)
+		status[prefix+"registries"] = strings.Join(dockerConfigRegistries(secret), ",")
+	}
+}
+
+// dockerConfigRegistries returns the registry hosts configured in a docker config secret
+func dockerConfigRegistries(secret *corev1.Secret) []string {
+	var auths map[string]json.RawMessage
+
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		var config struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+			return nil
+		}
+		auths = config.Auths
+	case corev1.SecretTypeDockercfg:
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	registries := make([]string, 0, len(auths))
+	for host := range auths {
+		registries = append(registries, host)
+	}
+	sort.Strings(registries)
+	return registries
+}
@@ -0,0 +1,138 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// metricsAPI is the path of the resource metrics API served by metrics-server
+const metricsAPI = "/apis/metrics.k8s.io/v1beta1"
+
+// metricsRetryInterval is how long a collector stops asking for usage once the metrics API
+// was found not to be served, so a cluster without metrics-server is not probed once per
+// pod while a long-running collector still notices it being installed
+const metricsRetryInterval = 5 * time.Minute
+
+// podMetrics is the part of a metrics.k8s.io PodMetrics object the analyzers use
+type podMetrics struct {
+	Timestamp  metav1.Time     `json:"timestamp"`
+	Window     metav1.Duration `json:"window"`
+	Containers []struct {
+		Name  string              `json:"name"`
+		Usage corev1.ResourceList `json:"usage"`
+	} `json:"containers"`
+}
+
+// nodeMetrics is the part of a metrics.k8s.io NodeMetrics object the analyzers use
+type nodeMetrics struct {
+	Usage corev1.ResourceList `json:"usage"`
+}
+
+// collectMetrics records the live CPU and memory usage of the pod's containers and of
+// its node next to their requests, limits and allocatable resources. CPU is recorded
+// in millicores and memory in bytes. Without metrics-server only metrics.available is
+// recorded, as false.
+func (c *Collector) collectMetrics(ctx context.Context, pod *corev1.Pod, status map[string]string) {
+	if err := c.unavailableMetrics(); err != nil {
+		status["metrics.available"] = "false"
+		status["metrics.error"] = err.Error()
+		return
+	}
+
+	var usage podMetrics
+	if err := c.getMetrics(ctx, fmt.Sprintf("%s/namespaces/%s/pods/%s", metricsAPI, pod.Namespace, pod.Name), &usage); err != nil {
+		c.probeMetricsAPI(ctx)
+		status["metrics.available"] = "false"
+		status["metrics.error"] = err.Error()
+		return
+	}
+	status["metrics.available"] = "true"
+	status["metrics.timestamp"] = usage.Timestamp.UTC().Format("2006-01-02T15:04:05Z")
+	status["metrics.window"] = usage.Window.Duration.String()
+
+	for i, containerStatus := range pod.Status.ContainerStatuses {
+		prefix := fmt.Sprintf("container.%d.", i)
+		for _, container := range pod.Spec.Containers {
+			if container.Name == containerStatus.Name {
+				recordResources(status, prefix+"requests.", container.Resources.Requests)
+				recordResources(status, prefix+"limits.", container.Resources.Limits)
+				break
+			}
+		}
+		for _, container := range usage.Containers {
+			if container.Name == containerStatus.Name {
+				recordResources(status, prefix+"usage.", container.Usage)
+				break
+			}
+		}
+	}
+
+	if pod.Spec.NodeName == "" {
+		return
+	}
+	var node nodeMetrics
+	if err := c.getMetrics(ctx, fmt.Sprintf("%s/nodes/%s", metricsAPI, pod.Spec.NodeName), &node); err != nil {
+		status["metrics.node.error"] = err.Error()
+		return
+	}
+	status["metrics.node.name"] = pod.Spec.NodeName
+	recordResources(status, "metrics.node.usage.", node.Usage)
+	if nodeObject, err := c.clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{}); err == nil {
+		recordResources(status, "metrics.node.allocatable.", nodeObject.Status.Allocatable)
+	}
+}
+
+// unavailableMetrics returns why the metrics API was not served when it was last probed,
+// or nil when it was or when the probe is older than metricsRetryInterval
+func (c *Collector) unavailableMetrics() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metricsUnavailable != nil && time.Since(c.metricsCheckedAt) < metricsRetryInterval {
+		return c.metricsUnavailable
+	}
+	return nil
+}
+
+// probeMetricsAPI checks whether the metrics API is served after the usage of a pod could
+// not be read, which also happens for pods metrics-server has not scraped yet
+func (c *Collector) probeMetricsAPI(ctx context.Context) {
+	var resources metav1.APIResourceList
+	err := c.getMetrics(ctx, metricsAPI, &resources)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.metricsUnavailable = err
+	c.metricsCheckedAt = time.Now()
+}
+
+// getMetrics reads an object of the metrics API into out
+func (c *Collector) getMetrics(ctx context.Context, path string, out interface{}) error {
+	client := c.clientset.Discovery().RESTClient()
+	if client == nil {
+		return fmt.Errorf("metrics API not available")
+	}
+
+	data, err := client.Get().AbsPath(path).DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("metrics API not available: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode metrics: %w", err)
+	}
+	return nil
+}
+
+// recordResources records the CPU in millicores and the memory in bytes of a resource list
+func recordResources(status map[string]string, prefix string, resources corev1.ResourceList) {
+	if cpu, ok := resources[corev1.ResourceCPU]; ok {
+		status[prefix+"cpu"] = fmt.Sprintf("%d", cpu.MilliValue())
+	}
+	if memory, ok := resources[corev1.ResourceMemory]; ok {
+		status[prefix+"memory"] = fmt.Sprintf("%d", memory.Value())
+	}
+}
@@ -0,0 +1,51 @@
+package pod
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func TestCollectMetrics_RemembersUnavailableAPI(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, metricsAPI) {
+			requests.Add(1)
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatalf("NewForConfig() error = %v", err)
+	}
+	c := NewCollector(clientset)
+
+	for _, name := range []string{"web-1", "web-2", "web-3"} {
+		status := make(map[string]string)
+		c.collectMetrics(context.Background(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"}}, status)
+		if status["metrics.available"] != "false" || status["metrics.error"] == "" {
+			t.Errorf("Expected %s to record the metrics API as unavailable, got %v", name, status)
+		}
+	}
+	// The first pod asks for its usage and probes the API; the others are not asked for
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Expected 2 requests to the metrics API, got %d", got)
+	}
+
+	// Once the probe is old the API is asked again
+	c.metricsCheckedAt = c.metricsCheckedAt.Add(-metricsRetryInterval)
+	c.collectMetrics(context.Background(), &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-4", Namespace: "shop"}}, make(map[string]string))
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Expected the metrics API to be probed again, got %d requests", got)
+	}
+}
@@ -0,0 +1,167 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// forbiddenNamespaceRegex finds the namespaces named in RBAC "forbidden" errors
+var forbiddenNamespaceRegex = regexp.MustCompile(`forbidden: User "system:serviceaccount:[^"]+" cannot [^"]+ resource "[^"]+" in API group "[^"]*" in the namespace "([^"]+)"`)
+
+// hasForbiddenErrors reports whether any of the lines contain a service account RBAC denial
+func hasForbiddenErrors(lines []string) bool {
+	for _, line := range lines {
+		if strings.Contains(line, "forbidden: User \"system:serviceaccount:") {
+			return true
+		}
+	}
+	return false
+}
+
+// collectRBACRules records the RBAC rules granted to the pod's service account.
+// It is only called when the pod's logs or events show a permission denial, since
+// it lists bindings across the namespace and the cluster. Bindings whose role cannot
+// be read are recorded under rbac.binding.N and skipped; rbac.complete is only set
+// when every binding was resolved, since only then is a missing rule certain.
+func (c *Collector) collectRBACRules(ctx context.Context, pod *corev1.Pod, lines []string, status map[string]string) error {
+	serviceAccount := pod.Spec.ServiceAccountName
+	if serviceAccount == "" {
+		serviceAccount = "default"
+	}
+	status["rbac.serviceAccount"] = pod.Namespace + ":" + serviceAccount
+
+	// Role bindings only grant access in their own namespace, so look at every
+	// namespace the denials mention in addition to the pod's own
+	namespaces := []string{pod.Namespace}
+	for _, line := range lines {
+		for _, match := range forbiddenNamespaceRegex.FindAllStringSubmatch(line, -1) {
+			if !containsString(namespaces, match[1]) {
+				namespaces = append(namespaces, match[1])
+			}
+		}
+	}
+
+	index := 0
+	addRules := func(rules []rbacv1.PolicyRule, namespace, binding, role string) {
+		for _, rule := range rules {
+			prefix := fmt.Sprintf("rbac.rule.%d.", index)
+			status[prefix+"namespace"] = namespace
+			status[prefix+"binding"] = binding
+			status[prefix+"role"] = role
+			status[prefix+"apiGroups"] = strings.Join(rule.APIGroups, ",")
+			status[prefix+"resources"] = strings.Join(rule.Resources, ",")
+			status[prefix+"verbs"] = strings.Join(rule.Verbs, ",")
+			status[prefix+"resourceNames"] = strings.Join(rule.ResourceNames, ",")
+			index++
+		}
+	}
+
+	unresolved := 0
+	addUnresolved := func(namespace, binding string, err error) {
+		prefix := fmt.Sprintf("rbac.binding.%d.", unresolved)
+		status[prefix+"namespace"] = namespace
+		status[prefix+"name"] = binding
+		status[prefix+"error"] = err.Error()
+		unresolved++
+	}
+
+	for _, namespace := range namespaces {
+		roleBindings, err := c.clientset.RbacV1().RoleBindings(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to list role bindings in namespace %s: %w", namespace, err)
+		}
+
+		for _, binding := range roleBindings.Items {
+			if !bindsServiceAccount(binding.Subjects, pod.Namespace, serviceAccount) {
+				continue
+			}
+			rules, err := c.roleRules(ctx, namespace, binding.RoleRef)
+			if err != nil {
+				addUnresolved(namespace, "RoleBinding/"+binding.Name, err)
+				continue
+			}
+			addRules(rules, namespace, "RoleBinding/"+binding.Name, binding.RoleRef.Kind+"/"+binding.RoleRef.Name)
+		}
+	}
+
+	clusterRoleBindings, err := c.clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list cluster role bindings: %w", err)
+	}
+
+	for _, binding := range clusterRoleBindings.Items {
+		if !bindsServiceAccount(binding.Subjects, pod.Namespace, serviceAccount) {
+			continue
+		}
+		rules, err := c.roleRules(ctx, "", binding.RoleRef)
+		if err != nil {
+			addUnresolved("", "ClusterRoleBinding/"+binding.Name, err)
+			continue
+		}
+		addRules(rules, "", "ClusterRoleBinding/"+binding.Name, binding.RoleRef.Kind+"/"+binding.RoleRef.Name)
+	}
+
+	if unresolved == 0 {
+		status["rbac.complete"] = "true"
+	}
+	return nil
+}
+
+// roleRules resolves the rules of the Role or ClusterRole a binding points at
+func (c *Collector) roleRules(ctx context.Context, namespace string, roleRef rbacv1.RoleRef) ([]rbacv1.PolicyRule, error) {
+	switch roleRef.Kind {
+	case "Role":
+		role, err := c.clientset.RbacV1().Roles(namespace).Get(ctx, roleRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get role %s: %w", roleRef.Name, err)
+		}
+		return role.Rules, nil
+	case "ClusterRole":
+		role, err := c.clientset.RbacV1().ClusterRoles().Get(ctx, roleRef.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cluster role %s: %w", roleRef.Name, err)
+		}
+		return role.Rules, nil
+	default:
+		return nil, fmt.Errorf("unsupported role kind: %s", roleRef.Kind)
+	}
+}
+
+// bindsServiceAccount reports whether the subjects include the service account,
+// either directly or through one of the groups every service account belongs to
+func bindsServiceAccount(subjects []rbacv1.Subject, namespace, name string) bool {
+	for _, subject := range subjects {
+		switch subject.Kind {
+		case rbacv1.ServiceAccountKind:
+			if subject.Name == name && subject.Namespace == namespace {
+				return true
+			}
+		case rbacv1.GroupKind:
+			switch subject.Name {
+			case "system:serviceaccounts", "system:serviceaccounts:" + namespace, "system:authenticated":
+				return true
+			}
+		case rbacv1.UserKind:
+			if subject.Name == "system:serviceaccount:"+namespace+":"+name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsString reports whether the slice contains the value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
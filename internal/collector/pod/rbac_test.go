@@ -0,0 +1,78 @@
+package pod
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCollectRBACRules_DanglingBinding(t *testing.T) {
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "checkout", Namespace: "shop"}}
+	clientset := fake.NewClientset(
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-getter", Namespace: "shop"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout", Namespace: "shop"},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "pod-getter"},
+		},
+		// Points at a Role that was deleted
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-lister", Namespace: "shop"},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "pod-lister"},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-reader"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"list"}}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "checkout-nodes"},
+			Subjects:   subjects,
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "node-reader"},
+		},
+	)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "checkout-7d9f", Namespace: "shop"},
+		Spec:       corev1.PodSpec{ServiceAccountName: "checkout"},
+	}
+
+	status := make(map[string]string)
+	if err := NewCollector(clientset).collectRBACRules(context.Background(), pod, nil, status); err != nil {
+		t.Fatalf("collectRBACRules() error = %v", err)
+	}
+
+	// The bindings after the dangling one are still collected
+	if status["rbac.rule.0.role"] != "Role/pod-getter" || status["rbac.rule.1.role"] != "ClusterRole/node-reader" {
+		t.Errorf("Expected the rules of the resolvable bindings, got %v", status)
+	}
+	if status["rbac.binding.0.name"] != "RoleBinding/pod-lister" || !strings.Contains(status["rbac.binding.0.error"], "not found") {
+		t.Errorf("Expected the dangling binding to be recorded, got %v", status)
+	}
+	if _, ok := status["rbac.complete"]; ok {
+		t.Errorf("Expected the rules to be marked incomplete")
+	}
+
+	// Once the Role exists again, the rules are complete
+	_, err := clientset.RbacV1().Roles("shop").Create(context.Background(), &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod-lister", Namespace: "shop"},
+		Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("failed to create role: %v", err)
+	}
+	status = make(map[string]string)
+	if err := NewCollector(clientset).collectRBACRules(context.Background(), pod, nil, status); err != nil {
+		t.Fatalf("collectRBACRules() error = %v", err)
+	}
+	if status["rbac.complete"] != "true" || status["rbac.rule.2.role"] == "" {
+		t.Errorf("Expected three rules marked complete, got %v", status)
+	}
+}
@@ -0,0 +1,236 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// configReference describes a single ConfigMap or Secret reference found in a pod spec
+type configReference struct {
+	Kind      string // ConfigMap or Secret
+	Name      string
+	Key       string // Empty when the whole object is referenced
+	Source    string // env, envFrom or volume
+	Container string // Empty for volume references
+	Target    string // Env var name or volume name
+	Optional  bool
+}
+
+// configObject holds what we learned about a referenced object, without any values
+type configObject struct {
+	exists bool
+	keys   map[string]bool
+	err    error
+}
+
+// findConfigReferences walks the pod spec and returns every ConfigMap and Secret reference
+func findConfigReferences(pod *corev1.Pod) []configReference {
+	refs := make([]configReference, 0)
+
+	containers := make([]corev1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	containers = append(containers, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs = append(refs, configReference{
+					Kind:      "ConfigMap",
+					Name:      ref.Name,
+					Key:       ref.Key,
+					Source:    "env",
+					Container: container.Name,
+					Target:    env.Name,
+					Optional:  ref.Optional != nil && *ref.Optional,
+				})
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				refs = append(refs, configReference{
+					Kind:      "Secret",
+					Name:      ref.Name,
+					Key:       ref.Key,
+					Source:    "env",
+					Container: container.Name,
+					Target:    env.Name,
+					Optional:  ref.Optional != nil && *ref.Optional,
+				})
+			}
+		}
+
+		for _, envFrom := range container.EnvFrom {
+			if ref := envFrom.ConfigMapRef; ref != nil {
+				refs = append(refs, configReference{
+					Kind:      "ConfigMap",
+					Name:      ref.Name,
+					Source:    "envFrom",
+					Container: container.Name,
+					Optional:  ref.Optional != nil && *ref.Optional,
+				})
+			}
+			if ref := envFrom.SecretRef; ref != nil {
+				refs = append(refs, configReference{
+					Kind:      "Secret",
+					Name:      ref.Name,
+					Source:    "envFrom",
+					Container: container.Name,
+					Optional:  ref.Optional != nil && *ref.Optional,
+				})
+			}
+		}
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		if cm := volume.ConfigMap; cm != nil {
+			refs = append(refs, volumeReferences("ConfigMap", cm.Name, volume.Name, cm.Items, cm.Optional)...)
+		}
+		if secret := volume.Secret; secret != nil {
+			refs = append(refs, volumeReferences("Secret", secret.SecretName, volume.Name, secret.Items, secret.Optional)...)
+		}
+		if projected := volume.Projected; projected != nil {
+			for _, source := range projected.Sources {
+				if cm := source.ConfigMap; cm != nil {
+					refs = append(refs, volumeReferences("ConfigMap", cm.Name, volume.Name, cm.Items, cm.Optional)...)
+				}
+				if secret := source.Secret; secret != nil {
+					refs = append(refs, volumeReferences("Secret", secret.Name, volume.Name, secret.Items, secret.Optional)...)
+				}
+			}
+		}
+	}
+
+	return refs
+}
+
+// volumeReferences builds references for a ConfigMap or Secret volume source
+func volumeReferences(kind, name, volumeName string, items []corev1.KeyToPath, optional *bool) []configReference {
+	isOptional := optional != nil && *optional
+
+	// Without items the whole object is projected into the volume
+	if len(items) == 0 {
+		return []configReference{{
+			Kind:     kind,
+			Name:     name,
+			Source:   "volume",
+			Target:   volumeName,
+			Optional: isOptional,
+		}}
+	}
+
+	refs := make([]configReference, 0, len(items))
+	for _, item := range items {
+		refs = append(refs, configReference{
+			Kind:     kind,
+			Name:     name,
+			Key:      item.Key,
+			Source:   "volume",
+			Target:   volumeName,
+			Optional: isOptional,
+		})
+	}
+	return refs
+}
+
+// collectConfigReferences resolves the pod's ConfigMap and Secret references and
+// records the outcome in the status map. Only key names are read, never values.
+func (c *Collector) collectConfigReferences(ctx context.Context, pod *corev1.Pod, status map[string]string) {
+	refs := findConfigReferences(pod)
+	objects := make(map[string]*configObject)
+
+	for i, ref := range refs {
+		cacheKey := ref.Kind + "/" + ref.Name
+		object, ok := objects[cacheKey]
+		if !ok {
+			object = c.lookupConfigObject(ctx, pod.Namespace, ref.Kind, ref.Name)
+			objects[cacheKey] = object
+		}
+
+		prefix := fmt.Sprintf("configRef.%d.", i)
+		status[prefix+"kind"] = ref.Kind
+		status[prefix+"name"] = ref.Name
+		status[prefix+"source"] = ref.Source
+		status[prefix+"optional"] = fmt.Sprintf("%v", ref.Optional)
+		if ref.Key != "" {
+			status[prefix+"key"] = ref.Key
+		}
+		if ref.Container != "" {
+			status[prefix+"container"] = ref.Container
+		}
+		if ref.Target != "" {
+			status[prefix+"target"] = ref.Target
+		}
+
+		if object.err != nil {
+			status[prefix+"error"] = object.err.Error()
+			continue
+		}
+
+		status[prefix+"exists"] = fmt.Sprintf("%v", object.exists)
+		if object.exists && ref.Key != "" {
+			status[prefix+"keyExists"] = fmt.Sprintf("%v", object.keys[ref.Key])
+			if !object.keys[ref.Key] {
+				status[prefix+"availableKeys"] = joinKeys(object.keys)
+			}
+		}
+	}
+}
+
+// lookupConfigObject fetches a ConfigMap or Secret and keeps only its key names
+func (c *Collector) lookupConfigObject(ctx context.Context, namespace, kind, name string) *configObject {
+	object := &configObject{keys: make(map[string]bool)}
+
+	var err error
+	switch kind {
+	case "ConfigMap":
+		var cm *corev1.ConfigMap
+		cm, err = c.clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			for key := range cm.Data {
+				object.keys[key] = true
+			}
+			for key := range cm.BinaryData {
+				object.keys[key] = true
+			}
+		}
+	case "Secret":
+		var secret *corev1.Secret
+		secret, err = c.clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			for key := range secret.Data {
+				object.keys[key] = true
+			}
+		}
+	default:
+		object.err = fmt.Errorf("unsupported reference kind: %s", kind)
+		return object
+	}
+
+	switch {
+	case err == nil:
+		object.exists = true
+	case apierrors.IsNotFound(err):
+		object.exists = false
+	default:
+		object.err = fmt.Errorf("failed to get %s %s: %w", strings.ToLower(kind), name, err)
+	}
+
+	return object
+}
+
+// joinKeys returns the key names sorted and comma separated
+func joinKeys(keys map[string]bool) string {
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
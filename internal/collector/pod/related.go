@@ -0,0 +1,152 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// relatedResources accumulates a pod's related resources without duplicates
+type relatedResources struct {
+	namespace string
+	items     []collector.RelatedResource
+	seen      map[string]bool
+}
+
+// add records a related resource; an empty namespace stands for the pod's own
+func (r *relatedResources) add(kind, name, namespace string, relation collector.Relation) {
+	if name == "" {
+		return
+	}
+	if namespace == "" && kind != "Node" {
+		namespace = r.namespace
+	}
+
+	key := string(relation) + "/" + kind + "/" + namespace + "/" + name
+	if r.seen[key] {
+		return
+	}
+	r.seen[key] = true
+	r.items = append(r.items, collector.RelatedResource{
+		ResourceInfo: collector.ResourceInfo{Kind: kind, Name: name, Namespace: namespace},
+		Relation:     relation,
+	})
+}
+
+// collectRelated records the pod's owners, the objects it mounts and references, the
+// Services selecting it and its node
+func (c *Collector) collectRelated(ctx context.Context, pod *corev1.Pod) []collector.RelatedResource {
+	related := &relatedResources{namespace: pod.Namespace, seen: make(map[string]bool)}
+
+	if err := c.collectOwners(ctx, pod, related); err != nil {
+		// Log the error but continue
+		fmt.Printf("Warning: failed to resolve owners of pod %s: %v\n", pod.Name, err)
+	}
+
+	for _, volume := range pod.Spec.Volumes {
+		switch {
+		case volume.ConfigMap != nil:
+			related.add("ConfigMap", volume.ConfigMap.Name, "", collector.RelationMounts)
+		case volume.Secret != nil:
+			related.add("Secret", volume.Secret.SecretName, "", collector.RelationMounts)
+		case volume.PersistentVolumeClaim != nil:
+			related.add("PersistentVolumeClaim", volume.PersistentVolumeClaim.ClaimName, "", collector.RelationMounts)
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil {
+					related.add("ConfigMap", source.ConfigMap.Name, "", collector.RelationMounts)
+				}
+				if source.Secret != nil {
+					related.add("Secret", source.Secret.Name, "", collector.RelationMounts)
+				}
+			}
+		}
+	}
+
+	containers := append(append([]corev1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...)
+	for _, container := range containers {
+		for _, source := range container.EnvFrom {
+			if source.ConfigMapRef != nil {
+				related.add("ConfigMap", source.ConfigMapRef.Name, "", collector.RelationReferences)
+			}
+			if source.SecretRef != nil {
+				related.add("Secret", source.SecretRef.Name, "", collector.RelationReferences)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				related.add("ConfigMap", ref.Name, "", collector.RelationReferences)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				related.add("Secret", ref.Name, "", collector.RelationReferences)
+			}
+		}
+	}
+	for _, secret := range pod.Spec.ImagePullSecrets {
+		related.add("Secret", secret.Name, "", collector.RelationReferences)
+	}
+	related.add("ServiceAccount", pod.Spec.ServiceAccountName, "", collector.RelationReferences)
+
+	if err := c.collectSelectingServices(ctx, pod, related); err != nil {
+		// Log the error but continue
+		fmt.Printf("Warning: failed to list services selecting pod %s: %v\n", pod.Name, err)
+	}
+
+	related.add("Node", pod.Spec.NodeName, "", collector.RelationScheduledOn)
+
+	return related.items
+}
+
+// collectOwners walks the pod's controller references upward, through ReplicaSets to
+// their Deployment and through Jobs to their CronJob
+func (c *Collector) collectOwners(ctx context.Context, pod *corev1.Pod, related *relatedResources) error {
+	owner := metav1.GetControllerOf(pod)
+	for owner != nil {
+		related.add(owner.Kind, owner.Name, "", collector.RelationOwner)
+
+		switch owner.Kind {
+		case "ReplicaSet":
+			rs, err := c.clientset.AppsV1().ReplicaSets(pod.Namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get replica set %s: %w", owner.Name, err)
+			}
+			owner = metav1.GetControllerOf(rs)
+		case "Job":
+			job, err := c.clientset.BatchV1().Jobs(pod.Namespace).Get(ctx, owner.Name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get job %s: %w", owner.Name, err)
+			}
+			owner = metav1.GetControllerOf(job)
+		default:
+			owner = nil
+		}
+	}
+	return nil
+}
+
+// collectSelectingServices records the Services whose selector matches the pod
+func (c *Collector) collectSelectingServices(ctx context.Context, pod *corev1.Pod, related *relatedResources) error {
+	services, err := c.clientset.CoreV1().Services(pod.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, service := range services.Items {
+		// Services without a selector have manually managed endpoints
+		if len(service.Spec.Selector) == 0 {
+			continue
+		}
+		if labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+			related.add("Service", service.Name, "", collector.RelationSelectedBy)
+		}
+	}
+	return nil
+}
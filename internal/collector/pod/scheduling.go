@@ -0,0 +1,193 @@
+package pod
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// isUnschedulable reports whether the scheduler has rejected the pod
+func isUnschedulable(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled &&
+			condition.Status == corev1.ConditionFalse &&
+			condition.Reason == corev1.PodReasonUnschedulable {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeLoadTTL is how long the listed nodes and their requests are reused: long enough to
+// explain every unschedulable pod of one collection with a single pair of lists, short
+// enough that a long-running collector sees the nodes change
+const nodeLoadTTL = 30 * time.Second
+
+// nodeLoad is the nodes of the cluster and the requests of the pods running on each
+type nodeLoad struct {
+	nodes     []corev1.Node
+	requested map[string]corev1.ResourceList
+	listedAt  time.Time
+}
+
+// nodeLoad lists the nodes and the running pods of the cluster, reusing the last lists
+// for nodeLoadTTL
+func (c *Collector) nodeLoad(ctx context.Context) (*nodeLoad, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.load != nil && time.Since(c.load.listedAt) < nodeLoadTTL {
+		return c.load, nil
+	}
+
+	nodes, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	// Sum the requests of everything already running on each node
+	pods, err := c.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+		FieldSelector: "status.phase!=Succeeded,status.phase!=Failed",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	requested := make(map[string]corev1.ResourceList)
+	for i := range pods.Items {
+		nodeName := pods.Items[i].Spec.NodeName
+		if nodeName == "" {
+			continue
+		}
+		if requested[nodeName] == nil {
+			requested[nodeName] = corev1.ResourceList{}
+		}
+		addResources(requested[nodeName], podRequests(&pods.Items[i]))
+	}
+
+	c.load = &nodeLoad{nodes: nodes.Items, requested: requested, listedAt: time.Now()}
+	return c.load, nil
+}
+
+// collectSchedulingContext records the pod's scheduling constraints and the state of every
+// node, so analyzers can explain each predicate of a FailedScheduling message with real
+// node names. It needs all nodes and running pods, so it is only called for pods the
+// scheduler has rejected, and the lists are shared by the pods of a collection.
+func (c *Collector) collectSchedulingContext(ctx context.Context, pod *corev1.Pod, status map[string]string) error {
+	requests := podRequests(pod)
+	for name, quantity := range requests {
+		status["scheduling.request."+string(name)] = quantityString(name, quantity)
+	}
+	status["scheduling.nodeSelector"] = joinLabels(pod.Spec.NodeSelector)
+	status["scheduling.tolerations"] = joinTolerations(pod.Spec.Tolerations)
+
+	load, err := c.nodeLoad(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i, node := range load.nodes {
+		prefix := fmt.Sprintf("scheduling.node.%d.", i)
+		status[prefix+"name"] = node.Name
+		status[prefix+"unschedulable"] = fmt.Sprintf("%v", node.Spec.Unschedulable)
+		status[prefix+"ready"] = nodeReady(&node)
+		status[prefix+"labels"] = joinLabels(node.Labels)
+		status[prefix+"taints"] = joinTaints(node.Spec.Taints)
+
+		for name := range requests {
+			if allocatable, ok := node.Status.Allocatable[name]; ok {
+				status[prefix+"allocatable."+string(name)] = quantityString(name, allocatable)
+			} else {
+				status[prefix+"allocatable."+string(name)] = "0"
+			}
+			used := load.requested[node.Name][name]
+			status[prefix+"requested."+string(name)] = quantityString(name, used)
+		}
+	}
+
+	return nil
+}
+
+// podRequests returns the effective resource requests of a pod: the larger of the
+// summed app containers and any single init container, plus pod overhead
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	total := corev1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		addResources(total, container.Resources.Requests)
+	}
+
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := total[name]; !ok || quantity.Cmp(current) > 0 {
+				total[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	addResources(total, pod.Spec.Overhead)
+	return total
+}
+
+// addResources adds every quantity in delta to total
+func addResources(total, delta corev1.ResourceList) {
+	for name, quantity := range delta {
+		current := total[name]
+		current.Add(quantity)
+		total[name] = current
+	}
+}
+
+// quantityString renders CPU in millicores and everything else in base units
+func quantityString(name corev1.ResourceName, quantity resource.Quantity) string {
+	if name == corev1.ResourceCPU {
+		return fmt.Sprintf("%d", quantity.MilliValue())
+	}
+	return fmt.Sprintf("%d", quantity.Value())
+}
+
+// nodeReady returns the status of the node's Ready condition
+func nodeReady(node *corev1.Node) string {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return string(condition.Status)
+		}
+	}
+	return string(corev1.ConditionUnknown)
+}
+
+// joinLabels renders a label map as sorted key=value pairs
+func joinLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// joinTaints renders taints as key=value:effect
+func joinTaints(taints []corev1.Taint) string {
+	rendered := make([]string, 0, len(taints))
+	for _, taint := range taints {
+		rendered = append(rendered, taint.Key+"="+taint.Value+":"+string(taint.Effect))
+	}
+	return strings.Join(rendered, ",")
+}
+
+// joinTolerations renders tolerations as key=value:effect, or key:effect for Exists
+func joinTolerations(tolerations []corev1.Toleration) string {
+	rendered := make([]string, 0, len(tolerations))
+	for _, toleration := range tolerations {
+		if toleration.Operator == corev1.TolerationOpExists {
+			rendered = append(rendered, toleration.Key+":"+string(toleration.Effect))
+		} else {
+			rendered = append(rendered, toleration.Key+"="+toleration.Value+":"+string(toleration.Effect))
+		}
+	}
+	return strings.Join(rendered, ",")
+}
@@ -0,0 +1,78 @@
+package pod
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// pendingPod returns a pod the scheduler has rejected
+func pendingPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:      "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+		}}},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{{
+				Type:   corev1.PodScheduled,
+				Status: corev1.ConditionFalse,
+				Reason: corev1.PodReasonUnschedulable,
+			}},
+		},
+	}
+}
+
+func TestCollectSchedulingContext_SharedLists(t *testing.T) {
+	clientset := fake.NewClientset(
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+			Spec:       corev1.NodeSpec{Taints: []corev1.Taint{{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}}},
+			Status:     corev1.NodeStatus{Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "batch-1", Namespace: "jobs"},
+			Spec: corev1.PodSpec{NodeName: "node-1", Containers: []corev1.Container{{
+				Name:      "worker",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}},
+			}}},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		},
+		pendingPod("web-1"),
+		pendingPod("web-2"),
+	)
+
+	collected, err := NewCollector(clientset).CollectAll(context.Background(), collector.CollectionOptions{Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("CollectAll() error = %v", err)
+	}
+	if len(collected) != 2 {
+		t.Fatalf("Expected the two pending pods, got %d", len(collected))
+	}
+	for _, data := range collected {
+		status := data.Status
+		if status["scheduling.node.0.name"] != "node-1" || status["scheduling.node.0.taints"] != "gpu=true:NoSchedule" ||
+			status["scheduling.node.0.allocatable.cpu"] != "4000" || status["scheduling.node.0.requested.cpu"] != "3000" {
+			t.Errorf("Unexpected scheduling context of %s: %v", data.Resource.Name, status)
+		}
+	}
+
+	// The nodes and the pods of the cluster are listed once for both pods
+	lists := make(map[string]int)
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "list" {
+			lists[action.GetNamespace()+"/"+action.GetResource().Resource]++
+		}
+	}
+	if lists["/nodes"] != 1 || lists["/pods"] != 1 {
+		t.Errorf("Expected the cluster to be listed once, got %v", lists)
+	}
+}
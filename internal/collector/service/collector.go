@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Collector implements Service data collection
+type Collector struct {
+	clientset kubernetes.Interface
+}
+
+// NewCollector creates a new Service collector
+func NewCollector(clientset kubernetes.Interface) *Collector {
+	return &Collector{
+		clientset: clientset,
+	}
+}
+
+// Collect gathers data about a single Service
+func (c *Collector) Collect(ctx context.Context, options collector.CollectionOptions) (*collector.ResourceData, error) {
+	// Validate options
+	if options.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	if options.ResourceName == "" {
+		return nil, fmt.Errorf("service name is required")
+	}
+
+	service, err := c.clientset.CoreV1().Services(options.Namespace).Get(ctx, options.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service %s: %w", options.ResourceName, err)
+	}
+
+	return c.collectService(ctx, service, options), nil
+}
+
+// CollectAll gathers data about every Service in the namespace, or in all namespaces
+// when no namespace is set
+func (c *Collector) CollectAll(ctx context.Context, options collector.CollectionOptions) ([]*collector.ResourceData, error) {
+	services, err := c.clientset.CoreV1().Services(options.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: options.LabelSelector,
+		Limit:         options.Limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %w", err)
+	}
+
+	results := make([]*collector.ResourceData, 0, len(services.Items))
+	for i := range services.Items {
+		results = append(results, c.collectService(ctx, &services.Items[i], options))
+	}
+
+	return results, nil
+}
+
+// collectService builds the resource data for a Service, including its endpoints and
+// the pods its selector matches
+func (c *Collector) collectService(ctx context.Context, service *corev1.Service, options collector.CollectionOptions) *collector.ResourceData {
+	resourceData := &collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Service",
+			Name:      service.Name,
+			Namespace: service.Namespace,
+			Labels:    service.Labels,
+		},
+		Manifest: collector.FormatManifest(service, corev1.SchemeGroupVersion.WithKind("Service")),
+		Status:   extractServiceStatus(service),
+		Related:  []collector.RelatedResource{},
+	}
+
+	// Services without a selector have manually managed endpoints
+	if len(service.Spec.Selector) > 0 && service.Spec.Type != corev1.ServiceTypeExternalName {
+		if err := c.collectEndpoints(ctx, service, resourceData.Status); err != nil {
+			resourceData.Status["endpoints.error"] = err.Error()
+		}
+		if err := c.collectSelectedPods(ctx, service, resourceData); err != nil {
+			resourceData.Status["pods.error"] = err.Error()
+		}
+	}
+
+	// Collect events if requested
+	if options.IncludeEvents {
+		events, err := c.collectEvents(ctx, service)
+		if err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to collect events: %v\n", err)
+		} else {
+			resourceData.Events = events
+		}
+	}
+
+	return resourceData
+}
+
+// extractServiceStatus records the type, selector and ports of a Service
+func extractServiceStatus(service *corev1.Service) map[string]string {
+	status := make(map[string]string)
+	status["type"] = string(service.Spec.Type)
+	status["clusterIP"] = service.Spec.ClusterIP
+	if service.Spec.ExternalName != "" {
+		status["externalName"] = service.Spec.ExternalName
+	}
+
+	selector := make([]string, 0, len(service.Spec.Selector))
+	for key, value := range service.Spec.Selector {
+		selector = append(selector, key+"="+value)
+	}
+	sort.Strings(selector)
+	status["selector"] = strings.Join(selector, ",")
+
+	for i, port := range service.Spec.Ports {
+		prefix := fmt.Sprintf("port.%d.", i)
+		status[prefix+"name"] = port.Name
+		status[prefix+"port"] = fmt.Sprintf("%d", port.Port)
+		status[prefix+"targetPort"] = port.TargetPort.String()
+		status[prefix+"protocol"] = string(port.Protocol)
+	}
+
+	// Load balancers that never get an address are stuck in their cloud provider
+	if service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+		addresses := make([]string, 0, len(service.Status.LoadBalancer.Ingress))
+		for _, lb := range service.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				addresses = append(addresses, lb.IP)
+			} else if lb.Hostname != "" {
+				addresses = append(addresses, lb.Hostname)
+			}
+		}
+		status["loadBalancer"] = strings.Join(addresses, ",")
+	}
+
+	return status
+}
+
+// collectEndpoints records how many ready and not ready addresses back the Service
+func (c *Collector) collectEndpoints(ctx context.Context, service *corev1.Service, status map[string]string) error {
+	endpoints, err := c.clientset.CoreV1().Endpoints(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get endpoints: %w", err)
+	}
+
+	ready, notReady := 0, 0
+	if endpoints != nil && err == nil {
+		for _, subset := range endpoints.Subsets {
+			ready += len(subset.Addresses)
+			notReady += len(subset.NotReadyAddresses)
+		}
+	}
+	status["endpoints.ready"] = fmt.Sprintf("%d", ready)
+	status["endpoints.notReady"] = fmt.Sprintf("%d", notReady)
+	return nil
+}
+
+// collectSelectedPods records the pods the selector matches and how many of them are ready
+func (c *Collector) collectSelectedPods(ctx context.Context, service *corev1.Service, resourceData *collector.ResourceData) error {
+	pods, err := c.clientset.CoreV1().Pods(service.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(service.Spec.Selector).String(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	ready := 0
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready++
+				break
+			}
+		}
+		resourceData.Related = append(resourceData.Related, collector.RelatedResource{
+			ResourceInfo: collector.ResourceInfo{
+				Kind:      "Pod",
+				Name:      pod.Name,
+				Namespace: pod.Namespace,
+			},
+			Relation: collector.RelationSelects,
+		})
+	}
+	resourceData.Status["pods.matching"] = fmt.Sprintf("%d", len(pods.Items))
+	resourceData.Status["pods.ready"] = fmt.Sprintf("%d", ready)
+	return nil
+}
+
+// collectEvents gathers events related to the Service
+func (c *Collector) collectEvents(ctx context.Context, service *corev1.Service) ([]string, error) {
+	fieldSelector := fmt.Sprintf("involvedObject.name=%s,involvedObject.namespace=%s,involvedObject.kind=Service",
+		service.Name, service.Namespace)
+
+	return collector.ListEvents(ctx, c.clientset, service.Namespace, fieldSelector)
+}
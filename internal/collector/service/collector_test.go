@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCollectAll(t *testing.T) {
+	selector := map[string]string{"app": "web"}
+	pod := func(name string, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "shop", Labels: selector},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}},
+		}
+	}
+
+	clientset := fake.NewClientset(
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Spec: corev1.ServiceSpec{
+				Type:      corev1.ServiceTypeClusterIP,
+				ClusterIP: "10.96.0.10",
+				Selector:  map[string]string{"tier": "frontend", "app": "web"},
+				Ports:     []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromString("http"), Protocol: corev1.ProtocolTCP}},
+			},
+		},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "shop"},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}},
+				NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.2"}},
+			}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "shop", Labels: map[string]string{"tier": "frontend", "app": "web"}},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "shop", Labels: map[string]string{"tier": "frontend", "app": "web"}},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}},
+		},
+		// Services whose Endpoints object was never created have no backends at all
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Selector: map[string]string{"app": "api"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "lb", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer, Selector: selector},
+			Status: corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{
+				{IP: "203.0.113.7"}, {Hostname: "lb.example.com"},
+			}}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeExternalName, ExternalName: "db.example.com", Selector: selector},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Type: corev1.ServiceTypeClusterIP},
+		},
+		pod("lb-1", corev1.ConditionTrue),
+	)
+
+	results, err := NewCollector(clientset).CollectAll(context.Background(), collector.CollectionOptions{Namespace: "shop"})
+	if err != nil {
+		t.Fatalf("CollectAll() error = %v", err)
+	}
+
+	byName := make(map[string]*collector.ResourceData)
+	for _, result := range results {
+		byName[result.Resource.Name] = result
+	}
+
+	tests := []struct {
+		name    string
+		want    map[string]string
+		absent  []string
+		related []string
+	}{
+		{
+			name: "web",
+			want: map[string]string{
+				"type":               "ClusterIP",
+				"clusterIP":          "10.96.0.10",
+				"selector":           "app=web,tier=frontend",
+				"port.0.name":        "http",
+				"port.0.port":        "80",
+				"port.0.targetPort":  "http",
+				"port.0.protocol":    "TCP",
+				"endpoints.ready":    "1",
+				"endpoints.notReady": "1",
+				"pods.matching":      "2",
+				"pods.ready":         "1",
+			},
+			absent:  []string{"loadBalancer", "externalName"},
+			related: []string{"web-1", "web-2"},
+		},
+		{
+			name: "api",
+			want: map[string]string{
+				"endpoints.ready":    "0",
+				"endpoints.notReady": "0",
+				"pods.matching":      "0",
+				"loadBalancer":       "",
+			},
+			absent: []string{"endpoints.error"},
+		},
+		{
+			name: "lb",
+			want: map[string]string{
+				"loadBalancer":  "203.0.113.7,lb.example.com",
+				"pods.matching": "3",
+				"pods.ready":    "2",
+			},
+			related: []string{"lb-1", "web-1", "web-2"},
+		},
+		{
+			name:   "db",
+			want:   map[string]string{"type": "ExternalName", "externalName": "db.example.com"},
+			absent: []string{"endpoints.ready", "pods.matching"},
+		},
+		{
+			name:   "manual",
+			want:   map[string]string{"selector": ""},
+			absent: []string{"endpoints.ready", "pods.matching"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := byName[tt.name]
+			if !ok {
+				t.Fatalf("Expected service %s to be collected", tt.name)
+			}
+			for key, want := range tt.want {
+				if got, ok := result.Status[key]; !ok || got != want {
+					t.Errorf("Expected %s to be %q, got %q", key, want, got)
+				}
+			}
+			for _, key := range tt.absent {
+				if got, ok := result.Status[key]; ok {
+					t.Errorf("Expected %s to be absent, got %q", key, got)
+				}
+			}
+			related := make(map[string]bool)
+			for _, rel := range result.Related {
+				if rel.Kind != "Pod" || rel.Relation != collector.RelationSelects {
+					t.Errorf("Unexpected related resource %+v", rel)
+				}
+				related[rel.Name] = true
+			}
+			if len(related) != len(tt.related) {
+				t.Errorf("Expected related pods %v, got %+v", tt.related, result.Related)
+			}
+			for _, name := range tt.related {
+				if !related[name] {
+					t.Errorf("Expected pod %s to be related", name)
+				}
+			}
+		})
+	}
+}
+
+func TestCollect_RequiresName(t *testing.T) {
+	c := NewCollector(fake.NewClientset())
+	if _, err := c.Collect(context.Background(), collector.CollectionOptions{Namespace: "shop"}); err == nil {
+		t.Errorf("Expected an error without a service name")
+	}
+	if _, err := c.Collect(context.Background(), collector.CollectionOptions{Namespace: "shop", ResourceName: "missing"}); err == nil {
+		t.Errorf("Expected an error for a missing service")
+	}
+}
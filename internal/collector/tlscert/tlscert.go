@@ -0,0 +1,142 @@
+package tlscert
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Describe records metadata about the certificates of a PEM bundle under prefix. The
+// first certificate is treated as the leaf and the rest as its chain. Certificates are
+// parsed locally and the PEM data itself is never recorded.
+func Describe(status map[string]string, prefix string, data []byte) {
+	certs, err := parse(data)
+	status[prefix+"certificates"] = fmt.Sprintf("%d", len(certs))
+	if err != nil {
+		status[prefix+"parseError"] = err.Error()
+		return
+	}
+
+	leaf := certs[0]
+	describeCertificate(status, prefix, leaf)
+
+	// Intermediates often expire before the leaf does
+	earliest := leaf
+	for _, cert := range certs[1:] {
+		if cert.NotAfter.Before(earliest.NotAfter) {
+			earliest = cert
+		}
+	}
+	status[prefix+"chainNotAfter"] = earliest.NotAfter.UTC().Format(time.RFC3339)
+	status[prefix+"chainNotAfterSubject"] = earliest.Subject.String()
+
+	if chainErr := checkChain(certs); chainErr != "" {
+		status[prefix+"chainError"] = chainErr
+	}
+}
+
+// DescribeBundle records metadata about each certificate of a CA bundle under
+// prefix+"ca.N.". Unlike Describe it does not treat the bundle as a chain: a bundle may
+// hold several independent CAs, such as the old and new CA while one is rotated.
+func DescribeBundle(status map[string]string, prefix string, data []byte) {
+	certs, err := parse(data)
+	status[prefix+"certificates"] = fmt.Sprintf("%d", len(certs))
+	if err != nil {
+		status[prefix+"parseError"] = err.Error()
+		return
+	}
+
+	for i, cert := range certs {
+		describeCertificate(status, fmt.Sprintf("%sca.%d.", prefix, i), cert)
+	}
+}
+
+// describeCertificate records the fields of a single certificate under prefix
+func describeCertificate(status map[string]string, prefix string, cert *x509.Certificate) {
+	status[prefix+"subject"] = cert.Subject.String()
+	status[prefix+"issuer"] = cert.Issuer.String()
+	status[prefix+"notBefore"] = cert.NotBefore.UTC().Format(time.RFC3339)
+	status[prefix+"notAfter"] = cert.NotAfter.UTC().Format(time.RFC3339)
+	status[prefix+"dnsNames"] = strings.Join(cert.DNSNames, ",")
+	status[prefix+"isCA"] = fmt.Sprintf("%v", cert.IsCA)
+	status[prefix+"selfSigned"] = fmt.Sprintf("%v", selfSigned(cert))
+
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	status[prefix+"ipAddresses"] = strings.Join(ips, ",")
+}
+
+// DescribeSecret records the type of a Secret and the certificate stored under key.
+// For kubernetes.io/tls secrets it also checks that tls.key matches tls.crt, recording
+// keyError when it does not.
+func DescribeSecret(status map[string]string, prefix string, secret *corev1.Secret, key string) {
+	status[prefix+"type"] = string(secret.Type)
+
+	data, ok := secret.Data[key]
+	if !ok {
+		status[prefix+"parseError"] = fmt.Sprintf("secret has no %s key", key)
+		return
+	}
+	Describe(status, prefix, data)
+
+	if key == corev1.TLSCertKey && secret.Type == corev1.SecretTypeTLS {
+		if _, err := tls.X509KeyPair(data, secret.Data[corev1.TLSPrivateKeyKey]); err != nil {
+			status[prefix+"keyError"] = err.Error()
+		}
+	}
+}
+
+// parse decodes every certificate in a PEM bundle
+func parse(data []byte) ([]*x509.Certificate, error) {
+	certs := make([]*x509.Certificate, 0)
+	rest := bytes.TrimSpace(data)
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certs, fmt.Errorf("certificate %d: %w", len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
+
+// checkChain verifies that every certificate in the bundle is signed by the next one.
+// It does not consult any trust store, so a leaf served without intermediates passes.
+func checkChain(certs []*x509.Certificate) string {
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			return fmt.Sprintf("certificate %d (%s) is not signed by certificate %d (%s): %v",
+				i+1, certs[i].Subject, i+2, certs[i+1].Subject, err)
+		}
+	}
+	return ""
+}
+
+// selfSigned reports whether the certificate signs itself
+func selfSigned(cert *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, cert.RawSubject) {
+		return false
+	}
+	// CheckSignatureFrom would reject self-signed leaf certificates that are not CAs
+	return cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
@@ -0,0 +1,229 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// issued is a certificate with its key, both PEM encoded
+type issued struct {
+	cert   *x509.Certificate
+	key    *ecdsa.PrivateKey
+	pem    []byte
+	keyPEM []byte
+}
+
+// issue creates a certificate for the subject valid until notAfter, signed by parent or
+// self-signed when parent is nil
+func issue(t *testing.T, subject string, notAfter time.Time, isCA bool, parent *issued, dnsNames ...string) *issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: subject},
+		NotBefore:             notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		DNSNames:              dnsNames,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	return &issued{
+		cert:   cert,
+		key:    key,
+		pem:    pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	root := issue(t, "root", now.Add(10*365*24*time.Hour), true, nil)
+	intermediate := issue(t, "intermediate", now.Add(30*24*time.Hour), true, root)
+	leaf := issue(t, "web", now.Add(90*24*time.Hour), false, intermediate, "web.example.com")
+	other := issue(t, "other", now.Add(90*24*time.Hour), true, nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]string
+		// keys that must not be recorded
+		absent []string
+		// keys whose value must contain the string
+		contains map[string]string
+	}{
+		{
+			name: "leaf with its intermediate",
+			data: append(append([]byte{}, leaf.pem...), intermediate.pem...),
+			want: map[string]string{
+				"certificates": "2",
+				"subject":      "CN=web",
+				"issuer":       "CN=intermediate",
+				"dnsNames":     "web.example.com",
+				"isCA":         "false",
+				"selfSigned":   "false",
+				"notAfter":     now.Add(90 * 24 * time.Hour).Format(time.RFC3339),
+				// The intermediate expires before the leaf
+				"chainNotAfter":        now.Add(30 * 24 * time.Hour).Format(time.RFC3339),
+				"chainNotAfterSubject": "CN=intermediate",
+			},
+			absent: []string{"chainError", "parseError"},
+		},
+		{
+			name:     "leaf followed by an unrelated CA",
+			data:     append(append([]byte{}, leaf.pem...), other.pem...),
+			want:     map[string]string{"certificates": "2"},
+			contains: map[string]string{"chainError": "certificate 1 (CN=web) is not signed by certificate 2 (CN=other)"},
+		},
+		{
+			name: "self-signed CA",
+			data: other.pem,
+			want: map[string]string{"certificates": "1", "isCA": "true", "selfSigned": "true"},
+		},
+		{
+			name:     "not PEM",
+			data:     []byte("not a certificate"),
+			want:     map[string]string{"certificates": "0"},
+			contains: map[string]string{"parseError": "no PEM encoded certificate found"},
+			absent:   []string{"subject"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := make(map[string]string)
+			Describe(status, "tls.0.", tt.data)
+			for key, want := range tt.want {
+				if status["tls.0."+key] != want {
+					t.Errorf("Expected %s to be %q, got %q", key, want, status["tls.0."+key])
+				}
+			}
+			for key, want := range tt.contains {
+				if !strings.Contains(status["tls.0."+key], want) {
+					t.Errorf("Expected %s to contain %q, got %q", key, want, status["tls.0."+key])
+				}
+			}
+			for _, key := range tt.absent {
+				if value, ok := status["tls.0."+key]; ok {
+					t.Errorf("Expected no %s, got %q", key, value)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeBundle(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	oldCA := issue(t, "webhook-ca-1", now.Add(24*time.Hour), true, nil)
+	newCA := issue(t, "webhook-ca-2", now.Add(365*24*time.Hour), true, nil)
+
+	status := make(map[string]string)
+	DescribeBundle(status, "webhook.0.caBundle.", append(append([]byte{}, oldCA.pem...), newCA.pem...))
+
+	want := map[string]string{
+		"certificates":  "2",
+		"ca.0.subject":  "CN=webhook-ca-1",
+		"ca.0.notAfter": now.Add(24 * time.Hour).Format(time.RFC3339),
+		"ca.0.isCA":     "true",
+		"ca.1.subject":  "CN=webhook-ca-2",
+		"ca.1.notAfter": now.Add(365 * 24 * time.Hour).Format(time.RFC3339),
+	}
+	for key, value := range want {
+		if status["webhook.0.caBundle."+key] != value {
+			t.Errorf("Expected %s to be %q, got %q", key, value, status["webhook.0.caBundle."+key])
+		}
+	}
+	// Independent CAs are not a broken chain
+	for key := range status {
+		if strings.Contains(key, "chain") {
+			t.Errorf("Expected no chain fields for a bundle, got %s", key)
+		}
+	}
+}
+
+func TestDescribeSecret(t *testing.T) {
+	now := time.Now()
+	cert := issue(t, "web", now.Add(24*time.Hour), false, nil, "web.example.com")
+	otherKey := issue(t, "other", now.Add(24*time.Hour), false, nil)
+
+	tests := []struct {
+		name         string
+		secret       *corev1.Secret
+		wantKeyError bool
+		wantParse    string
+	}{
+		{
+			name: "matching key",
+			secret: &corev1.Secret{Type: corev1.SecretTypeTLS, Data: map[string][]byte{
+				corev1.TLSCertKey: cert.pem, corev1.TLSPrivateKeyKey: cert.keyPEM,
+			}},
+		},
+		{
+			name: "key of another certificate",
+			secret: &corev1.Secret{Type: corev1.SecretTypeTLS, Data: map[string][]byte{
+				corev1.TLSCertKey: cert.pem, corev1.TLSPrivateKeyKey: otherKey.keyPEM,
+			}},
+			wantKeyError: true,
+		},
+		{
+			name:      "missing certificate",
+			secret:    &corev1.Secret{Type: corev1.SecretTypeOpaque, Data: map[string][]byte{"ca.crt": cert.pem}},
+			wantParse: "secret has no tls.crt key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := make(map[string]string)
+			DescribeSecret(status, "", tt.secret, corev1.TLSCertKey)
+			if status["type"] != string(tt.secret.Type) {
+				t.Errorf("Expected type %s, got %q", tt.secret.Type, status["type"])
+			}
+			if _, ok := status["keyError"]; ok != tt.wantKeyError {
+				t.Errorf("Expected keyError: %v, got %v", tt.wantKeyError, status)
+			}
+			if status["parseError"] != tt.wantParse {
+				t.Errorf("Expected parseError %q, got %q", tt.wantParse, status["parseError"])
+			}
+		})
+	}
+}
+
+func TestDescribeCertificate_IPAddresses(t *testing.T) {
+	cert := &x509.Certificate{IPAddresses: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("::1")}}
+	status := make(map[string]string)
+	describeCertificate(status, "", cert)
+	if status["ipAddresses"] != "10.0.0.1,::1" {
+		t.Errorf("Expected both IP addresses, got %q", status["ipAddresses"])
+	}
+}
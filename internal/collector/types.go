@@ -11,9 +11,11 @@ type CollectionOptions struct {
 	LabelSelector string
 	IncludeEvents bool
 	IncludeLogs   bool
-	SinceSeconds  int64
-	TailLines     int64
-	Limit         int64
+	// IncludeMetrics queries metrics-server for the live usage of pods and their node
+	IncludeMetrics bool
+	SinceSeconds   int64
+	TailLines      int64
+	Limit          int64
 }
 
 // ResourceInfo contains basic information about a Kubernetes resource
@@ -24,6 +26,41 @@ type ResourceInfo struct {
 	Labels    map[string]string
 }
 
+// Relation is the type of the edge from a collected resource to a related one
+type Relation string
+
+// Relation types
+const (
+	// RelationOwner means the related resource owns the collected one, directly or
+	// through other owners (Pod -> ReplicaSet -> Deployment)
+	RelationOwner Relation = "owner"
+
+	// RelationMounts means the collected pod mounts the related ConfigMap, Secret or
+	// PersistentVolumeClaim as a volume
+	RelationMounts Relation = "mounts"
+
+	// RelationReferences means the collected resource refers to the related one, such
+	// as the env sources and service account of a pod or the TLS secrets of an ingress
+	RelationReferences Relation = "references"
+
+	// RelationSelects means the collected resource selects the related one, such as the
+	// pods and workloads covered by a PodDisruptionBudget
+	RelationSelects Relation = "selects"
+
+	// RelationSelectedBy means the related resource selects the collected one, such as
+	// a Service selecting a pod
+	RelationSelectedBy Relation = "selectedBy"
+
+	// RelationScheduledOn means the collected pod runs on the related node
+	RelationScheduledOn Relation = "scheduledOn"
+)
+
+// RelatedResource is a resource connected to the collected resource by a typed edge
+type RelatedResource struct {
+	ResourceInfo
+	Relation Relation
+}
+
 // ResourceData represents the collected data for a Kubernetes resource
 type ResourceData struct {
 	Resource ResourceInfo
@@ -31,7 +68,7 @@ type ResourceData struct {
 	Events   []string
 	Logs     []string
 	Status   map[string]string
-	Related  []ResourceInfo
+	Related  []RelatedResource
 }
 
 // ResourceCollector defines the interface for resource collectors
@@ -0,0 +1,327 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8smed/k8smed/internal/collector"
+	"github.com/k8smed/k8smed/internal/collector/tlscert"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Resource kinds produced by the collector
+const (
+	KindValidating = "ValidatingWebhookConfiguration"
+	KindMutating   = "MutatingWebhookConfiguration"
+)
+
+// Collector implements admission webhook configuration data collection
+type Collector struct {
+	clientset kubernetes.Interface
+}
+
+// NewCollector creates a new admission webhook configuration collector
+func NewCollector(clientset kubernetes.Interface) *Collector {
+	return &Collector{
+		clientset: clientset,
+	}
+}
+
+// webhook is the part of a validating or mutating webhook the collector records
+type webhook struct {
+	name              string
+	clientConfig      admissionregistrationv1.WebhookClientConfig
+	failurePolicy     *admissionregistrationv1.FailurePolicyType
+	namespaceSelector *metav1.LabelSelector
+	objectSelector    *metav1.LabelSelector
+	rules             []admissionregistrationv1.RuleWithOperations
+	timeoutSeconds    *int32
+}
+
+// clusterState holds what the collector looks up once for all configurations
+type clusterState struct {
+	kubeSystemLabels labels.Set
+	failedCreates    []corev1.Event
+}
+
+// Collect gathers data about a single webhook configuration, looking for a validating
+// configuration with the name first and a mutating one second
+func (c *Collector) Collect(ctx context.Context, options collector.CollectionOptions) (*collector.ResourceData, error) {
+	if options.ResourceName == "" {
+		return nil, fmt.Errorf("webhook configuration name is required")
+	}
+
+	state := c.lookupClusterState(ctx, options)
+
+	validating, err := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, options.ResourceName, metav1.GetOptions{})
+	if err == nil {
+		return c.collectValidating(ctx, validating, state), nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get validating webhook configuration %s: %w", options.ResourceName, err)
+	}
+
+	mutating, err := c.clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, options.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook configuration %s: %w", options.ResourceName, err)
+	}
+	return c.collectMutating(ctx, mutating, state), nil
+}
+
+// CollectAll gathers data about every validating and mutating webhook configuration
+func (c *Collector) CollectAll(ctx context.Context, options collector.CollectionOptions) ([]*collector.ResourceData, error) {
+	listOptions := metav1.ListOptions{
+		LabelSelector: options.LabelSelector,
+		Limit:         options.Limit,
+	}
+
+	validating, err := c.clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validating webhook configurations: %w", err)
+	}
+	mutating, err := c.clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, listOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutating webhook configurations: %w", err)
+	}
+
+	state := c.lookupClusterState(ctx, options)
+
+	results := make([]*collector.ResourceData, 0, len(validating.Items)+len(mutating.Items))
+	for i := range validating.Items {
+		results = append(results, c.collectValidating(ctx, &validating.Items[i], state))
+	}
+	for i := range mutating.Items {
+		results = append(results, c.collectMutating(ctx, &mutating.Items[i], state))
+	}
+
+	return results, nil
+}
+
+// lookupClusterState reads the kube-system labels and, when events are requested, the
+// FailedCreate events that may come from webhook calls
+func (c *Collector) lookupClusterState(ctx context.Context, options collector.CollectionOptions) clusterState {
+	state := clusterState{}
+
+	kubeSystem, err := c.clientset.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+	if err != nil {
+		// Log the error but continue
+		fmt.Printf("Warning: failed to get namespace kube-system: %v\n", err)
+	} else {
+		state.kubeSystemLabels = labels.Set(kubeSystem.Labels)
+	}
+
+	// Rejected creates are reported on the controller, not on the pod that was never created
+	if options.IncludeEvents {
+		events, err := c.clientset.CoreV1().Events(options.Namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: "reason=FailedCreate",
+		})
+		if err != nil {
+			// Log the error but continue
+			fmt.Printf("Warning: failed to list FailedCreate events: %v\n", err)
+		} else {
+			state.failedCreates = events.Items
+		}
+	}
+
+	return state
+}
+
+// collectValidating builds the resource data for a validating webhook configuration
+func (c *Collector) collectValidating(ctx context.Context, config *admissionregistrationv1.ValidatingWebhookConfiguration, state clusterState) *collector.ResourceData {
+	webhooks := make([]webhook, 0, len(config.Webhooks))
+	for _, w := range config.Webhooks {
+		webhooks = append(webhooks, webhook{
+			name:              w.Name,
+			clientConfig:      w.ClientConfig,
+			failurePolicy:     w.FailurePolicy,
+			namespaceSelector: w.NamespaceSelector,
+			objectSelector:    w.ObjectSelector,
+			rules:             w.Rules,
+			timeoutSeconds:    w.TimeoutSeconds,
+		})
+	}
+	resourceData := c.collectConfiguration(ctx, KindValidating, config.ObjectMeta, webhooks, state)
+	resourceData.Manifest = collector.FormatManifest(config, admissionregistrationv1.SchemeGroupVersion.WithKind(KindValidating))
+	return resourceData
+}
+
+// collectMutating builds the resource data for a mutating webhook configuration
+func (c *Collector) collectMutating(ctx context.Context, config *admissionregistrationv1.MutatingWebhookConfiguration, state clusterState) *collector.ResourceData {
+	webhooks := make([]webhook, 0, len(config.Webhooks))
+	for _, w := range config.Webhooks {
+		webhooks = append(webhooks, webhook{
+			name:              w.Name,
+			clientConfig:      w.ClientConfig,
+			failurePolicy:     w.FailurePolicy,
+			namespaceSelector: w.NamespaceSelector,
+			objectSelector:    w.ObjectSelector,
+			rules:             w.Rules,
+			timeoutSeconds:    w.TimeoutSeconds,
+		})
+	}
+	resourceData := c.collectConfiguration(ctx, KindMutating, config.ObjectMeta, webhooks, state)
+	resourceData.Manifest = collector.FormatManifest(config, admissionregistrationv1.SchemeGroupVersion.WithKind(KindMutating))
+	return resourceData
+}
+
+// collectConfiguration records each webhook's endpoint, policy, selectors, backend
+// health and CA bundle
+func (c *Collector) collectConfiguration(ctx context.Context, kind string, meta metav1.ObjectMeta, webhooks []webhook, state clusterState) *collector.ResourceData {
+	resourceData := &collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:   kind,
+			Name:   meta.Name,
+			Labels: meta.Labels,
+		},
+		Status:  make(map[string]string),
+		Events:  []string{},
+		Related: []collector.RelatedResource{},
+	}
+
+	for i, w := range webhooks {
+		prefix := fmt.Sprintf("webhook.%d.", i)
+		resourceData.Status[prefix+"name"] = w.name
+
+		// The API server defaults failurePolicy to Fail
+		failurePolicy := admissionregistrationv1.Fail
+		if w.failurePolicy != nil {
+			failurePolicy = *w.failurePolicy
+		}
+		resourceData.Status[prefix+"failurePolicy"] = string(failurePolicy)
+		if w.timeoutSeconds != nil {
+			resourceData.Status[prefix+"timeoutSeconds"] = fmt.Sprintf("%d", *w.timeoutSeconds)
+		}
+		resourceData.Status[prefix+"rules"] = formatRules(w.rules)
+
+		if w.namespaceSelector != nil {
+			resourceData.Status[prefix+"namespaceSelector"] = metav1.FormatLabelSelector(w.namespaceSelector)
+		}
+		if w.objectSelector != nil {
+			resourceData.Status[prefix+"objectSelector"] = metav1.FormatLabelSelector(w.objectSelector)
+		}
+		if state.kubeSystemLabels != nil {
+			if matches, err := selectorMatches(w.namespaceSelector, state.kubeSystemLabels); err == nil {
+				resourceData.Status[prefix+"matchesKubeSystem"] = fmt.Sprintf("%v", matches)
+			}
+		}
+
+		if service := w.clientConfig.Service; service != nil {
+			resourceData.Status[prefix+"service"] = service.Namespace + "/" + service.Name
+			if service.Port != nil {
+				resourceData.Status[prefix+"servicePort"] = fmt.Sprintf("%d", *service.Port)
+			}
+			if service.Path != nil {
+				resourceData.Status[prefix+"path"] = *service.Path
+			}
+			resourceData.Related = append(resourceData.Related, collector.RelatedResource{
+				ResourceInfo: collector.ResourceInfo{
+					Kind:      "Service",
+					Name:      service.Name,
+					Namespace: service.Namespace,
+				},
+				Relation: collector.RelationReferences,
+			})
+			c.collectBackend(ctx, service, prefix, resourceData.Status)
+		}
+		if w.clientConfig.URL != nil {
+			resourceData.Status[prefix+"url"] = *w.clientConfig.URL
+		}
+
+		// An empty bundle means the API server verifies the webhook with its own trust roots
+		if len(w.clientConfig.CABundle) > 0 {
+			tlscert.DescribeBundle(resourceData.Status, prefix+"caBundle.", w.clientConfig.CABundle)
+		}
+
+		resourceData.Events = append(resourceData.Events, webhookEvents(state.failedCreates, w.name)...)
+	}
+
+	return resourceData
+}
+
+// collectBackend records whether the webhook's Service exists and how many endpoints
+// are ready to serve it
+func (c *Collector) collectBackend(ctx context.Context, service *admissionregistrationv1.ServiceReference, prefix string, status map[string]string) {
+	if _, err := c.clientset.CoreV1().Services(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			status[prefix+"serviceExists"] = "false"
+		} else {
+			status[prefix+"serviceError"] = err.Error()
+		}
+		return
+	}
+	status[prefix+"serviceExists"] = "true"
+
+	endpoints, err := c.clientset.CoreV1().Endpoints(service.Namespace).Get(ctx, service.Name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		status[prefix+"serviceError"] = err.Error()
+		return
+	}
+
+	ready, notReady := 0, 0
+	if err == nil {
+		for _, subset := range endpoints.Subsets {
+			ready += len(subset.Addresses)
+			notReady += len(subset.NotReadyAddresses)
+		}
+	}
+	status[prefix+"readyEndpoints"] = fmt.Sprintf("%d", ready)
+	status[prefix+"notReadyEndpoints"] = fmt.Sprintf("%d", notReady)
+}
+
+// selectorMatches evaluates a webhook selector, where a nil selector matches everything
+func selectorMatches(selector *metav1.LabelSelector, set labels.Set) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+	parsed, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return parsed.Matches(set), nil
+}
+
+// formatRules summarizes rules as "CREATE,UPDATE:apps/deployments" entries separated by ";"
+func formatRules(rules []admissionregistrationv1.RuleWithOperations) string {
+	formatted := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		operations := make([]string, 0, len(rule.Operations))
+		for _, operation := range rule.Operations {
+			operations = append(operations, string(operation))
+		}
+
+		resources := make([]string, 0, len(rule.Resources))
+		for _, resource := range rule.Resources {
+			for _, group := range rule.APIGroups {
+				if group == "" {
+					resources = append(resources, resource)
+				} else {
+					resources = append(resources, group+"/"+resource)
+				}
+			}
+		}
+
+		formatted = append(formatted, strings.Join(operations, ",")+":"+strings.Join(resources, ","))
+	}
+	return strings.Join(formatted, ";")
+}
+
+// webhookEvents formats the FailedCreate events that mention the webhook
+func webhookEvents(events []corev1.Event, name string) []string {
+	quoted := `"` + name + `"`
+	matching := make([]string, 0)
+	for i, event := range events {
+		if !strings.Contains(event.Message, quoted) {
+			continue
+		}
+
+		matching = append(matching, collector.FormatEventWithObject(&events[i]))
+	}
+	return matching
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8smed/k8smed/internal/collector"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCollect(t *testing.T) {
+	ignore := admissionregistrationv1.Ignore
+	port := int32(8443)
+	backend := func(name string) admissionregistrationv1.WebhookClientConfig {
+		return admissionregistrationv1.WebhookClientConfig{
+			Service:  &admissionregistrationv1.ServiceReference{Namespace: "policy", Name: name, Port: &port},
+			CABundle: []byte("not a certificate"),
+		}
+	}
+	clientset := fake.NewClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:   "kube-system",
+			Labels: map[string]string{"kubernetes.io/metadata.name": "kube-system"},
+		}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "policy"}},
+		&corev1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "policy"},
+			Subsets: []corev1.EndpointSubset{{
+				Addresses:         []corev1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}},
+				NotReadyAddresses: []corev1.EndpointAddress{{IP: "10.0.0.3"}},
+			}},
+		},
+		// A Service whose Endpoints were never created has no endpoints
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "scaled-down", Namespace: "policy"}},
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{
+					Name:         "healthy.policy.example.com",
+					ClientConfig: backend("healthy"),
+					Rules: []admissionregistrationv1.RuleWithOperations{{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+						Rule:       admissionregistrationv1.Rule{APIGroups: []string{"", "apps"}, Resources: []string{"pods"}},
+					}},
+				},
+				{
+					Name:          "scaled-down.policy.example.com",
+					ClientConfig:  backend("scaled-down"),
+					FailurePolicy: &ignore,
+					NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{
+						Key:      "kubernetes.io/metadata.name",
+						Operator: metav1.LabelSelectorOpNotIn,
+						Values:   []string{"kube-system"},
+					}}},
+				},
+				{
+					Name:         "missing.policy.example.com",
+					ClientConfig: backend("missing"),
+				},
+			},
+		},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web.1", Namespace: "shop"},
+			InvolvedObject: corev1.ObjectReference{Kind: "ReplicaSet", Name: "web-7d9f", Namespace: "shop"},
+			Reason:         "FailedCreate",
+			Message:        `Error creating: Internal error occurred: failed calling webhook "missing.policy.example.com": service "missing" not found`,
+		},
+	)
+
+	data, err := NewCollector(clientset).Collect(context.Background(), collector.CollectionOptions{ResourceName: "policy", IncludeEvents: true})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if data.Resource.Kind != KindValidating {
+		t.Errorf("Expected a %s, got %s", KindValidating, data.Resource.Kind)
+	}
+
+	tests := []struct {
+		name   string
+		prefix string
+		want   map[string]string
+	}{
+		{
+			name:   "healthy backend",
+			prefix: "webhook.0.",
+			want: map[string]string{
+				"failurePolicy":     "Fail",
+				"rules":             "CREATE,UPDATE:pods,apps/pods",
+				"service":           "policy/healthy",
+				"servicePort":       "8443",
+				"serviceExists":     "true",
+				"readyEndpoints":    "2",
+				"notReadyEndpoints": "1",
+				// Without a selector every namespace, kube-system included, is intercepted
+				"namespaceSelector": "",
+				"matchesKubeSystem": "true",
+				// The bundle is read as a set of CAs, not a chain
+				"caBundle.certificates":  "0",
+				"caBundle.parseError":    "no PEM encoded certificate found",
+				"caBundle.chainNotAfter": "",
+			},
+		},
+		{
+			name:   "backend without endpoints excluding kube-system",
+			prefix: "webhook.1.",
+			want: map[string]string{
+				"failurePolicy":     "Ignore",
+				"namespaceSelector": "kubernetes.io/metadata.name notin (kube-system)",
+				"matchesKubeSystem": "false",
+				"serviceExists":     "true",
+				"readyEndpoints":    "0",
+				"notReadyEndpoints": "0",
+			},
+		},
+		{
+			name:   "missing backend",
+			prefix: "webhook.2.",
+			want: map[string]string{
+				"serviceExists":     "false",
+				"readyEndpoints":    "",
+				"matchesKubeSystem": "true",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, want := range tt.want {
+				if got := data.Status[tt.prefix+key]; got != want {
+					t.Errorf("Expected %s to be %q, got %q", tt.prefix+key, want, got)
+				}
+			}
+		})
+	}
+
+	// The FailedCreate event names only the webhook with the missing Service
+	if len(data.Events) != 1 {
+		t.Errorf("Expected the FailedCreate event of the missing backend, got %v", data.Events)
+	}
+	if len(data.Related) != 3 || data.Related[2].Name != "missing" {
+		t.Errorf("Expected the three backend Services to be related, got %+v", data.Related)
+	}
+}
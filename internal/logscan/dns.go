@@ -0,0 +1,159 @@
+package logscan
+
+import (
+	"net"
+	"regexp"
+	"strings"
+)
+
+// DNSFailureKind classifies a failed name resolution
+type DNSFailureKind string
+
+// Kinds of DNS failures
+const (
+	// DNSNotFound means the name does not exist (NXDOMAIN)
+	DNSNotFound DNSFailureKind = "NotFound"
+	// DNSTimeout means the DNS server did not answer
+	DNSTimeout DNSFailureKind = "Timeout"
+	// DNSServerFailure means the DNS server answered with an error (SERVFAIL)
+	DNSServerFailure DNSFailureKind = "ServerFailure"
+)
+
+// DNSFailure is a failed name resolution found in a log line
+type DNSFailure struct {
+	// Host is the name that failed to resolve; empty when the line does not say
+	Host string
+	// Server is the DNS server that was queried, when known
+	Server string
+	Kind   DNSFailureKind
+	Line   string
+}
+
+var (
+	// goLookupRegex matches Go resolver errors: "lookup host on 10.96.0.10:53: no such host"
+	goLookupRegex = regexp.MustCompile(`lookup ([A-Za-z0-9._-]+?)\.?(?: on ([0-9A-Fa-f.:\[\]]+))?: (.*)`)
+
+	// nodeLookupRegex matches Node.js resolver errors: "getaddrinfo ENOTFOUND host"
+	nodeLookupRegex = regexp.MustCompile(`getaddrinfo (ENOTFOUND|EAI_AGAIN) ([A-Za-z0-9._-]+)`)
+
+	// javaLookupRegex matches Java resolver errors: "java.net.UnknownHostException: host"
+	javaLookupRegex = regexp.MustCompile(`UnknownHostException: ([A-Za-z0-9._-]+)`)
+
+	// pythonLookupRegex matches urllib3 and curl style errors
+	pythonLookupRegex = regexp.MustCompile(`(?:Failed to resolve '([^']+)'|Could not resolve host: ([A-Za-z0-9._-]+))`)
+
+	// dnsDialRegex matches a failed connection to a DNS server without a host name
+	dnsDialRegex = regexp.MustCompile(`dial udp ([0-9A-Fa-f.:\[\]]+:53): i/o timeout`)
+
+	// hostTokenRegex finds host names in free-form text
+	hostTokenRegex = regexp.MustCompile(`[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)+`)
+)
+
+// IsDNSFailure reports whether the line describes a failed name resolution
+func IsDNSFailure(line string) bool {
+	_, ok := parseDNSFailure(line)
+	return ok
+}
+
+// ExtractDNSFailures returns every DNS failure found in the text, one per line at most
+func ExtractDNSFailures(text string) []DNSFailure {
+	failures := make([]DNSFailure, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if failure, ok := parseDNSFailure(line); ok {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// parseDNSFailure recognizes resolver errors from Go, Node.js, Java, Python and curl
+func parseDNSFailure(line string) (DNSFailure, bool) {
+	failure := DNSFailure{Line: strings.TrimSpace(line)}
+
+	if m := goLookupRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[1]
+		failure.Server = m[2]
+		detail := strings.ToLower(m[3])
+		switch {
+		case strings.Contains(detail, "no such host"):
+			failure.Kind = DNSNotFound
+		case strings.Contains(detail, "i/o timeout") || strings.Contains(detail, "temporary failure"):
+			failure.Kind = DNSTimeout
+		case strings.Contains(detail, "server misbehaving") || strings.Contains(detail, "servfail"):
+			failure.Kind = DNSServerFailure
+		default:
+			return failure, false
+		}
+		return failure, true
+	}
+
+	if m := nodeLookupRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[2]
+		failure.Kind = DNSNotFound
+		if m[1] == "EAI_AGAIN" {
+			failure.Kind = DNSTimeout
+		}
+		return failure, true
+	}
+
+	if m := javaLookupRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[1]
+		failure.Kind = DNSNotFound
+		if strings.Contains(line, "Temporary failure") {
+			failure.Kind = DNSTimeout
+		}
+		return failure, true
+	}
+
+	if m := pythonLookupRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[1] + m[2]
+		failure.Kind = DNSNotFound
+		if strings.Contains(line, "Temporary failure") {
+			failure.Kind = DNSTimeout
+		}
+		return failure, true
+	}
+
+	if m := dnsDialRegex.FindStringSubmatch(line); m != nil {
+		failure.Server = m[1]
+		failure.Kind = DNSTimeout
+		return failure, true
+	}
+
+	if strings.Contains(line, "SERVFAIL") {
+		failure.Kind = DNSServerFailure
+		for _, token := range hostTokenRegex.FindAllString(line, -1) {
+			if net.ParseIP(token) == nil {
+				failure.Host = token
+				break
+			}
+		}
+		return failure, true
+	}
+
+	return failure, false
+}
+
+// SplitServiceHost returns the service and namespace a cluster-internal name such as
+// "api", "api.shop" or "api.shop.svc.cluster.local" refers to. Names with more labels
+// and no "svc" label are external, and an empty service is returned for them.
+func SplitServiceHost(host, defaultNamespace string) (service, namespace string) {
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+
+	// Everything up to the "svc" label is service.namespace
+	for i, label := range labels {
+		if label == "svc" {
+			labels = labels[:i]
+			break
+		}
+	}
+
+	switch len(labels) {
+	case 1:
+		return labels[0], defaultNamespace
+	case 2:
+		return labels[0], labels[1]
+	default:
+		return "", ""
+	}
+}
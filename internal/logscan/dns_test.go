@@ -0,0 +1,89 @@
+package logscan
+
+import "testing"
+
+func TestExtractDNSFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		host   string
+		server string
+		kind   DNSFailureKind
+	}{
+		{
+			name:   "go resolver",
+			line:   `dial tcp: lookup orders.shop.svc.cluster.local on 10.96.0.10:53: no such host`,
+			host:   "orders.shop.svc.cluster.local",
+			server: "10.96.0.10:53",
+			kind:   DNSNotFound,
+		},
+		{
+			name:   "go resolver timeout",
+			line:   `lookup redis on 10.96.0.10:53: read udp 10.244.1.5:41234->10.96.0.10:53: i/o timeout`,
+			host:   "redis",
+			server: "10.96.0.10:53",
+			kind:   DNSTimeout,
+		},
+		{
+			name: "node.js",
+			line: `Error: getaddrinfo ENOTFOUND payments-api`,
+			host: "payments-api",
+			kind: DNSNotFound,
+		},
+		{
+			name: "java",
+			line: `java.net.UnknownHostException: db.prod.svc.cluster.local`,
+			host: "db.prod.svc.cluster.local",
+			kind: DNSNotFound,
+		},
+		{
+			name: "curl",
+			line: `curl: (6) Could not resolve host: api.example.com`,
+			host: "api.example.com",
+			kind: DNSNotFound,
+		},
+		{
+			name:   "dns server unreachable",
+			line:   `dial udp 10.96.0.10:53: i/o timeout`,
+			server: "10.96.0.10:53",
+			kind:   DNSTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := ExtractDNSFailures("starting\n" + tt.line)
+			if len(failures) != 1 {
+				t.Fatalf("Expected 1 failure, got %d", len(failures))
+			}
+			failure := failures[0]
+			if failure.Host != tt.host || failure.Server != tt.server || failure.Kind != tt.kind {
+				t.Errorf("Got %+v, want host=%q server=%q kind=%s", failure, tt.host, tt.server, tt.kind)
+			}
+		})
+	}
+
+	if IsDNSFailure("dial tcp 10.0.0.5:5432: connect: connection refused") {
+		t.Error("Expected a refused connection not to be a DNS failure")
+	}
+}
+
+func TestSplitServiceHost(t *testing.T) {
+	tests := []struct {
+		host      string
+		service   string
+		namespace string
+	}{
+		{"redis", "redis", "default"},
+		{"redis.cache", "redis", "cache"},
+		{"redis.cache.svc.cluster.local.", "redis", "cache"},
+		{"api.example.com", "", ""},
+	}
+
+	for _, tt := range tests {
+		service, namespace := SplitServiceHost(tt.host, "default")
+		if service != tt.service || namespace != tt.namespace {
+			t.Errorf("SplitServiceHost(%q) = %q, %q, want %q, %q", tt.host, service, namespace, tt.service, tt.namespace)
+		}
+	}
+}
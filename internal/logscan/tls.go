@@ -0,0 +1,116 @@
+package logscan
+
+import (
+	"regexp"
+	"strings"
+)
+
+// X509FailureKind classifies a failed certificate verification
+type X509FailureKind string
+
+// Kinds of certificate verification failures
+const (
+	// X509Expired means the certificate has expired or is not yet valid
+	X509Expired X509FailureKind = "Expired"
+	// X509UnknownAuthority means the certificate chain does not lead to a trusted root
+	X509UnknownAuthority X509FailureKind = "UnknownAuthority"
+	// X509HostnameMismatch means the certificate does not cover the requested host
+	X509HostnameMismatch X509FailureKind = "HostnameMismatch"
+)
+
+// X509Failure is a failed certificate verification found in a log line
+type X509Failure struct {
+	Kind X509FailureKind
+	// Host is the server name being verified, when the line says
+	Host string
+	// ValidFor lists the names the certificate covers, for hostname mismatches
+	ValidFor []string
+	Line     string
+}
+
+// x509Pattern maps an error message pattern to the kind of failure it describes
+type x509Pattern struct {
+	regex *regexp.Regexp
+	kind  X509FailureKind
+}
+
+var (
+	// x509Patterns covers Go, Java, Node.js, Python and OpenSSL messages
+	x509Patterns = []x509Pattern{
+		{regexp.MustCompile(`(?i)certificate has expired|CERT_HAS_EXPIRED|CertificateExpiredException|certificate is not yet valid`), X509Expired},
+		{regexp.MustCompile(`(?i)signed by unknown authority|PKIX path building failed|unable to get local issuer certificate|self[- ]signed certificate|UNABLE_TO_VERIFY_LEAF_SIGNATURE`), X509UnknownAuthority},
+		{regexp.MustCompile(`(?i)x509: certificate is valid for|ERR_TLS_CERT_ALTNAME_INVALID|hostname '[^']+' doesn't match|does not match certificate's altnames|No subject alternative (?:DNS name|names) (?:matching|present)`), X509HostnameMismatch},
+	}
+
+	// goHostnameRegex matches "x509: certificate is valid for a, b, not c"
+	goHostnameRegex = regexp.MustCompile(`certificate is valid for (.+?), not ([A-Za-z0-9.*-]+)`)
+
+	// nodeHostnameRegex matches "Host: c. is not in the cert's altnames: DNS:a, DNS:b"
+	nodeHostnameRegex = regexp.MustCompile(`Host: ([A-Za-z0-9.-]+?)\.? is not in the cert's altnames: (.+)`)
+
+	// pythonHostnameRegex matches "hostname 'c' doesn't match 'a'"
+	pythonHostnameRegex = regexp.MustCompile(`hostname '([^']+)' doesn't match (.+)`)
+
+	// urlHostRegex finds the server in a request URL or dial error
+	urlHostRegex = regexp.MustCompile(`(?:https://|wss://|dial tcp )([A-Za-z0-9.-]+[A-Za-z0-9])`)
+)
+
+// IsX509Failure reports whether the line describes a failed certificate verification
+func IsX509Failure(line string) bool {
+	_, ok := parseX509Failure(line)
+	return ok
+}
+
+// ExtractX509Failures returns every certificate verification failure found in the text,
+// one per line at most
+func ExtractX509Failures(text string) []X509Failure {
+	failures := make([]X509Failure, 0)
+	for _, line := range strings.Split(text, "\n") {
+		if failure, ok := parseX509Failure(line); ok {
+			failures = append(failures, failure)
+		}
+	}
+	return failures
+}
+
+// parseX509Failure recognizes certificate verification errors and the host involved
+func parseX509Failure(line string) (X509Failure, bool) {
+	failure := X509Failure{Line: strings.TrimSpace(line)}
+
+	for _, pattern := range x509Patterns {
+		if pattern.regex.MatchString(line) {
+			failure.Kind = pattern.kind
+			break
+		}
+	}
+	if failure.Kind == "" {
+		return failure, false
+	}
+
+	if m := goHostnameRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[2]
+		failure.ValidFor = splitNames(m[1], "")
+	} else if m := nodeHostnameRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[1]
+		failure.ValidFor = splitNames(m[2], "DNS:")
+	} else if m := pythonHostnameRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[1]
+		failure.ValidFor = splitNames(strings.ReplaceAll(m[2], "'", ""), "")
+	} else if m := urlHostRegex.FindStringSubmatch(line); m != nil {
+		failure.Host = m[1]
+	}
+
+	return failure, true
+}
+
+// splitNames splits a list of certificate names separated by commas or "or"
+func splitNames(list, prefix string) []string {
+	names := make([]string, 0)
+	for _, part := range strings.Split(strings.ReplaceAll(list, " or ", ","), ",") {
+		name := strings.TrimPrefix(strings.TrimSpace(part), prefix)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,83 @@
+package logscan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractX509Failures(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		kind     X509FailureKind
+		host     string
+		validFor []string
+	}{
+		{
+			name: "go expired",
+			line: `Get "https://api.example.com/v1": x509: certificate has expired or is not yet valid: current time 2024-05-01T10:00:00Z is after 2024-04-30T23:59:59Z`,
+			kind: X509Expired,
+			host: "api.example.com",
+		},
+		{
+			name:     "go hostname",
+			line:     `tls: failed to verify certificate: x509: certificate is valid for web.shop.svc, web, not api.shop.svc`,
+			kind:     X509HostnameMismatch,
+			host:     "api.shop.svc",
+			validFor: []string{"web.shop.svc", "web"},
+		},
+		{
+			name:     "node hostname",
+			line:     `Error [ERR_TLS_CERT_ALTNAME_INVALID]: Hostname/IP does not match certificate's altnames: Host: api.example.com. is not in the cert's altnames: DNS:www.example.com, DNS:example.com`,
+			kind:     X509HostnameMismatch,
+			host:     "api.example.com",
+			validFor: []string{"www.example.com", "example.com"},
+		},
+		{
+			name: "java",
+			line: `javax.net.ssl.SSLHandshakeException: PKIX path building failed: unable to find valid certification path`,
+			kind: X509UnknownAuthority,
+		},
+		{
+			name: "java hostname",
+			line: `javax.net.ssl.SSLHandshakeException: No subject alternative DNS name matching api.shop.svc found.`,
+			kind: X509HostnameMismatch,
+		},
+		{
+			name:     "python hostname",
+			line:     `ssl.CertificateError: hostname 'api.shop.svc' doesn't match 'web.shop.svc'`,
+			kind:     X509HostnameMismatch,
+			host:     "api.shop.svc",
+			validFor: []string{"web.shop.svc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := ExtractX509Failures(tt.line)
+			if len(failures) != 1 {
+				t.Fatalf("Expected 1 failure, got %d", len(failures))
+			}
+			failure := failures[0]
+			if failure.Kind != tt.kind || failure.Host != tt.host {
+				t.Errorf("Got %+v, want kind=%s host=%q", failure, tt.kind, tt.host)
+			}
+			if tt.validFor != nil && !reflect.DeepEqual(failure.ValidFor, tt.validFor) {
+				t.Errorf("ValidFor = %v, want %v", failure.ValidFor, tt.validFor)
+			}
+		})
+	}
+
+	// Mismatches outside certificate verification are not failures
+	for _, line := range []string{
+		"TLS handshake completed",
+		"login failed for user admin: password doesn't match",
+		"migration aborted: schema doesn't match the expected version 12",
+		"checksum does not match",
+		"No subject alternative configured, using defaults",
+	} {
+		if IsX509Failure(line) {
+			t.Errorf("Expected %q not to be a certificate failure", line)
+		}
+	}
+}
@@ -3,42 +3,61 @@ package analyzer
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
+	"github.com/k8smed/k8smed/internal/logscan"
+	"github.com/k8smed/k8smed/pkg/analyzer/logpattern"
 	"github.com/k8smed/k8smed/pkg/collector"
 )
 
 // AnalysisContext contains all the information needed for analysis
 type AnalysisContext struct {
 	// Original user query
-	Query string
+	Query string `json:"query"`
 
 	// Collected resources data
-	Resources []collector.ResourceData
+	Resources []collector.ResourceData `json:"resources"`
 
-	// Analysis details (filled by analyzers)
-	Details []AnalysisDetail
+	// Analysis details (filled by the Engine once every analyzer has finished)
+	Details []AnalysisDetail `json:"details,omitempty"`
 }
 
 // AnalysisDetail represents a single finding or observation during analysis
 type AnalysisDetail struct {
-	// Type of the issue/finding (error, warning, info)
-	Type string
+	// Stable machine-readable identifier of the check, such as POD_CRASHLOOP
+	ID string `json:"id"`
+
+	// Name of the analyzer that produced the finding
+	Analyzer string `json:"analyzer,omitempty"`
+
+	// How serious the issue is
+	Severity Severity `json:"severity"`
+
+	// How sure the analyzer is about the finding, from 0 to 1
+	Confidence float64 `json:"confidence"`
 
 	// Short description of the issue
-	Title string
+	Title string `json:"title"`
 
 	// Detailed explanation
-	Description string
+	Description string `json:"description"`
+
+	// Collected data that triggered the finding
+	Evidence []Evidence `json:"evidence,omitempty"`
 
 	// Resource related to this finding
-	Resource collector.ResourceInfo
+	Resource collector.ResourceInfo `json:"resource"`
+
+	// Resource the finding blames, when it is not Resource itself (such as a
+	// missing ConfigMap referenced by a pod); used to correlate findings
+	Cause *collector.ResourceInfo `json:"cause,omitempty"`
 
 	// Suggested remediation steps
-	Remediation []string
+	Remediation []string `json:"remediation,omitempty"`
 
 	// Commands that could help fix the issue
-	RemediationCommands []string
+	RemediationCommands []string `json:"remediationCommands,omitempty"`
 }
 
 // Analyzer interface defines methods for analyzing Kubernetes resources
@@ -49,12 +68,27 @@ type Analyzer interface {
 	// Description returns the analyzer description
 	Description() string
 
-	// Analyze performs analysis on the provided context
-	Analyze(ctx context.Context, analysisCtx *AnalysisContext) error
+	// Analyze returns the findings for the provided context. Analyzers run
+	// concurrently and must treat the context as read-only.
+	Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error)
 }
 
+// Superseder is implemented by analyzers that explain a problem in more detail than
+// the generic finding another analyzer reports for it. When both run, the Engine drops
+// the generic findings about the resource and container of each finding that replaces them.
+type Superseder interface {
+	// Supersedes returns the IDs of the findings that detail replaces
+	Supersedes(detail AnalysisDetail) []string
+}
+
+// maxTraceLines limits how much of a stack trace is shown in a finding
+const maxTraceLines = 20
+
 // PodAnalyzer analyzes pod-related issues
-type PodAnalyzer struct{}
+type PodAnalyzer struct {
+	// Patterns recognizes failures in logs; nil uses logpattern.DefaultLibrary
+	Patterns *logpattern.Library
+}
 
 // Name implements the Analyzer interface
 func (a *PodAnalyzer) Name() string {
@@ -67,34 +101,47 @@ func (a *PodAnalyzer) Description() string {
 }
 
 // Analyze implements the Analyzer interface
-func (a *PodAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) error {
+func (a *PodAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+
 	// Filter for pod resources
 	for _, resource := range analysisCtx.Resources {
 		if resource.Resource.Kind == "Pod" {
 			// Check container states for common issues
-			a.checkContainerStates(resource, analysisCtx)
+			details = append(details, a.checkContainerStates(resource)...)
 
 			// Check pod events for issues
-			a.checkEvents(resource, analysisCtx)
+			details = append(details, a.checkEvents(resource)...)
 
 			// Check pod logs if available
 			if len(resource.Logs) > 0 {
-				a.checkLogs(resource, analysisCtx)
+				details = append(details, a.checkLogs(resource)...)
 			}
 
 			// Check pod status
-			a.checkPodStatus(resource, analysisCtx)
+			details = append(details, a.checkPodStatus(resource)...)
 		}
 	}
 
-	return nil
+	return details, nil
 }
 
 // checkContainerStates checks for common container state issues
-func (a *PodAnalyzer) checkContainerStates(resource collector.ResourceData, analysisCtx *AnalysisContext) {
-	// Loop through all possible container states in the status map
-	for key, value := range resource.Status {
-		if strings.HasSuffix(key, ".state") && value == "waiting" {
+func (a *PodAnalyzer) checkContainerStates(resource collector.ResourceData) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+
+	// Loop through all app container states in the status map, in key order so the
+	// findings are stable; init containers are left to the InitContainerAnalyzer,
+	// which knows about their ordering
+	keys := make([]string, 0, len(resource.Status))
+	for key := range resource.Status {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := resource.Status[key]
+		if strings.HasPrefix(key, "container.") && strings.HasSuffix(key, ".state") && value == "waiting" {
 			// Extract container index and name
 			parts := strings.Split(key, ".")
 			if len(parts) < 2 {
@@ -112,12 +159,19 @@ func (a *PodAnalyzer) checkContainerStates(resource collector.ResourceData, anal
 			}
 
 			// Handle specific waiting reasons
+			evidence := statusEvidence(resource.Status, containerPrefix+"name", containerPrefix+"state",
+				containerPrefix+"reason", containerPrefix+"message", containerPrefix+"restartCount",
+				containerPrefix+"lastExitCode", containerPrefix+"lastReason")
+
 			switch waitReason {
 			case "CrashLoopBackOff":
 				detail := AnalysisDetail{
-					Type:        "error",
+					ID:          "POD_CRASHLOOP",
+					Severity:    SeverityError,
+					Confidence:  ConfidenceCertain,
 					Title:       "Container in CrashLoopBackOff",
 					Description: "Container " + containerName + " is crash looping: " + waitMessage,
+					Evidence:    evidence,
 					Resource:    resource.Resource,
 					Remediation: []string{
 						"Check container logs for errors",
@@ -130,13 +184,16 @@ func (a *PodAnalyzer) checkContainerStates(resource collector.ResourceData, anal
 						"kubectl describe pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
 					},
 				}
-				analysisCtx.Details = append(analysisCtx.Details, detail)
+				details = append(details, detail)
 
 			case "ImagePullBackOff", "ErrImagePull":
 				detail := AnalysisDetail{
-					Type:        "error",
+					ID:          "POD_IMAGE_PULL_FAILED",
+					Severity:    SeverityError,
+					Confidence:  ConfidenceCertain,
 					Title:       "Image pull failure",
 					Description: "Container " + containerName + " cannot pull its image: " + waitMessage,
+					Evidence:    evidence,
 					Resource:    resource.Resource,
 					Remediation: []string{
 						"Verify the image name and tag are correct",
@@ -148,13 +205,16 @@ func (a *PodAnalyzer) checkContainerStates(resource collector.ResourceData, anal
 						"kubectl describe pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
 					},
 				}
-				analysisCtx.Details = append(analysisCtx.Details, detail)
+				details = append(details, detail)
 
 			case "CreateContainerConfigError":
 				detail := AnalysisDetail{
-					Type:        "error",
+					ID:          "POD_CONTAINER_CONFIG_ERROR",
+					Severity:    SeverityError,
+					Confidence:  ConfidenceCertain,
 					Title:       "Container configuration error",
 					Description: "Container " + containerName + " has configuration errors: " + waitMessage,
+					Evidence:    evidence,
 					Resource:    resource.Resource,
 					Remediation: []string{
 						"Check if referenced ConfigMaps exist",
@@ -168,17 +228,21 @@ func (a *PodAnalyzer) checkContainerStates(resource collector.ResourceData, anal
 						"kubectl get secrets -n " + resource.Resource.Namespace,
 					},
 				}
-				analysisCtx.Details = append(analysisCtx.Details, detail)
+				details = append(details, detail)
 			}
 		}
 	}
+
+	return details
 }
 
 // checkEvents examines pod events for issues
-func (a *PodAnalyzer) checkEvents(resource collector.ResourceData, analysisCtx *AnalysisContext) {
+func (a *PodAnalyzer) checkEvents(resource collector.ResourceData) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+
 	// Skip if no events
 	if len(resource.Events) == 0 {
-		return
+		return details
 	}
 
 	// Look for specific event patterns
@@ -188,9 +252,12 @@ func (a *PodAnalyzer) checkEvents(resource collector.ResourceData, analysisCtx *
 		// Look for OOMKilled issues
 		if strings.Contains(lowerEvent, "oomkilled") {
 			detail := AnalysisDetail{
-				Type:        "error",
+				ID:          "POD_OOM_KILLED",
+				Severity:    SeverityError,
+				Confidence:  ConfidenceHigh,
 				Title:       "Container terminated due to OOMKilled",
 				Description: "A container was terminated because it exceeded its memory limits: " + event,
+				Evidence:    eventEvidence(event),
 				Resource:    resource.Resource,
 				Remediation: []string{
 					"Increase memory limits for the container",
@@ -202,15 +269,18 @@ func (a *PodAnalyzer) checkEvents(resource collector.ResourceData, analysisCtx *
 					"kubectl get pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace + " -o yaml",
 				},
 			}
-			analysisCtx.Details = append(analysisCtx.Details, detail)
+			details = append(details, detail)
 		}
 
 		// Look for eviction issues
 		if strings.Contains(lowerEvent, "evict") {
 			detail := AnalysisDetail{
-				Type:        "warning",
+				ID:          "POD_EVICTED",
+				Severity:    SeverityWarning,
+				Confidence:  ConfidenceMedium,
 				Title:       "Pod was evicted",
 				Description: "The pod was evicted from its node: " + event,
+				Evidence:    eventEvidence(event),
 				Resource:    resource.Resource,
 				Remediation: []string{
 					"Check node resource pressure (CPU, memory, disk)",
@@ -222,26 +292,39 @@ func (a *PodAnalyzer) checkEvents(resource collector.ResourceData, analysisCtx *
 					"kubectl top nodes",
 				},
 			}
-			analysisCtx.Details = append(analysisCtx.Details, detail)
+			details = append(details, detail)
 		}
 	}
+
+	return details
 }
 
 // checkLogs looks for error patterns in logs
-func (a *PodAnalyzer) checkLogs(resource collector.ResourceData, analysisCtx *AnalysisContext) {
+func (a *PodAnalyzer) checkLogs(resource collector.ResourceData) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+
 	// Combine all logs to search for patterns
 	combinedLogs := strings.Join(resource.Logs, "\n")
 	lowerLogs := strings.ToLower(combinedLogs)
 
-	// Look for common error patterns
-	if strings.Contains(lowerLogs, "exception") || strings.Contains(lowerLogs, "error") {
+	// Recognized failures are reported with their root cause and stack trace
+	matches := a.library().ScanLogs(resource.Logs)
+	for _, match := range matches {
+		details = append(details, logMatchDetail(resource, match))
+	}
+
+	// Fall back to a snippet around the first error when no pattern recognizes the failure
+	if len(matches) == 0 && (strings.Contains(lowerLogs, "exception") || strings.Contains(lowerLogs, "error")) {
 		// Extract a snippet around the error
 		errorSnippet := a.extractErrorSnippet(combinedLogs)
 
 		detail := AnalysisDetail{
-			Type:        "warning",
+			ID:          "LOG_ERRORS",
+			Severity:    SeverityWarning,
+			Confidence:  ConfidenceLow,
 			Title:       "Errors detected in logs",
 			Description: "The pod logs contain errors or exceptions: " + errorSnippet,
+			Evidence:    []Evidence{logEvidence("", errorSnippet)},
 			Resource:    resource.Resource,
 			Remediation: []string{
 				"Review application logs for detailed error information",
@@ -252,17 +335,18 @@ func (a *PodAnalyzer) checkLogs(resource collector.ResourceData, analysisCtx *An
 				"kubectl logs " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
 			},
 		}
-		analysisCtx.Details = append(analysisCtx.Details, detail)
+		details = append(details, detail)
 	}
 
-	// Look for connection issues
-	if strings.Contains(lowerLogs, "connection refused") ||
-		strings.Contains(lowerLogs, "cannot connect") ||
-		strings.Contains(lowerLogs, "dial tcp") {
+	// Look for connection issues; name resolution failures are left to the DNSAnalyzer
+	if line := connectionIssue(combinedLogs); line != "" {
 		detail := AnalysisDetail{
-			Type:        "warning",
+			ID:          "LOG_CONNECTION_ISSUES",
+			Severity:    SeverityWarning,
+			Confidence:  ConfidenceMedium,
 			Title:       "Connection issues detected",
 			Description: "The logs show connection problems to other services",
+			Evidence:    []Evidence{logEvidence("", strings.TrimSpace(line))},
 			Resource:    resource.Resource,
 			Remediation: []string{
 				"Verify the service endpoints are correct",
@@ -275,16 +359,84 @@ func (a *PodAnalyzer) checkLogs(resource collector.ResourceData, analysisCtx *An
 				"kubectl get networkpolicies -n " + resource.Resource.Namespace,
 			},
 		}
-		analysisCtx.Details = append(analysisCtx.Details, detail)
+		details = append(details, detail)
+	}
+
+	return details
+}
+
+// library returns the log pattern library used by checkLogs. The default library is
+// not stored, since the analyzer may run concurrently.
+func (a *PodAnalyzer) library() *logpattern.Library {
+	if a.Patterns == nil {
+		return logpattern.DefaultLibrary()
+	}
+	return a.Patterns
+}
+
+// logMatchDetail turns a recognized log failure into a finding
+func logMatchDetail(resource collector.ResourceData, match logpattern.Match) AnalysisDetail {
+	description := match.RootCause
+	if match.Container != "" {
+		description = "Container " + match.Container + ": " + description
+	}
+	if match.Location != "" {
+		description += " (at " + match.Location + ")"
+	}
+	if match.Count > 1 {
+		description += fmt.Sprintf(", seen %d times", match.Count)
+	}
+	if len(match.Trace) > 0 {
+		trace := match.Trace
+		if len(trace) > maxTraceLines {
+			trace = append(trace[:maxTraceLines:maxTraceLines], fmt.Sprintf("... %d more lines", len(match.Trace)-maxTraceLines))
+		}
+		description += "\n" + strings.Join(trace, "\n")
+	}
+
+	command := "kubectl logs " + resource.Resource.Name + " -n " + resource.Resource.Namespace
+	if match.Container != "" {
+		command += " -c " + match.Container
+	}
+
+	return AnalysisDetail{
+		ID:                  "LOG_" + strings.ToUpper(strings.ReplaceAll(match.Pattern, "-", "_")),
+		Severity:            SeverityError,
+		Confidence:          ConfidenceHigh,
+		Title:               match.Title,
+		Description:         description,
+		Evidence:            []Evidence{logEvidence(match.Container, match.RootCause)},
+		Resource:            resource.Resource,
+		Remediation:         match.Remediation,
+		RemediationCommands: []string{command, command + " --previous"},
 	}
 }
 
+// connectionIssue returns the first log line that shows a connection problem other than
+// a failed DNS lookup
+func connectionIssue(logs string) string {
+	for _, line := range strings.Split(logs, "\n") {
+		lower := strings.ToLower(line)
+		if !strings.Contains(lower, "connection refused") &&
+			!strings.Contains(lower, "cannot connect") &&
+			!strings.Contains(lower, "dial tcp") {
+			continue
+		}
+		if !logscan.IsDNSFailure(line) {
+			return line
+		}
+	}
+	return ""
+}
+
 // checkPodStatus checks the pod's phase and conditions
-func (a *PodAnalyzer) checkPodStatus(resource collector.ResourceData, analysisCtx *AnalysisContext) {
+func (a *PodAnalyzer) checkPodStatus(resource collector.ResourceData) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+
 	// Check pod phase
 	phase, ok := resource.Status["phase"]
 	if !ok {
-		return
+		return details
 	}
 
 	switch phase {
@@ -311,10 +463,14 @@ func (a *PodAnalyzer) checkPodStatus(resource collector.ResourceData, analysisCt
 				message := resource.Status[condMessageKey]
 
 				detail := AnalysisDetail{
-					Type:        "error",
+					ID:          "POD_UNSCHEDULABLE",
+					Severity:    SeverityError,
+					Confidence:  ConfidenceCertain,
 					Title:       "Pod scheduling issues",
 					Description: "The pod is in a Pending state and cannot be scheduled: " + message,
-					Resource:    resource.Resource,
+					Evidence: statusEvidence(resource.Status, "phase", condTypeKey, condStatusKey,
+						condReasonKey, condMessageKey),
+					Resource: resource.Resource,
 					Remediation: []string{
 						"Check cluster resource capacity",
 						"Check node taints and affinities",
@@ -325,21 +481,24 @@ func (a *PodAnalyzer) checkPodStatus(resource collector.ResourceData, analysisCt
 				if strings.Contains(reason, "Insufficient") {
 					detail.RemediationCommands = []string{
 						"kubectl get nodes",
-						"kubectl describe nodes <node-name>",
+						"kubectl describe nodes",
 						"kubectl top nodes",
 					}
 				}
 
-				analysisCtx.Details = append(analysisCtx.Details, detail)
+				details = append(details, detail)
 			}
 		}
 
 	case "Failed":
 		// Pod in Failed state
 		detail := AnalysisDetail{
-			Type:        "error",
+			ID:          "POD_FAILED",
+			Severity:    SeverityError,
+			Confidence:  ConfidenceCertain,
 			Title:       "Pod failed",
 			Description: "The pod is in a Failed state",
+			Evidence:    statusEvidence(resource.Status, "phase", "reason", "message"),
 			Resource:    resource.Resource,
 			Remediation: []string{
 				"Check pod logs for errors",
@@ -351,8 +510,10 @@ func (a *PodAnalyzer) checkPodStatus(resource collector.ResourceData, analysisCt
 				"kubectl describe pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
 			},
 		}
-		analysisCtx.Details = append(analysisCtx.Details, detail)
+		details = append(details, detail)
 	}
+
+	return details
 }
 
 // extractErrorSnippet extracts a short snippet around the first error or exception in the logs
@@ -403,48 +564,10 @@ func (a *PodAnalyzer) extractErrorSnippet(logs string) string {
 	return strings.Join(lines, "\n")
 }
 
-// DeploymentAnalyzer analyzes deployment-related issues
-type DeploymentAnalyzer struct{}
-
-// Name implements the Analyzer interface
-func (a *DeploymentAnalyzer) Name() string {
-	return "DeploymentAnalyzer"
-}
-
-// Description implements the Analyzer interface
-func (a *DeploymentAnalyzer) Description() string {
-	return "Analyzes deployment issues like unavailable replicas, rollout failures, etc."
-}
-
-// Analyze implements the Analyzer interface
-func (a *DeploymentAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) error {
-	// This is a placeholder implementation
-
-	// For now, just add a dummy detail
-	analysisCtx.Details = append(analysisCtx.Details, AnalysisDetail{
-		Type:  "info",
-		Title: "Deployment analyzer executed",
-		Description: "This is a placeholder for the deployment analyzer. In a real implementation, " +
-			"it would analyze replica status, rollout status, pod template issues, etc.",
-		Resource: collector.ResourceInfo{
-			Kind: "Deployment",
-		},
-		Remediation: []string{
-			"Check deployment status using 'kubectl rollout status deployment <name>'",
-			"Check deployment events using 'kubectl describe deployment <name>'",
-		},
-		RemediationCommands: []string{
-			"kubectl rollout status deployment ${DEPLOYMENT_NAME}",
-			"kubectl describe deployment ${DEPLOYMENT_NAME}",
-		},
-	})
-
-	return nil
-}
-
-// Registry keeps track of available analyzers
+// Registry keeps track of available analyzers in the order they were registered
 type Registry struct {
 	analyzers map[string]Analyzer
+	order     []string
 }
 
 // NewRegistry creates a new registry with the default analyzers
@@ -454,15 +577,32 @@ func NewRegistry() *Registry {
 	}
 
 	// Register default analyzers
-	registry.Register(&PodAnalyzer{})
+	registry.Register(&PodAnalyzer{Patterns: logpattern.DefaultLibrary()})
 	registry.Register(&DeploymentAnalyzer{})
+	registry.Register(&ConfigReferenceAnalyzer{})
+	registry.Register(&RBACAnalyzer{})
+	registry.Register(&ImageAnalyzer{})
+	registry.Register(&SchedulingAnalyzer{})
+	registry.Register(&PDBAnalyzer{})
+	registry.Register(&DNSAnalyzer{})
+	registry.Register(&TLSAnalyzer{})
+	registry.Register(&WebhookAnalyzer{})
+	registry.Register(&InitContainerAnalyzer{})
+	registry.Register(&ServiceAnalyzer{})
+	registry.Register(&ConditionAnalyzer{})
+	registry.Register(&ResourceUsageAnalyzer{})
 
 	return registry
 }
 
-// Register adds an analyzer to the registry
+// Register adds an analyzer to the registry. An analyzer with the same name as a
+// registered one replaces it in place.
 func (r *Registry) Register(analyzer Analyzer) {
-	r.analyzers[analyzer.Name()] = analyzer
+	name := analyzer.Name()
+	if _, ok := r.analyzers[name]; !ok {
+		r.order = append(r.order, name)
+	}
+	r.analyzers[name] = analyzer
 }
 
 // Get returns the analyzer with the given name
@@ -470,11 +610,48 @@ func (r *Registry) Get(name string) Analyzer {
 	return r.analyzers[name]
 }
 
-// GetAll returns all registered analyzers
+// GetAll returns all registered analyzers in registration order
 func (r *Registry) GetAll() []Analyzer {
-	analyzers := make([]Analyzer, 0, len(r.analyzers))
-	for _, analyzer := range r.analyzers {
-		analyzers = append(analyzers, analyzer)
+	analyzers := make([]Analyzer, 0, len(r.order))
+	for _, name := range r.order {
+		analyzers = append(analyzers, r.analyzers[name])
 	}
 	return analyzers
 }
+
+// Names returns the names of all registered analyzers in registration order
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.order...)
+}
+
+// Select returns the names of the analyzers to run in registration order: those in
+// only, or all of them when only is empty, minus those in skip
+func (r *Registry) Select(only, skip []string) ([]string, error) {
+	for _, name := range append(append([]string(nil), only...), skip...) {
+		if err := r.checkName(name); err != nil {
+			return nil, err
+		}
+	}
+
+	selected := make([]string, 0, len(r.order))
+	for _, name := range r.order {
+		if (len(only) == 0 || containsName(only, name)) && !containsName(skip, name) {
+			selected = append(selected, name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no analyzers selected")
+	}
+	return selected, nil
+}
+
+// checkName returns an error suggesting the closest registered name when name is unknown
+func (r *Registry) checkName(name string) error {
+	if _, ok := r.analyzers[name]; ok {
+		return nil
+	}
+	if suggestion := closestKey(name, r.order); suggestion != "" {
+		return fmt.Errorf("unknown analyzer %q, did you mean %q?", name, suggestion)
+	}
+	return fmt.Errorf("unknown analyzer %q", name)
+}
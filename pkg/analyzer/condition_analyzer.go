@@ -0,0 +1,248 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// readyConditions report the overall health of a resource when True
+var readyConditions = map[string]bool{
+	"Ready":       true,
+	"Available":   true,
+	"Healthy":     true,
+	"Synced":      true,
+	"Established": true,
+}
+
+// problemConditions report a problem when True
+var problemConditions = map[string]bool{
+	"Degraded":       true,
+	"Stalled":        true,
+	"Failed":         true,
+	"Failure":        true,
+	"InvalidSpec":    true,
+	"ReplicaFailure": true,
+
+	// Node conditions
+	"MemoryPressure":     true,
+	"DiskPressure":       true,
+	"PIDPressure":        true,
+	"NetworkUnavailable": true,
+}
+
+// failedPhases are phases, such as those of PersistentVolumeClaims, that do not recover
+var failedPhases = map[string]bool{
+	"Failed": true,
+	"Lost":   true,
+}
+
+// transientConditions describe work in progress rather than health
+var transientConditions = map[string]bool{
+	"Reconciling": true,
+	"Issuing":     true,
+	"Paused":      true,
+}
+
+// recentTransition is how long after a condition changes a controller may still be
+// working towards the desired state
+const recentTransition = 5 * time.Minute
+
+// maxConditionEvents limits the warning events attached to a condition finding
+const maxConditionEvents = 3
+
+// resourceCondition is a collected condition.N status entry
+type resourceCondition struct {
+	Prefix             string
+	Type               string
+	Status             string
+	Reason             string
+	Message            string
+	LastTransitionTime time.Time
+}
+
+// ConditionAnalyzer reports failing status conditions of resources collected through the
+// dynamic client, such as Argo Rollouts, cert-manager Certificates or Crossplane claims
+type ConditionAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *ConditionAnalyzer) Name() string {
+	return "ConditionAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *ConditionAnalyzer) Description() string {
+	return "Analyzes status conditions of custom and other generically collected resources that are not Ready or are False with a reason"
+}
+
+// Analyze implements the Analyzer interface
+func (a *ConditionAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	now := time.Now()
+	for _, resource := range analysisCtx.Resources {
+		// Only the generic collector records the apiVersion; the dedicated collectors
+		// have analyzers that understand their conditions
+		if resource.Status["apiVersion"] == "" {
+			continue
+		}
+		// Workload conditions are covered, with their replica counts, by the DeploymentAnalyzer
+		if workloadResources[resource.Status["resource"]] {
+			continue
+		}
+
+		if detail, ok := a.phaseDetail(resource); ok {
+			details = append(details, detail)
+		}
+
+		for _, condition := range resourceConditions(resource.Status) {
+			if detail, ok := a.conditionDetail(resource, condition, now); ok {
+				details = append(details, detail)
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// resourceConditions reads the collected condition.N entries
+func resourceConditions(status map[string]string) []resourceCondition {
+	conditions := make([]resourceCondition, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("condition.%d.", i)
+		conditionType, ok := status[prefix+"type"]
+		if !ok {
+			break
+		}
+		condition := resourceCondition{
+			Prefix:  prefix,
+			Type:    conditionType,
+			Status:  status[prefix+"status"],
+			Reason:  status[prefix+"reason"],
+			Message: status[prefix+"message"],
+		}
+		condition.LastTransitionTime, _ = time.Parse(time.RFC3339, status[prefix+"lastTransitionTime"])
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// conditionDetail builds the finding for a failing condition, if the condition fails
+func (a *ConditionAnalyzer) conditionDetail(resource collector.ResourceData, condition resourceCondition, now time.Time) (AnalysisDetail, bool) {
+	var id, title string
+	var severity Severity
+
+	switch {
+	case transientConditions[condition.Type]:
+		return AnalysisDetail{}, false
+	case readyConditions[condition.Type] && condition.Status != "True":
+		id, title, severity = "CR_NOT_READY", resource.Resource.Kind+" is not "+condition.Type, SeverityError
+		if condition.Status == "Unknown" {
+			severity = SeverityWarning
+		}
+	case problemConditions[condition.Type] && condition.Status == "True":
+		id, title, severity = "CR_CONDITION_PROBLEM", resource.Resource.Kind+" reports "+condition.Type, SeverityError
+	case !readyConditions[condition.Type] && !problemConditions[condition.Type] && condition.Status == "False" && condition.Reason != "":
+		id, title, severity = "CR_CONDITION_FALSE", resource.Resource.Kind+" condition "+condition.Type+" is False", SeverityWarning
+	default:
+		return AnalysisDetail{}, false
+	}
+
+	description := fmt.Sprintf("%s %s has condition %s=%s", resource.Resource.Kind, resource.Resource.Name, condition.Type, condition.Status)
+	if condition.Reason != "" {
+		description += " (" + condition.Reason + ")"
+	}
+	if condition.Message != "" {
+		description += ": " + condition.Message
+	}
+
+	// The controller may still be converging shortly after the condition changed
+	confidence := ConfidenceHigh
+	if !condition.LastTransitionTime.IsZero() && now.Sub(condition.LastTransitionTime) < recentTransition {
+		confidence = ConfidenceMedium
+		description += fmt.Sprintf(". The condition changed %s ago, so the controller may still be reconciling",
+			now.Sub(condition.LastTransitionTime).Round(time.Second))
+	}
+
+	evidence := prefixEvidence(resource.Status, condition.Prefix)
+	evidence = append(evidence, eventEvidence(warningEvents(resource.Events, maxConditionEvents)...)...)
+
+	return AnalysisDetail{
+		ID:          id,
+		Severity:    severity,
+		Confidence:  confidence,
+		Title:       title,
+		Description: description,
+		Evidence:    evidence,
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Check the reason and message of the condition and the events of the " + resource.Resource.Kind,
+			"Check the logs of the controller that manages " + resource.Status["resource"],
+			"Check the resources the " + resource.Resource.Kind + " depends on, such as secrets, issuers or providers",
+		},
+		RemediationCommands: conditionCommands(resource),
+	}, true
+}
+
+// phaseDetail reports a resource in a failed phase, or pending with warning events
+func (a *ConditionAnalyzer) phaseDetail(resource collector.ResourceData) (AnalysisDetail, bool) {
+	phase := resource.Status["phase"]
+	warnings := warningEvents(resource.Events, maxConditionEvents)
+
+	var id string
+	var severity Severity
+	switch {
+	case failedPhases[phase]:
+		id, severity = "CR_PHASE_FAILED", SeverityError
+	case phase == "Pending" && len(warnings) > 0:
+		id, severity = "CR_PHASE_PENDING", SeverityWarning
+	default:
+		return AnalysisDetail{}, false
+	}
+
+	return AnalysisDetail{
+		ID:          id,
+		Severity:    severity,
+		Confidence:  ConfidenceHigh,
+		Title:       resource.Resource.Kind + " is " + phase,
+		Description: fmt.Sprintf("%s %s is in phase %s", resource.Resource.Kind, resource.Resource.Name, phase),
+		Evidence:    append(statusEvidence(resource.Status, "phase"), eventEvidence(warnings...)...),
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Check the warning events of the " + resource.Resource.Kind,
+			"Check the logs of the controller that manages " + resource.Status["resource"],
+		},
+		RemediationCommands: conditionCommands(resource),
+	}, true
+}
+
+// warningEvents returns up to limit Warning events
+func warningEvents(events []string, limit int) []string {
+	warnings := make([]string, 0, limit)
+	for _, event := range events {
+		if len(warnings) == limit {
+			break
+		}
+		if strings.Contains(event, "] Warning ") {
+			warnings = append(warnings, event)
+		}
+	}
+	return warnings
+}
+
+// conditionCommands returns the commands to inspect a generically collected resource
+func conditionCommands(resource collector.ResourceData) []string {
+	target := resource.Status["resource"] + " " + resource.Resource.Name
+	namespace := ""
+	if resource.Resource.Namespace != "" {
+		namespace = " -n " + resource.Resource.Namespace
+	}
+
+	return []string{
+		"kubectl describe " + target + namespace,
+		"kubectl get " + target + namespace + " -o jsonpath='{.status.conditions}'",
+		"kubectl get events" + namespace + " --field-selector involvedObject.name=" + resource.Resource.Name,
+	}
+}
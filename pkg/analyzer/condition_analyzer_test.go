@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestConditionAnalyzer(t *testing.T) {
+	longAgo := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	justNow := time.Now().Add(-30 * time.Second).UTC().Format(time.RFC3339)
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Certificate", Name: "web-tls", Namespace: "shop"},
+				Status: map[string]string{
+					"apiVersion":                     "cert-manager.io/v1",
+					"resource":                       "certificates.cert-manager.io",
+					"condition.0.type":               "Ready",
+					"condition.0.status":             "False",
+					"condition.0.reason":             "DoesNotExist",
+					"condition.0.message":            "Issuer letsencrypt not found",
+					"condition.0.lastTransitionTime": longAgo,
+					"condition.1.type":               "Issuing",
+					"condition.1.status":             "True",
+				},
+				Events: []string{
+					"[2026-10-18 10:00:00] Normal Requested: Created new CertificateRequest (count: 1)",
+					"[2026-10-18 10:00:05] Warning IssuerNotFound: Referenced issuer letsencrypt not found (count: 4)",
+				},
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "Rollout", Name: "web", Namespace: "shop"},
+				Status: map[string]string{
+					"apiVersion":                     "argoproj.io/v1alpha1",
+					"resource":                       "rollouts.argoproj.io",
+					"condition.0.type":               "Available",
+					"condition.0.status":             "True",
+					"condition.1.type":               "Progressing",
+					"condition.1.status":             "False",
+					"condition.1.reason":             "ProgressDeadlineExceeded",
+					"condition.1.lastTransitionTime": justNow,
+					"condition.2.type":               "InvalidSpec",
+					"condition.2.status":             "True",
+					"condition.2.reason":             "InvalidSpec",
+					"condition.3.type":               "Paused",
+					"condition.3.status":             "False",
+					"condition.3.reason":             "RolloutResumed",
+				},
+			},
+			{
+				// Dedicated collectors do not record the apiVersion and are left to their analyzers
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-0", Namespace: "shop"},
+				Status: map[string]string{
+					"condition.0.type":   "Ready",
+					"condition.0.status": "False",
+				},
+			},
+		},
+	}
+
+	details, err := (&ConditionAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ids := make([]string, 0)
+	for _, detail := range details {
+		ids = append(ids, detail.ID+"/"+detail.Resource.Name)
+	}
+	if got := strings.Join(ids, ","); got != "CR_NOT_READY/web-tls,CR_CONDITION_FALSE/web,CR_CONDITION_PROBLEM/web" {
+		t.Fatalf("Unexpected findings: %s", got)
+	}
+
+	notReady := details[0]
+	if notReady.Severity != SeverityError || notReady.Confidence != ConfidenceHigh {
+		t.Errorf("Expected a high confidence error, got %s/%v", notReady.Severity, notReady.Confidence)
+	}
+	if !strings.Contains(notReady.Description, "Ready=False (DoesNotExist): Issuer letsencrypt not found") {
+		t.Errorf("Expected the reason and message in the description, got %q", notReady.Description)
+	}
+	events := 0
+	for _, evidence := range notReady.Evidence {
+		if evidence.Kind == EvidenceEvent {
+			events++
+		}
+	}
+	if events != 1 {
+		t.Errorf("Expected only the warning event as evidence, got %+v", notReady.Evidence)
+	}
+	if notReady.RemediationCommands[0] != "kubectl describe certificates.cert-manager.io web-tls -n shop" {
+		t.Errorf("Unexpected command: %s", notReady.RemediationCommands[0])
+	}
+
+	// A condition that just changed may still be converging
+	if details[1].Confidence != ConfidenceMedium || details[1].Severity != SeverityWarning {
+		t.Errorf("Expected a medium confidence warning for the recent transition, got %s/%v", details[1].Severity, details[1].Confidence)
+	}
+}
+
+func TestConditionAnalyzer_NodesAndPhases(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				// Pressure conditions are healthy when False, whatever their reason
+				Resource: collector.ResourceInfo{Kind: "Node", Name: "node-1"},
+				Status: map[string]string{
+					"apiVersion":         "v1",
+					"resource":           "nodes",
+					"condition.0.type":   "MemoryPressure",
+					"condition.0.status": "False",
+					"condition.0.reason": "KubeletHasSufficientMemory",
+					"condition.1.type":   "DiskPressure",
+					"condition.1.status": "True",
+					"condition.1.reason": "KubeletHasDiskPressure",
+					"condition.2.type":   "Ready",
+					"condition.2.status": "True",
+				},
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "PersistentVolumeClaim", Name: "data-db-0", Namespace: "shop"},
+				Status:   map[string]string{"apiVersion": "v1", "resource": "persistentvolumeclaims", "phase": "Pending"},
+				Events: []string{
+					"[2026-10-18 10:00:00] Warning ProvisioningFailed: storageclass.storage.k8s.io \"fast\" not found (count: 3)",
+				},
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "PersistentVolumeClaim", Name: "data-db-1", Namespace: "shop"},
+				Status:   map[string]string{"apiVersion": "v1", "resource": "persistentvolumeclaims", "phase": "Lost"},
+			},
+			{
+				// Claims waiting for their first consumer are pending without warnings
+				Resource: collector.ResourceInfo{Kind: "PersistentVolumeClaim", Name: "cache", Namespace: "shop"},
+				Status:   map[string]string{"apiVersion": "v1", "resource": "persistentvolumeclaims", "phase": "Pending"},
+				Events:   []string{"[2026-10-18 10:00:00] Normal WaitForFirstConsumer: waiting for first consumer (count: 1)"},
+			},
+			{
+				// Workloads are left to the DeploymentAnalyzer
+				Resource: collector.ResourceInfo{Kind: "Deployment", Name: "web", Namespace: "shop"},
+				Status: map[string]string{
+					"apiVersion":         "apps/v1",
+					"resource":           "deployments.apps",
+					"condition.0.type":   "Available",
+					"condition.0.status": "False",
+				},
+			},
+		},
+	}
+
+	details, err := (&ConditionAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ids := make([]string, 0)
+	for _, detail := range details {
+		ids = append(ids, detail.ID+"/"+detail.Resource.Name)
+	}
+	if got := strings.Join(ids, ","); got != "CR_CONDITION_PROBLEM/node-1,CR_PHASE_PENDING/data-db-0,CR_PHASE_FAILED/data-db-1" {
+		t.Fatalf("Unexpected findings: %s", got)
+	}
+}
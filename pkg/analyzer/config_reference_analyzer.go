@@ -0,0 +1,255 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// ConfigReferenceAnalyzer checks that ConfigMaps and Secrets referenced by a pod exist
+type ConfigReferenceAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *ConfigReferenceAnalyzer) Name() string {
+	return "ConfigReferenceAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *ConfigReferenceAnalyzer) Description() string {
+	return "Analyzes ConfigMap and Secret references in env, envFrom and volumes for missing objects or keys"
+}
+
+// Analyze implements the Analyzer interface
+func (a *ConfigReferenceAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Pod" {
+			continue
+		}
+
+		for i := 0; ; i++ {
+			prefix := fmt.Sprintf("configRef.%d.", i)
+			kind, ok := resource.Status[prefix+"kind"]
+			if !ok {
+				break
+			}
+
+			ref := configRef{
+				Prefix:        prefix,
+				Kind:          kind,
+				Name:          resource.Status[prefix+"name"],
+				Key:           resource.Status[prefix+"key"],
+				Source:        resource.Status[prefix+"source"],
+				Container:     resource.Status[prefix+"container"],
+				Target:        resource.Status[prefix+"target"],
+				Optional:      resource.Status[prefix+"optional"] == "true",
+				Exists:        resource.Status[prefix+"exists"],
+				KeyExists:     resource.Status[prefix+"keyExists"],
+				AvailableKeys: resource.Status[prefix+"availableKeys"],
+				Error:         resource.Status[prefix+"error"],
+			}
+
+			// Optional references never block container creation
+			if ref.Optional {
+				continue
+			}
+
+			if ref.Error != "" {
+				details = append(details, a.unverifiedDetail(resource, ref))
+			} else if ref.Exists == "false" {
+				details = append(details, a.missingObjectDetail(resource, ref))
+			} else if ref.KeyExists == "false" {
+				details = append(details, a.missingKeyDetail(resource, ref))
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// configRef is the analyzer's view of a collected configRef.N status entry
+type configRef struct {
+	Prefix        string // status key prefix of the entry
+	Kind          string
+	Name          string
+	Key           string
+	Source        string
+	Container     string
+	Target        string
+	Optional      bool
+	Exists        string
+	KeyExists     string
+	AvailableKeys string
+	Error         string // why the object could not be read, such as Forbidden
+}
+
+// location describes where in the pod spec the reference is made
+func (r configRef) location() string {
+	switch r.Source {
+	case "env":
+		return fmt.Sprintf("env var %s of container %s", r.Target, r.Container)
+	case "envFrom":
+		return fmt.Sprintf("envFrom of container %s", r.Container)
+	case "volume":
+		return fmt.Sprintf("volume %s", r.Target)
+	default:
+		return r.Source
+	}
+}
+
+// object returns the referenced ConfigMap or Secret
+func (r configRef) object(namespace string) *collector.ResourceInfo {
+	return &collector.ResourceInfo{Kind: r.Kind, Name: r.Name, Namespace: namespace}
+}
+
+// missingObjectDetail builds the finding for a ConfigMap or Secret that does not exist
+func (a *ConfigReferenceAnalyzer) missingObjectDetail(resource collector.ResourceData, ref configRef) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	resourceType := strings.ToLower(ref.Kind)
+
+	return AnalysisDetail{
+		ID:         "CONFIG_REF_MISSING",
+		Severity:   SeverityError,
+		Confidence: ConfidenceCertain,
+		Title:      "Missing " + ref.Kind + " reference",
+		Description: fmt.Sprintf("%s %s referenced by %s does not exist in namespace %s",
+			ref.Kind, ref.Name, ref.location(), namespace),
+		Evidence: prefixEvidence(resource.Status, ref.Prefix),
+		Resource: resource.Resource,
+		Cause:    ref.object(namespace),
+		Remediation: []string{
+			fmt.Sprintf("Create %s %s in namespace %s", ref.Kind, ref.Name, namespace),
+			"Check the reference for typos in the " + ref.Kind + " name",
+			"Mark the reference as optional if the pod can start without it",
+		},
+		RemediationCommands: []string{
+			"kubectl get " + resourceType + "s -n " + namespace,
+			"kubectl create " + createCommand(ref.Kind) + " " + ref.Name + " -n " + namespace + " --from-literal=<key>=<value>",
+		},
+	}
+}
+
+// unverifiedDetail builds the finding for a reference whose object could not be read,
+// so that a lookup denied by RBAC or timed out is not mistaken for a healthy reference
+func (a *ConfigReferenceAnalyzer) unverifiedDetail(resource collector.ResourceData, ref configRef) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	resourceType := strings.ToLower(ref.Kind)
+
+	return AnalysisDetail{
+		ID:         "CONFIG_REF_UNVERIFIED",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceLow,
+		Title:      "Unverified " + ref.Kind + " reference",
+		Description: fmt.Sprintf("%s %s referenced by %s could not be checked: %s",
+			ref.Kind, ref.Name, ref.location(), ref.Error),
+		Evidence: prefixEvidence(resource.Status, ref.Prefix),
+		Resource: resource.Resource,
+		Cause:    ref.object(namespace),
+		Remediation: []string{
+			fmt.Sprintf("Check that k8smed may get %ss in namespace %s", resourceType, namespace),
+			fmt.Sprintf("Check by hand that %s %s exists%s", ref.Kind, ref.Name, keySuffix(ref.Key)),
+		},
+		RemediationCommands: []string{
+			"kubectl auth can-i get " + resourceType + "s -n " + namespace,
+			"kubectl get " + resourceType + " " + ref.Name + " -n " + namespace,
+		},
+	}
+}
+
+// keySuffix describes the referenced key, if any
+func keySuffix(key string) string {
+	if key == "" {
+		return ""
+	}
+	return fmt.Sprintf(" and has key %q", key)
+}
+
+// missingKeyDetail builds the finding for a key that is absent from an existing object
+func (a *ConfigReferenceAnalyzer) missingKeyDetail(resource collector.ResourceData, ref configRef) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	resourceType := strings.ToLower(ref.Kind)
+
+	description := fmt.Sprintf("%s %s has no key %q, which is referenced by %s",
+		ref.Kind, ref.Name, ref.Key, ref.location())
+
+	var keys []string
+	if ref.AvailableKeys != "" {
+		keys = strings.Split(ref.AvailableKeys, ",")
+		description += ". Available keys: " + strings.Join(keys, ", ")
+	}
+
+	remediation := []string{
+		fmt.Sprintf("Add key %q to %s %s", ref.Key, ref.Kind, ref.Name),
+		"Update the reference to use one of the existing keys",
+	}
+	if suggestion := closestKey(ref.Key, keys); suggestion != "" {
+		remediation = append([]string{fmt.Sprintf("Did you mean %q? Update the reference to use it", suggestion)}, remediation...)
+	}
+
+	return AnalysisDetail{
+		ID:          "CONFIG_REF_MISSING_KEY",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceCertain,
+		Title:       "Missing key in " + ref.Kind,
+		Description: description,
+		Evidence:    prefixEvidence(resource.Status, ref.Prefix),
+		Resource:    resource.Resource,
+		Cause:       ref.object(namespace),
+		Remediation: remediation,
+		RemediationCommands: []string{
+			"kubectl describe " + resourceType + " " + ref.Name + " -n " + namespace,
+		},
+	}
+}
+
+// createCommand returns the kubectl create subcommand for the given kind
+func createCommand(kind string) string {
+	if kind == "Secret" {
+		return "secret generic"
+	}
+	return "configmap"
+}
+
+// closestKey returns the candidate most similar to key, or empty if none is close
+func closestKey(key string, candidates []string) string {
+	best := ""
+	bestDistance := -1
+	for _, candidate := range candidates {
+		distance := levenshtein(strings.ToLower(key), strings.ToLower(candidate))
+		if bestDistance < 0 || distance < bestDistance {
+			best = candidate
+			bestDistance = distance
+		}
+	}
+
+	// Only suggest keys that look like a typo of the original
+	if bestDistance < 0 || bestDistance > len(key)/3+1 {
+		return ""
+	}
+	return best
+}
+
+// levenshtein computes the edit distance between two strings
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,153 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestConfigReferenceAnalyzer_MissingObject(t *testing.T) {
+	analyzer := &ConfigReferenceAnalyzer{}
+
+	// Create a mock pod whose env var references a ConfigMap that does not exist
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Pod",
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: map[string]string{
+			"phase":                 "Pending",
+			"configRef.0.kind":      "ConfigMap",
+			"configRef.0.name":      "app-config",
+			"configRef.0.key":       "LOG_LEVEL",
+			"configRef.0.source":    "env",
+			"configRef.0.container": "app",
+			"configRef.0.target":    "LOG_LEVEL",
+			"configRef.0.optional":  "false",
+			"configRef.0.exists":    "false",
+			"configRef.1.kind":      "Secret",
+			"configRef.1.name":      "optional-secret",
+			"configRef.1.source":    "envFrom",
+			"configRef.1.container": "app",
+			"configRef.1.optional":  "true",
+			"configRef.1.exists":    "false",
+		},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	// The optional Secret must not produce a finding
+	if len(details) != 1 {
+		t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+	}
+
+	detail := details[0]
+	if detail.Title != "Missing ConfigMap reference" {
+		t.Errorf("Expected title 'Missing ConfigMap reference', got '%s'", detail.Title)
+	}
+
+	if !strings.Contains(detail.Description, "app-config") || !strings.Contains(detail.Description, "env var LOG_LEVEL of container app") {
+		t.Errorf("Expected description to name the reference, got '%s'", detail.Description)
+	}
+}
+
+func TestConfigReferenceAnalyzer_WrongKey(t *testing.T) {
+	analyzer := &ConfigReferenceAnalyzer{}
+
+	// Create a mock pod whose secret volume references a misspelled key
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Pod",
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: map[string]string{
+			"configRef.0.kind":          "Secret",
+			"configRef.0.name":          "db-credentials",
+			"configRef.0.key":           "pasword",
+			"configRef.0.source":        "volume",
+			"configRef.0.target":        "creds",
+			"configRef.0.optional":      "false",
+			"configRef.0.exists":        "true",
+			"configRef.0.keyExists":     "false",
+			"configRef.0.availableKeys": "password,username",
+		},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+	}
+
+	detail := details[0]
+	if detail.Title != "Missing key in Secret" {
+		t.Errorf("Expected title 'Missing key in Secret', got '%s'", detail.Title)
+	}
+
+	if len(detail.Remediation) == 0 || !strings.Contains(detail.Remediation[0], `"password"`) {
+		t.Errorf("Expected the first remediation step to suggest 'password', got %v", detail.Remediation)
+	}
+}
+
+func TestConfigReferenceAnalyzer_LookupError(t *testing.T) {
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: "test-pod", Namespace: "default"},
+		Status: map[string]string{
+			"configRef.0.kind":      "Secret",
+			"configRef.0.name":      "db-credentials",
+			"configRef.0.key":       "password",
+			"configRef.0.source":    "env",
+			"configRef.0.container": "app",
+			"configRef.0.target":    "DB_PASSWORD",
+			"configRef.0.optional":  "false",
+			"configRef.0.error":     `secrets "db-credentials" is forbidden: User "system:serviceaccount:k8smed-system:k8smed" cannot get resource "secrets"`,
+		},
+	}
+
+	details, err := (&ConfigReferenceAnalyzer{}).Analyze(context.Background(), &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(details) != 1 {
+		t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+	}
+
+	detail := details[0]
+	if detail.ID != "CONFIG_REF_UNVERIFIED" || detail.Confidence != ConfidenceLow {
+		t.Errorf("Expected a low confidence CONFIG_REF_UNVERIFIED finding, got %s at %v", detail.ID, detail.Confidence)
+	}
+	if !strings.Contains(detail.Description, "is forbidden") {
+		t.Errorf("Expected the lookup error in the description, got %q", detail.Description)
+	}
+	found := false
+	for _, evidence := range detail.Evidence {
+		if evidence.Key == "configRef.0.error" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the lookup error as evidence, got %v", detail.Evidence)
+	}
+}
@@ -0,0 +1,241 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// RootCause is a probable root cause: the findings on the most upstream resource of a
+// failure, and the findings on the resources that failure affects
+type RootCause struct {
+	// Resource is where the failure starts
+	Resource collector.ResourceInfo `json:"resource"`
+
+	// Findings are the findings on, or blamed on, Resource
+	Findings []AnalysisDetail `json:"findings"`
+
+	// Symptoms are the findings on resources affected by Resource
+	Symptoms []Symptom `json:"symptoms,omitempty"`
+}
+
+// Symptom is a finding explained by a root cause
+type Symptom struct {
+	Detail AnalysisDetail `json:"detail"`
+
+	// Chain leads from the root cause's resource to the resource of the finding
+	Chain []collector.ResourceInfo `json:"chain"`
+}
+
+// Severity returns the highest severity of the root cause's findings and symptoms
+func (r RootCause) Severity() Severity {
+	severity := SeverityInfo
+	for _, detail := range r.Findings {
+		severity = max(severity, detail.Severity)
+	}
+	for _, symptom := range r.Symptoms {
+		severity = max(severity, symptom.Detail.Severity)
+	}
+	return severity
+}
+
+// controllerRank lists the controllers that own pods, which are affected by the health
+// of the pods they own, from the innermost owner to the outermost
+var controllerRank = map[string]int{
+	"replicaset":  1,
+	"job":         1,
+	"deployment":  2,
+	"statefulset": 2,
+	"daemonset":   2,
+	"cronjob":     3,
+}
+
+// Correlate groups findings under their probable root causes. It builds a graph of how
+// a problem on one resource affects another from ResourceData.Related and the Cause of
+// each finding (a missing ConfigMap affects the pods referencing it, a pod affects its
+// ReplicaSet and Deployment), then collapses the findings on affected resources under the
+// most upstream resource with findings. Root causes are ranked by severity, then by the
+// number of findings they explain.
+func Correlate(resources []collector.ResourceData, details []AnalysisDetail) []RootCause {
+	graph := newImpactGraph()
+	for _, resource := range resources {
+		graph.addNode(resource.Resource)
+		for _, related := range resource.Related {
+			if affects(resource.Resource, related) {
+				graph.addEdge(resource.Resource, related.ResourceInfo)
+			} else {
+				graph.addEdge(related.ResourceInfo, resource.Resource)
+			}
+		}
+	}
+
+	// A finding sits on the resource it blames, which affects the resource it was found on
+	findings := make(map[string][]AnalysisDetail)
+	for _, detail := range details {
+		location := detail.Resource
+		if detail.Cause != nil {
+			location = *detail.Cause
+			graph.addEdge(location, detail.Resource)
+		}
+		key := graph.addNode(location)
+		findings[key] = append(findings[key], detail)
+	}
+
+	// Rank resources with findings so that upstream ones, which reach more findings, come first
+	reach := make(map[string]int)
+	candidates := make([]string, 0, len(findings))
+	for _, key := range graph.keys {
+		if len(findings[key]) == 0 {
+			continue
+		}
+		candidates = append(candidates, key)
+		for _, other := range graph.reachable(key) {
+			reach[key] += len(findings[other])
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return reach[candidates[i]] > reach[candidates[j]]
+	})
+
+	rootCauses := make([]RootCause, 0)
+	assigned := make(map[string]bool)
+	for _, key := range candidates {
+		if assigned[key] {
+			continue
+		}
+		assigned[key] = true
+
+		rootCause := RootCause{Resource: graph.nodes[key], Findings: findings[key]}
+		for _, other := range graph.reachable(key) {
+			if assigned[other] || len(findings[other]) == 0 {
+				continue
+			}
+			assigned[other] = true
+			chain := graph.path(key, other)
+			for _, detail := range findings[other] {
+				rootCause.Symptoms = append(rootCause.Symptoms, Symptom{Detail: detail, Chain: chain})
+			}
+		}
+		rootCauses = append(rootCauses, rootCause)
+	}
+
+	sort.SliceStable(rootCauses, func(i, j int) bool {
+		a, b := rootCauses[i], rootCauses[j]
+		if a.Severity() != b.Severity() {
+			return a.Severity() > b.Severity()
+		}
+		return len(a.Findings)+len(a.Symptoms) > len(b.Findings)+len(b.Symptoms)
+	})
+	return rootCauses
+}
+
+// affects reports whether a problem on resource affects the related resource, rather
+// than the other way around. Resources depend on what they mount, reference, select or
+// run on, and owners and selecting services depend on their pods.
+func affects(resource collector.ResourceInfo, related collector.RelatedResource) bool {
+	switch related.Relation {
+	case collector.RelationOwner, collector.RelationSelectedBy:
+		return true
+	case collector.RelationMounts, collector.RelationReferences, collector.RelationSelects, collector.RelationScheduledOn:
+		return false
+	}
+
+	// Untyped edges, such as those added by plugins, are judged by the kinds: controllers,
+	// services and disruption budgets depend on their pods
+	kind := strings.ToLower(resource.Kind)
+	relatedKind := strings.ToLower(related.Kind)
+	switch {
+	case controllerRank[relatedKind] > 0 && (kind == "pod" || kind == "replicaset" || kind == "job"):
+		return true
+	case kind == "pod" && (relatedKind == "service" || relatedKind == "poddisruptionbudget"):
+		return true
+	default:
+		return false
+	}
+}
+
+// impactGraph is a directed graph where an edge from A to B means a problem on A affects B
+type impactGraph struct {
+	nodes map[string]collector.ResourceInfo
+	keys  []string // nodes in insertion order, so traversals are deterministic
+	edges map[string][]string
+	seen  map[[2]string]bool
+}
+
+func newImpactGraph() *impactGraph {
+	return &impactGraph{
+		nodes: make(map[string]collector.ResourceInfo),
+		edges: make(map[string][]string),
+		seen:  make(map[[2]string]bool),
+	}
+}
+
+// resourceKey identifies a resource regardless of the case of its kind
+func resourceKey(resource collector.ResourceInfo) string {
+	return strings.ToLower(resource.Kind) + "/" + resource.Namespace + "/" + resource.Name
+}
+
+// addNode adds the resource if it is new and returns its key
+func (g *impactGraph) addNode(resource collector.ResourceInfo) string {
+	key := resourceKey(resource)
+	if _, ok := g.nodes[key]; !ok {
+		g.nodes[key] = resource
+		g.keys = append(g.keys, key)
+	}
+	return key
+}
+
+// addEdge records that a problem on from affects to
+func (g *impactGraph) addEdge(from, to collector.ResourceInfo) {
+	edge := [2]string{g.addNode(from), g.addNode(to)}
+	if edge[0] == edge[1] || g.seen[edge] {
+		return
+	}
+	g.seen[edge] = true
+	g.edges[edge[0]] = append(g.edges[edge[0]], edge[1])
+}
+
+// reachable returns the nodes affected by key, directly or indirectly, nearest first
+func (g *impactGraph) reachable(key string) []string {
+	visited := map[string]bool{key: true}
+	queue := []string{key}
+	reached := make([]string, 0)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, next := range g.edges[current] {
+			if !visited[next] {
+				visited[next] = true
+				reached = append(reached, next)
+				queue = append(queue, next)
+			}
+		}
+	}
+	return reached
+}
+
+// path returns the shortest chain of resources from one node to another
+func (g *impactGraph) path(from, to string) []collector.ResourceInfo {
+	parents := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			break
+		}
+		for _, next := range g.edges[current] {
+			if _, ok := parents[next]; !ok {
+				parents[next] = current
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	chain := make([]collector.ResourceInfo, 0)
+	for key := to; key != ""; key = parents[key] {
+		chain = append([]collector.ResourceInfo{g.nodes[key]}, chain...)
+	}
+	return chain
+}
@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// chainString renders a symptom chain as kind/name hops
+func chainString(chain []collector.ResourceInfo) string {
+	hops := make([]string, 0, len(chain))
+	for _, resource := range chain {
+		hops = append(hops, resource.Kind+"/"+resource.Name)
+	}
+	return strings.Join(hops, " -> ")
+}
+
+func TestCorrelate_MissingConfigMap(t *testing.T) {
+	replicaSet := collector.ResourceInfo{Kind: "ReplicaSet", Name: "web-7d9f", Namespace: "shop"}
+	deployment := collector.ResourceInfo{Kind: "Deployment", Name: "web", Namespace: "shop"}
+	configMap := &collector.ResourceInfo{Kind: "ConfigMap", Name: "web-config", Namespace: "shop"}
+
+	resources := []collector.ResourceData{{Resource: deployment}}
+	details := []AnalysisDetail{
+		{ID: "DEPLOYMENT_UNAVAILABLE", Severity: SeverityWarning, Resource: deployment},
+	}
+	for _, name := range []string{"web-7d9f-a", "web-7d9f-b", "web-7d9f-c"} {
+		pod := collector.ResourceInfo{Kind: "Pod", Name: name, Namespace: "shop"}
+		resources = append(resources, collector.ResourceData{Resource: pod, Related: []collector.RelatedResource{
+			{ResourceInfo: replicaSet, Relation: collector.RelationOwner},
+		}})
+		details = append(details,
+			AnalysisDetail{ID: "POD_CONTAINER_CONFIG_ERROR", Severity: SeverityError, Resource: pod},
+			AnalysisDetail{ID: "CONFIG_REF_MISSING", Severity: SeverityError, Resource: pod, Cause: configMap},
+		)
+	}
+	resources = append(resources, collector.ResourceData{Resource: replicaSet, Related: []collector.RelatedResource{
+		{ResourceInfo: deployment, Relation: collector.RelationOwner},
+	}})
+
+	// An unrelated finding stays on its own
+	details = append(details, AnalysisDetail{ID: "IMAGE_MUTABLE_LATEST_TAG", Severity: SeverityInfo,
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: "debug", Namespace: "shop"}})
+
+	rootCauses := Correlate(resources, details)
+	if len(rootCauses) != 2 {
+		t.Fatalf("Expected 2 root causes, got %+v", rootCauses)
+	}
+
+	rootCause := rootCauses[0]
+	if rootCause.Resource.Kind != "ConfigMap" || len(rootCause.Findings) != 3 {
+		t.Fatalf("Expected the ConfigMap with 3 findings as the top root cause, got %+v", rootCause)
+	}
+	if len(rootCause.Symptoms) != 4 {
+		t.Fatalf("Expected the pod and deployment findings as symptoms, got %+v", rootCause.Symptoms)
+	}
+	last := rootCause.Symptoms[len(rootCause.Symptoms)-1]
+	if last.Detail.ID != "DEPLOYMENT_UNAVAILABLE" {
+		t.Errorf("Expected the farthest symptom last, got %s", last.Detail.ID)
+	}
+	if got := chainString(last.Chain); got != "ConfigMap/web-config -> Pod/web-7d9f-a -> ReplicaSet/web-7d9f -> Deployment/web" {
+		t.Errorf("Unexpected symptom chain: %s", got)
+	}
+	if rootCause.Severity() != SeverityError {
+		t.Errorf("Expected error severity, got %s", rootCause.Severity())
+	}
+
+	if rootCauses[1].Findings[0].ID != "IMAGE_MUTABLE_LATEST_TAG" || len(rootCauses[1].Symptoms) != 0 {
+		t.Errorf("Expected the unrelated finding as its own root cause, got %+v", rootCauses[1])
+	}
+}
+
+func TestCorrelate_Relations(t *testing.T) {
+	pdb := collector.ResourceInfo{Kind: "PodDisruptionBudget", Name: "web", Namespace: "shop"}
+	pod := collector.ResourceInfo{Kind: "Pod", Name: "web-0", Namespace: "shop"}
+	node := collector.ResourceInfo{Kind: "Node", Name: "worker-1"}
+	service := collector.ResourceInfo{Kind: "Service", Name: "web", Namespace: "shop"}
+
+	tests := []struct {
+		name      string
+		resources []collector.ResourceData
+		details   []AnalysisDetail
+		rootKind  string
+	}{
+		{
+			name: "typed: a node problem affects the pods scheduled on it",
+			resources: []collector.ResourceData{{Resource: pod, Related: []collector.RelatedResource{
+				{ResourceInfo: node, Relation: collector.RelationScheduledOn},
+			}}},
+			details: []AnalysisDetail{
+				{ID: "POD_NOT_READY", Resource: pod},
+				{ID: "NODE_NOT_READY", Resource: node},
+			},
+			rootKind: "Node",
+		},
+		{
+			name: "typed: a pod problem affects the services selecting it",
+			resources: []collector.ResourceData{{Resource: pod, Related: []collector.RelatedResource{
+				{ResourceInfo: service, Relation: collector.RelationSelectedBy},
+			}}},
+			details: []AnalysisDetail{
+				{ID: "SERVICE_NO_ENDPOINTS", Resource: service},
+				{ID: "POD_CRASHLOOP", Resource: pod},
+			},
+			rootKind: "Pod",
+		},
+		{
+			name: "untyped: pods affect the disruption budget covering them",
+			resources: []collector.ResourceData{
+				{Resource: pdb, Related: []collector.RelatedResource{{ResourceInfo: pod}}},
+				{Resource: pod},
+			},
+			details: []AnalysisDetail{
+				{ID: "PDB_NO_DISRUPTIONS_ALLOWED", Resource: pdb},
+				{ID: "POD_CRASHLOOP", Resource: pod},
+			},
+			rootKind: "Pod",
+		},
+	}
+
+	for _, tt := range tests {
+		rootCauses := Correlate(tt.resources, tt.details)
+		if len(rootCauses) != 1 || rootCauses[0].Resource.Kind != tt.rootKind || len(rootCauses[0].Symptoms) != 1 {
+			t.Errorf("%s: expected a %s root cause with one symptom, got %+v", tt.name, tt.rootKind, rootCauses)
+		}
+	}
+}
+
+func TestConfigReferenceAnalyzer_Cause(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "shop"},
+				Status: map[string]string{
+					"configRef.0.kind":     "ConfigMap",
+					"configRef.0.name":     "web-config",
+					"configRef.0.source":   "envFrom",
+					"configRef.0.optional": "false",
+					"configRef.0.exists":   "false",
+				},
+			},
+		},
+	}
+
+	details, err := (&ConfigReferenceAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(details) != 1 || details[0].Cause == nil || details[0].Cause.Name != "web-config" || details[0].Cause.Namespace != "shop" {
+		t.Errorf("Expected the missing ConfigMap as the cause, got %+v", details)
+	}
+}
@@ -0,0 +1,153 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// workloadResources are the generically collected workloads the DeploymentAnalyzer
+// covers, by their resource
+var workloadResources = map[string]bool{
+	"deployments.apps":  true,
+	"statefulsets.apps": true,
+	"daemonsets.apps":   true,
+}
+
+// DeploymentAnalyzer reports workloads with unavailable replicas or stuck rollouts
+type DeploymentAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *DeploymentAnalyzer) Name() string {
+	return "DeploymentAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *DeploymentAnalyzer) Description() string {
+	return "Analyzes unavailable replicas, stuck rollouts and replica failures of Deployments, StatefulSets and DaemonSets"
+}
+
+// Analyze implements the Analyzer interface
+func (a *DeploymentAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if !workloadResources[resource.Status["resource"]] {
+			continue
+		}
+
+		if detail, ok := a.replicasDetail(resource); ok {
+			details = append(details, detail)
+		}
+
+		for _, condition := range resourceConditions(resource.Status) {
+			switch {
+			case condition.Type == "Progressing" && condition.Status == "False" && condition.Reason == "ProgressDeadlineExceeded":
+				details = append(details, a.conditionDetail(resource, condition, "DEPLOYMENT_ROLLOUT_STUCK",
+					"Rollout exceeded its progress deadline", []string{
+						"Check the findings of the new pods; they usually show why they do not become ready",
+						"Roll back with kubectl rollout undo if the new version is broken",
+					}))
+			case condition.Type == "ReplicaFailure" && condition.Status == "True":
+				details = append(details, a.conditionDetail(resource, condition, "WORKLOAD_REPLICA_FAILURE",
+					"Pods cannot be created", []string{
+						"Check resource quotas and limit ranges in the namespace",
+						"Check admission webhooks and pod security settings that may reject the pods",
+					}))
+			}
+		}
+
+		if misscheduled := statusNumber(resource.Status, "numberMisscheduled"); misscheduled > 0 {
+			details = append(details, AnalysisDetail{
+				ID:         "DAEMONSET_MISSCHEDULED",
+				Severity:   SeverityWarning,
+				Confidence: ConfidenceCertain,
+				Title:      "DaemonSet pods run on nodes they should not",
+				Description: fmt.Sprintf("DaemonSet %s runs %d pod(s) on nodes its node selector, affinity or tolerations no longer allow",
+					resource.Resource.Name, misscheduled),
+				Evidence: statusEvidence(resource.Status, "numberMisscheduled", "desiredNumberScheduled"),
+				Resource: resource.Resource,
+				Remediation: []string{
+					"Check for node label or taint changes that exclude nodes the pods still run on",
+				},
+				RemediationCommands: []string{
+					"kubectl get pods -n " + resource.Resource.Namespace + " -o wide",
+				},
+			})
+		}
+	}
+
+	return details, nil
+}
+
+// replicasDetail reports a workload with fewer ready replicas than it wants
+func (a *DeploymentAnalyzer) replicasDetail(resource collector.ResourceData) (AnalysisDetail, bool) {
+	// DaemonSets want one pod per eligible node rather than a replica count
+	desiredKey, readyKey := "spec.replicas", "readyReplicas"
+	if resource.Status["resource"] == "daemonsets.apps" {
+		desiredKey, readyKey = "desiredNumberScheduled", "numberReady"
+	}
+	desired := statusNumber(resource.Status, desiredKey)
+	ready := statusNumber(resource.Status, readyKey)
+	if desired == 0 || ready >= desired {
+		return AnalysisDetail{}, false
+	}
+
+	severity := SeverityWarning
+	if ready == 0 {
+		severity = SeverityError
+	}
+	description := fmt.Sprintf("%s %s has %d of %d replicas ready", resource.Resource.Kind, resource.Resource.Name, ready, desired)
+	if statusNumber(resource.Status, "observedGeneration") < statusNumber(resource.Status, "generation") {
+		description += "; the controller has not yet processed the latest spec change"
+	}
+
+	target := resource.Status["resource"] + "/" + resource.Resource.Name + " -n " + resource.Resource.Namespace
+	return AnalysisDetail{
+		ID:          "WORKLOAD_REPLICAS_UNAVAILABLE",
+		Severity:    severity,
+		Confidence:  ConfidenceCertain,
+		Title:       resource.Resource.Kind + " replicas unavailable",
+		Description: description,
+		Evidence: statusEvidence(resource.Status, desiredKey, readyKey, "availableReplicas", "updatedReplicas",
+			"unavailableReplicas", "observedGeneration", "generation"),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Check the findings of the workload's pods; they usually show why they are not ready",
+			"Check the rollout status and the events of the " + resource.Resource.Kind,
+		},
+		RemediationCommands: []string{
+			"kubectl rollout status " + target,
+			"kubectl describe " + target,
+		},
+	}, true
+}
+
+// conditionDetail builds the finding for a failing workload condition
+func (a *DeploymentAnalyzer) conditionDetail(resource collector.ResourceData, condition resourceCondition, id, title string, remediation []string) AnalysisDetail {
+	description := fmt.Sprintf("%s %s has condition %s=%s (%s)", resource.Resource.Kind, resource.Resource.Name,
+		condition.Type, condition.Status, condition.Reason)
+	if condition.Message != "" {
+		description += ": " + condition.Message
+	}
+
+	return AnalysisDetail{
+		ID:                  id,
+		Severity:            SeverityError,
+		Confidence:          ConfidenceCertain,
+		Title:               title,
+		Description:         description,
+		Evidence:            append(prefixEvidence(resource.Status, condition.Prefix), eventEvidence(warningEvents(resource.Events, maxConditionEvents)...)...),
+		Resource:            resource.Resource,
+		Remediation:         remediation,
+		RemediationCommands: conditionCommands(resource),
+	}
+}
+
+// statusNumber parses a numeric status key; missing keys are zero, as the API server
+// omits zero counts
+func statusNumber(status map[string]string, key string) int64 {
+	number, _ := strconv.ParseInt(status[key], 10, 64)
+	return number
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestDeploymentAnalyzer(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Deployment", Name: "web", Namespace: "shop"},
+				Status: map[string]string{
+					"apiVersion":          "apps/v1",
+					"resource":            "deployments.apps",
+					"generation":          "4",
+					"observedGeneration":  "4",
+					"spec.replicas":       "3",
+					"replicas":            "4",
+					"updatedReplicas":     "1",
+					"readyReplicas":       "3",
+					"condition.0.type":    "Available",
+					"condition.0.status":  "True",
+					"condition.1.type":    "Progressing",
+					"condition.1.status":  "False",
+					"condition.1.reason":  "ProgressDeadlineExceeded",
+					"condition.1.message": `ReplicaSet "web-5d8f7" has timed out progressing.`,
+				},
+			},
+			{
+				// Zero counts are omitted by the API server
+				Resource: collector.ResourceInfo{Kind: "StatefulSet", Name: "db", Namespace: "shop"},
+				Status: map[string]string{
+					"apiVersion":    "apps/v1",
+					"resource":      "statefulsets.apps",
+					"spec.replicas": "2",
+					"replicas":      "1",
+				},
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "DaemonSet", Name: "agent", Namespace: "kube-system"},
+				Status: map[string]string{
+					"apiVersion":             "apps/v1",
+					"resource":               "daemonsets.apps",
+					"desiredNumberScheduled": "5",
+					"numberReady":            "4",
+					"numberMisscheduled":     "1",
+				},
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "Deployment", Name: "api", Namespace: "shop"},
+				Status: map[string]string{
+					"apiVersion":    "apps/v1",
+					"resource":      "deployments.apps",
+					"spec.replicas": "2",
+					"readyReplicas": "2",
+				},
+			},
+		},
+	}
+
+	details, err := (&DeploymentAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ids := make([]string, 0)
+	for _, detail := range details {
+		ids = append(ids, detail.ID+"/"+detail.Resource.Name+"/"+detail.Severity.String())
+	}
+	want := "DEPLOYMENT_ROLLOUT_STUCK/web/error,WORKLOAD_REPLICAS_UNAVAILABLE/db/error," +
+		"WORKLOAD_REPLICAS_UNAVAILABLE/agent/warning,DAEMONSET_MISSCHEDULED/agent/warning"
+	if got := strings.Join(ids, ","); got != want {
+		t.Fatalf("Unexpected findings:\n got %s\nwant %s", got, want)
+	}
+
+	if !strings.Contains(details[0].Description, "has timed out progressing") {
+		t.Errorf("Expected the condition message in the description, got %q", details[0].Description)
+	}
+	if details[1].Description != "StatefulSet db has 0 of 2 replicas ready" {
+		t.Errorf("Unexpected description: %q", details[1].Description)
+	}
+	if details[1].RemediationCommands[0] != "kubectl rollout status statefulsets.apps/db -n shop" {
+		t.Errorf("Unexpected command: %s", details[1].RemediationCommands[0])
+	}
+}
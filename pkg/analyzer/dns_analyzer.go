@@ -0,0 +1,335 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8smed/k8smed/internal/logscan"
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// clusterDomain is the default cluster DNS domain used in suggested service names
+const clusterDomain = "cluster.local"
+
+// DNSAnalyzer explains DNS resolution failures found in pod logs
+type DNSAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *DNSAnalyzer) Name() string {
+	return "DNSAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *DNSAnalyzer) Description() string {
+	return "Analyzes DNS resolution failures in logs against existing Services, namespaces and CoreDNS health"
+}
+
+// dnsHost is the analyzer's view of a host name that failed to resolve
+type dnsHost struct {
+	Name              string
+	Kind              logscan.DNSFailureKind
+	Line              string
+	Prefix            string   // Status key prefix of the collected dns.host.N entry
+	Collected         bool     // Whether the collector looked the name up
+	NamespaceServices []string // Services in the namespace the name points into
+	ServiceNamespaces []string // Namespaces that have a service with the name
+}
+
+// Analyze implements the Analyzer interface
+func (a *DNSAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Pod" || len(resource.Logs) == 0 {
+			continue
+		}
+
+		failures := logscan.ExtractDNSFailures(strings.Join(resource.Logs, "\n"))
+		if len(failures) == 0 {
+			continue
+		}
+
+		for _, host := range dnsHosts(resource.Status, failures) {
+			if detail, ok := a.hostDetail(resource, host); ok {
+				details = append(details, detail)
+			}
+		}
+
+		timeouts := make([]logscan.DNSFailure, 0)
+		for _, failure := range failures {
+			if failure.Kind != logscan.DNSNotFound {
+				timeouts = append(timeouts, failure)
+			}
+		}
+
+		if detail, ok := a.coreDNSDetail(resource); ok {
+			details = append(details, detail)
+		} else if len(timeouts) > 0 {
+			details = append(details, a.timeoutDetail(resource, timeouts))
+		}
+	}
+
+	return details, nil
+}
+
+// dnsHosts combines the failures found in the logs with the collected dns.host.N entries,
+// one entry per failing host name
+func dnsHosts(status map[string]string, failures []logscan.DNSFailure) []dnsHost {
+	collected := make(map[string]string)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("dns.host.%d.", i)
+		name, ok := status[prefix+"name"]
+		if !ok {
+			break
+		}
+		collected[name] = prefix
+	}
+
+	hosts := make([]dnsHost, 0)
+	seen := make(map[string]bool)
+	for _, failure := range failures {
+		if failure.Host == "" || seen[failure.Host] {
+			continue
+		}
+		seen[failure.Host] = true
+
+		host := dnsHost{Name: failure.Host, Kind: failure.Kind, Line: failure.Line}
+		if prefix, ok := collected[failure.Host]; ok {
+			host.Prefix = prefix
+			_, host.Collected = status[prefix+"serviceNamespaces"]
+			host.NamespaceServices = nonEmptyList(status[prefix+"namespaceServices"])
+			host.ServiceNamespaces = nonEmptyList(status[prefix+"serviceNamespaces"])
+		}
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// hostDetail checks a failing name against the Services and namespaces of the cluster
+func (a *DNSAnalyzer) hostDetail(resource collector.ResourceData, host dnsHost) (AnalysisDetail, bool) {
+	namespace := resource.Resource.Namespace
+	service, targetNamespace := logscan.SplitServiceHost(host.Name, namespace)
+	namespaces := nonEmptyList(resource.Status["dns.namespaces"])
+
+	detail := AnalysisDetail{
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceHigh,
+		Evidence:   []Evidence{logEvidence("", host.Line)},
+		Resource:   resource.Resource,
+	}
+	if host.Prefix != "" {
+		detail.Evidence = append(detail.Evidence, prefixEvidence(resource.Status, host.Prefix)...)
+	}
+
+	// A misspelled cluster domain never resolves, whatever the service
+	if suffix := clusterSuffix(host.Name); suffix != "" && suffix != clusterDomain && levenshtein(suffix, clusterDomain) <= 3 {
+		fixed := strings.TrimSuffix(strings.TrimSuffix(host.Name, "."), suffix) + clusterDomain
+		detail.Severity = SeverityError
+		detail.ID = "DNS_DOMAIN_TYPO"
+		detail.Title = "Misspelled cluster DNS domain"
+		detail.Description = fmt.Sprintf("Pod %s cannot resolve %s: the domain %s looks like a typo of %s",
+			resource.Resource.Name, host.Name, suffix, clusterDomain)
+		detail.Remediation = []string{
+			"Use " + fixed + " in the application configuration",
+		}
+		return detail, true
+	}
+
+	// Without a lookup from the collector only external names can be reported
+	if service == "" || !host.Collected {
+		if service != "" {
+			return AnalysisDetail{}, false
+		}
+		return a.externalDetail(detail, resource, host)
+	}
+
+	// Two-label names such as example.com are only in-cluster if they name a namespace
+	// or a service; otherwise they are external names
+	namespaceExists := containsName(namespaces, targetNamespace)
+	if !strings.Contains(host.Name, ".svc") && strings.Contains(host.Name, ".") &&
+		!namespaceExists && len(host.ServiceNamespaces) == 0 {
+		return a.externalDetail(detail, resource, host)
+	}
+
+	fqdn := func(ns string) string {
+		return fmt.Sprintf("%s.%s.svc.%s", service, ns, clusterDomain)
+	}
+
+	switch {
+	case containsName(host.ServiceNamespaces, targetNamespace):
+		// The service exists, so the lookup itself is failing
+		detail.Severity = SeverityError
+		detail.ID = "DNS_LOOKUP_FAILED"
+		detail.Title = "DNS lookup failed for existing Service"
+		detail.Description = fmt.Sprintf("Pod %s cannot resolve %s although Service %s exists in namespace %s",
+			resource.Resource.Name, host.Name, service, targetNamespace)
+		detail.Remediation = []string{
+			"Check that CoreDNS pods in kube-system are running and ready",
+			"Check that NetworkPolicies allow egress to kube-dns on port 53 (UDP and TCP)",
+			"Check the pod's dnsPolicy and /etc/resolv.conf",
+		}
+		detail.RemediationCommands = []string{
+			"kubectl get pods -n kube-system -l k8s-app=kube-dns",
+			"kubectl get networkpolicies -n " + namespace,
+			"kubectl exec " + resource.Resource.Name + " -n " + namespace + " -- cat /etc/resolv.conf",
+		}
+
+	case len(host.ServiceNamespaces) > 0:
+		detail.ID = "DNS_SERVICE_WRONG_NAMESPACE"
+		detail.Title = "Service exists in a different namespace"
+		detail.Description = fmt.Sprintf("Pod %s looks up %s in namespace %s, but Service %s only exists in namespace %s",
+			resource.Resource.Name, host.Name, targetNamespace, service, strings.Join(host.ServiceNamespaces, ", "))
+		detail.Remediation = []string{
+			"Use the fully qualified name " + fqdn(host.ServiceNamespaces[0]),
+		}
+		detail.RemediationCommands = []string{
+			"kubectl get svc " + service + " -n " + host.ServiceNamespaces[0],
+		}
+
+	case !namespaceExists:
+		detail.ID = "DNS_NAMESPACE_NOT_FOUND"
+		detail.Title = "DNS name points to a missing namespace"
+		detail.Description = fmt.Sprintf("Pod %s looks up %s, but namespace %s does not exist",
+			resource.Resource.Name, host.Name, targetNamespace)
+		if suggestion := closestKey(targetNamespace, namespaces); suggestion != "" {
+			detail.Description += fmt.Sprintf(" (did you mean %s?)", suggestion)
+			detail.Remediation = append(detail.Remediation, "Use "+fqdn(suggestion))
+		}
+		detail.Remediation = append(detail.Remediation, "Check the namespace in the application configuration")
+		detail.RemediationCommands = []string{"kubectl get namespaces"}
+
+	default:
+		detail.ID = "DNS_SERVICE_NOT_FOUND"
+		detail.Title = "DNS name matches no Service"
+		detail.Description = fmt.Sprintf("Pod %s looks up %s, but there is no Service %s in namespace %s",
+			resource.Resource.Name, host.Name, service, targetNamespace)
+		if suggestion := closestKey(service, host.NamespaceServices); suggestion != "" {
+			detail.ID = "DNS_SERVICE_TYPO"
+			detail.Title = "Service name typo in DNS lookup"
+			detail.Description += fmt.Sprintf(" (did you mean %s?)", suggestion)
+			detail.Remediation = append(detail.Remediation, "Use "+fqdn(suggestion))
+		}
+		detail.Remediation = append(detail.Remediation,
+			"Check the service name in the application configuration",
+			"Create the Service if the workload behind it is missing one")
+		detail.RemediationCommands = []string{"kubectl get svc -n " + targetNamespace}
+	}
+
+	return detail, true
+}
+
+// externalDetail reports an external name that does not resolve. Timeouts are left to
+// the CoreDNS checks, as they do not depend on the name.
+func (a *DNSAnalyzer) externalDetail(detail AnalysisDetail, resource collector.ResourceData, host dnsHost) (AnalysisDetail, bool) {
+	if host.Kind != logscan.DNSNotFound {
+		return AnalysisDetail{}, false
+	}
+	detail.ID = "DNS_EXTERNAL_NOT_FOUND"
+	detail.Confidence = ConfidenceMedium
+	detail.Title = "External host name not found"
+	detail.Description = fmt.Sprintf("Pod %s cannot resolve %s: %s", resource.Resource.Name, host.Name, host.Line)
+	detail.Remediation = []string{
+		"Check the host name in the application configuration",
+		"Verify CoreDNS forwards external queries to a working upstream resolver",
+	}
+	detail.RemediationCommands = []string{
+		"kubectl get configmap coredns -n kube-system -o yaml",
+	}
+	return detail, true
+}
+
+// coreDNSDetail reports CoreDNS pods or the kube-dns Service being unavailable
+func (a *DNSAnalyzer) coreDNSDetail(resource collector.ResourceData) (AnalysisDetail, bool) {
+	pods, ok := resource.Status["dns.coredns.pods"]
+	if !ok {
+		return AnalysisDetail{}, false
+	}
+
+	problems := make([]string, 0)
+	if pods == "0" {
+		problems = append(problems, "no CoreDNS pods were found in kube-system")
+	} else if ready := resource.Status["dns.coredns.readyPods"]; ready == "0" {
+		problems = append(problems, fmt.Sprintf("none of the %s CoreDNS pods are ready", pods))
+	}
+	if serviceErr := resource.Status["dns.service.error"]; serviceErr != "" {
+		problems = append(problems, "the kube-dns Service could not be read: "+serviceErr)
+	} else if resource.Status["dns.service.endpoints"] == "0" {
+		problems = append(problems, "the kube-dns Service has no endpoints")
+	}
+
+	if len(problems) == 0 {
+		return AnalysisDetail{}, false
+	}
+
+	return AnalysisDetail{
+		ID:          "DNS_COREDNS_UNAVAILABLE",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceHigh,
+		Title:       "CoreDNS unavailable",
+		Description: fmt.Sprintf("Pod %s has DNS failures and %s", resource.Resource.Name, strings.Join(problems, "; ")),
+		Evidence: append(statusEvidence(resource.Status, "dns.coredns.pods", "dns.coredns.readyPods",
+			"dns.service.clusterIP", "dns.service.endpoints", "dns.service.error"), prefixEvidence(resource.Status, "dns.coredns.pod.")...),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Check the CoreDNS pods' events and logs",
+			"Make sure the CoreDNS deployment has available replicas",
+		},
+		RemediationCommands: []string{
+			"kubectl get pods -n kube-system -l k8s-app=kube-dns -o wide",
+			"kubectl logs -n kube-system -l k8s-app=kube-dns",
+			"kubectl get endpoints kube-dns -n kube-system",
+		},
+	}, true
+}
+
+// timeoutDetail reports DNS queries that time out while CoreDNS looks healthy
+func (a *DNSAnalyzer) timeoutDetail(resource collector.ResourceData, failures []logscan.DNSFailure) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	description := fmt.Sprintf("Pod %s has %d DNS queries that timed out or failed: %s",
+		resource.Resource.Name, len(failures), failures[0].Line)
+	if failures[0].Server != "" {
+		description += fmt.Sprintf(" (server %s)", failures[0].Server)
+	}
+
+	evidence := make([]Evidence, 0, len(failures))
+	for _, failure := range failures {
+		evidence = append(evidence, logEvidence("", failure.Line))
+	}
+
+	return AnalysisDetail{
+		ID:          "DNS_TIMEOUT",
+		Severity:    SeverityWarning,
+		Confidence:  ConfidenceMedium,
+		Title:       "DNS queries timing out",
+		Description: description,
+		Evidence:    evidence,
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Check that NetworkPolicies allow egress to kube-dns on port 53 (UDP and TCP)",
+			"Check CoreDNS load and logs for upstream errors",
+		},
+		RemediationCommands: []string{
+			"kubectl get networkpolicies -n " + namespace,
+			"kubectl logs -n kube-system -l k8s-app=kube-dns",
+		},
+	}
+}
+
+// clusterSuffix returns what follows the "svc" label of a service name, if anything
+func clusterSuffix(host string) string {
+	index := strings.Index(host, ".svc.")
+	if index < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(host[index+len(".svc."):], ".")
+}
+
+// containsName reports whether the list contains the name
+func containsName(names []string, name string) bool {
+	for _, candidate := range names {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestDNSAnalyzer_ServiceInOtherNamespace(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "checkout-5f6d", Namespace: "shop"},
+			Status: map[string]string{
+				"phase":                          "Running",
+				"dns.namespaces":                 "default,kube-system,payments,shop",
+				"dns.host.0.name":                "payments-api",
+				"dns.host.0.namespaceServices":   "cart,checkout",
+				"dns.host.0.serviceNamespaces":   "payments",
+				"dns.coredns.pods":               "2",
+				"dns.coredns.readyPods":          "2",
+				"dns.service.clusterIP":          "10.96.0.10",
+				"dns.service.endpoints":          "2",
+				"dns.coredns.pod.0.name":         "coredns-1",
+				"dns.coredns.pod.0.ready":        "true",
+				"dns.coredns.pod.0.restartCount": "0",
+			},
+			Logs: []string{"=== Logs for container: app ===\n" +
+				"dial tcp: lookup payments-api on 10.96.0.10:53: no such host"},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&DNSAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("Expected 1 detail, got %+v", details)
+	}
+	detail := details[0]
+	if detail.Title != "Service exists in a different namespace" {
+		t.Errorf("Unexpected title '%s'", detail.Title)
+	}
+	if len(detail.Remediation) == 0 || !strings.Contains(detail.Remediation[0], "payments-api.payments.svc.cluster.local") {
+		t.Errorf("Expected the fully qualified name in the remediation, got %v", detail.Remediation)
+	}
+}
+
+func TestDNSAnalyzer_TypoAndCoreDNSDown(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "shop"},
+			Status: map[string]string{
+				"dns.namespaces":               "default,kube-system,shop",
+				"dns.host.0.name":              "checkout.shop.svc.cluster.local",
+				"dns.host.0.namespaceServices": "cart,checkout",
+				"dns.host.0.serviceNamespaces": "shop",
+				"dns.host.1.name":              "chekout.shop.svc.cluster.local",
+				"dns.host.1.namespaceServices": "cart,checkout",
+				"dns.host.1.serviceNamespaces": "",
+				"dns.coredns.pods":             "2",
+				"dns.coredns.readyPods":        "0",
+				"dns.service.endpoints":        "0",
+			},
+			Logs: []string{
+				"lookup checkout.shop.svc.cluster.local on 10.96.0.10:53: read udp: i/o timeout\n" +
+					"lookup chekout.shop.svc.cluster.local on 10.96.0.10:53: no such host",
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&DNSAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	titles := make(map[string]AnalysisDetail)
+	for _, detail := range details {
+		titles[detail.Title] = detail
+	}
+
+	if _, ok := titles["DNS lookup failed for existing Service"]; !ok {
+		t.Errorf("Expected a finding for the existing service, got %v", details)
+	}
+	typo, ok := titles["Service name typo in DNS lookup"]
+	if !ok {
+		t.Fatalf("Expected a typo finding, got %v", details)
+	}
+	if !strings.Contains(typo.Description, "did you mean checkout?") {
+		t.Errorf("Expected a suggestion in the description, got '%s'", typo.Description)
+	}
+	coreDNS, ok := titles["CoreDNS unavailable"]
+	if !ok {
+		t.Fatalf("Expected a CoreDNS finding, got %v", details)
+	}
+	if coreDNS.Severity != SeverityError {
+		t.Errorf("Expected detail severity to be error, got %s", coreDNS.Severity)
+	}
+}
+
+func TestDNSAnalyzer_TwoLabelExternalName(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "ci-runner-1", Namespace: "build"},
+			Status: map[string]string{
+				"dns.namespaces":               "build,default,kube-system,shop",
+				"dns.host.0.name":              "github.com",
+				"dns.host.0.namespaceServices": "",
+				"dns.host.0.serviceNamespaces": "",
+				"dns.host.1.name":              "cart.shop",
+				"dns.host.1.namespaceServices": "checkout",
+				"dns.host.1.serviceNamespaces": "",
+			},
+			Logs: []string{"=== Logs for container: app ===\n" +
+				"dial tcp: lookup github.com on 10.96.0.10:53: no such host\n" +
+				"dial tcp: lookup cart.shop on 10.96.0.10:53: no such host"},
+		}},
+	}
+
+	details, err := (&DNSAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ids := make(map[string]AnalysisDetail)
+	for _, detail := range details {
+		ids[detail.ID] = detail
+	}
+	// github.com names neither a namespace nor a service, so it is an external name
+	external, ok := ids["DNS_EXTERNAL_NOT_FOUND"]
+	if !ok || !strings.Contains(external.Description, "github.com") {
+		t.Errorf("Expected github.com to be reported as an external name, got %+v", details)
+	}
+	// cart.shop names an existing namespace, so it is looked up as a service
+	if missing, ok := ids["DNS_SERVICE_NOT_FOUND"]; !ok || !strings.Contains(missing.Description, "no Service cart in namespace shop") {
+		t.Errorf("Expected cart.shop to be reported as a missing service, got %+v", details)
+	}
+	if len(details) != 2 {
+		t.Errorf("Expected 2 details, got %+v", details)
+	}
+}
+
+func TestPodAnalyzer_DNSFailureIsNotConnectionIssue(t *testing.T) {
+	if connectionIssue("dial tcp: lookup redis on 10.96.0.10:53: no such host") != "" {
+		t.Error("Expected a DNS failure not to count as a connection issue")
+	}
+	if connectionIssue("dial tcp 10.0.0.5:6379: connect: connection refused") == "" {
+		t.Error("Expected a refused connection to count as a connection issue")
+	}
+}
@@ -0,0 +1,238 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAnalyzerTimeout bounds a single analyzer run when EngineOptions.Timeout is unset
+const DefaultAnalyzerTimeout = 60 * time.Second
+
+// EngineOptions configures how an Engine runs analyzers
+type EngineOptions struct {
+	// Timeout bounds each analyzer run; zero uses DefaultAnalyzerTimeout
+	Timeout time.Duration
+
+	// Concurrency limits how many analyzers run at once; zero runs them all at once
+	Concurrency int
+
+	// Suppressions hide accepted findings from Report.Details
+	Suppressions []Suppression
+}
+
+// AnalyzerResult is the outcome of a single analyzer run
+type AnalyzerResult struct {
+	Analyzer string
+	Details  []AnalysisDetail
+	Duration time.Duration
+
+	// Err is set when the analyzer failed, panicked or timed out. Details it
+	// returned alongside an error are kept.
+	Err error
+}
+
+// Report is the outcome of an Engine run
+type Report struct {
+	// Details are the findings of every analyzer, in analyzer order
+	Details []AnalysisDetail
+
+	// Suppressed are the findings hidden by a suppression
+	Suppressed []AnalysisDetail
+
+	// RootCauses groups Details under their probable root causes, most likely first
+	RootCauses []RootCause
+
+	// Results has one entry per analyzer run, in analyzer order
+	Results []AnalyzerResult
+}
+
+// Err joins the errors of the analyzers that failed, or returns nil
+func (r *Report) Err() error {
+	errs := make([]error, 0)
+	for _, result := range r.Results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("analyzer %s: %w", result.Analyzer, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Engine runs the analyzers of a registry concurrently
+type Engine struct {
+	registry *Registry
+	options  EngineOptions
+}
+
+// NewEngine creates an engine for the analyzers in the registry
+func NewEngine(registry *Registry, options EngineOptions) *Engine {
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultAnalyzerTimeout
+	}
+	return &Engine{
+		registry: registry,
+		options:  options,
+	}
+}
+
+// Run runs the named analyzers, or every registered analyzer when no names are given,
+// and stores the unsuppressed findings in analysisCtx.Details. A failing analyzer does not
+// stop the others; its error is recorded in the report. Run only returns an error when
+// a name is not registered.
+func (e *Engine) Run(ctx context.Context, analysisCtx *AnalysisContext, names ...string) (*Report, error) {
+	analyzers, err := e.selectAnalyzers(names)
+	if err != nil {
+		return nil, err
+	}
+
+	// Analyzers get their own copy of the context so none can see another's findings
+	input := &AnalysisContext{
+		Query:     analysisCtx.Query,
+		Resources: analysisCtx.Resources,
+	}
+
+	limit := e.options.Concurrency
+	if limit <= 0 || limit > len(analyzers) {
+		limit = len(analyzers)
+	}
+	slots := make(chan struct{}, limit)
+
+	results := make([]AnalyzerResult, len(analyzers))
+	var wg sync.WaitGroup
+	for i, analyzer := range analyzers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			results[i] = e.runAnalyzer(ctx, analyzer, input)
+		}()
+	}
+	wg.Wait()
+
+	details := withoutSuperseded(analyzers, results)
+
+	report := &Report{Results: results}
+	report.Details, report.Suppressed = Suppress(details, e.options.Suppressions, time.Now())
+	report.RootCauses = Correlate(analysisCtx.Resources, report.Details)
+	analysisCtx.Details = report.Details
+
+	return report, nil
+}
+
+// withoutSuperseded returns the findings of every analyzer, leaving out those that a
+// Superseder replaced with its own findings about the same resource and container
+func withoutSuperseded(analyzers []Analyzer, results []AnalyzerResult) []AnalysisDetail {
+	superseded := make(map[string]bool)
+	for i, analyzer := range analyzers {
+		superseder, ok := analyzer.(Superseder)
+		if !ok {
+			continue
+		}
+		for _, detail := range results[i].Details {
+			for _, id := range superseder.Supersedes(detail) {
+				superseded[supersededKey(detail, id)] = true
+			}
+		}
+	}
+
+	details := make([]AnalysisDetail, 0)
+	for _, result := range results {
+		for _, detail := range result.Details {
+			if !superseded[supersededKey(detail, detail.ID)] {
+				details = append(details, detail)
+			}
+		}
+	}
+	return details
+}
+
+// supersededKey identifies a finding ID about the resource and container of detail
+func supersededKey(detail AnalysisDetail, id string) string {
+	return resourceKey(detail.Resource) + "/" + findingContainer(detail) + "/" + id
+}
+
+// selectAnalyzers resolves names to analyzers in registry order
+func (e *Engine) selectAnalyzers(names []string) ([]Analyzer, error) {
+	if len(names) == 0 {
+		return e.registry.GetAll(), nil
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range names {
+		if err := e.registry.checkName(name); err != nil {
+			return nil, err
+		}
+		selected[name] = true
+	}
+
+	analyzers := make([]Analyzer, 0, len(selected))
+	for _, analyzer := range e.registry.GetAll() {
+		if selected[analyzer.Name()] {
+			analyzers = append(analyzers, analyzer)
+		}
+	}
+	return analyzers, nil
+}
+
+// runAnalyzer runs one analyzer with the engine's timeout, turning a panic into an error.
+// An analyzer that ignores its context is abandoned when the timeout expires.
+func (e *Engine) runAnalyzer(ctx context.Context, analyzer Analyzer, analysisCtx *AnalysisContext) AnalyzerResult {
+	name := analyzer.Name()
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, e.options.Timeout)
+	defer cancel()
+
+	// Buffered so an abandoned analyzer can still deliver its result and exit
+	done := make(chan AnalyzerResult, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- AnalyzerResult{Err: fmt.Errorf("panic: %v\n%s", r, panicLocation(debug.Stack()))}
+			}
+		}()
+		details, err := analyzer.Analyze(ctx, analysisCtx)
+		done <- AnalyzerResult{Details: details, Err: err}
+	}()
+
+	var result AnalyzerResult
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			result.Err = fmt.Errorf("timed out after %s", e.options.Timeout)
+		} else {
+			result.Err = ctx.Err()
+		}
+	}
+
+	result.Analyzer = name
+	result.Duration = time.Since(start)
+	for i := range result.Details {
+		if result.Details[i].Analyzer == "" {
+			result.Details[i].Analyzer = name
+		}
+	}
+	return result
+}
+
+// panicLocation returns the frames of a stack trace below the panic call, which
+// point at the code that panicked
+func panicLocation(stack []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(stack)), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "panic(") && i+2 < len(lines) {
+			lines = lines[i+2:]
+			break
+		}
+	}
+	if len(lines) > 4 {
+		lines = lines[:4]
+	}
+	return strings.Join(lines, "\n")
+}
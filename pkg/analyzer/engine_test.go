@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// stubAnalyzer is a configurable analyzer for engine tests
+type stubAnalyzer struct {
+	name    string
+	analyze func(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error)
+}
+
+func (s *stubAnalyzer) Name() string        { return s.name }
+func (s *stubAnalyzer) Description() string { return "stub " + s.name }
+func (s *stubAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	return s.analyze(ctx, analysisCtx)
+}
+
+// findingAnalyzer returns one finding per resource after an optional delay
+func findingAnalyzer(name string, delay time.Duration) *stubAnalyzer {
+	return &stubAnalyzer{name: name, analyze: func(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+		time.Sleep(delay)
+		details := make([]AnalysisDetail, 0)
+		for _, resource := range analysisCtx.Resources {
+			details = append(details, AnalysisDetail{ID: strings.ToUpper(name), Title: name, Resource: resource.Resource})
+		}
+		return details, nil
+	}}
+}
+
+func TestRegistry_GetAllOrder(t *testing.T) {
+	registry := NewRegistry()
+	want := []string{
+		"PodAnalyzer", "DeploymentAnalyzer", "ConfigReferenceAnalyzer", "RBACAnalyzer", "ImageAnalyzer",
+		"SchedulingAnalyzer", "PDBAnalyzer", "DNSAnalyzer", "TLSAnalyzer", "WebhookAnalyzer", "InitContainerAnalyzer",
+		"ServiceAnalyzer", "ConditionAnalyzer", "ResourceUsageAnalyzer",
+	}
+
+	for i := 0; i < 5; i++ {
+		got := make([]string, 0)
+		for _, analyzer := range registry.GetAll() {
+			got = append(got, analyzer.Name())
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("Expected registration order %v, got %v", want, got)
+		}
+	}
+
+	// Replacing an analyzer keeps its position
+	registry.Register(findingAnalyzer("PodAnalyzer", 0))
+	if names := registry.Names(); names[0] != "PodAnalyzer" || len(names) != len(want) {
+		t.Errorf("Expected the replacement to keep its position, got %v", names)
+	}
+}
+
+func TestEngine_Run(t *testing.T) {
+	registry := &Registry{analyzers: make(map[string]Analyzer)}
+	// The slowest analyzer is registered first; its findings must still come first
+	registry.Register(findingAnalyzer("slow", 50*time.Millisecond))
+	registry.Register(findingAnalyzer("fast", 0))
+	registry.Register(&stubAnalyzer{name: "failing", analyze: func(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+		return nil, fmt.Errorf("cannot analyze")
+	}})
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{Resource: collector.ResourceInfo{Kind: "Pod", Name: "a"}},
+			{Resource: collector.ResourceInfo{Kind: "Pod", Name: "b"}},
+		},
+	}
+
+	report, err := NewEngine(registry, EngineOptions{}).Run(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	ids := make([]string, 0)
+	for _, detail := range report.Details {
+		ids = append(ids, detail.ID+"/"+detail.Resource.Name+"/"+detail.Analyzer)
+	}
+	if got := strings.Join(ids, ","); got != "SLOW/a/slow,SLOW/b/slow,FAST/a/fast,FAST/b/fast" {
+		t.Errorf("Unexpected details: %s", got)
+	}
+	if len(analysisCtx.Details) != len(report.Details) {
+		t.Errorf("Expected the details to be stored in the context, got %d", len(analysisCtx.Details))
+	}
+
+	if len(report.Results) != 3 {
+		t.Fatalf("Expected one result per analyzer, got %d", len(report.Results))
+	}
+	if slow := report.Results[0]; slow.Analyzer != "slow" || slow.Duration < 50*time.Millisecond || slow.Err != nil {
+		t.Errorf("Unexpected result for the slow analyzer: %+v", slow)
+	}
+	if err := report.Err(); err == nil || !strings.Contains(err.Error(), "analyzer failing: cannot analyze") {
+		t.Errorf("Expected the failing analyzer's error, got %v", err)
+	}
+}
+
+func TestEngine_RunSelected(t *testing.T) {
+	registry := &Registry{analyzers: make(map[string]Analyzer)}
+	registry.Register(findingAnalyzer("first", 0))
+	registry.Register(findingAnalyzer("second", 0))
+	engine := NewEngine(registry, EngineOptions{})
+
+	analysisCtx := &AnalysisContext{Resources: []collector.ResourceData{{Resource: collector.ResourceInfo{Name: "a"}}}}
+	report, err := engine.Run(context.Background(), analysisCtx, "second")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Analyzer != "second" {
+		t.Errorf("Expected only the selected analyzer to run, got %+v", report.Results)
+	}
+
+	if _, err := engine.Run(context.Background(), analysisCtx, "secnd"); err == nil || !strings.Contains(err.Error(), `did you mean "second"`) {
+		t.Errorf("Expected an unknown analyzer error with a suggestion, got %v", err)
+	}
+}
+
+func TestEngine_TimeoutAndPanic(t *testing.T) {
+	registry := &Registry{analyzers: make(map[string]Analyzer)}
+	registry.Register(&stubAnalyzer{name: "stuck", analyze: func(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+		// Ignores its context on purpose
+		time.Sleep(2 * time.Second)
+		return []AnalysisDetail{{ID: "LATE"}}, nil
+	}})
+	registry.Register(&stubAnalyzer{name: "panicking", analyze: func(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+		var resources []collector.ResourceData
+		return []AnalysisDetail{{Resource: resources[1].Resource}}, nil
+	}})
+	registry.Register(findingAnalyzer("healthy", 0))
+
+	analysisCtx := &AnalysisContext{Resources: []collector.ResourceData{{Resource: collector.ResourceInfo{Name: "a"}}}}
+	start := time.Now()
+	report, err := NewEngine(registry, EngineOptions{Timeout: 100 * time.Millisecond}).Run(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("Expected the engine to stop waiting at the timeout, took %s", time.Since(start))
+	}
+
+	if err := report.Results[0].Err; err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if err := report.Results[1].Err; err == nil || !strings.Contains(err.Error(), "panic: runtime error: index out of range") {
+		t.Errorf("Expected a recovered panic, got %v", err)
+	}
+	if len(report.Details) != 1 || report.Details[0].ID != "HEALTHY" {
+		t.Errorf("Expected only the healthy analyzer's finding, got %+v", report.Details)
+	}
+}
+
+func TestEngine_Concurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	registry := &Registry{analyzers: make(map[string]Analyzer)}
+	for i := 0; i < 6; i++ {
+		registry.Register(&stubAnalyzer{name: fmt.Sprintf("a%d", i), analyze: func(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				old := peak.Load()
+				if current <= old || peak.CompareAndSwap(old, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			return nil, nil
+		}})
+	}
+
+	if _, err := NewEngine(registry, EngineOptions{Concurrency: 2}).Run(context.Background(), &AnalysisContext{}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 analyzers at once, saw %d", peak.Load())
+	}
+}
@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how serious a finding is; a higher value is more serious
+type Severity int
+
+const (
+	// SeverityInfo is an observation that needs no action
+	SeverityInfo Severity = iota
+	// SeverityWarning is a problem that may cause failures
+	SeverityWarning
+	// SeverityError is a problem that is causing failures
+	SeverityError
+)
+
+// severityNames are the names severities are written as
+var severityNames = map[Severity]string{
+	SeverityInfo:    "info",
+	SeverityWarning: "warning",
+	SeverityError:   "error",
+}
+
+// String returns the severity's name
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Severity) MarshalText() ([]byte, error) {
+	if _, ok := severityNames[s]; !ok {
+		return nil, fmt.Errorf("invalid severity %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Severity) UnmarshalText(text []byte) error {
+	severity, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = severity
+	return nil
+}
+
+// ParseSeverity parses a severity name such as "warning"
+func ParseSeverity(name string) (Severity, error) {
+	for severity, severityName := range severityNames {
+		if strings.EqualFold(name, severityName) {
+			return severity, nil
+		}
+	}
+	return SeverityInfo, fmt.Errorf("unknown severity %q, want info, warning or error", name)
+}
+
+// Confidence levels shared by the built-in analyzers
+const (
+	// ConfidenceCertain is for states Kubernetes reports directly, such as a waiting reason
+	ConfidenceCertain = 1.0
+	// ConfidenceHigh is for conclusions drawn from several consistent signals
+	ConfidenceHigh = 0.8
+	// ConfidenceMedium is for conclusions drawn from a single indirect signal
+	ConfidenceMedium = 0.5
+	// ConfidenceLow is for keyword matches that may be unrelated to a problem
+	ConfidenceLow = 0.2
+)
+
+// EvidenceKind says where a piece of evidence came from
+type EvidenceKind string
+
+const (
+	// EvidenceStatus is a collected status key and its value
+	EvidenceStatus EvidenceKind = "status"
+	// EvidenceEvent is a collected event line
+	EvidenceEvent EvidenceKind = "event"
+	// EvidenceLog is a log line or excerpt; the key names the container
+	EvidenceLog EvidenceKind = "log"
+	// EvidenceManifest is a manifest field; the key is its path
+	EvidenceManifest EvidenceKind = "manifest"
+)
+
+// Evidence is a piece of collected data that triggered a finding
+type Evidence struct {
+	Kind  EvidenceKind `json:"kind"`
+	Key   string       `json:"key,omitempty"`
+	Value string       `json:"value"`
+}
+
+// statusEvidence returns the given status keys that are set, in order
+func statusEvidence(status map[string]string, keys ...string) []Evidence {
+	evidence := make([]Evidence, 0, len(keys))
+	for _, key := range keys {
+		if value, ok := status[key]; ok && value != "" {
+			evidence = append(evidence, Evidence{Kind: EvidenceStatus, Key: key, Value: value})
+		}
+	}
+	return evidence
+}
+
+// prefixEvidence returns every set status key starting with prefix, sorted by key
+func prefixEvidence(status map[string]string, prefix string) []Evidence {
+	keys := make([]string, 0)
+	for key := range status {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return statusEvidence(status, keys...)
+}
+
+// eventEvidence returns events as evidence
+func eventEvidence(events ...string) []Evidence {
+	evidence := make([]Evidence, 0, len(events))
+	for _, event := range events {
+		evidence = append(evidence, Evidence{Kind: EvidenceEvent, Value: event})
+	}
+	return evidence
+}
+
+// logEvidence returns a log excerpt from a container as evidence
+func logEvidence(container, excerpt string) Evidence {
+	return Evidence{Kind: EvidenceLog, Key: container, Value: excerpt}
+}
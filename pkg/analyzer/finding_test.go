@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestSeverity(t *testing.T) {
+	if !(SeverityInfo < SeverityWarning && SeverityWarning < SeverityError) {
+		t.Error("Expected severities to be ordered info < warning < error")
+	}
+
+	for _, name := range []string{"info", "warning", "error", "Warning"} {
+		severity, err := ParseSeverity(name)
+		if err != nil {
+			t.Errorf("ParseSeverity(%q) error = %v", name, err)
+			continue
+		}
+		if !strings.EqualFold(severity.String(), name) {
+			t.Errorf("ParseSeverity(%q) = %s", name, severity)
+		}
+	}
+	if _, err := ParseSeverity("critical"); err == nil {
+		t.Error("Expected an unknown severity to be rejected")
+	}
+}
+
+func TestAnalysisDetail_JSON(t *testing.T) {
+	detail := AnalysisDetail{
+		ID:         "POD_CRASHLOOP",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceHigh,
+		Title:      "Container in CrashLoopBackOff",
+		Evidence:   []Evidence{{Kind: EvidenceStatus, Key: "container.0.reason", Value: "CrashLoopBackOff"}},
+	}
+
+	data, err := json.Marshal(detail)
+	if err != nil {
+		t.Fatalf("Marshal error = %v", err)
+	}
+	if !strings.Contains(string(data), `"severity":"warning"`) {
+		t.Errorf("Expected the severity to be written by name, got %s", data)
+	}
+
+	var decoded AnalysisDetail
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error = %v", err)
+	}
+	if decoded.Severity != SeverityWarning || decoded.ID != detail.ID || len(decoded.Evidence) != 1 {
+		t.Errorf("Round trip changed the detail: %+v", decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"severity":"fatal"}`), &decoded); err == nil {
+		t.Error("Expected an unknown severity to fail to decode")
+	}
+}
+
+func TestPodAnalyzer_CrashLoopEvidence(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "default"},
+				Status: map[string]string{
+					"phase":                    "Running",
+					"container.0.name":         "app",
+					"container.0.state":        "waiting",
+					"container.0.reason":       "CrashLoopBackOff",
+					"container.0.restartCount": "5",
+				},
+			},
+		},
+	}
+
+	details, err := (&PodAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	var crashLoop *AnalysisDetail
+	for i, detail := range details {
+		if detail.ID == "POD_CRASHLOOP" {
+			crashLoop = &details[i]
+		}
+	}
+	if crashLoop == nil {
+		t.Fatalf("Expected a POD_CRASHLOOP detail, got %+v", details)
+	}
+	if crashLoop.Confidence != ConfidenceCertain {
+		t.Errorf("Expected certain confidence, got %v", crashLoop.Confidence)
+	}
+
+	found := false
+	for _, evidence := range crashLoop.Evidence {
+		if evidence.Kind == EvidenceStatus && evidence.Key == "container.0.reason" && evidence.Value == "CrashLoopBackOff" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the waiting reason as evidence, got %+v", crashLoop.Evidence)
+	}
+}
@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// FindingGroup is one issue found on several replicas of the same workload
+type FindingGroup struct {
+	// Detail is the first finding of the group and stands in for the others
+	Detail AnalysisDetail `json:"detail"`
+
+	// Owner is the controller owning the affected resources, when it is known
+	Owner *collector.ResourceInfo `json:"owner,omitempty"`
+
+	// Resources are the affected resources, in the order they were found
+	Resources []collector.ResourceInfo `json:"resources"`
+}
+
+// Count returns the number of affected resources
+func (g FindingGroup) Count() int {
+	return len(g.Resources)
+}
+
+// Generated name suffixes use the Kubernetes random string alphabet, which has no vowels
+const generatedChars = "[bcdfghjklmnpqrstvwxz2456789]"
+
+var (
+	// Pods of a ReplicaSet are named <deployment>-<pod-template-hash>-<random>
+	replicaSetPodSuffix = regexp.MustCompile(`-` + generatedChars + `{6,10}-` + generatedChars + `{5}\b`)
+
+	// Pods of a DaemonSet or Job, and ReplicaSets, end in a single generated suffix
+	generatedSuffix = regexp.MustCompile(`-` + generatedChars + `{5,10}\b`)
+
+	// Pods of a StatefulSet end in their ordinal
+	ordinalSuffix = regexp.MustCompile(`-[0-9]+$`)
+)
+
+// GroupFindings merges the findings that report the same issue on replicas of one
+// workload. Findings are grouped by ID, owning controller and description, with the
+// generated suffixes of pod names stripped so that "web-7d9f8c6b5-x2k4p" and
+// "web-7d9f8c6b5-q8r5t" compare equal. The owner comes from the controllers in
+// ResourceData.Related; without one, resources are matched by their names with the
+// suffixes stripped. Groups keep the order of their first finding.
+func GroupFindings(resources []collector.ResourceData, details []AnalysisDetail) []FindingGroup {
+	related := make(map[string][]collector.RelatedResource)
+	for _, resource := range resources {
+		key := resourceKey(resource.Resource)
+		related[key] = append(related[key], resource.Related...)
+	}
+
+	groups := make([]FindingGroup, 0)
+	index := make(map[string]int)
+	for _, detail := range details {
+		owner := findOwner(detail.Resource, related)
+
+		workload := resourceKey(collector.ResourceInfo{
+			Kind:      detail.Resource.Kind,
+			Namespace: detail.Resource.Namespace,
+			Name:      normalizeName(detail.Resource.Name),
+		})
+		if owner != nil {
+			workload = resourceKey(*owner)
+		}
+		key := detail.ID + "|" + workload + "|" + normalizeDescription(detail.Description, detail.Resource.Name)
+
+		if i, ok := index[key]; ok {
+			groups[i].Resources = append(groups[i].Resources, detail.Resource)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, FindingGroup{
+			Detail:    detail,
+			Owner:     owner,
+			Resources: []collector.ResourceInfo{detail.Resource},
+		})
+	}
+	return groups
+}
+
+// PrioritizeGroups orders groups from the most to the least pressing: by severity, then
+// by confidence, then by the number of affected resources. Ties keep their order.
+func PrioritizeGroups(groups []FindingGroup) []FindingGroup {
+	prioritized := append([]FindingGroup{}, groups...)
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		a, b := prioritized[i], prioritized[j]
+		if a.Detail.Severity != b.Detail.Severity {
+			return a.Detail.Severity > b.Detail.Severity
+		}
+		if a.Detail.Confidence != b.Detail.Confidence {
+			return a.Detail.Confidence > b.Detail.Confidence
+		}
+		return a.Count() > b.Count()
+	})
+	return prioritized
+}
+
+// findOwner returns the outermost controller reachable through the Related resources
+// of resource, such as the Deployment of a pod's ReplicaSet, or nil if there is none
+func findOwner(resource collector.ResourceInfo, related map[string][]collector.RelatedResource) *collector.ResourceInfo {
+	var owner *collector.ResourceInfo
+	visited := map[string]bool{resourceKey(resource): true}
+	queue := []collector.ResourceInfo{resource}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, candidate := range related[resourceKey(current)] {
+			key := resourceKey(candidate.ResourceInfo)
+			if visited[key] || !affects(current, candidate) || controllerRank[strings.ToLower(candidate.Kind)] == 0 {
+				continue
+			}
+			visited[key] = true
+			if owner == nil || controllerRank[strings.ToLower(candidate.Kind)] > controllerRank[strings.ToLower(owner.Kind)] {
+				found := candidate.ResourceInfo
+				owner = &found
+			}
+			queue = append(queue, candidate.ResourceInfo)
+		}
+	}
+	return owner
+}
+
+// normalizeName strips the generated suffix or ordinal from a pod or ReplicaSet name
+func normalizeName(name string) string {
+	if stripped := replicaSetPodSuffix.ReplaceAllString(name, ""); stripped != name {
+		return stripped
+	}
+	if stripped := generatedSuffix.ReplaceAllString(name, ""); stripped != name {
+		return stripped
+	}
+	return ordinalSuffix.ReplaceAllString(name, "")
+}
+
+// normalizeDescription replaces the resource's name in a description with its normalized
+// form and strips any other generated pod name suffixes
+func normalizeDescription(description, name string) string {
+	if name != "" {
+		description = strings.ReplaceAll(description, name, normalizeName(name))
+	}
+	description = replicaSetPodSuffix.ReplaceAllString(description, "")
+	return generatedSuffix.ReplaceAllString(description, "")
+}
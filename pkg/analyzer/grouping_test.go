@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := map[string]string{
+		"web-7d9f8c6b5-x2k4p": "web",
+		"web-7d9f8c6b5":       "web",
+		"node-exporter-q8r5t": "node-exporter",
+		"postgres-2":          "postgres",
+		"report-29871-abcde":  "report-29871-abcde",
+		"api-server":          "api-server",
+	}
+	for name, want := range tests {
+		if got := normalizeName(name); got != want {
+			t.Errorf("normalizeName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestGroupFindings(t *testing.T) {
+	crashLoop := func(name string) AnalysisDetail {
+		return AnalysisDetail{
+			ID:          "POD_CRASHLOOP",
+			Severity:    SeverityError,
+			Title:       "Container in CrashLoopBackOff",
+			Description: "Container app of pod " + name + " is in CrashLoopBackOff",
+			Resource:    collector.ResourceInfo{Kind: "Pod", Name: name, Namespace: "shop"},
+		}
+	}
+
+	details := make([]AnalysisDetail, 0)
+	for _, name := range []string{"web-7d9f8c6b5-x2k4p", "web-7d9f8c6b5-q8r5t", "web-6b5d4c7f8-zz9wb"} {
+		details = append(details, crashLoop(name))
+	}
+	details = append(details, crashLoop("api-5c8d7b6f4-mm2pl"))
+
+	groups := GroupFindings(nil, details)
+	if len(groups) != 2 {
+		t.Fatalf("Expected the web replicas and the api pod in separate groups, got %+v", groups)
+	}
+	if groups[0].Count() != 3 || groups[0].Detail.Resource.Name != "web-7d9f8c6b5-x2k4p" || groups[0].Owner != nil {
+		t.Errorf("Unexpected web group: %+v", groups[0])
+	}
+	if groups[1].Count() != 1 {
+		t.Errorf("Expected a single api finding, got %+v", groups[1])
+	}
+}
+
+func TestGroupFindings_Owner(t *testing.T) {
+	deployment := collector.ResourceInfo{Kind: "Deployment", Name: "web", Namespace: "shop"}
+	replicaSet := collector.ResourceInfo{Kind: "ReplicaSet", Name: "web-7d9f8c6b5", Namespace: "shop"}
+	resources := []collector.ResourceData{
+		{Resource: collector.ResourceInfo{Kind: "Pod", Name: "frontend-a", Namespace: "shop"}, Related: []collector.RelatedResource{
+			{ResourceInfo: replicaSet, Relation: collector.RelationOwner},
+		}},
+		{Resource: collector.ResourceInfo{Kind: "Pod", Name: "backend-b", Namespace: "shop"}, Related: []collector.RelatedResource{
+			{ResourceInfo: replicaSet, Relation: collector.RelationOwner},
+		}},
+		{Resource: replicaSet, Related: []collector.RelatedResource{{ResourceInfo: deployment, Relation: collector.RelationOwner}}},
+	}
+
+	// The names do not share a prefix, but the pods have the same owner
+	details := []AnalysisDetail{
+		{ID: "POD_OOMKILLED", Description: "Container app was OOMKilled", Resource: resources[0].Resource},
+		{ID: "POD_OOMKILLED", Description: "Container app was OOMKilled", Resource: resources[1].Resource},
+		{ID: "POD_OOMKILLED", Description: "Container sidecar was OOMKilled", Resource: resources[1].Resource},
+	}
+
+	groups := GroupFindings(resources, details)
+	if len(groups) != 2 || groups[0].Count() != 2 {
+		t.Fatalf("Expected the findings with the same description to be grouped, got %+v", groups)
+	}
+	if owner := groups[0].Owner; owner == nil || owner.Kind != "Deployment" || owner.Name != "web" {
+		t.Errorf("Expected the Deployment as the owner, got %+v", owner)
+	}
+}
+
+func TestPrioritizeGroups(t *testing.T) {
+	group := func(id string, severity Severity, confidence float64, count int) FindingGroup {
+		resources := make([]collector.ResourceInfo, count)
+		return FindingGroup{Detail: AnalysisDetail{ID: id, Severity: severity, Confidence: confidence}, Resources: resources}
+	}
+	groups := []FindingGroup{
+		group("INFO", SeverityInfo, ConfidenceCertain, 9),
+		group("WARNING", SeverityWarning, ConfidenceHigh, 1),
+		group("ERROR_ONE", SeverityError, ConfidenceHigh, 1),
+		group("ERROR_MANY", SeverityError, ConfidenceHigh, 3),
+		group("ERROR_CERTAIN", SeverityError, ConfidenceCertain, 1),
+	}
+
+	ids := make([]string, 0)
+	for _, prioritized := range PrioritizeGroups(groups) {
+		ids = append(ids, prioritized.Detail.ID)
+	}
+	if got := strings.Join(ids, ","); got != "ERROR_CERTAIN,ERROR_MANY,ERROR_ONE,WARNING,INFO" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+	if groups[0].Detail.ID != "INFO" {
+		t.Errorf("Expected the input to be left unchanged")
+	}
+}
@@ -0,0 +1,424 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// defaultRegistry is the registry used for image references without a registry host
+const defaultRegistry = "docker.io"
+
+// ImageReference is a parsed container image reference
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// String returns the fully qualified image reference
+func (r ImageReference) String() string {
+	ref := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		ref += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		ref += "@" + r.Digest
+	}
+	return ref
+}
+
+// ParseImageReference splits an image reference into registry, repository, tag and digest,
+// applying the same defaults as the container runtime
+func ParseImageReference(image string) (ImageReference, error) {
+	ref := ImageReference{}
+	if image == "" {
+		return ref, fmt.Errorf("image reference is empty")
+	}
+
+	name := image
+	if at := strings.Index(name, "@"); at >= 0 {
+		ref.Digest = name[at+1:]
+		name = name[:at]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("invalid digest %q", ref.Digest)
+		}
+	}
+
+	// A colon after the last slash separates the tag; earlier colons belong to a registry port
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		ref.Tag = name[colon+1:]
+		name = name[:colon]
+	}
+
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry = first
+		ref.Repository = rest
+	} else {
+		ref.Registry = defaultRegistry
+		ref.Repository = name
+	}
+
+	if ref.Registry == defaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if ref.Repository == "" || ref.Repository != strings.ToLower(ref.Repository) {
+		return ref, fmt.Errorf("invalid repository name %q: must be non-empty and lowercase", ref.Repository)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	return ref, nil
+}
+
+// pullFailure classifies why an image pull failed
+type pullFailure int
+
+const (
+	pullFailureUnknown pullFailure = iota
+	pullFailureNotFound
+	pullFailureUnauthorized
+	pullFailureNetwork
+	pullFailureRateLimited
+)
+
+// classifyPullFailure inspects the runtime's pull error message
+func classifyPullFailure(message string) pullFailure {
+	lower := strings.ToLower(message)
+
+	switch {
+	case strings.Contains(lower, "toomanyrequests") || strings.Contains(lower, "rate limit"):
+		return pullFailureRateLimited
+	case strings.Contains(lower, "unauthorized") || strings.Contains(lower, "authentication required") ||
+		strings.Contains(lower, "access denied") || strings.Contains(lower, "403 forbidden") ||
+		strings.Contains(lower, "denied:"):
+		return pullFailureUnauthorized
+	case strings.Contains(lower, "manifest unknown") || strings.Contains(lower, "not found") ||
+		strings.Contains(lower, "name unknown") || strings.Contains(lower, "code = notfound"):
+		return pullFailureNotFound
+	case strings.Contains(lower, "no such host") || strings.Contains(lower, "i/o timeout") ||
+		strings.Contains(lower, "dial tcp") || strings.Contains(lower, "tls handshake timeout") ||
+		strings.Contains(lower, "deadline exceeded") || strings.Contains(lower, "network is unreachable"):
+		return pullFailureNetwork
+	default:
+		return pullFailureUnknown
+	}
+}
+
+// ImageAnalyzer diagnoses image pull failures using the image reference and the pull error
+type ImageAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *ImageAnalyzer) Name() string {
+	return "ImageAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *ImageAnalyzer) Description() string {
+	return "Analyzes image pull failures such as bad tags, missing pull secrets, registry outages and rate limits"
+}
+
+// Analyze implements the Analyzer interface
+func (a *ImageAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Pod" {
+			continue
+		}
+
+		for _, containers := range []string{"initContainer", "container"} {
+			details = append(details, a.analyzeContainers(resource, containers)...)
+		}
+	}
+
+	return details, nil
+}
+
+// Supersedes implements the Superseder interface: a classified pull failure explains
+// more than the PodAnalyzer's report of the back-off
+func (a *ImageAnalyzer) Supersedes(detail AnalysisDetail) []string {
+	switch detail.ID {
+	case "IMAGE_NOT_FOUND", "IMAGE_REGISTRY_UNAUTHORIZED", "IMAGE_REGISTRY_UNREACHABLE", "IMAGE_REGISTRY_RATE_LIMITED":
+		return []string{"POD_IMAGE_PULL_FAILED"}
+	}
+	return nil
+}
+
+// analyzeContainers checks the images of the containers recorded under the status key
+// prefix, "container" or "initContainer"
+func (a *ImageAnalyzer) analyzeContainers(resource collector.ResourceData, containers string) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("%s.%d.", containers, i)
+		containerName, ok := resource.Status[prefix+"name"]
+		if !ok {
+			break
+		}
+
+		image := resource.Status[prefix+"image"]
+		if image == "" {
+			continue
+		}
+
+		ref, err := ParseImageReference(image)
+		reason := resource.Status[prefix+"reason"]
+		evidence := statusEvidence(resource.Status, prefix+"name", prefix+"image", prefix+"imagePullPolicy",
+			prefix+"state", prefix+"reason", prefix+"message")
+
+		if err != nil || reason == "InvalidImageName" {
+			details = append(details, a.invalidReferenceDetail(resource, containers, containerName, image, err, evidence))
+			continue
+		}
+
+		if resource.Status[prefix+"state"] == "waiting" && (reason == "ImagePullBackOff" || reason == "ErrImagePull") {
+			events := pullEvents(resource, image)
+			message := strings.Join(append([]string{resource.Status[prefix+"message"]}, events...), "\n")
+			evidence := append(evidence, eventEvidence(events...)...)
+			if detail, ok := a.pullFailureDetail(resource, containerName, ref, message, evidence); ok {
+				details = append(details, detail)
+			}
+		}
+
+		if ref.Tag == "latest" && ref.Digest == "" && resource.Status[prefix+"imagePullPolicy"] == "IfNotPresent" {
+			details = append(details, a.latestTagDetail(resource, containerName, image, evidence))
+		}
+	}
+	return details
+}
+
+// pullEvents returns the pull failure events for the image, which say why the pull
+// failed when the back-off message alone does not
+func pullEvents(resource collector.ResourceData, image string) []string {
+	events := make([]string, 0)
+	for _, event := range resource.Events {
+		if strings.Contains(event, `"`+image+`"`) && strings.Contains(event, "Failed") {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// pullFailureDetail builds the finding for a classified pull failure
+func (a *ImageAnalyzer) pullFailureDetail(resource collector.ResourceData, containerName string, ref ImageReference, message string, evidence []Evidence) (AnalysisDetail, bool) {
+	namespace := resource.Resource.Namespace
+	describe := "kubectl describe pod " + resource.Resource.Name + " -n " + namespace
+
+	detail := AnalysisDetail{
+		Severity:   SeverityError,
+		Confidence: ConfidenceHigh,
+		Evidence:   evidence,
+		Resource:   resource.Resource,
+	}
+
+	switch classifyPullFailure(message) {
+	case pullFailureNotFound:
+		detail.ID = "IMAGE_NOT_FOUND"
+		detail.Title = "Image tag or repository not found"
+		detail.Description = fmt.Sprintf("Container %s references %s, but registry %s has no manifest for repository %s with %s",
+			containerName, ref.String(), ref.Registry, ref.Repository, tagOrDigest(ref))
+		detail.Remediation = []string{
+			"Verify the tag exists in the registry (tags are case sensitive)",
+			"Check that the image was pushed for the node's architecture",
+			"Check the repository path for typos",
+		}
+		detail.RemediationCommands = []string{
+			"skopeo list-tags docker://" + ref.Registry + "/" + ref.Repository,
+			describe,
+		}
+
+	case pullFailureUnauthorized:
+		detail.ID = "IMAGE_REGISTRY_UNAUTHORIZED"
+		detail.Title = "Image registry authentication failed"
+		detail.Evidence = append(detail.Evidence, prefixEvidence(resource.Status, "imagePullSecret.")...)
+		detail.Description = fmt.Sprintf("Container %s cannot pull %s: the registry %s rejected the request. %s",
+			containerName, ref.String(), ref.Registry, pullSecretDiagnosis(resource.Status, ref.Registry))
+		detail.Remediation = []string{
+			"Create a docker-registry secret with credentials for " + ref.Registry,
+			"Reference the secret in the pod's imagePullSecrets or its ServiceAccount",
+			"Verify the credentials have pull access to " + ref.Repository,
+			"Check that the repository exists, since some registries report missing repositories as access denied",
+		}
+		detail.RemediationCommands = []string{
+			"kubectl create secret docker-registry <secret-name> -n " + namespace +
+				" --docker-server=" + ref.Registry + " --docker-username=<user> --docker-password=<password>",
+			"kubectl get secrets -n " + namespace + " --field-selector type=kubernetes.io/dockerconfigjson",
+			describe,
+		}
+
+	case pullFailureNetwork:
+		detail.ID = "IMAGE_REGISTRY_UNREACHABLE"
+		detail.Title = "Image registry unreachable"
+		detail.Description = fmt.Sprintf("Container %s cannot pull %s: the node could not reach registry %s. %s",
+			containerName, ref.String(), ref.Registry, networkDiagnosis(message))
+		detail.Remediation = []string{
+			"Check DNS resolution of the registry host from the nodes",
+			"Check egress firewall rules and proxy settings for the nodes",
+			"Mirror the image to a registry reachable from the cluster",
+		}
+		detail.RemediationCommands = []string{
+			"kubectl run registry-check --rm -it --restart=Never --image=busybox -n " + namespace + " -- nslookup " + registryHost(ref.Registry),
+			describe,
+		}
+
+	case pullFailureRateLimited:
+		detail.ID = "IMAGE_REGISTRY_RATE_LIMITED"
+		detail.Title = "Image registry rate limit exceeded"
+		detail.Description = fmt.Sprintf("Container %s cannot pull %s: registry %s is rate limiting pulls",
+			containerName, ref.String(), ref.Registry)
+		detail.Remediation = []string{
+			"Authenticate pulls with an imagePullSecret to get a higher rate limit",
+			"Use a pull-through cache or mirror registry",
+			"Avoid imagePullPolicy Always for images that rarely change",
+		}
+		detail.RemediationCommands = []string{
+			describe,
+		}
+
+	default:
+		return detail, false
+	}
+
+	return detail, true
+}
+
+// invalidReferenceDetail builds the finding for an image reference the runtime cannot parse
+func (a *ImageAnalyzer) invalidReferenceDetail(resource collector.ResourceData, containers, containerName, image string, err error, evidence []Evidence) AnalysisDetail {
+	description := fmt.Sprintf("Container %s uses an invalid image reference %q", containerName, image)
+	if err != nil {
+		description += ": " + err.Error()
+	}
+
+	return AnalysisDetail{
+		ID:          "IMAGE_INVALID_REFERENCE",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceCertain,
+		Title:       "Invalid image reference",
+		Description: description,
+		Evidence:    evidence,
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Use the form [registry/]repository[:tag][@digest] with a lowercase repository name",
+			"Check templating in the manifest did not leave the image empty or malformed",
+		},
+		RemediationCommands: []string{
+			"kubectl get pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace + " -o jsonpath='{.spec." + containers + "s[*].image}'",
+		},
+	}
+}
+
+// latestTagDetail builds the warning for :latest images that are never re-pulled
+func (a *ImageAnalyzer) latestTagDetail(resource collector.ResourceData, containerName, image string, evidence []Evidence) AnalysisDetail {
+	return AnalysisDetail{
+		ID:         "IMAGE_MUTABLE_LATEST_TAG",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceCertain,
+		Title:      "Mutable :latest tag with IfNotPresent pull policy",
+		Description: fmt.Sprintf("Container %s uses %s with imagePullPolicy IfNotPresent, so nodes keep whichever :latest image they cached first "+
+			"and different nodes may run different versions", containerName, image),
+		Evidence: evidence,
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Pin the image to a specific version tag or digest",
+			"Use imagePullPolicy Always if the :latest tag is intentional",
+		},
+	}
+}
+
+// pullSecretDiagnosis explains how the pod's image pull secrets relate to the registry
+func pullSecretDiagnosis(status map[string]string, registry string) string {
+	if _, ok := status["imagePullSecret.0.name"]; !ok {
+		return "The pod has no imagePullSecrets."
+	}
+
+	diagnoses := make([]string, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("imagePullSecret.%d.", i)
+		name, ok := status[prefix+"name"]
+		if !ok {
+			break
+		}
+
+		switch {
+		case status[prefix+"exists"] == "false":
+			diagnoses = append(diagnoses, fmt.Sprintf("imagePullSecret %s does not exist.", name))
+		case status[prefix+"exists"] != "true":
+			continue
+		case status[prefix+"type"] != "kubernetes.io/dockerconfigjson" && status[prefix+"type"] != "kubernetes.io/dockercfg":
+			diagnoses = append(diagnoses, fmt.Sprintf("imagePullSecret %s has type %s instead of kubernetes.io/dockerconfigjson.", name, status[prefix+"type"]))
+		default:
+			registries := status[prefix+"registries"]
+			if registryMatches(registries, registry) {
+				return fmt.Sprintf("imagePullSecret %s has credentials for %s, but they were rejected; they may be expired or lack pull access.", name, registry)
+			}
+			diagnoses = append(diagnoses, fmt.Sprintf("imagePullSecret %s only has credentials for %s, not %s.", name, registries, registry))
+		}
+	}
+
+	return strings.Join(diagnoses, " ")
+}
+
+// registryMatches reports whether any of the comma separated docker config hosts covers the registry
+func registryMatches(registries, registry string) bool {
+	want := normalizeRegistry(registry)
+	for _, host := range strings.Split(registries, ",") {
+		host = normalizeRegistry(host)
+		if host == want {
+			return true
+		}
+		// Docker config supports a leading wildcard for subdomains
+		if strings.HasPrefix(host, "*.") && strings.HasSuffix(want, host[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRegistry strips scheme and path from a docker config host and folds Docker Hub aliases
+func normalizeRegistry(host string) string {
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	host, _, _ = strings.Cut(host, "/")
+
+	switch host {
+	case "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return defaultRegistry
+	}
+	return host
+}
+
+// registryHost returns the registry hostname without a port
+func registryHost(registry string) string {
+	if registry == defaultRegistry {
+		return "registry-1.docker.io"
+	}
+	host, _, _ := strings.Cut(registry, ":")
+	return host
+}
+
+// networkDiagnosis distinguishes DNS failures from connectivity failures
+func networkDiagnosis(message string) string {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "no such host"):
+		return "DNS lookup of the registry host failed."
+	case strings.Contains(lower, "i/o timeout") || strings.Contains(lower, "deadline exceeded") || strings.Contains(lower, "tls handshake timeout"):
+		return "The connection to the registry timed out."
+	default:
+		return "The connection to the registry failed."
+	}
+}
+
+// tagOrDigest describes the version part of an image reference
+func tagOrDigest(ref ImageReference) string {
+	if ref.Digest != "" {
+		return "digest " + ref.Digest
+	}
+	return "tag " + ref.Tag
+}
@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestParseImageReference(t *testing.T) {
+	tests := []struct {
+		image   string
+		want    ImageReference
+		wantErr bool
+	}{
+		{image: "nginx", want: ImageReference{Registry: "docker.io", Repository: "library/nginx", Tag: "latest"}},
+		{image: "bitnami/redis:7.2", want: ImageReference{Registry: "docker.io", Repository: "bitnami/redis", Tag: "7.2"}},
+		{image: "registry.example.com:5000/team/app:v1", want: ImageReference{Registry: "registry.example.com:5000", Repository: "team/app", Tag: "v1"}},
+		{image: "ghcr.io/org/app@sha256:abc123", want: ImageReference{Registry: "ghcr.io", Repository: "org/app", Digest: "sha256:abc123"}},
+		{image: "localhost/app:dev", want: ImageReference{Registry: "localhost", Repository: "app", Tag: "dev"}},
+		{image: "Team/App:v1", wantErr: true},
+		{image: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			got, err := ParseImageReference(tt.image)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseImageReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseImageReference() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageAnalyzer_PullFailures(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      map[string]string
+		events      []string
+		wantTitle   string
+		wantMessage string
+	}{
+		{
+			name: "bad tag",
+			events: []string{
+				`[2024-01-01 10:00:00] Warning Failed: Failed to pull image "nginx:1.999": rpc error: code = NotFound desc = failed to resolve reference "docker.io/library/nginx:1.999": docker.io/library/nginx:1.999: not found (count: 3)`,
+			},
+			wantTitle:   "Image tag or repository not found",
+			wantMessage: "tag 1.999",
+		},
+		{
+			name: "pull secret for another registry",
+			status: map[string]string{
+				"imagePullSecret.0.name":       "regcred",
+				"imagePullSecret.0.exists":     "true",
+				"imagePullSecret.0.type":       "kubernetes.io/dockerconfigjson",
+				"imagePullSecret.0.registries": "https://index.docker.io/v1/",
+			},
+			events: []string{
+				`[2024-01-01 10:00:00] Warning Failed: Failed to pull image "nginx:1.999": failed to authorize: 401 Unauthorized (count: 1)`,
+			},
+			wantTitle:   "Image registry authentication failed",
+			wantMessage: "credentials for docker.io",
+		},
+		{
+			name: "rate limited",
+			events: []string{
+				`[2024-01-01 10:00:00] Warning Failed: Failed to pull image "nginx:1.999": toomanyrequests: You have reached your pull rate limit (count: 1)`,
+			},
+			wantTitle: "Image registry rate limit exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := map[string]string{
+				"phase":                       "Pending",
+				"container.0.name":            "app",
+				"container.0.image":           "nginx:1.999",
+				"container.0.imagePullPolicy": "IfNotPresent",
+				"container.0.state":           "waiting",
+				"container.0.reason":          "ImagePullBackOff",
+				"container.0.message":         `Back-off pulling image "nginx:1.999"`,
+			}
+			for key, value := range tt.status {
+				status[key] = value
+			}
+
+			analysisCtx := &AnalysisContext{
+				Resources: []collector.ResourceData{{
+					Resource: collector.ResourceInfo{Kind: "Pod", Name: "test-pod", Namespace: "default"},
+					Status:   status,
+					Events:   tt.events,
+				}},
+				Details: []AnalysisDetail{},
+			}
+
+			details, err := (&ImageAnalyzer{}).Analyze(context.Background(), analysisCtx)
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+
+			if len(details) != 1 {
+				t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+			}
+			if details[0].Title != tt.wantTitle {
+				t.Errorf("Expected title '%s', got '%s'", tt.wantTitle, details[0].Title)
+			}
+			if !strings.Contains(details[0].Description, tt.wantMessage) {
+				t.Errorf("Expected description to contain '%s', got '%s'", tt.wantMessage, details[0].Description)
+			}
+		})
+	}
+}
+
+func TestImageAnalyzer_LatestWithIfNotPresent(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "test-pod", Namespace: "default"},
+			Status: map[string]string{
+				"container.0.name":            "app",
+				"container.0.image":           "example.com/app",
+				"container.0.imagePullPolicy": "IfNotPresent",
+				"container.0.state":           "running",
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&ImageAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 || details[0].Severity != SeverityWarning {
+		t.Fatalf("Expected a single warning, got %+v", details)
+	}
+}
+
+func TestImageAnalyzer_SupersedesPodAnalyzer(t *testing.T) {
+	notFound := `[2024-01-01 10:00:00] Warning Failed: Failed to pull image "nginx:1.999": rpc error: code = NotFound desc = nginx:1.999: not found (count: 3)`
+	unknown := `[2024-01-01 10:00:00] Warning Failed: Failed to pull image "busybox:1.36": rpc error: code = Unknown desc = unexpected EOF (count: 2)`
+	analysisCtx := &AnalysisContext{Resources: []collector.ResourceData{{
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: "test-pod", Namespace: "default"},
+		Status: map[string]string{
+			"phase":                           "Pending",
+			"initContainer.0.name":            "migrate",
+			"initContainer.0.image":           "busybox:1.36",
+			"initContainer.0.imagePullPolicy": "IfNotPresent",
+			"initContainer.0.state":           "waiting",
+			"initContainer.0.reason":          "ImagePullBackOff",
+			"initContainer.0.message":         `Back-off pulling image "busybox:1.36"`,
+			"initContainer.1.name":            "seed",
+			"initContainer.1.image":           "nginx:1.999",
+			"initContainer.1.imagePullPolicy": "IfNotPresent",
+			"initContainer.1.state":           "waiting",
+			"initContainer.1.reason":          "ErrImagePull",
+			"container.0.name":                "app",
+			"container.0.image":               "nginx:1.999",
+			"container.0.imagePullPolicy":     "IfNotPresent",
+			"container.0.state":               "waiting",
+			"container.0.reason":              "ImagePullBackOff",
+			"container.0.message":             `Back-off pulling image "nginx:1.999"`,
+			"container.1.name":                "proxy",
+			"container.1.image":               "busybox:1.36",
+			"container.1.imagePullPolicy":     "IfNotPresent",
+			"container.1.state":               "waiting",
+			"container.1.reason":              "ImagePullBackOff",
+			"container.1.message":             `Back-off pulling image "busybox:1.36"`,
+		},
+		Events: []string{notFound, unknown},
+	}}}
+
+	engine := NewEngine(NewRegistry(), EngineOptions{})
+	report, err := engine.Run(context.Background(), analysisCtx, "PodAnalyzer", "ImageAnalyzer")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	got := make(map[string]int)
+	for _, detail := range report.Details {
+		got[detail.ID+" "+findingContainer(detail)]++
+	}
+	// The init container's bad tag is classified too; an unclassified failure keeps the
+	// generic finding, and a classified one replaces it
+	want := map[string]int{
+		"IMAGE_NOT_FOUND initContainer.1":   1,
+		"IMAGE_NOT_FOUND container.0":       1,
+		"POD_IMAGE_PULL_FAILED container.1": 1,
+	}
+	for key, count := range want {
+		if got[key] != count {
+			t.Errorf("Expected %d %s, got %d", count, key, got[key])
+		}
+	}
+	if got["POD_IMAGE_PULL_FAILED container.0"] != 0 {
+		t.Errorf("Expected the classified failure to replace the generic one, got %v", got)
+	}
+}
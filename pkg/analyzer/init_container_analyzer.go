@@ -0,0 +1,268 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// initLogLines is how many trailing log lines of the blocking init container are shown
+const initLogLines = 10
+
+// initContainer is the analyzer's view of a collected initContainer.N entry
+type initContainer struct {
+	Prefix       string // status key prefix, such as "initContainer.0."
+	Name         string
+	Step         int // 1-based position in the init sequence
+	Sidecar      bool
+	State        string
+	Reason       string
+	Message      string
+	ExitCode     string
+	LastExitCode string
+	Started      bool
+	RestartCount int
+}
+
+// done reports whether the container no longer blocks the containers after it
+func (c initContainer) done() bool {
+	if c.Sidecar {
+		// The kubelet moves on once a sidecar has started and passed its startup probe
+		return c.Started
+	}
+	return c.State == "terminated" && c.ExitCode == "0"
+}
+
+// InitContainerAnalyzer finds the init step that keeps a pod from starting
+type InitContainerAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *InitContainerAnalyzer) Name() string {
+	return "InitContainerAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *InitContainerAnalyzer) Description() string {
+	return "Analyzes init containers and native sidecars to find the step blocking pod startup"
+}
+
+// Analyze implements the Analyzer interface
+func (a *InitContainerAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Pod" {
+			continue
+		}
+
+		containers := initContainers(resource.Status)
+		if len(containers) == 0 {
+			continue
+		}
+
+		// Once startup is over, sidecars can still crash alongside the app containers;
+		// a restarting sidecar reports started=false again, so it no longer blocks anything
+		if podInitialized(resource.Status) {
+			for _, container := range containers {
+				if container.Sidecar && container.Reason == "CrashLoopBackOff" {
+					details = append(details, a.sidecarCrashDetail(resource, container))
+				}
+			}
+			continue
+		}
+
+		for _, container := range containers {
+			if container.done() {
+				continue
+			}
+			details = append(details, a.blockingDetail(resource, container, len(containers)))
+			break
+		}
+	}
+
+	return details, nil
+}
+
+// initContainers reads the collected initContainer.N entries in init order
+func initContainers(status map[string]string) []initContainer {
+	containers := make([]initContainer, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("initContainer.%d.", i)
+		name, ok := status[prefix+"name"]
+		if !ok {
+			break
+		}
+		restarts, _ := strconv.Atoi(status[prefix+"restartCount"])
+		containers = append(containers, initContainer{
+			Prefix:       prefix,
+			Name:         name,
+			Step:         i + 1,
+			Sidecar:      status[prefix+"restartPolicy"] == "Always",
+			State:        status[prefix+"state"],
+			Reason:       status[prefix+"reason"],
+			Message:      status[prefix+"message"],
+			ExitCode:     status[prefix+"exitCode"],
+			LastExitCode: status[prefix+"lastExitCode"],
+			Started:      status[prefix+"started"] == "true",
+			RestartCount: restarts,
+		})
+	}
+	return containers
+}
+
+// podInitialized reports whether the pod's init sequence has completed, from its
+// Initialized condition or, when conditions were not collected, from app containers
+// that have already run
+func podInitialized(status map[string]string) bool {
+	for _, condition := range resourceConditions(status) {
+		if condition.Type == "Initialized" {
+			return condition.Status == "True"
+		}
+	}
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("container.%d.", i)
+		if _, ok := status[prefix+"name"]; !ok {
+			return false
+		}
+		state := status[prefix+"state"]
+		if state == "running" || state == "terminated" || status[prefix+"lastExitCode"] != "" {
+			return true
+		}
+	}
+}
+
+// blockingDetail explains why the given init container keeps the pod from starting
+func (a *InitContainerAnalyzer) blockingDetail(resource collector.ResourceData, container initContainer, total int) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	role := "Init container"
+	if container.Sidecar {
+		role = "Sidecar container"
+	}
+	step := fmt.Sprintf("%s %s (step %d of %d)", role, container.Name, container.Step, total)
+
+	detail := AnalysisDetail{
+		Severity:   SeverityError,
+		Confidence: ConfidenceCertain,
+		Evidence:   prefixEvidence(resource.Status, container.Prefix),
+		Resource:   resource.Resource,
+		RemediationCommands: []string{
+			"kubectl logs " + resource.Resource.Name + " -c " + container.Name + " -n " + namespace,
+			"kubectl describe pod " + resource.Resource.Name + " -n " + namespace,
+		},
+	}
+
+	var description string
+	switch {
+	case container.Reason == "CrashLoopBackOff" || (container.State == "terminated" && container.ExitCode != "0"):
+		exitCode := container.ExitCode
+		if exitCode == "" {
+			exitCode = container.LastExitCode
+		}
+		detail.ID = "INIT_CONTAINER_FAILING"
+		detail.Title = "Init container failing"
+		description = fmt.Sprintf("%s exits with code %s and has restarted %d times", step, exitCode, container.RestartCount)
+		detail.Remediation = []string{
+			"Fix the error reported in the init container's logs",
+			"Check that the services and files the init step depends on are available",
+		}
+		detail.RemediationCommands = append(detail.RemediationCommands,
+			"kubectl logs "+resource.Resource.Name+" -c "+container.Name+" -n "+namespace+" --previous")
+
+	case container.State == "waiting" && container.Reason != "" && container.Reason != "PodInitializing":
+		detail.ID = "INIT_CONTAINER_CANNOT_START"
+		detail.Title = "Init container cannot start"
+		description = fmt.Sprintf("%s is waiting with %s", step, container.Reason)
+		if container.Message != "" {
+			description += ": " + container.Message
+		}
+		detail.Remediation = []string{
+			"Fix the image or configuration of the init container",
+		}
+
+	case container.Sidecar:
+		detail.ID = "SIDECAR_NOT_STARTED"
+		detail.Title = "Sidecar container not started"
+		description = fmt.Sprintf("%s has not started, so the init containers after it and the app containers wait", step)
+		detail.Remediation = []string{
+			"Check the sidecar's startup probe; the next container only starts once it succeeds",
+		}
+
+	case container.State == "running":
+		detail.ID = "INIT_CONTAINER_NOT_COMPLETING"
+		detail.Severity = SeverityWarning
+		detail.Confidence = ConfidenceMedium
+		detail.Title = "Init container not completing"
+		description = fmt.Sprintf("%s is still running; init containers must exit before the next step starts", step)
+		detail.Remediation = []string{
+			"Check whether the init step is waiting for a dependency that never becomes available",
+			"If the container is meant to keep running, declare it as a native sidecar with restartPolicy: Always (Kubernetes 1.29+)",
+		}
+
+	default:
+		detail.ID = "INIT_CONTAINER_PENDING"
+		detail.Severity = SeverityWarning
+		detail.Title = "Init container pending"
+		description = fmt.Sprintf("%s has not run yet", step)
+		detail.Remediation = []string{
+			"Check the pod's events for scheduling or volume problems",
+		}
+	}
+
+	description += "; the app containers will not start until it completes"
+	if logs := containerLogTail(resource.Logs, container.Name, initLogLines); logs != "" {
+		description += ". Last log lines:\n" + logs
+		detail.Evidence = append(detail.Evidence, logEvidence(container.Name, logs))
+	}
+	detail.Description = description
+
+	return detail
+}
+
+// sidecarCrashDetail reports a native sidecar that crash loops after pod startup
+func (a *InitContainerAnalyzer) sidecarCrashDetail(resource collector.ResourceData, container initContainer) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	description := fmt.Sprintf("Sidecar container %s is crash looping with exit code %s after %d restarts",
+		container.Name, container.LastExitCode, container.RestartCount)
+	evidence := prefixEvidence(resource.Status, container.Prefix)
+	if logs := containerLogTail(resource.Logs, container.Name, initLogLines); logs != "" {
+		description += ". Last log lines:\n" + logs
+		evidence = append(evidence, logEvidence(container.Name, logs))
+	}
+
+	return AnalysisDetail{
+		ID:          "SIDECAR_CRASHLOOP",
+		Severity:    SeverityWarning,
+		Confidence:  ConfidenceCertain,
+		Title:       "Sidecar container crash looping",
+		Description: description,
+		Evidence:    evidence,
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Fix the error reported in the sidecar's logs",
+		},
+		RemediationCommands: []string{
+			"kubectl logs " + resource.Resource.Name + " -c " + container.Name + " -n " + namespace + " --previous",
+		},
+	}
+}
+
+// containerLogTail returns the last lines of a container's logs from the collected logs,
+// which start with a "=== Logs for container: <name> ===" header
+func containerLogTail(logs []string, name string, lines int) string {
+	header := "=== Logs for container: " + name + " ==="
+	for _, entry := range logs {
+		body, ok := strings.CutPrefix(entry, header)
+		if !ok {
+			continue
+		}
+
+		entryLines := strings.Split(strings.TrimSpace(body), "\n")
+		if len(entryLines) > lines {
+			entryLines = entryLines[len(entryLines)-lines:]
+		}
+		return strings.Join(entryLines, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestInitContainerAnalyzer_BlockingStep(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "shop"},
+			Status: map[string]string{
+				"phase":                         "Pending",
+				"initContainers":                "3",
+				"initContainer.0.name":          "proxy",
+				"initContainer.0.restartPolicy": "Always",
+				"initContainer.0.started":       "true",
+				"initContainer.0.state":         "running",
+				"initContainer.1.name":          "migrate",
+				"initContainer.1.state":         "waiting",
+				"initContainer.1.reason":        "CrashLoopBackOff",
+				"initContainer.1.lastExitCode":  "1",
+				"initContainer.1.restartCount":  "4",
+				"initContainer.2.name":          "warmup",
+				"initContainer.2.state":         "waiting",
+				"initContainer.2.reason":        "PodInitializing",
+				"container.0.name":              "app",
+				"container.0.state":             "waiting",
+				"container.0.reason":            "PodInitializing",
+			},
+			Logs: []string{
+				"=== Logs for container: migrate ===\nconnecting to db\nERROR: relation \"users\" does not exist\n",
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&InitContainerAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("Expected 1 detail, got %+v", details)
+	}
+	detail := details[0]
+	if detail.Title != "Init container failing" {
+		t.Errorf("Unexpected title '%s'", detail.Title)
+	}
+	if !strings.Contains(detail.Description, "migrate (step 2 of 3) exits with code 1") {
+		t.Errorf("Expected the blocking step in the description, got '%s'", detail.Description)
+	}
+	if !strings.Contains(detail.Description, `relation "users" does not exist`) {
+		t.Errorf("Expected the init container logs in the description, got '%s'", detail.Description)
+	}
+}
+
+func TestInitContainerAnalyzer_SidecarNotStarted(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-2", Namespace: "shop"},
+			Status: map[string]string{
+				"initContainer.0.name":          "proxy",
+				"initContainer.0.restartPolicy": "Always",
+				"initContainer.0.started":       "false",
+				"initContainer.0.state":         "running",
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&InitContainerAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 || details[0].Title != "Sidecar container not started" {
+		t.Fatalf("Expected a sidecar finding, got %+v", details)
+	}
+}
+
+func TestInitContainerAnalyzer_SidecarCrashAfterStartup(t *testing.T) {
+	status := map[string]string{
+		"initContainer.0.name":          "migrate",
+		"initContainer.0.state":         "terminated",
+		"initContainer.0.exitCode":      "0",
+		"initContainer.1.name":          "proxy",
+		"initContainer.1.restartPolicy": "Always",
+		"initContainer.1.started":       "false",
+		"initContainer.1.state":         "waiting",
+		"initContainer.1.reason":        "CrashLoopBackOff",
+		"initContainer.1.lastExitCode":  "137",
+		"initContainer.1.restartCount":  "6",
+		"container.0.name":              "app",
+		"container.0.state":             "running",
+		"condition.0.type":              "Initialized",
+		"condition.0.status":            "True",
+	}
+	analyze := func(status map[string]string) []AnalysisDetail {
+		details, err := (&InitContainerAnalyzer{}).Analyze(context.Background(), &AnalysisContext{
+			Resources: []collector.ResourceData{{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-3", Namespace: "shop"},
+				Status:   status,
+			}},
+		})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		return details
+	}
+
+	details := analyze(status)
+	if len(details) != 1 || details[0].ID != "SIDECAR_CRASHLOOP" {
+		t.Fatalf("Expected the sidecar crash loop, got %+v", details)
+	}
+	if !strings.Contains(details[0].Description, "exit code 137 after 6 restarts") {
+		t.Errorf("Unexpected description: %s", details[0].Description)
+	}
+
+	// Without conditions, the running app container shows that startup is over
+	delete(status, "condition.0.type")
+	delete(status, "condition.0.status")
+	if details := analyze(status); len(details) != 1 || details[0].ID != "SIDECAR_CRASHLOOP" {
+		t.Errorf("Expected the sidecar crash loop from the app container state, got %+v", details)
+	}
+
+	// A sidecar crashing during startup still blocks the pod
+	status["container.0.state"] = "waiting"
+	status["container.0.reason"] = "PodInitializing"
+	status["condition.0.type"] = "Initialized"
+	status["condition.0.status"] = "False"
+	if details := analyze(status); len(details) != 1 || details[0].ID != "INIT_CONTAINER_FAILING" {
+		t.Errorf("Expected the sidecar to block startup, got %+v", details)
+	}
+}
@@ -0,0 +1,126 @@
+package logpattern
+
+import (
+	"sort"
+	"strings"
+)
+
+// containerHeaderPrefix starts the header the pod collector puts before each container's logs
+const containerHeaderPrefix = "=== Logs for container: "
+
+// Match is a failure recognized in logs
+type Match struct {
+	// Pattern is the name of the pattern that matched
+	Pattern string
+	Title   string
+	// RootCause is the line that best explains the failure, such as the innermost
+	// "Caused by" of a Java exception
+	RootCause string
+	// Subject is the value the failure is about, such as a port or variable name
+	Subject string
+	// Location is the source location of the failing frame, when known
+	Location string
+	// Trace holds every line of a stack trace; single-line matches leave it empty
+	Trace []string
+	// Remediation suggests how to fix the failure
+	Remediation []string
+	// Container is the container the logs came from, when known
+	Container string
+	// Line is the index of the first matched line within the container's logs
+	Line int
+	// Count is how many times the same failure occurred
+	Count int
+}
+
+// Pattern recognizes one kind of failure in log lines
+type Pattern interface {
+	// Name identifies the pattern
+	Name() string
+	// Find returns every failure the pattern recognizes in the lines
+	Find(lines []string) []Match
+}
+
+// Library is an ordered set of patterns applied to logs
+type Library struct {
+	patterns []Pattern
+}
+
+// NewLibrary creates a library with the given patterns
+func NewLibrary(patterns ...Pattern) *Library {
+	return &Library{patterns: patterns}
+}
+
+// DefaultLibrary returns a library with the built-in stack trace and startup failure patterns
+func DefaultLibrary() *Library {
+	return NewLibrary(
+		&goPanicPattern{},
+		&javaExceptionPattern{},
+		&pythonTracebackPattern{},
+		&nodeErrorPattern{},
+		portInUsePattern,
+		missingEnvPattern,
+		databaseAuthPattern,
+	)
+}
+
+// Register adds a pattern to the library
+func (l *Library) Register(pattern Pattern) {
+	l.patterns = append(l.patterns, pattern)
+}
+
+// Patterns returns the patterns in the library
+func (l *Library) Patterns() []Pattern {
+	return l.patterns
+}
+
+// Scan applies every pattern to the text, merging repeated failures
+func (l *Library) Scan(text string) []Match {
+	lines := strings.Split(text, "\n")
+
+	matches := make([]Match, 0)
+	for _, pattern := range l.patterns {
+		matches = append(matches, pattern.Find(lines)...)
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Line < matches[j].Line
+	})
+
+	return mergeRepeated(matches)
+}
+
+// ScanLogs scans logs as collected by the pod collector, one entry per container,
+// and records the container each match came from
+func (l *Library) ScanLogs(logs []string) []Match {
+	matches := make([]Match, 0)
+	for _, entry := range logs {
+		container := ""
+		if strings.HasPrefix(entry, containerHeaderPrefix) {
+			header, body, _ := strings.Cut(entry, "\n")
+			container = strings.TrimSuffix(strings.TrimPrefix(header, containerHeaderPrefix), " ===")
+			entry = body
+		}
+
+		for _, match := range l.Scan(entry) {
+			match.Container = container
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// mergeRepeated keeps the first of identical failures, counting the repeats
+func mergeRepeated(matches []Match) []Match {
+	merged := make([]Match, 0, len(matches))
+	index := make(map[string]int)
+	for _, match := range matches {
+		key := match.Pattern + "\x00" + match.RootCause
+		if i, ok := index[key]; ok {
+			merged[i].Count++
+			continue
+		}
+		match.Count = 1
+		index[key] = len(merged)
+		merged = append(merged, match)
+	}
+	return merged
+}
@@ -0,0 +1,183 @@
+package logpattern
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLibraryScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		logs      string
+		pattern   string
+		rootCause string
+		subject   string
+		location  string
+		trace     int
+	}{
+		{
+			name: "go panic",
+			logs: `starting server
+panic: runtime error: invalid memory address or nil pointer dereference
+[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4a2b3c]
+
+goroutine 1 [running]:
+main.(*Server).handle(0x0)
+	/app/server.go:42 +0x1c
+main.main()
+	/app/main.go:15 +0x85
+exit status 2`,
+			pattern:   "go-panic",
+			rootCause: "panic: runtime error: invalid memory address or nil pointer dereference",
+			location:  "/app/server.go:42",
+			trace:     9,
+		},
+		{
+			name: "java caused by chain",
+			logs: `2024-01-01 12:00:00 ERROR Application run failed
+org.springframework.beans.factory.BeanCreationException: Error creating bean with name 'dataSource'
+	at org.springframework.beans.factory.support.AbstractBeanFactory.getBean(AbstractBeanFactory.java:208)
+	at com.example.App.main(App.java:10)
+Caused by: java.lang.IllegalStateException: Failed to load driver
+	at com.example.db.Pool.init(Pool.java:31)
+	... 2 more
+Caused by: java.lang.ClassNotFoundException: org.postgresql.Driver
+	at java.base/jdk.internal.loader.BuiltinClassLoader.loadClass(BuiltinClassLoader.java:641)
+	... 5 more
+shutting down`,
+			pattern:   "java-exception",
+			rootCause: "java.lang.ClassNotFoundException: org.postgresql.Driver",
+			location:  "java.base/jdk.internal.loader.BuiltinClassLoader.loadClass(BuiltinClassLoader.java:641)",
+			trace:     9,
+		},
+		{
+			name: "python chained traceback",
+			logs: `Traceback (most recent call last):
+  File "/app/db.py", line 12, in connect
+    conn = pool.get()
+ConnectionError: pool exhausted
+
+During handling of the above exception, another exception occurred:
+
+Traceback (most recent call last):
+  File "/app/main.py", line 5, in <module>
+    db.connect()
+RuntimeError: database unavailable`,
+			pattern:   "python-traceback",
+			rootCause: "ConnectionError: pool exhausted",
+			location:  "/app/db.py:12",
+			trace:     11,
+		},
+		{
+			name: "node uncaught exception",
+			logs: `/app/index.js:10
+TypeError: Cannot read properties of undefined (reading 'port')
+    at loadConfig (/app/config.js:10:25)
+    at Object.<anonymous> (/app/index.js:3:16)
+    at node:internal/main/run_main_module:28:49
+
+Node.js v20.11.0`,
+			pattern:   "node-error",
+			rootCause: "TypeError: Cannot read properties of undefined (reading 'port')",
+			location:  "/app/config.js:10:25",
+			trace:     4,
+		},
+		{
+			name:      "node unhandled rejection",
+			logs:      `[UnhandledPromiseRejection: This error originated either by throwing inside of an async function without a catch block, or by rejecting a promise which was not handled with .catch(). The promise rejected with the reason "connect ECONNREFUSED".]`,
+			pattern:   "node-error",
+			rootCause: "connect ECONNREFUSED",
+			trace:     1,
+		},
+		{
+			name:      "port in use",
+			logs:      `listen tcp :8080: bind: address already in use`,
+			pattern:   "port-in-use",
+			rootCause: "listen tcp :8080: bind: address already in use",
+			subject:   "8080",
+		},
+		{
+			name:      "missing env var",
+			logs:      `FATAL: environment variable DATABASE_URL is not set`,
+			pattern:   "missing-env-var",
+			rootCause: "FATAL: environment variable DATABASE_URL is not set",
+			subject:   "DATABASE_URL",
+		},
+		{
+			name:      "postgres auth",
+			logs:      `FATAL:  password authentication failed for user "app"`,
+			pattern:   "database-auth-failed",
+			rootCause: `FATAL:  password authentication failed for user "app"`,
+			subject:   "app",
+		},
+	}
+
+	library := DefaultLibrary()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := library.Scan(tt.logs)
+			if len(matches) != 1 {
+				t.Fatalf("Expected 1 match, got %d: %+v", len(matches), matches)
+			}
+			match := matches[0]
+			if match.Pattern != tt.pattern {
+				t.Errorf("Expected pattern %q, got %q", tt.pattern, match.Pattern)
+			}
+			if match.RootCause != tt.rootCause {
+				t.Errorf("Expected root cause %q, got %q", tt.rootCause, match.RootCause)
+			}
+			if match.Subject != tt.subject {
+				t.Errorf("Expected subject %q, got %q", tt.subject, match.Subject)
+			}
+			if match.Location != tt.location {
+				t.Errorf("Expected location %q, got %q", tt.location, match.Location)
+			}
+			if len(match.Trace) != tt.trace {
+				t.Errorf("Expected %d trace lines, got %d:\n%s", tt.trace, len(match.Trace), strings.Join(match.Trace, "\n"))
+			}
+		})
+	}
+}
+
+func TestLibraryScanLogs(t *testing.T) {
+	logs := []string{
+		"=== Logs for container: app ===\nlisten tcp :8080: bind: address already in use\nlisten tcp :8080: bind: address already in use",
+		"=== Logs for container: sidecar ===\nall good",
+	}
+
+	matches := DefaultLibrary().ScanLogs(logs)
+	if len(matches) != 1 {
+		t.Fatalf("Expected 1 match, got %d", len(matches))
+	}
+	if matches[0].Container != "app" {
+		t.Errorf("Expected container app, got %q", matches[0].Container)
+	}
+	if matches[0].Count != 2 {
+		t.Errorf("Expected the repeated failure to be counted twice, got %d", matches[0].Count)
+	}
+}
+
+func TestLibraryRegister(t *testing.T) {
+	library := NewLibrary()
+	library.Register(&LinePattern{
+		ID:    "oom-killer",
+		Title: "Out of memory",
+		Regexes: []*regexp.Regexp{
+			regexp.MustCompile(`java.lang.OutOfMemoryError: (.+)`),
+		},
+	})
+
+	matches := library.Scan("java.lang.OutOfMemoryError: Java heap space")
+	if len(matches) != 1 || matches[0].Subject != "Java heap space" {
+		t.Fatalf("Expected the registered pattern to match, got %+v", matches)
+	}
+}
+
+func TestLibraryIgnoresPlainErrors(t *testing.T) {
+	logs := `level=error msg="request failed" status=500
+Error: something went wrong`
+	if matches := DefaultLibrary().Scan(logs); len(matches) != 0 {
+		t.Errorf("Expected no matches, got %+v", matches)
+	}
+}
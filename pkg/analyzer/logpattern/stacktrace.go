@@ -0,0 +1,346 @@
+package logpattern
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// goFunctionRegex matches a function line of a goroutine trace, such as
+	// "main.main()" or "github.com/x/y.(*T).Run(0xc000010000)"
+	goFunctionRegex = regexp.MustCompile(`^[\w./*()\-]+\.[\w*()\-]+\(.*\)$`)
+
+	// goFileRegex matches the file line below a function line: "\t/app/main.go:12 +0x1d"
+	goFileRegex = regexp.MustCompile(`^\s+(\S+\.go:\d+)`)
+
+	// javaHeaderRegex matches an exception line: "java.lang.IllegalStateException: message"
+	javaHeaderRegex = regexp.MustCompile(`^(?:Exception in thread "[^"]*" )?((?:[a-z_$][\w$]*\.)+[A-Z][\w$]*(?:Exception|Error|Throwable)(?::.*)?)$`)
+
+	// javaFrameRegex matches a stack frame: "at com.example.App.main(App.java:12)"
+	javaFrameRegex = regexp.MustCompile(`^\s+at ([\w$.<>/]+\(([^)]*)\))`)
+
+	// javaMoreRegex matches the elided frames marker: "... 12 more"
+	javaMoreRegex = regexp.MustCompile(`^\s+\.\.\. \d+ (?:more|common frames omitted)`)
+
+	// pythonFileRegex matches a traceback frame: `File "/app/main.py", line 12, in main`
+	pythonFileRegex = regexp.MustCompile(`^\s+File "([^"]+)", line (\d+)`)
+
+	// pythonExceptionRegex matches the exception line ending a traceback: "ValueError: message"
+	pythonExceptionRegex = regexp.MustCompile(`^([A-Za-z_][\w.]*)(?::.*)?$`)
+
+	// nodeErrorRegex matches the first line of a JavaScript error: "TypeError: message"
+	nodeErrorRegex = regexp.MustCompile(`^(?:Uncaught )?([A-Z]\w*Error|Error)(?: \[[\w_]+\])?: (.+)$`)
+
+	// nodeFrameRegex matches a JavaScript stack frame: "at fn (/app/index.js:10:5)"
+	nodeFrameRegex = regexp.MustCompile(`^\s+at (?:.* \()?((?:node:|file://|/|[A-Za-z]:\\)[^)]*?:\d+:\d+)\)?$`)
+
+	// nodeRejectionRegex matches the reason of an unhandled promise rejection
+	nodeRejectionRegex = regexp.MustCompile(`UnhandledPromiseRejection(?:Warning)?:? (?:This error originated .*reason "(.+)"\.?\]?|(.+))`)
+)
+
+// goPanicPattern recognizes Go panics and fatal errors with their goroutine traces
+type goPanicPattern struct{}
+
+// Name implements the Pattern interface
+func (p *goPanicPattern) Name() string {
+	return "go-panic"
+}
+
+// Find implements the Pattern interface
+func (p *goPanicPattern) Find(lines []string) []Match {
+	matches := make([]Match, 0)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if !strings.HasPrefix(line, "panic: ") && !strings.HasPrefix(line, "fatal error: ") {
+			continue
+		}
+
+		end := i + 1
+		for end < len(lines) && isGoTraceLine(lines[end]) {
+			end++
+		}
+		trace := trimTrailingBlank(lines[i:end])
+
+		title := "Go panic"
+		if strings.HasPrefix(line, "fatal error: ") {
+			title = "Go fatal error"
+		}
+		matches = append(matches, Match{
+			Pattern:   p.Name(),
+			Title:     title,
+			RootCause: line,
+			Location:  goPanicLocation(trace),
+			Trace:     trace,
+			Line:      i,
+			Remediation: []string{
+				"Fix the code at the location of the first application frame in the trace",
+				"Check for nil pointers, out of range indexes and unchecked type assertions there",
+			},
+		})
+		i = end - 1
+	}
+	return matches
+}
+
+// isGoTraceLine reports whether a line belongs to a Go panic's trace output
+func isGoTraceLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	switch {
+	case trimmed == "":
+		return true
+	case strings.HasPrefix(line, "\t") || strings.HasPrefix(line, "  "):
+		return true
+	case strings.HasPrefix(trimmed, "goroutine "),
+		strings.HasPrefix(trimmed, "created by "),
+		strings.HasPrefix(trimmed, "[signal "),
+		strings.HasPrefix(trimmed, "panic: "),
+		strings.HasPrefix(trimmed, "exit status "):
+		return true
+	default:
+		return goFunctionRegex.MatchString(trimmed)
+	}
+}
+
+// goPanicLocation returns the file of the first frame outside the runtime
+func goPanicLocation(trace []string) string {
+	function := ""
+	for _, line := range trace {
+		trimmed := strings.TrimSpace(line)
+		if goFunctionRegex.MatchString(trimmed) {
+			function = trimmed
+			continue
+		}
+		if m := goFileRegex.FindStringSubmatch(line); m != nil && function != "" {
+			if !strings.HasPrefix(function, "runtime.") && !strings.HasPrefix(function, "panic(") {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
+
+// javaExceptionPattern recognizes Java exceptions and follows their "Caused by" chains
+type javaExceptionPattern struct{}
+
+// Name implements the Pattern interface
+func (p *javaExceptionPattern) Name() string {
+	return "java-exception"
+}
+
+// Find implements the Pattern interface
+func (p *javaExceptionPattern) Find(lines []string) []Match {
+	matches := make([]Match, 0)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		m := javaHeaderRegex.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		// A bare class name is only an exception when a stack frame follows
+		if !strings.HasPrefix(line, "Exception in thread") && (i+1 >= len(lines) || !javaFrameRegex.MatchString(lines[i+1])) {
+			continue
+		}
+
+		rootCause := m[1]
+		location := ""
+		end := i + 1
+		for ; end < len(lines); end++ {
+			next := lines[end]
+			trimmed := strings.TrimSpace(next)
+			if cause, ok := strings.CutPrefix(trimmed, "Caused by: "); ok {
+				rootCause = cause
+				location = ""
+				continue
+			}
+			if frame := javaFrameRegex.FindStringSubmatch(next); frame != nil {
+				if location == "" {
+					location = frame[1]
+				}
+				continue
+			}
+			if javaMoreRegex.MatchString(next) || strings.HasPrefix(trimmed, "Suppressed: ") {
+				continue
+			}
+			break
+		}
+
+		matches = append(matches, Match{
+			Pattern:   p.Name(),
+			Title:     "Java exception",
+			RootCause: rootCause,
+			Location:  location,
+			Trace:     lines[i:end],
+			Line:      i,
+			Remediation: []string{
+				"Start from the innermost \"Caused by\" exception, which is the root cause",
+			},
+		})
+		i = end - 1
+	}
+	return matches
+}
+
+// pythonTracebackPattern recognizes Python tracebacks, including chained exceptions
+type pythonTracebackPattern struct{}
+
+// Name implements the Pattern interface
+func (p *pythonTracebackPattern) Name() string {
+	return "python-traceback"
+}
+
+// Find implements the Pattern interface
+func (p *pythonTracebackPattern) Find(lines []string) []Match {
+	matches := make([]Match, 0)
+	for i := 0; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) != "Traceback (most recent call last):" {
+			continue
+		}
+
+		rootCause, location := "", ""
+		lastFile := ""
+		end := i + 1
+		for end < len(lines) {
+			line := lines[end]
+			trimmed := strings.TrimSpace(line)
+
+			if m := pythonFileRegex.FindStringSubmatch(line); m != nil {
+				lastFile = m[1] + ":" + m[2]
+				end++
+				continue
+			}
+			if strings.HasPrefix(line, " ") || trimmed == "" || trimmed == "Traceback (most recent call last):" {
+				end++
+				continue
+			}
+			if strings.HasPrefix(trimmed, "During handling of the above exception") ||
+				strings.HasPrefix(trimmed, "The above exception was the direct cause") {
+				end++
+				continue
+			}
+			if pythonExceptionRegex.MatchString(trimmed) {
+				// In a chain the first exception is the original cause
+				if rootCause == "" {
+					rootCause = trimmed
+					location = lastFile
+				}
+				end++
+				if end < len(lines) && isPythonChainLine(lines, end) {
+					continue
+				}
+			}
+			break
+		}
+
+		if rootCause == "" {
+			continue
+		}
+		matches = append(matches, Match{
+			Pattern:   p.Name(),
+			Title:     "Python exception",
+			RootCause: rootCause,
+			Location:  location,
+			Trace:     trimTrailingBlank(lines[i:end]),
+			Line:      i,
+			Remediation: []string{
+				"Fix the error raised at the last frame of the traceback",
+			},
+		})
+		i = end - 1
+	}
+	return matches
+}
+
+// isPythonChainLine reports whether a chained traceback continues at lines[index]
+func isPythonChainLine(lines []string, index int) bool {
+	for ; index < len(lines); index++ {
+		trimmed := strings.TrimSpace(lines[index])
+		if trimmed == "" {
+			continue
+		}
+		return strings.HasPrefix(trimmed, "During handling of the above exception") ||
+			strings.HasPrefix(trimmed, "The above exception was the direct cause")
+	}
+	return false
+}
+
+// nodeErrorPattern recognizes uncaught Node.js errors and unhandled promise rejections
+type nodeErrorPattern struct{}
+
+// Name implements the Pattern interface
+func (p *nodeErrorPattern) Name() string {
+	return "node-error"
+}
+
+// Find implements the Pattern interface
+func (p *nodeErrorPattern) Find(lines []string) []Match {
+	matches := make([]Match, 0)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		if m := nodeRejectionRegex.FindStringSubmatch(line); m != nil {
+			rootCause := m[1]
+			if rootCause == "" {
+				rootCause = m[2]
+			}
+			end, location := nodeFrames(lines, i+1)
+			matches = append(matches, Match{
+				Pattern:   p.Name(),
+				Title:     "Node.js unhandled promise rejection",
+				RootCause: strings.TrimSpace(rootCause),
+				Location:  location,
+				Trace:     lines[i:end],
+				Line:      i,
+				Remediation: []string{
+					"Add a catch handler or try/await around the promise that rejected",
+					"Since Node.js 15 an unhandled rejection terminates the process",
+				},
+			})
+			i = end - 1
+			continue
+		}
+
+		if m := nodeErrorRegex.FindStringSubmatch(line); m != nil && i+1 < len(lines) && nodeFrameRegex.MatchString(lines[i+1]) {
+			end, location := nodeFrames(lines, i+1)
+			matches = append(matches, Match{
+				Pattern:   p.Name(),
+				Title:     "Node.js uncaught exception",
+				RootCause: line,
+				Location:  location,
+				Trace:     lines[i:end],
+				Line:      i,
+				Remediation: []string{
+					"Fix the error thrown at the first application frame of the stack",
+				},
+			})
+			i = end - 1
+		}
+	}
+	return matches
+}
+
+// nodeFrames consumes the stack frames starting at lines[start], returning the end and
+// the first frame outside node internals and node_modules
+func nodeFrames(lines []string, start int) (int, string) {
+	location := ""
+	end := start
+	for ; end < len(lines); end++ {
+		m := nodeFrameRegex.FindStringSubmatch(lines[end])
+		if m == nil {
+			break
+		}
+		if location == "" && !strings.HasPrefix(m[1], "node:") && !strings.Contains(m[1], "node_modules") {
+			location = m[1]
+		}
+	}
+	return end, location
+}
+
+// trimTrailingBlank drops blank lines at the end of a block
+func trimTrailingBlank(lines []string) []string {
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	return lines[:end]
+}
@@ -0,0 +1,101 @@
+package logpattern
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LinePattern recognizes single-line failures with regular expressions. The first
+// capture group of a matching expression, if any, becomes the match's subject.
+type LinePattern struct {
+	ID          string
+	Title       string
+	Regexes     []*regexp.Regexp
+	Remediation []string
+}
+
+// Name implements the Pattern interface
+func (p *LinePattern) Name() string {
+	return p.ID
+}
+
+// Find implements the Pattern interface
+func (p *LinePattern) Find(lines []string) []Match {
+	matches := make([]Match, 0)
+	for i, line := range lines {
+		for _, regex := range p.Regexes {
+			m := regex.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+
+			match := Match{
+				Pattern:     p.ID,
+				Title:       p.Title,
+				RootCause:   strings.TrimSpace(line),
+				Line:        i,
+				Remediation: p.Remediation,
+			}
+			for _, group := range m[1:] {
+				if group != "" {
+					match.Subject = group
+					break
+				}
+			}
+			matches = append(matches, match)
+			break
+		}
+	}
+	return matches
+}
+
+var (
+	// portInUsePattern recognizes servers failing to bind their listen port
+	portInUsePattern = &LinePattern{
+		ID:    "port-in-use",
+		Title: "Port already in use",
+		Regexes: []*regexp.Regexp{
+			regexp.MustCompile(`listen tcp [^:]*:(\d+): bind: address already in use`),
+			regexp.MustCompile(`EADDRINUSE:? address already in use [^\s]*:(\d+)`),
+			regexp.MustCompile(`(?i)port (\d+) (?:is already in use|was already in use|is already allocated)`),
+			regexp.MustCompile(`(?i)address already in use`),
+		},
+		Remediation: []string{
+			"Make sure only one process in the pod listens on the port; sidecars share the pod's network namespace",
+			"With hostNetwork, check for other processes on the node using the port",
+		},
+	}
+
+	// missingEnvPattern recognizes applications refusing to start without an environment variable
+	missingEnvPattern = &LinePattern{
+		ID:    "missing-env-var",
+		Title: "Missing environment variable",
+		Regexes: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)(?:environment variable|env var(?:iable)?)\s+["'` + "`" + `]?([A-Z][A-Z0-9_]+)["'` + "`" + `]?\s+(?:is\s+)?(?:not set|not defined|missing|required|undefined|empty)`),
+			regexp.MustCompile(`(?i)missing (?:required )?(?:environment variable|env(?:ironment)? var(?:iable)?)s?:?\s+["'` + "`" + `]?([A-Z][A-Z0-9_]+)`),
+			regexp.MustCompile(`required key ([A-Z][A-Z0-9_]+) missing value`),
+			regexp.MustCompile(`KeyError: '([A-Z][A-Z0-9_]+)'`),
+		},
+		Remediation: []string{
+			"Add the variable to the container's env, or to the ConfigMap or Secret loaded with envFrom",
+		},
+	}
+
+	// databaseAuthPattern recognizes database logins rejected by PostgreSQL, MySQL, MongoDB,
+	// SQL Server and Oracle
+	databaseAuthPattern = &LinePattern{
+		ID:    "database-auth-failed",
+		Title: "Database authentication failed",
+		Regexes: []*regexp.Regexp{
+			regexp.MustCompile(`password authentication failed for user "?([^"\s]+)"?`),
+			regexp.MustCompile(`Access denied for user '([^']+)'`),
+			regexp.MustCompile(`Login failed for user '([^']+)'`),
+			regexp.MustCompile(`(?:MongoServerError|MongoError|MongoSecurityException).*Authentication failed`),
+			regexp.MustCompile(`ORA-01017`),
+		},
+		Remediation: []string{
+			"Check that the credentials Secret matches the database user and password",
+			"If the password was rotated, restart the pod so it picks up the new Secret",
+		},
+	}
+)
@@ -0,0 +1,303 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// pdbWorkload is a workload covered by a PodDisruptionBudget
+type pdbWorkload struct {
+	Kind     string
+	Name     string
+	Replicas int
+	Known    bool // Whether the replica count was collected
+}
+
+// PDBAnalyzer finds PodDisruptionBudgets that block evictions and node drains
+type PDBAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *PDBAnalyzer) Name() string {
+	return "PDBAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *PDBAnalyzer) Description() string {
+	return "Analyzes PodDisruptionBudgets that allow no disruptions, select no pods or block node drains"
+}
+
+// Analyze implements the Analyzer interface
+func (a *PDBAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "PodDisruptionBudget" {
+			continue
+		}
+
+		matchedPods, _ := strconv.Atoi(resource.Status["matchedPods"])
+		if matchedPods == 0 {
+			details = append(details, a.noPodsDetail(resource))
+			continue
+		}
+
+		workloads := pdbWorkloads(resource.Status)
+		fullyProtected := a.fullyProtectedWorkloads(resource.Status, workloads)
+		for _, workload := range fullyProtected {
+			details = append(details, a.minAvailableDetail(resource, workload))
+		}
+
+		if resource.Status["disruptionsAllowed"] == "0" {
+			details = append(details, a.noDisruptionsDetail(resource, workloads, fullyProtected))
+		}
+	}
+
+	return details, nil
+}
+
+// pdbWorkloads reads the collected workload.N entries
+func pdbWorkloads(status map[string]string) []pdbWorkload {
+	workloads := make([]pdbWorkload, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("workload.%d.", i)
+		kind, ok := status[prefix+"kind"]
+		if !ok {
+			break
+		}
+		workload := pdbWorkload{Kind: kind, Name: status[prefix+"name"]}
+		if replicas, ok := status[prefix+"replicas"]; ok {
+			workload.Replicas, _ = strconv.Atoi(replicas)
+			workload.Known = true
+		}
+		workloads = append(workloads, workload)
+	}
+	return workloads
+}
+
+// fullyProtectedWorkloads returns the workloads whose replicas can never be disrupted
+// because the budget requires all of them to stay available
+func (a *PDBAnalyzer) fullyProtectedWorkloads(status map[string]string, workloads []pdbWorkload) []pdbWorkload {
+	protected := make([]pdbWorkload, 0)
+	for _, workload := range workloads {
+		if blocksEveryReplica(status, workload) {
+			protected = append(protected, workload)
+		}
+	}
+	return protected
+}
+
+// blocksEveryReplica reports whether the budget's minAvailable or maxUnavailable leaves
+// no replica of the workload to disrupt. Without the replica count, only a budget that
+// allows no disruption at any scale counts.
+func blocksEveryReplica(status map[string]string, workload pdbWorkload) bool {
+	if workload.Known && workload.Replicas == 0 {
+		return false
+	}
+
+	if maxUnavailable, ok := status["maxUnavailable"]; ok {
+		// A single replica tells whether any disruption is allowed at all
+		replicas := 1
+		if workload.Known {
+			replicas = workload.Replicas
+		}
+		allowed, ok := scaleBudget(maxUnavailable, replicas)
+		return ok && allowed <= 0
+	}
+
+	minAvailable, ok := status["minAvailable"]
+	if !ok {
+		return false
+	}
+	if !workload.Known {
+		required, ok := scaleBudget(minAvailable, 100)
+		return ok && strings.HasSuffix(minAvailable, "%") && required >= 100
+	}
+	required, ok := scaleBudget(minAvailable, workload.Replicas)
+	return ok && required >= workload.Replicas
+}
+
+// scaleBudget converts a minAvailable or maxUnavailable value, a number or a percentage,
+// to a number of replicas, rounding up as the disruption controller does
+func scaleBudget(value string, replicas int) (int, bool) {
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		scaled, err := strconv.Atoi(percent)
+		if err != nil || scaled < 0 {
+			return 0, false
+		}
+		return (scaled*replicas + 99) / 100, true
+	}
+	scaled, err := strconv.Atoi(value)
+	return scaled, err == nil
+}
+
+// noPodsDetail builds the finding for a budget whose selector matches nothing
+func (a *PDBAnalyzer) noPodsDetail(resource collector.ResourceData) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+	selector := resource.Status["selector"]
+	if selector == "" {
+		selector = "<none>"
+	}
+
+	return AnalysisDetail{
+		ID:         "PDB_SELECTS_NO_PODS",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceCertain,
+		Title:      "PodDisruptionBudget selects no pods",
+		Description: fmt.Sprintf("PodDisruptionBudget %s with selector %s matches no pods in namespace %s, so it protects nothing",
+			resource.Resource.Name, selector, namespace),
+		Evidence: statusEvidence(resource.Status, "selector", "matchedPods"),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Fix the selector to match the labels of the workload's pod template",
+			"Delete the budget if the workload it protected was removed",
+		},
+		RemediationCommands: []string{
+			"kubectl get pods -n " + namespace + " --show-labels",
+			"kubectl get pdb " + resource.Resource.Name + " -n " + namespace + " -o yaml",
+		},
+	}
+}
+
+// minAvailableDetail builds the finding for a budget that requires every replica to stay up
+func (a *PDBAnalyzer) minAvailableDetail(resource collector.ResourceData, workload pdbWorkload) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+
+	requirement := "minAvailable " + resource.Status["minAvailable"]
+	if maxUnavailable, ok := resource.Status["maxUnavailable"]; ok {
+		requirement = "maxUnavailable " + maxUnavailable
+	}
+
+	target := workload.Kind + " " + workload.Name
+	if workload.Known {
+		target += fmt.Sprintf(" with %d replicas", workload.Replicas)
+	}
+
+	detail := AnalysisDetail{
+		ID:         "PDB_BLOCKS_ALL_DISRUPTIONS",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceCertain,
+		Title:      "PodDisruptionBudget allows no voluntary disruption",
+		Description: fmt.Sprintf("PodDisruptionBudget %s sets %s for %s, so no pod can ever be evicted and node drains will hang",
+			resource.Resource.Name, requirement, target),
+		Evidence: statusEvidence(resource.Status, "minAvailable", "maxUnavailable"),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Set minAvailable below the replica count or use maxUnavailable: 1",
+			"Increase the replica count so one pod can be disrupted",
+		},
+	}
+
+	if workload.Known && workload.Replicas > 1 {
+		detail.RemediationCommands = append(detail.RemediationCommands, fmt.Sprintf(
+			`kubectl patch pdb %s -n %s --type=merge -p '{"spec":{"minAvailable":null,"maxUnavailable":1}}'`,
+			resource.Resource.Name, namespace))
+	}
+	if workload.Known && (workload.Kind == "Deployment" || workload.Kind == "StatefulSet") {
+		detail.RemediationCommands = append(detail.RemediationCommands, fmt.Sprintf(
+			"kubectl scale %s %s -n %s --replicas=%d",
+			strings.ToLower(workload.Kind), workload.Name, namespace, workload.Replicas+1))
+	}
+
+	return detail
+}
+
+// noDisruptionsDetail builds the finding for a budget that currently allows no evictions,
+// escalating it when covered pods sit on cordoned nodes that are being drained
+func (a *PDBAnalyzer) noDisruptionsDetail(resource collector.ResourceData, workloads, fullyProtected []pdbWorkload) AnalysisDetail {
+	namespace := resource.Resource.Namespace
+
+	cordonedPods := make(map[string][]string)
+	unreadyPods := make([]string, 0)
+	podEvidence := make([]Evidence, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("pod.%d.", i)
+		name, ok := resource.Status[prefix+"name"]
+		if !ok {
+			break
+		}
+		if resource.Status[prefix+"nodeUnschedulable"] == "true" {
+			node := resource.Status[prefix+"node"]
+			cordonedPods[node] = append(cordonedPods[node], name)
+			podEvidence = append(podEvidence, statusEvidence(resource.Status, prefix+"name", prefix+"node", prefix+"nodeUnschedulable")...)
+		}
+		if resource.Status[prefix+"ready"] == "false" {
+			unreadyPods = append(unreadyPods, name)
+			podEvidence = append(podEvidence, statusEvidence(resource.Status, prefix+"name", prefix+"ready")...)
+		}
+	}
+
+	covered := make([]string, 0, len(workloads))
+	for _, workload := range workloads {
+		covered = append(covered, workload.Kind+"/"+workload.Name)
+	}
+
+	description := fmt.Sprintf("PodDisruptionBudget %s currently allows 0 disruptions (%s of %s desired pods healthy)",
+		resource.Resource.Name, resource.Status["currentHealthy"], resource.Status["desiredHealthy"])
+	if len(covered) > 0 {
+		description += " and covers " + strings.Join(covered, ", ")
+	}
+	description += "."
+
+	detail := AnalysisDetail{
+		ID:         "PDB_NO_DISRUPTIONS_ALLOWED",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceCertain,
+		Title:      "PodDisruptionBudget allows no disruptions",
+		Evidence: statusEvidence(resource.Status, "disruptionsAllowed", "currentHealthy", "desiredHealthy",
+			"minAvailable", "maxUnavailable", "unhealthyPodEvictionPolicy"),
+		Resource: resource.Resource,
+		RemediationCommands: []string{
+			"kubectl get pdb " + resource.Resource.Name + " -n " + namespace,
+		},
+	}
+
+	if len(cordonedPods) > 0 {
+		nodes := make([]string, 0, len(cordonedPods))
+		for node := range cordonedPods {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+
+		blocked := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			blocked = append(blocked, fmt.Sprintf("%s (pods %s)", node, strings.Join(cordonedPods[node], ", ")))
+		}
+
+		detail.ID = "PDB_BLOCKS_NODE_DRAIN"
+		detail.Severity = SeverityError
+		detail.Title = "Node drain blocked by PodDisruptionBudget"
+		description += " Evictions from cordoned nodes are refused: " + strings.Join(blocked, "; ") + "."
+		detail.RemediationCommands = append(detail.RemediationCommands,
+			"kubectl get pods -n "+namespace+" -o wide --field-selector spec.nodeName="+nodes[0])
+	}
+
+	switch {
+	case len(fullyProtected) > 0:
+		description += " The budget requires every replica to stay available."
+		detail.Remediation = append(detail.Remediation,
+			"Relax the budget so at least one replica may be disrupted",
+			"Temporarily scale the workload up so the drain can evict one pod")
+	case len(unreadyPods) > 0:
+		description += " Unhealthy pods consume the budget: " + strings.Join(unreadyPods, ", ") + "."
+		detail.Remediation = append(detail.Remediation,
+			"Fix the unhealthy pods so the budget regains headroom",
+			"Set unhealthyPodEvictionPolicy: AlwaysAllow so unhealthy pods do not block evictions")
+		if resource.Status["unhealthyPodEvictionPolicy"] != "AlwaysAllow" {
+			detail.RemediationCommands = append(detail.RemediationCommands, fmt.Sprintf(
+				`kubectl patch pdb %s -n %s --type=merge -p '{"spec":{"unhealthyPodEvictionPolicy":"AlwaysAllow"}}'`,
+				resource.Resource.Name, namespace))
+		}
+	default:
+		detail.Remediation = append(detail.Remediation,
+			"Wait for the covered workload to become fully available before draining",
+			"Increase the replica count to create disruption headroom")
+	}
+
+	detail.Description = description
+	detail.Evidence = append(detail.Evidence, podEvidence...)
+	return detail
+}
@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestPDBAnalyzer_DrainBlocked(t *testing.T) {
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "PodDisruptionBudget",
+			Name:      "web",
+			Namespace: "shop",
+		},
+		Status: map[string]string{
+			"minAvailable":            "2",
+			"selector":                "app=web",
+			"currentHealthy":          "2",
+			"desiredHealthy":          "2",
+			"expectedPods":            "2",
+			"disruptionsAllowed":      "0",
+			"matchedPods":             "2",
+			"pod.0.name":              "web-7d9f-abcde",
+			"pod.0.node":              "worker-1",
+			"pod.0.ready":             "true",
+			"pod.0.nodeUnschedulable": "true",
+			"pod.1.name":              "web-7d9f-fghij",
+			"pod.1.node":              "worker-2",
+			"pod.1.ready":             "true",
+			"pod.1.nodeUnschedulable": "false",
+			"workload.0.kind":         "Deployment",
+			"workload.0.name":         "web",
+			"workload.0.replicas":     "2",
+		},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := (&PDBAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	titles := make([]string, 0, len(details))
+	var drain *AnalysisDetail
+	for i, detail := range details {
+		titles = append(titles, detail.Title)
+		if detail.Title == "Node drain blocked by PodDisruptionBudget" {
+			drain = &details[i]
+		}
+	}
+
+	if drain == nil {
+		t.Fatalf("Expected a drain blocked finding, got %v", titles)
+	}
+	if drain.Severity != SeverityError {
+		t.Errorf("Expected detail severity to be error, got %s", drain.Severity)
+	}
+	if !strings.Contains(drain.Description, "worker-1 (pods web-7d9f-abcde)") {
+		t.Errorf("Expected the cordoned node and pod in the description, got '%s'", drain.Description)
+	}
+
+	if len(details) != 2 {
+		t.Errorf("Expected a minAvailable finding alongside the drain finding, got %v", titles)
+	}
+}
+
+func TestPDBAnalyzer_SelectorMatchesNothing(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "PodDisruptionBudget", Name: "old-api", Namespace: "shop"},
+			Status: map[string]string{
+				"maxUnavailable":     "1",
+				"selector":           "app=old-api",
+				"disruptionsAllowed": "0",
+				"matchedPods":        "0",
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&PDBAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 || details[0].Title != "PodDisruptionBudget selects no pods" {
+		t.Fatalf("Expected a single 'selects no pods' finding, got %+v", details)
+	}
+}
+
+func TestPDBAnalyzer_FullyProtected(t *testing.T) {
+	tests := []struct {
+		name     string
+		budget   map[string]string
+		replicas string
+		want     bool
+	}{
+		{name: "minAvailable equals replicas", budget: map[string]string{"minAvailable": "3"}, replicas: "3", want: true},
+		{name: "minAvailable above replicas", budget: map[string]string{"minAvailable": "5"}, replicas: "3", want: true},
+		{name: "minAvailable below replicas", budget: map[string]string{"minAvailable": "2"}, replicas: "3", want: false},
+		{name: "percentage rounding up to every replica", budget: map[string]string{"minAvailable": "90%"}, replicas: "3", want: true},
+		{name: "percentage leaving a replica", budget: map[string]string{"minAvailable": "50%"}, replicas: "3", want: false},
+		{name: "full percentage without replicas", budget: map[string]string{"minAvailable": "100%"}, want: true},
+		{name: "number without replicas", budget: map[string]string{"minAvailable": "3"}, want: false},
+		{name: "maxUnavailable zero", budget: map[string]string{"maxUnavailable": "0%"}, want: true},
+		{name: "maxUnavailable percentage rounding up to one", budget: map[string]string{"maxUnavailable": "10%"}, replicas: "3", want: false},
+		{name: "scaled to zero", budget: map[string]string{"minAvailable": "1"}, replicas: "0", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := map[string]string{
+				"matchedPods":     "3",
+				"workload.0.kind": "Deployment",
+				"workload.0.name": "web",
+			}
+			if tt.replicas != "" {
+				status["workload.0.replicas"] = tt.replicas
+			}
+			for key, value := range tt.budget {
+				status[key] = value
+			}
+
+			details, err := (&PDBAnalyzer{}).Analyze(context.Background(), &AnalysisContext{
+				Resources: []collector.ResourceData{{
+					Resource: collector.ResourceInfo{Kind: "PodDisruptionBudget", Name: "web", Namespace: "shop"},
+					Status:   status,
+				}},
+			})
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			if got := len(details) == 1; got != tt.want {
+				t.Errorf("Expected a finding: %v, got %+v", tt.want, details)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+const (
+	// PluginPrefix starts the file name of every analyzer plugin executable
+	PluginPrefix = "k8smed-analyzer-"
+
+	// PluginProtocolVersion is the plugin protocol version this build speaks
+	PluginProtocolVersion = 1
+
+	// defaultPluginTimeout bounds a plugin's analysis unless its handshake asks for another limit
+	defaultPluginTimeout = 30 * time.Second
+
+	// defaultHandshakeTimeout bounds the handshake of each plugin
+	defaultHandshakeTimeout = 5 * time.Second
+
+	// maxPluginOutput caps how much a plugin may write to stdout or stderr
+	maxPluginOutput = 16 << 20
+)
+
+// PluginOptions configures plugin discovery
+type PluginOptions struct {
+	// Dirs are searched for plugins before PATH
+	Dirs []string
+
+	// SkipPath disables searching PATH
+	SkipPath bool
+
+	// Timeout bounds each plugin's analysis; zero uses 30 seconds
+	Timeout time.Duration
+
+	// HandshakeTimeout bounds each plugin's handshake; zero uses 5 seconds
+	HandshakeTimeout time.Duration
+}
+
+// PluginHandshake is what a plugin prints when run with --handshake
+type PluginHandshake struct {
+	ProtocolVersion int    `json:"protocolVersion"`
+	Name            string `json:"name,omitempty"`
+	Description     string `json:"description,omitempty"`
+
+	// TimeoutSeconds overrides the analysis timeout for this plugin
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+// PluginRequest is what a plugin reads on stdin when run with --analyze.
+// The plugin answers with a JSON array of AnalysisDetail on stdout.
+type PluginRequest struct {
+	ProtocolVersion int                      `json:"protocolVersion"`
+	Query           string                   `json:"query"`
+	Resources       []collector.ResourceData `json:"resources"`
+}
+
+// PluginAnalyzer runs an external executable as an Analyzer
+type PluginAnalyzer struct {
+	name        string
+	description string
+	path        string
+	timeout     time.Duration
+}
+
+// Name implements the Analyzer interface
+func (p *PluginAnalyzer) Name() string {
+	return p.name
+}
+
+// Description implements the Analyzer interface
+func (p *PluginAnalyzer) Description() string {
+	if p.description != "" {
+		return p.description
+	}
+	return "External analyzer plugin " + p.path
+}
+
+// Path returns the plugin's executable
+func (p *PluginAnalyzer) Path() string {
+	return p.path
+}
+
+// Analyze implements the Analyzer interface. A plugin that fails, times out or answers
+// with invalid output returns no details and an error; it cannot affect other analyzers.
+func (p *PluginAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	request, err := json.Marshal(PluginRequest{
+		ProtocolVersion: PluginProtocolVersion,
+		Query:           analysisCtx.Query,
+		Resources:       analysisCtx.Resources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: failed to encode request: %w", p.name, err)
+	}
+
+	output, err := runPlugin(ctx, p.path, "--analyze", request, p.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", p.name, err)
+	}
+
+	var details []AnalysisDetail
+	if err := json.Unmarshal(output, &details); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid output: %w", p.name, err)
+	}
+	for i := range details {
+		if err := validatePluginDetail(details[i]); err != nil {
+			return nil, fmt.Errorf("plugin %s: detail %d: %w", p.name, i, err)
+		}
+		details[i].Analyzer = p.name
+	}
+
+	return details, nil
+}
+
+// validatePluginDetail rejects details the rest of the pipeline cannot handle.
+// Unknown severities are already rejected when decoding.
+func validatePluginDetail(detail AnalysisDetail) error {
+	if detail.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if detail.Title == "" {
+		return fmt.Errorf("missing title")
+	}
+	if detail.Confidence < 0 || detail.Confidence > 1 {
+		return fmt.Errorf("confidence %v is outside [0, 1]", detail.Confidence)
+	}
+	return nil
+}
+
+// DiscoverPlugins finds executables named k8smed-analyzer-* in the plugin dirs and on
+// PATH, and returns an analyzer for each one that completes the handshake. When several
+// executables share a name the first one found wins. Plugins that fail the handshake are
+// reported in the returned errors and skipped.
+func DiscoverPlugins(ctx context.Context, options PluginOptions) ([]*PluginAnalyzer, []error) {
+	dirs := append([]string{}, options.Dirs...)
+	if !options.SkipPath {
+		dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+	}
+
+	handshakeTimeout := options.HandshakeTimeout
+	if handshakeTimeout == 0 {
+		handshakeTimeout = defaultHandshakeTimeout
+	}
+	timeout := options.Timeout
+	if timeout == 0 {
+		timeout = defaultPluginTimeout
+	}
+
+	plugins := make([]*PluginAnalyzer, 0)
+	errs := make([]error, 0)
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), PluginPrefix) || seen[entry.Name()] {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			seen[entry.Name()] = true
+
+			plugin, err := handshake(ctx, path, handshakeTimeout, timeout)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins, errs
+}
+
+// RegisterPlugins discovers plugins and adds them to the registry. A plugin may not
+// replace a registered analyzer.
+func (r *Registry) RegisterPlugins(ctx context.Context, options PluginOptions) []error {
+	plugins, errs := DiscoverPlugins(ctx, options)
+	for _, plugin := range plugins {
+		if r.Get(plugin.Name()) != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: conflicts with a registered analyzer", plugin.path))
+			continue
+		}
+		r.Register(plugin)
+	}
+	return errs
+}
+
+// handshake asks a plugin which protocol it speaks and how it wants to be called
+func handshake(ctx context.Context, path string, handshakeTimeout, timeout time.Duration) (*PluginAnalyzer, error) {
+	output, err := runPlugin(ctx, path, "--handshake", nil, handshakeTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: handshake failed: %w", path, err)
+	}
+
+	var hello PluginHandshake
+	if err := json.Unmarshal(output, &hello); err != nil {
+		return nil, fmt.Errorf("plugin %s: invalid handshake: %w", path, err)
+	}
+	if hello.ProtocolVersion != PluginProtocolVersion {
+		return nil, fmt.Errorf("plugin %s: unsupported protocol version %d, want %d",
+			path, hello.ProtocolVersion, PluginProtocolVersion)
+	}
+
+	name := hello.Name
+	if name == "" {
+		name = strings.TrimPrefix(filepath.Base(path), PluginPrefix)
+	}
+	if hello.TimeoutSeconds > 0 {
+		timeout = time.Duration(hello.TimeoutSeconds) * time.Second
+	}
+
+	return &PluginAnalyzer{
+		name:        name,
+		description: hello.Description,
+		path:        path,
+		timeout:     timeout,
+	}, nil
+}
+
+// runPlugin runs a plugin with a single argument, feeding it stdin and returning stdout
+func runPlugin(ctx context.Context, path, arg string, stdin []byte, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, path, arg)
+	cmd.Stdin = bytes.NewReader(stdin)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(), fmt.Sprintf("K8SMED_PLUGIN_PROTOCOL_VERSION=%d", PluginProtocolVersion))
+	// Don't wait for children of the plugin that keep its output open after it is killed
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	if stdout.overflow || stderr.overflow {
+		return nil, fmt.Errorf("output exceeds %d bytes", maxPluginOutput)
+	}
+	return stdout.Bytes(), nil
+}
+
+// limitedBuffer keeps at most maxPluginOutput bytes, discarding the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	overflow bool
+}
+
+// Write implements io.Writer
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	room := maxPluginOutput - b.Len()
+	if len(p) > room {
+		b.overflow = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// isExecutable reports whether path is a regular file the current user may execute
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return info.Mode()&0o111 != 0
+}
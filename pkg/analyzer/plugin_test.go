@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// writePlugin writes a shell script plugin that answers the handshake and analyze calls
+func writePlugin(t *testing.T, dir, name, handshake, analyze string) {
+	t.Helper()
+	script := "#!/bin/sh\n" +
+		"case \"$1\" in\n" +
+		"--handshake)\n" + handshake + "\n;;\n" +
+		"--analyze)\n" + analyze + "\n;;\n" +
+		"esac\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write plugin: %v", err)
+	}
+}
+
+func TestPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins need a POSIX shell")
+	}
+
+	dir := t.TempDir()
+	writePlugin(t, dir, PluginPrefix+"quota",
+		`echo '{"protocolVersion": 1, "name": "QuotaAnalyzer", "description": "Checks quotas"}'`,
+		// Echo the namespace of the first resource back to prove the request arrived on stdin
+		`ns=$(sed -n 's/.*"namespace":"\([^"]*\)".*/\1/p')
+echo "[{\"id\": \"QUOTA_NEARLY_EXHAUSTED\", \"severity\": \"warning\", \"confidence\": 0.9, \"title\": \"Quota nearly exhausted\", \"description\": \"namespace $ns\"}]"`)
+	writePlugin(t, dir, PluginPrefix+"future",
+		`echo '{"protocolVersion": 2}'`,
+		`echo '[]'`)
+	writePlugin(t, dir, PluginPrefix+"broken",
+		`echo '{"protocolVersion": 1}'`,
+		`echo 'something went wrong' >&2; exit 3`)
+	writePlugin(t, dir, PluginPrefix+"garbage",
+		`echo '{"protocolVersion": 1}'`,
+		`echo 'not json'`)
+	writePlugin(t, dir, PluginPrefix+"slow",
+		`echo '{"protocolVersion": 1, "timeoutSeconds": 1}'`,
+		`sleep 10`)
+	if err := os.WriteFile(filepath.Join(dir, PluginPrefix+"notes.txt"), []byte("not a plugin"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := &Registry{analyzers: make(map[string]Analyzer)}
+	errs := registry.RegisterPlugins(context.Background(), PluginOptions{Dirs: []string{dir}, SkipPath: true})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "unsupported protocol version 2") {
+		t.Fatalf("Expected only the version 2 plugin to be rejected, got %v", errs)
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{Resource: collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "shop"}},
+		},
+	}
+
+	quota := registry.Get("QuotaAnalyzer")
+	if quota == nil {
+		t.Fatal("Expected the plugin to register under its handshake name")
+	}
+	if quota.Description() != "Checks quotas" {
+		t.Errorf("Unexpected description %q", quota.Description())
+	}
+	details, err := quota.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Plugin returned error: %v", err)
+	}
+	if len(details) != 1 || details[0].Description != "namespace shop" {
+		t.Fatalf("Unexpected details: %+v", details)
+	}
+	if detail := details[0]; detail.Severity != SeverityWarning || detail.Analyzer != "QuotaAnalyzer" {
+		t.Errorf("Expected a warning attributed to the plugin, got %+v", detail)
+	}
+
+	failures := map[string]string{
+		"broken":  "something went wrong",
+		"garbage": "invalid output",
+		"slow":    "timed out after 1s",
+	}
+	for name, want := range failures {
+		plugin := registry.Get(name)
+		if plugin == nil {
+			t.Fatalf("Expected plugin %s to be named after its executable", name)
+		}
+
+		start := time.Now()
+		details, err := plugin.Analyze(context.Background(), analysisCtx)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Plugin %s: expected error containing %q, got %v", name, want, err)
+		}
+		if len(details) != 0 {
+			t.Errorf("Plugin %s: expected a failing plugin to return no details, got %d", name, len(details))
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Errorf("Plugin %s was not stopped at its timeout", name)
+		}
+	}
+}
+
+func TestDiscoverPluginsFirstWins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugins need a POSIX shell")
+	}
+
+	first, second := t.TempDir(), t.TempDir()
+	writePlugin(t, first, PluginPrefix+"dup", `echo '{"protocolVersion": 1, "description": "first"}'`, `echo '[]'`)
+	writePlugin(t, second, PluginPrefix+"dup", `echo '{"protocolVersion": 1, "description": "second"}'`, `echo '[]'`)
+
+	plugins, errs := DiscoverPlugins(context.Background(), PluginOptions{Dirs: []string{first, second}, SkipPath: true})
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+	if len(plugins) != 1 || plugins[0].Description() != "first" {
+		t.Fatalf("Expected only the first plugin, got %+v", plugins)
+	}
+}
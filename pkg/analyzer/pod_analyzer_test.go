@@ -2,6 +2,8 @@ package analyzer
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/k8smed/k8smed/pkg/collector"
@@ -37,21 +39,21 @@ func TestPodAnalyzer_CrashLoopBackOff(t *testing.T) {
 	}
 
 	// Run the analyzer
-	err := analyzer.Analyze(context.Background(), analysisCtx)
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
 	if err != nil {
 		t.Fatalf("Analyze() error = %v", err)
 	}
 
 	// Verify the results
-	if len(analysisCtx.Details) == 0 {
+	if len(details) == 0 {
 		t.Fatal("Expected at least one analysis detail")
 	}
 
 	// Find the CrashLoopBackOff detail
 	var crashLoopDetail *AnalysisDetail
-	for i, detail := range analysisCtx.Details {
+	for i, detail := range details {
 		if detail.Title == "Container in CrashLoopBackOff" {
-			crashLoopDetail = &analysisCtx.Details[i]
+			crashLoopDetail = &details[i]
 			break
 		}
 	}
@@ -61,8 +63,8 @@ func TestPodAnalyzer_CrashLoopBackOff(t *testing.T) {
 	}
 
 	// Verify the detail properties
-	if crashLoopDetail.Type != "error" {
-		t.Errorf("Expected detail type to be 'error', got '%s'", crashLoopDetail.Type)
+	if crashLoopDetail.Severity != SeverityError {
+		t.Errorf("Expected detail severity to be error, got %s", crashLoopDetail.Severity)
 	}
 
 	if crashLoopDetail.Resource.Name != "test-pod" {
@@ -104,21 +106,21 @@ func TestPodAnalyzer_ImagePullBackOff(t *testing.T) {
 	}
 
 	// Run the analyzer
-	err := analyzer.Analyze(context.Background(), analysisCtx)
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
 	if err != nil {
 		t.Fatalf("Analyze() error = %v", err)
 	}
 
 	// Verify the results
-	if len(analysisCtx.Details) == 0 {
+	if len(details) == 0 {
 		t.Fatal("Expected at least one analysis detail")
 	}
 
 	// Find the ImagePullBackOff detail
 	var imagePullDetail *AnalysisDetail
-	for i, detail := range analysisCtx.Details {
+	for i, detail := range details {
 		if detail.Title == "Image pull failure" {
-			imagePullDetail = &analysisCtx.Details[i]
+			imagePullDetail = &details[i]
 			break
 		}
 	}
@@ -128,8 +130,8 @@ func TestPodAnalyzer_ImagePullBackOff(t *testing.T) {
 	}
 
 	// Verify the detail properties
-	if imagePullDetail.Type != "error" {
-		t.Errorf("Expected detail type to be 'error', got '%s'", imagePullDetail.Type)
+	if imagePullDetail.Severity != SeverityError {
+		t.Errorf("Expected detail severity to be error, got %s", imagePullDetail.Severity)
 	}
 
 	// Verify remediation steps exist
@@ -137,3 +139,119 @@ func TestPodAnalyzer_ImagePullBackOff(t *testing.T) {
 		t.Error("Expected multiple remediation steps to be provided")
 	}
 }
+
+func TestPodAnalyzer_LogStackTrace(t *testing.T) {
+	analyzer := &PodAnalyzer{}
+
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Pod",
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: map[string]string{
+			"phase": "Running",
+		},
+		Logs: []string{
+			"=== Logs for container: app ===\n" +
+				"Exception in thread \"main\" java.lang.RuntimeException: startup failed\n" +
+				"\tat com.example.App.main(App.java:10)\n" +
+				"Caused by: java.net.ConnectException: Connection refused\n" +
+				"\tat com.example.Client.connect(Client.java:22)\n" +
+				"\t... 1 more",
+		},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyzer returned error: %v", err)
+	}
+
+	var found *AnalysisDetail
+	for i, detail := range details {
+		if detail.Title == "Errors detected in logs" {
+			t.Errorf("Expected the generic log finding to be replaced by the stack trace finding")
+		}
+		if detail.Title == "Java exception" {
+			found = &details[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("Expected a Java exception finding, got %+v", details)
+	}
+	if !strings.Contains(found.Description, "Container app: java.net.ConnectException: Connection refused (at com.example.Client.connect(Client.java:22))") {
+		t.Errorf("Expected the root cause and location in the description, got %q", found.Description)
+	}
+	if !strings.Contains(found.Description, "\tat com.example.App.main(App.java:10)") {
+		t.Errorf("Expected the stack trace in the description, got %q", found.Description)
+	}
+}
+
+func TestPodAnalyzer_UnrecognizedLogErrors(t *testing.T) {
+	analyzer := &PodAnalyzer{}
+
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Pod",
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: map[string]string{
+			"phase": "Running",
+		},
+		Logs: []string{"=== Logs for container: app ===\nlevel=error msg=\"request failed\""},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyzer returned error: %v", err)
+	}
+
+	found := false
+	for _, detail := range details {
+		if detail.Title == "Errors detected in logs" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected the generic log finding when no pattern matches")
+	}
+}
+
+func TestPodAnalyzer_ConcurrentRuns(t *testing.T) {
+	analyzer := &PodAnalyzer{}
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "default"},
+			Status:   map[string]string{"phase": "Running"},
+			Logs:     []string{"panic: runtime error: invalid memory address or nil pointer dereference"},
+		}},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := analyzer.Analyze(context.Background(), analysisCtx); err != nil {
+				t.Errorf("Analyze() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The default library is used without being stored on the shared analyzer
+	if analyzer.Patterns != nil {
+		t.Error("Expected Analyze not to set Patterns")
+	}
+}
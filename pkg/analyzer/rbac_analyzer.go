@@ -0,0 +1,426 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// forbiddenRegex matches the API server's RBAC denial message for a service account
+var forbiddenRegex = regexp.MustCompile(`forbidden: User "system:serviceaccount:([^:"]+):([^"]+)" cannot ([a-z]+) resource "([^"]+)" in API group "([^"]*)"(?: in the namespace "([^"]+)"| at the cluster scope)`)
+
+// ForbiddenRequest is a permission denial extracted from a log line or event
+type ForbiddenRequest struct {
+	ServiceAccountNamespace string
+	ServiceAccount          string
+	Verb                    string
+	Resource                string
+	APIGroup                string
+	// Namespace is empty for cluster scoped requests
+	Namespace string
+}
+
+// ParseForbidden extracts every service account permission denial from the text
+func ParseForbidden(text string) []ForbiddenRequest {
+	requests := make([]ForbiddenRequest, 0)
+	for _, match := range forbiddenRegex.FindAllStringSubmatch(text, -1) {
+		requests = append(requests, ForbiddenRequest{
+			ServiceAccountNamespace: match[1],
+			ServiceAccount:          match[2],
+			Verb:                    match[3],
+			Resource:                match[4],
+			APIGroup:                match[5],
+			Namespace:               match[6],
+		})
+	}
+	return requests
+}
+
+// rbacRule is a policy rule collected for the pod's service account
+type rbacRule struct {
+	Namespace     string
+	Binding       string
+	Role          string
+	APIGroups     []string
+	Resources     []string
+	Verbs         []string
+	ResourceNames []string
+}
+
+// RBACAnalyzer explains service account permission denials found in logs and events
+type RBACAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *RBACAnalyzer) Name() string {
+	return "RBACAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *RBACAnalyzer) Description() string {
+	return "Analyzes RBAC permission denials of service accounts and suggests the missing Role"
+}
+
+// Analyze implements the Analyzer interface
+func (a *RBACAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Pod" {
+			continue
+		}
+
+		text := strings.Join(resource.Events, "\n") + "\n" + strings.Join(resource.Logs, "\n")
+		seen := make(map[ForbiddenRequest]bool)
+		rules, rulesCollected := rbacRules(resource.Status)
+		unresolved := rbacUnresolved(resource.Status)
+
+		for _, request := range ParseForbidden(text) {
+			if seen[request] {
+				continue
+			}
+			seen[request] = true
+
+			// Rules are only collected for the pod's own service account
+			serviceAccount := request.ServiceAccountNamespace + ":" + request.ServiceAccount
+			known := rulesCollected && resource.Status["rbac.serviceAccount"] == serviceAccount
+
+			details = append(details, a.forbiddenDetail(resource, request, rules, known, unresolved))
+		}
+	}
+
+	return details, nil
+}
+
+// forbiddenDetail builds the finding for a single permission denial. Unresolved lists
+// the bindings whose rules could not be collected; while there are any, a rule missing
+// from the collected ones may still be granted by them.
+func (a *RBACAnalyzer) forbiddenDetail(resource collector.ResourceData, request ForbiddenRequest, rules []rbacRule, known bool, unresolved []string) AnalysisDetail {
+	scope := "at the cluster scope"
+	namespaceFlag := ""
+	if request.Namespace != "" {
+		scope = "in namespace " + request.Namespace
+		namespaceFlag = " -n " + request.Namespace
+	}
+
+	group := request.APIGroup
+	if group == "" {
+		group = "core"
+	}
+
+	description := fmt.Sprintf("ServiceAccount %s/%s cannot %s %s (API group %s) %s.",
+		request.ServiceAccountNamespace, request.ServiceAccount, request.Verb, request.Resource, group, scope)
+
+	if known {
+		applicable := applicableRules(rules, request.Namespace)
+		if granting := grantingRule(applicable, request); granting != nil {
+			// The denial may predate a permission change
+			return AnalysisDetail{
+				ID:         "RBAC_DENIED_PREVIOUSLY",
+				Severity:   SeverityInfo,
+				Confidence: ConfidenceMedium,
+				Title:      "RBAC permission denied earlier",
+				Description: description + fmt.Sprintf(" %s (via %s) now grants this request, so the denial may predate a permission change.",
+					granting.Role, granting.Binding),
+				Evidence: forbiddenEvidence(resource, request),
+				Resource: resource.Resource,
+				Remediation: []string{
+					"Restart the pod if it cached the failure and verify the error no longer appears",
+				},
+				RemediationCommands: []string{
+					fmt.Sprintf("kubectl auth can-i %s %s --as=system:serviceaccount:%s:%s%s",
+						request.Verb, qualifiedResource(request), request.ServiceAccountNamespace, request.ServiceAccount, namespaceFlag),
+				},
+			}
+		}
+
+		if len(unresolved) > 0 {
+			return a.unverifiedDetail(resource, request, description, unresolved, namespaceFlag)
+		}
+
+		if len(applicable) == 0 {
+			description += " No Role or ClusterRole bound to it applies there."
+		} else {
+			description += fmt.Sprintf(" None of the %d rules bound to it allow this request.", len(applicable))
+			if partial := partialMatches(applicable, request); len(partial) > 0 {
+				description += " Closest matches: " + strings.Join(partial, "; ") + "."
+			}
+		}
+	} else {
+		return a.uncollectedDetail(resource, request, description, namespaceFlag)
+	}
+
+	missingRule := fmt.Sprintf("Missing rule: apiGroups [%q], resources [%q], verbs [%q]", request.APIGroup, request.Resource, request.Verb)
+
+	return AnalysisDetail{
+		ID:          "RBAC_PERMISSION_MISSING",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceCertain,
+		Title:       "Missing RBAC permission",
+		Description: description,
+		Evidence:    forbiddenEvidence(resource, request),
+		Resource:    resource.Resource,
+		Remediation: []string{
+			missingRule,
+			"Grant the permission with a Role and RoleBinding (or ClusterRole and ClusterRoleBinding for cluster scoped access)",
+			"Verify the application really needs this access before granting it",
+		},
+		RemediationCommands: []string{
+			fmt.Sprintf("kubectl auth can-i %s %s --as=system:serviceaccount:%s:%s%s",
+				request.Verb, qualifiedResource(request), request.ServiceAccountNamespace, request.ServiceAccount, namespaceFlag),
+			roleSnippet(request),
+		},
+	}
+}
+
+// unverifiedDetail builds the finding for a denial that none of the collected rules
+// explain, while some bindings of the service account could not be resolved
+func (a *RBACAnalyzer) unverifiedDetail(resource collector.ResourceData, request ForbiddenRequest, description string, unresolved []string, namespaceFlag string) AnalysisDetail {
+	description += fmt.Sprintf(" None of the collected rules allow this request, but %d binding(s) could not be resolved, so they may grant it: %s.",
+		len(unresolved), strings.Join(unresolved, "; "))
+
+	evidence := forbiddenEvidence(resource, request)
+	evidence = append(evidence, prefixEvidence(resource.Status, "rbac.binding.")...)
+	evidence = append(evidence, statusEvidence(resource.Status, "rbac.error")...)
+
+	return AnalysisDetail{
+		ID:          "RBAC_PERMISSION_MISSING",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceLow,
+		Title:       "RBAC permission denied",
+		Description: description,
+		Evidence:    evidence,
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Check the bindings that could not be resolved; a binding to a deleted Role grants nothing",
+			"Check that k8smed may read the Roles and ClusterRoles the bindings point at",
+		},
+		RemediationCommands: []string{
+			fmt.Sprintf("kubectl auth can-i %s %s --as=system:serviceaccount:%s:%s%s",
+				request.Verb, qualifiedResource(request), request.ServiceAccountNamespace, request.ServiceAccount, namespaceFlag),
+		},
+	}
+}
+
+// uncollectedDetail builds the finding for a denial of a service account whose rules were
+// not collected, so the denial is known but not which rule is missing
+func (a *RBACAnalyzer) uncollectedDetail(resource collector.ResourceData, request ForbiddenRequest, description string, namespaceFlag string) AnalysisDetail {
+	serviceAccount := "system:serviceaccount:" + request.ServiceAccountNamespace + ":" + request.ServiceAccount
+	return AnalysisDetail{
+		ID:          "RBAC_PERMISSION_MISSING",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceMedium,
+		Title:       "RBAC permission denied",
+		Description: description + " The rules bound to this service account were not collected, so the missing rule cannot be named.",
+		Evidence:    forbiddenEvidence(resource, request),
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"List the Roles and ClusterRoles bound to the service account and check whether one should allow this request",
+			"Verify the application really needs this access before granting it",
+		},
+		RemediationCommands: []string{
+			fmt.Sprintf("kubectl auth can-i %s %s --as=%s%s",
+				request.Verb, qualifiedResource(request), serviceAccount, namespaceFlag),
+			fmt.Sprintf("kubectl auth can-i --list --as=%s%s", serviceAccount, namespaceFlag),
+		},
+	}
+}
+
+// rbacUnresolved describes the bindings whose rules were not collected, and the error
+// that stopped the collection if any. Rules collected before rbac.complete was
+// recorded are never complete.
+func rbacUnresolved(status map[string]string) []string {
+	if _, ok := status["rbac.serviceAccount"]; !ok || status["rbac.complete"] == "true" {
+		return nil
+	}
+
+	unresolved := make([]string, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("rbac.binding.%d.", i)
+		name, ok := status[prefix+"name"]
+		if !ok {
+			break
+		}
+		unresolved = append(unresolved, name+": "+status[prefix+"error"])
+	}
+	if err := status["rbac.error"]; err != "" {
+		unresolved = append(unresolved, err)
+	}
+	if len(unresolved) == 0 {
+		unresolved = append(unresolved, "the collection did not complete")
+	}
+	return unresolved
+}
+
+// forbiddenEvidence returns the event or log line that reported the denial
+func forbiddenEvidence(resource collector.ResourceData, request ForbiddenRequest) []Evidence {
+	reports := func(line string) bool {
+		for _, found := range ParseForbidden(line) {
+			if found == request {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, event := range resource.Events {
+		if reports(event) {
+			return eventEvidence(event)
+		}
+	}
+	for _, logs := range resource.Logs {
+		for _, line := range strings.Split(logs, "\n") {
+			if reports(line) {
+				return []Evidence{logEvidence("", strings.TrimSpace(line))}
+			}
+		}
+	}
+	return nil
+}
+
+// rbacRules reads the collected rbac.rule.N entries from the status map
+func rbacRules(status map[string]string) ([]rbacRule, bool) {
+	if _, ok := status["rbac.serviceAccount"]; !ok {
+		return nil, false
+	}
+
+	rules := make([]rbacRule, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("rbac.rule.%d.", i)
+		role, ok := status[prefix+"role"]
+		if !ok {
+			break
+		}
+		rules = append(rules, rbacRule{
+			Namespace:     status[prefix+"namespace"],
+			Binding:       status[prefix+"binding"],
+			Role:          role,
+			APIGroups:     splitList(status[prefix+"apiGroups"]),
+			Resources:     splitList(status[prefix+"resources"]),
+			Verbs:         splitList(status[prefix+"verbs"]),
+			ResourceNames: nonEmptyList(status[prefix+"resourceNames"]),
+		})
+	}
+	return rules, true
+}
+
+// applicableRules returns the rules that take effect for a request in the namespace
+func applicableRules(rules []rbacRule, namespace string) []rbacRule {
+	applicable := make([]rbacRule, 0)
+	for _, rule := range rules {
+		// Cluster role bindings apply everywhere, role bindings only in their namespace
+		if rule.Namespace == "" || (namespace != "" && rule.Namespace == namespace) {
+			applicable = append(applicable, rule)
+		}
+	}
+	return applicable
+}
+
+// grantingRule returns the first rule that fully allows the request, if any
+func grantingRule(rules []rbacRule, request ForbiddenRequest) *rbacRule {
+	for i, rule := range rules {
+		if matchesAny(rule.APIGroups, request.APIGroup) &&
+			matchesAny(rule.Resources, request.Resource) &&
+			matchesAny(rule.Verbs, request.Verb) &&
+			len(rule.ResourceNames) == 0 {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// partialMatches describes rules that cover the resource but not the verb or not every name
+func partialMatches(rules []rbacRule, request ForbiddenRequest) []string {
+	matches := make([]string, 0)
+	for _, rule := range rules {
+		if !matchesAny(rule.APIGroups, request.APIGroup) {
+			continue
+		}
+		resourceMatch := matchesAny(rule.Resources, request.Resource)
+		verbMatch := matchesAny(rule.Verbs, request.Verb)
+
+		switch {
+		case resourceMatch && !verbMatch:
+			matches = append(matches, fmt.Sprintf("%s (via %s) grants %s on %s but not %s",
+				rule.Role, rule.Binding, strings.Join(rule.Verbs, ","), request.Resource, request.Verb))
+		case resourceMatch && verbMatch && len(rule.ResourceNames) > 0:
+			matches = append(matches, fmt.Sprintf("%s (via %s) grants %s on %s only for names %s",
+				rule.Role, rule.Binding, request.Verb, request.Resource, strings.Join(rule.ResourceNames, ",")))
+		}
+	}
+	return matches
+}
+
+// matchesAny reports whether the list contains the value or a wildcard
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == value || v == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// nonEmptyList splits a comma separated status value, returning nil when it is empty
+func nonEmptyList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return splitList(value)
+}
+
+// splitList splits a comma separated status value, keeping empty entries such as the core API group
+func splitList(value string) []string {
+	return strings.Split(value, ",")
+}
+
+// qualifiedResource returns the resource in the form kubectl auth can-i expects
+func qualifiedResource(request ForbiddenRequest) string {
+	resource, subresource, found := strings.Cut(request.Resource, "/")
+	if request.APIGroup != "" {
+		resource += "." + request.APIGroup
+	}
+	if found {
+		resource += " --subresource=" + subresource
+	}
+	return resource
+}
+
+// roleSnippet generates a minimal Role and RoleBinding granting the denied request
+func roleSnippet(request ForbiddenRequest) string {
+	roleKind, bindingKind := "Role", "RoleBinding"
+	namespaceLine := "  namespace: " + request.Namespace + "\n"
+	if request.Namespace == "" {
+		roleKind, bindingKind = "ClusterRole", "ClusterRoleBinding"
+		namespaceLine = ""
+	}
+
+	name := strings.ToLower(fmt.Sprintf("%s-%s-%s", request.ServiceAccount, request.Verb, strings.ReplaceAll(request.Resource, "/", "-")))
+
+	var b strings.Builder
+	b.WriteString("apiVersion: rbac.authorization.k8s.io/v1\n")
+	b.WriteString("kind: " + roleKind + "\n")
+	b.WriteString("metadata:\n")
+	b.WriteString("  name: " + name + "\n")
+	b.WriteString(namespaceLine)
+	b.WriteString("rules:\n")
+	b.WriteString(fmt.Sprintf("- apiGroups: [%q]\n", request.APIGroup))
+	b.WriteString(fmt.Sprintf("  resources: [%q]\n", request.Resource))
+	b.WriteString(fmt.Sprintf("  verbs: [%q]\n", request.Verb))
+	b.WriteString("---\n")
+	b.WriteString("apiVersion: rbac.authorization.k8s.io/v1\n")
+	b.WriteString("kind: " + bindingKind + "\n")
+	b.WriteString("metadata:\n")
+	b.WriteString("  name: " + name + "\n")
+	b.WriteString(namespaceLine)
+	b.WriteString("subjects:\n")
+	b.WriteString("- kind: ServiceAccount\n")
+	b.WriteString("  name: " + request.ServiceAccount + "\n")
+	b.WriteString("  namespace: " + request.ServiceAccountNamespace + "\n")
+	b.WriteString("roleRef:\n")
+	b.WriteString("  apiGroup: rbac.authorization.k8s.io\n")
+	b.WriteString("  kind: " + roleKind + "\n")
+	b.WriteString("  name: " + name + "\n")
+	return b.String()
+}
@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestParseForbidden(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want ForbiddenRequest
+	}{
+		{
+			name: "namespaced request",
+			line: `E0101 pods is forbidden: User "system:serviceaccount:shop:checkout" cannot list resource "pods" in API group "" in the namespace "shop"`,
+			want: ForbiddenRequest{
+				ServiceAccountNamespace: "shop",
+				ServiceAccount:          "checkout",
+				Verb:                    "list",
+				Resource:                "pods",
+				APIGroup:                "",
+				Namespace:               "shop",
+			},
+		},
+		{
+			name: "cluster scoped request",
+			line: `nodes is forbidden: User "system:serviceaccount:monitoring:agent" cannot watch resource "nodes" in API group "" at the cluster scope`,
+			want: ForbiddenRequest{
+				ServiceAccountNamespace: "monitoring",
+				ServiceAccount:          "agent",
+				Verb:                    "watch",
+				Resource:                "nodes",
+				APIGroup:                "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseForbidden(tt.line)
+			if len(got) != 1 {
+				t.Fatalf("Expected one request, got %d", len(got))
+			}
+			if got[0] != tt.want {
+				t.Errorf("ParseForbidden() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestRBACAnalyzer_MissingVerb(t *testing.T) {
+	analyzer := &RBACAnalyzer{}
+
+	// Create a mock pod whose service account can get pods but not list them
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Pod",
+			Name:      "checkout-7d9f",
+			Namespace: "shop",
+		},
+		Logs: []string{
+			`=== Logs for container: app ===
+pods is forbidden: User "system:serviceaccount:shop:checkout" cannot list resource "pods" in API group "" in the namespace "shop"`,
+		},
+		Status: map[string]string{
+			"rbac.serviceAccount":       "shop:checkout",
+			"rbac.complete":             "true",
+			"rbac.rule.0.namespace":     "shop",
+			"rbac.rule.0.binding":       "RoleBinding/checkout",
+			"rbac.rule.0.role":          "Role/pod-getter",
+			"rbac.rule.0.apiGroups":     "",
+			"rbac.rule.0.resources":     "pods",
+			"rbac.rule.0.verbs":         "get,watch",
+			"rbac.rule.0.resourceNames": "",
+		},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := analyzer.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+	}
+
+	detail := details[0]
+	if detail.Title != "Missing RBAC permission" {
+		t.Errorf("Expected title 'Missing RBAC permission', got '%s'", detail.Title)
+	}
+
+	if !strings.Contains(detail.Description, "Role/pod-getter") {
+		t.Errorf("Expected description to mention the partially matching role, got '%s'", detail.Description)
+	}
+
+	// The last command is the Role/RoleBinding snippet
+	snippet := detail.RemediationCommands[len(detail.RemediationCommands)-1]
+	if !strings.HasPrefix(snippet, "apiVersion:") || !strings.Contains(snippet, "kind: RoleBinding") {
+		t.Errorf("Expected a Role and RoleBinding snippet, got '%s'", snippet)
+	}
+}
+
+func TestRBACAnalyzer_UnresolvedBinding(t *testing.T) {
+	// The binding granting list points at a Role that was deleted
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: "checkout-7d9f", Namespace: "shop"},
+		Logs: []string{
+			`pods is forbidden: User "system:serviceaccount:shop:checkout" cannot list resource "pods" in API group "" in the namespace "shop"`,
+		},
+		Status: map[string]string{
+			"rbac.serviceAccount":       "shop:checkout",
+			"rbac.rule.0.namespace":     "shop",
+			"rbac.rule.0.binding":       "RoleBinding/checkout",
+			"rbac.rule.0.role":          "Role/pod-getter",
+			"rbac.rule.0.apiGroups":     "",
+			"rbac.rule.0.resources":     "pods",
+			"rbac.rule.0.verbs":         "get",
+			"rbac.rule.0.resourceNames": "",
+			"rbac.binding.0.namespace":  "shop",
+			"rbac.binding.0.name":       "RoleBinding/pod-lister",
+			"rbac.binding.0.error":      `failed to get role pod-lister: roles.rbac.authorization.k8s.io "pod-lister" not found`,
+		},
+	}
+
+	details, err := (&RBACAnalyzer{}).Analyze(context.Background(), &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(details) != 1 {
+		t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+	}
+
+	detail := details[0]
+	if detail.Confidence != ConfidenceLow || detail.Title != "RBAC permission denied" {
+		t.Errorf("Expected a low confidence denial, got %q at %v", detail.Title, detail.Confidence)
+	}
+	if !strings.Contains(detail.Description, "RoleBinding/pod-lister") {
+		t.Errorf("Expected the unresolved binding in the description, got %q", detail.Description)
+	}
+	for _, remediation := range detail.Remediation {
+		if strings.HasPrefix(remediation, "Missing rule") {
+			t.Errorf("Expected no missing rule to be claimed, got %q", remediation)
+		}
+	}
+}
+
+func TestRBACAnalyzer_RulesNotCollected(t *testing.T) {
+	// The denial names another service account than the pod's, whose rules were collected
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: "checkout-7d9f", Namespace: "shop"},
+		Logs: []string{
+			`secrets is forbidden: User "system:serviceaccount:shop:deployer" cannot get resource "secrets" in API group "" in the namespace "shop"`,
+		},
+		Status: map[string]string{
+			"rbac.serviceAccount": "shop:checkout",
+			"rbac.complete":       "true",
+		},
+	}
+
+	details, err := (&RBACAnalyzer{}).Analyze(context.Background(), &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(details) != 1 {
+		t.Fatalf("Expected exactly one analysis detail, got %d", len(details))
+	}
+
+	detail := details[0]
+	if detail.Confidence != ConfidenceMedium || detail.Title != "RBAC permission denied" {
+		t.Errorf("Expected a medium confidence denial, got %q at %v", detail.Title, detail.Confidence)
+	}
+	if !strings.Contains(detail.Description, "were not collected") {
+		t.Errorf("Expected the description to say the rules are unknown, got %q", detail.Description)
+	}
+	for _, remediation := range detail.Remediation {
+		if strings.HasPrefix(remediation, "Missing rule") {
+			t.Errorf("Expected no missing rule to be claimed, got %q", remediation)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// memoryLimitThreshold is the share of its memory limit above which a container is
+// close to being OOMKilled
+const memoryLimitThreshold = 0.9
+
+// cpuLimitThreshold is the share of its CPU limit above which a container is throttled
+const cpuLimitThreshold = 0.9
+
+// nodeUsageThreshold is the share of allocatable memory above which the kubelet may
+// soon start evicting pods from the node
+const nodeUsageThreshold = 0.9
+
+// ResourceUsageAnalyzer compares the live usage reported by metrics-server with the
+// requests and limits of containers and the allocatable resources of their node
+type ResourceUsageAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *ResourceUsageAnalyzer) Name() string {
+	return "ResourceUsageAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *ResourceUsageAnalyzer) Description() string {
+	return "Analyzes live CPU and memory usage from metrics-server against container limits, requests and node capacity"
+}
+
+// Analyze implements the Analyzer interface
+func (a *ResourceUsageAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		// Without metrics-server there is nothing to compare
+		if resource.Resource.Kind != "Pod" || resource.Status["metrics.available"] != "true" {
+			continue
+		}
+
+		for i := 0; ; i++ {
+			prefix := fmt.Sprintf("container.%d.", i)
+			name, ok := resource.Status[prefix+"name"]
+			if !ok {
+				break
+			}
+			if detail, ok := a.memoryLimitDetail(resource, prefix, name); ok {
+				details = append(details, detail)
+			}
+			if detail, ok := a.cpuLimitDetail(resource, prefix, name); ok {
+				details = append(details, detail)
+			}
+		}
+
+		if detail, ok := a.nodeMemoryDetail(resource); ok {
+			details = append(details, detail)
+		}
+	}
+
+	return details, nil
+}
+
+// memoryLimitDetail reports a container using most of its memory limit
+func (a *ResourceUsageAnalyzer) memoryLimitDetail(resource collector.ResourceData, prefix, name string) (AnalysisDetail, bool) {
+	usage, limit, ok := usageAndLimit(resource.Status, prefix+"usage.memory", prefix+"limits.memory")
+	if !ok || float64(usage) < memoryLimitThreshold*float64(limit) {
+		return AnalysisDetail{}, false
+	}
+
+	description := fmt.Sprintf("Container %s uses %s of its %s memory limit (%.0f%%) and will be OOMKilled if it grows further",
+		name, formatBytes(usage), formatBytes(limit), percent(usage, limit))
+	severity := SeverityWarning
+	if resource.Status[prefix+"lastReason"] == "OOMKilled" {
+		// It has been killed for this before, so the usage is not a one-off spike
+		severity = SeverityError
+		description += ". It was already OOMKilled before its last restart"
+	}
+
+	return AnalysisDetail{
+		ID:          "USAGE_MEMORY_NEAR_LIMIT",
+		Severity:    severity,
+		Confidence:  ConfidenceHigh,
+		Title:       "Container memory usage close to its limit",
+		Description: description,
+		Evidence: statusEvidence(resource.Status, prefix+"name", prefix+"usage.memory", prefix+"limits.memory",
+			prefix+"requests.memory", prefix+"restartCount", prefix+"lastReason", "metrics.timestamp"),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Raise the memory limit of the container if the usage is expected",
+			"Check the application for memory leaks or unbounded caches",
+			"Size runtime heaps, such as the JVM -Xmx, below the container limit",
+		},
+		RemediationCommands: []string{
+			"kubectl top pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace + " --containers",
+			"kubectl describe pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
+		},
+	}, true
+}
+
+// cpuLimitDetail reports a container using most of its CPU limit, where it is throttled
+func (a *ResourceUsageAnalyzer) cpuLimitDetail(resource collector.ResourceData, prefix, name string) (AnalysisDetail, bool) {
+	usage, limit, ok := usageAndLimit(resource.Status, prefix+"usage.cpu", prefix+"limits.cpu")
+	if !ok || float64(usage) < cpuLimitThreshold*float64(limit) {
+		return AnalysisDetail{}, false
+	}
+
+	return AnalysisDetail{
+		ID:         "USAGE_CPU_NEAR_LIMIT",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceMedium,
+		Title:      "Container CPU usage close to its limit",
+		Description: fmt.Sprintf("Container %s uses %dm of its %dm CPU limit (%.0f%%) and is likely throttled, which slows requests and can fail probes",
+			name, usage, limit, percent(usage, limit)),
+		Evidence: statusEvidence(resource.Status, prefix+"name", prefix+"usage.cpu", prefix+"limits.cpu",
+			prefix+"requests.cpu", "metrics.timestamp"),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Raise or remove the CPU limit of the container",
+			"Check whether probe timeouts or slow responses coincide with the throttling",
+		},
+		RemediationCommands: []string{
+			"kubectl top pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace + " --containers",
+		},
+	}, true
+}
+
+// nodeMemoryDetail reports a node whose memory is nearly used up
+func (a *ResourceUsageAnalyzer) nodeMemoryDetail(resource collector.ResourceData) (AnalysisDetail, bool) {
+	usage, allocatable, ok := usageAndLimit(resource.Status, "metrics.node.usage.memory", "metrics.node.allocatable.memory")
+	if !ok || float64(usage) < nodeUsageThreshold*float64(allocatable) {
+		return AnalysisDetail{}, false
+	}
+	node := resource.Status["metrics.node.name"]
+
+	return AnalysisDetail{
+		ID:         "USAGE_NODE_MEMORY_HIGH",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceMedium,
+		Title:      "Node memory nearly exhausted",
+		Description: fmt.Sprintf("Node %s, where the pod runs, uses %s of %s allocatable memory (%.0f%%); pods using more than they request are evicted first under memory pressure",
+			node, formatBytes(usage), formatBytes(allocatable), percent(usage, allocatable)),
+		Evidence: statusEvidence(resource.Status, "metrics.node.name", "metrics.node.usage.memory",
+			"metrics.node.allocatable.memory", "metrics.timestamp"),
+		Resource: resource.Resource,
+		Remediation: []string{
+			"Set memory requests close to actual usage so the scheduler spreads pods correctly",
+			"Add nodes or move memory-heavy workloads to larger nodes",
+		},
+		RemediationCommands: []string{
+			"kubectl top node " + node,
+			"kubectl describe node " + node,
+		},
+	}, true
+}
+
+// usageAndLimit parses a usage and the limit it is compared with; ok is false when
+// either is missing or the limit is zero
+func usageAndLimit(status map[string]string, usageKey, limitKey string) (usage, limit int64, ok bool) {
+	usage, err := strconv.ParseInt(status[usageKey], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	limit, err = strconv.ParseInt(status[limitKey], 10, 64)
+	if err != nil || limit <= 0 {
+		return 0, 0, false
+	}
+	return usage, limit, true
+}
+
+// percent returns usage as a percentage of limit
+func percent(usage, limit int64) float64 {
+	return float64(usage) * 100 / float64(limit)
+}
+
+// formatBytes writes a byte count in binary units, as kubectl top does
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	switch {
+	case bytes >= unit*unit*unit:
+		return fmt.Sprintf("%.1fGi", float64(bytes)/(unit*unit*unit))
+	case bytes >= unit*unit:
+		return fmt.Sprintf("%dMi", bytes/(unit*unit))
+	case bytes >= unit:
+		return fmt.Sprintf("%dKi", bytes/unit)
+	default:
+		return fmt.Sprintf("%d", bytes)
+	}
+}
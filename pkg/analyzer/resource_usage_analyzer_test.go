@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestResourceUsageAnalyzer(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-0", Namespace: "shop"},
+				Status: map[string]string{
+					"metrics.available":               "true",
+					"metrics.timestamp":               "2026-10-18T10:00:00Z",
+					"container.0.name":                "app",
+					"container.0.lastReason":          "OOMKilled",
+					"container.0.usage.memory":        "490733568", // 468Mi
+					"container.0.limits.memory":       "536870912", // 512Mi
+					"container.0.usage.cpu":           "120",
+					"container.0.limits.cpu":          "500",
+					"container.1.name":                "proxy",
+					"container.1.usage.memory":        "20971520",
+					"container.1.limits.memory":       "134217728",
+					"container.1.usage.cpu":           "195",
+					"container.1.limits.cpu":          "200",
+					"container.2.name":                "unbounded",
+					"container.2.usage.memory":        "1073741824",
+					"metrics.node.name":               "node-1",
+					"metrics.node.usage.memory":       "7516192768",
+					"metrics.node.allocatable.memory": "8053063680",
+				},
+			},
+			{
+				// Without metrics-server nothing is reported
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "shop"},
+				Status: map[string]string{
+					"metrics.available":         "false",
+					"container.0.name":          "app",
+					"container.0.usage.memory":  "536870912",
+					"container.0.limits.memory": "536870912",
+				},
+			},
+		},
+	}
+
+	details, err := (&ResourceUsageAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ids := make([]string, 0)
+	for _, detail := range details {
+		ids = append(ids, detail.ID+"/"+detail.Resource.Name)
+	}
+	if got := strings.Join(ids, ","); got != "USAGE_MEMORY_NEAR_LIMIT/web-0,USAGE_CPU_NEAR_LIMIT/web-0,USAGE_NODE_MEMORY_HIGH/web-0" {
+		t.Fatalf("Unexpected findings: %s", got)
+	}
+
+	memory := details[0]
+	if memory.Severity != SeverityError {
+		t.Errorf("Expected an error for a container OOMKilled before, got %s", memory.Severity)
+	}
+	if !strings.Contains(memory.Description, "uses 468Mi of its 512Mi memory limit (91%)") {
+		t.Errorf("Unexpected description: %s", memory.Description)
+	}
+	if !strings.Contains(details[1].Description, "Container proxy uses 195m of its 200m CPU limit") {
+		t.Errorf("Unexpected description: %s", details[1].Description)
+	}
+	if !strings.Contains(details[2].Description, "Node node-1") || !strings.Contains(details[2].Description, "7.0Gi of 7.5Gi") {
+		t.Errorf("Unexpected description: %s", details[2].Description)
+	}
+}
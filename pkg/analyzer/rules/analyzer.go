@@ -0,0 +1,499 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k8smed/k8smed/pkg/analyzer"
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// nonIDChars matches the characters of a rule name that are replaced in its default ID
+var nonIDChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// eventReasonRegex extracts the reason from a collected event: "[time] Type Reason: Message"
+var eventReasonRegex = regexp.MustCompile(`^\[[^\]]*\] \S+ (\w+)`)
+
+// RuleAnalyzer is an Analyzer compiled from a Rule
+type RuleAnalyzer struct {
+	rule       Rule
+	id         string
+	severity   analyzer.Severity
+	confidence float64
+
+	status  []statusMatcher
+	logs    *regexp.Regexp
+	fields  []fieldMatcher
+	program cel.Program
+	// usesManifest is set when manifest fields or the condition read the manifest
+	usesManifest bool
+
+	description *template.Template
+	remediation []*template.Template
+	commands    []*template.Template
+}
+
+// statusMatcher checks the status keys matching a key pattern
+type statusMatcher struct {
+	key   *regexp.Regexp
+	value *regexp.Regexp
+}
+
+// fieldMatcher checks a manifest field
+type fieldMatcher struct {
+	FieldMatch
+	path  *jsonpath.JSONPath
+	regex *regexp.Regexp
+}
+
+// templateData is what finding templates can refer to
+type templateData struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Status    map[string]string
+	LogMatch  string
+	Values    map[string]string
+}
+
+// Compile turns a rule into an Analyzer, reporting invalid regular expressions,
+// JSONPaths, CEL conditions and templates
+func Compile(rule Rule) (*RuleAnalyzer, error) {
+	if err := rule.validate(); err != nil {
+		return nil, err
+	}
+	a := &RuleAnalyzer{
+		rule:       rule,
+		id:         rule.Finding.ID,
+		severity:   analyzer.SeverityWarning,
+		confidence: rule.Finding.Confidence,
+	}
+	if a.id == "" {
+		a.id = strings.ToUpper(nonIDChars.ReplaceAllString(rule.Name, "_"))
+	}
+	if rule.Finding.Severity != "" {
+		severity, err := analyzer.ParseSeverity(rule.Finding.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.Name, err)
+		}
+		a.severity = severity
+	}
+	if a.confidence == 0 {
+		a.confidence = analyzer.ConfidenceHigh
+	}
+
+	for key, value := range rule.Match.Status {
+		keyRegex := "^" + strings.ReplaceAll(regexp.QuoteMeta(key), `\*`, `[^.]+`) + "$"
+		valueRegex, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: status %s: %w", rule.Name, key, err)
+		}
+		a.status = append(a.status, statusMatcher{key: regexp.MustCompile(keyRegex), value: valueRegex})
+	}
+
+	if rule.Match.Logs != "" {
+		logs, err := regexp.Compile(rule.Match.Logs)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: logs: %w", rule.Name, err)
+		}
+		a.logs = logs
+	}
+
+	for _, field := range rule.Match.Manifest {
+		path := jsonpath.New(field.Path).AllowMissingKeys(true)
+		if err := path.Parse(field.Path); err != nil {
+			return nil, fmt.Errorf("rule %s: manifest path %s: %w", rule.Name, field.Path, err)
+		}
+		matcher := fieldMatcher{FieldMatch: field, path: path}
+		if field.Regex != "" {
+			regex, err := regexp.Compile(field.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("rule %s: manifest path %s: %w", rule.Name, field.Path, err)
+			}
+			matcher.regex = regex
+		}
+		a.fields = append(a.fields, matcher)
+		a.usesManifest = true
+	}
+
+	if rule.Condition != "" {
+		program, usesManifest, err := compileCondition(rule.Condition)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: condition: %w", rule.Name, err)
+		}
+		a.program = program
+		a.usesManifest = a.usesManifest || usesManifest
+	}
+
+	var err error
+	if a.description, err = parseTemplate(rule.Name, rule.Finding.Description); err != nil {
+		return nil, err
+	}
+	for _, text := range rule.Finding.Remediation {
+		tmpl, err := parseTemplate(rule.Name, text)
+		if err != nil {
+			return nil, err
+		}
+		a.remediation = append(a.remediation, tmpl)
+	}
+	for _, text := range rule.Finding.Commands {
+		tmpl, err := parseTemplate(rule.Name, text)
+		if err != nil {
+			return nil, err
+		}
+		a.commands = append(a.commands, tmpl)
+	}
+
+	return a, nil
+}
+
+// compileCondition compiles a CEL expression that must evaluate to a bool and reports
+// whether it reads the manifest
+func compileCondition(expression string) (cel.Program, bool, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("kind", cel.StringType),
+		cel.Variable("name", cel.StringType),
+		cel.Variable("namespace", cel.StringType),
+		cel.Variable("status", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("events", cel.ListType(cel.StringType)),
+		cel.Variable("eventReasons", cel.ListType(cel.StringType)),
+		cel.Variable("logs", cel.ListType(cel.StringType)),
+		cel.Variable("manifest", cel.DynType),
+		// Manifest numbers are decoded as doubles, so "replicas < 2" must compare across types
+		cel.CrossTypeNumericComparisons(true),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, false, err
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, false, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+		return nil, false, fmt.Errorf("expression returns %s, not bool", ast.OutputType())
+	}
+
+	usesManifest := false
+	for _, reference := range ast.NativeRep().ReferenceMap() {
+		if reference.Name == "manifest" {
+			usesManifest = true
+		}
+	}
+	program, err := env.Program(ast)
+	return program, usesManifest, err
+}
+
+// parseTemplate parses a finding template
+func parseTemplate(rule, text string) (*template.Template, error) {
+	tmpl, err := template.New(rule).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: finding template: %w", rule, err)
+	}
+	return tmpl, nil
+}
+
+// Name implements the Analyzer interface
+func (a *RuleAnalyzer) Name() string {
+	return a.rule.Name
+}
+
+// Description implements the Analyzer interface
+func (a *RuleAnalyzer) Description() string {
+	if a.rule.Description != "" {
+		return a.rule.Description
+	}
+	return "Custom rule: " + a.rule.Finding.Title
+}
+
+// Rule returns the rule the analyzer was compiled from
+func (a *RuleAnalyzer) Rule() Rule {
+	return a.rule
+}
+
+// Analyze implements the Analyzer interface. A resource the rule cannot be evaluated
+// against does not stop the others; the findings are returned with the joined errors.
+func (a *RuleAnalyzer) Analyze(ctx context.Context, analysisCtx *analyzer.AnalysisContext) ([]analyzer.AnalysisDetail, error) {
+	details := make([]analyzer.AnalysisDetail, 0)
+	var errs []error
+	for _, resource := range analysisCtx.Resources {
+		detail, ok, err := a.evaluate(resource)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %s %s/%s: %w", a.rule.Name, resource.Resource.Kind,
+				resource.Resource.Namespace, resource.Resource.Name, err))
+			continue
+		}
+		if ok {
+			details = append(details, detail)
+		}
+	}
+	return details, errors.Join(errs...)
+}
+
+// evaluate checks the rule against one resource and builds its finding
+func (a *RuleAnalyzer) evaluate(resource collector.ResourceData) (analyzer.AnalysisDetail, bool, error) {
+	match := a.rule.Match
+	if len(match.Kinds) > 0 && !containsFold(match.Kinds, resource.Resource.Kind) {
+		return analyzer.AnalysisDetail{}, false, nil
+	}
+	if len(match.Namespaces) > 0 && !containsFold(match.Namespaces, resource.Resource.Namespace) {
+		return analyzer.AnalysisDetail{}, false, nil
+	}
+
+	evidence := make([]analyzer.Evidence, 0)
+	for _, matcher := range a.status {
+		key, ok := matcher.match(resource.Status)
+		if !ok {
+			return analyzer.AnalysisDetail{}, false, nil
+		}
+		evidence = append(evidence, analyzer.Evidence{Kind: analyzer.EvidenceStatus, Key: key, Value: resource.Status[key]})
+	}
+
+	reasons := eventReasons(resource.Events)
+	if len(match.EventReasons) > 0 {
+		event, ok := findEvent(resource.Events, match.EventReasons)
+		if !ok {
+			return analyzer.AnalysisDetail{}, false, nil
+		}
+		evidence = append(evidence, analyzer.Evidence{Kind: analyzer.EvidenceEvent, Value: event})
+	}
+
+	data := templateData{
+		Kind:      resource.Resource.Kind,
+		Name:      resource.Resource.Name,
+		Namespace: resource.Resource.Namespace,
+		Status:    resource.Status,
+		Values:    make(map[string]string),
+	}
+
+	if a.logs != nil {
+		logMatch, line, ok := a.matchLogs(resource.Logs)
+		if !ok {
+			return analyzer.AnalysisDetail{}, false, nil
+		}
+		data.LogMatch = logMatch
+		evidence = append(evidence, analyzer.Evidence{Kind: analyzer.EvidenceLog, Value: line})
+	}
+
+	// Without a manifest every field would read as missing, matching "exists: false"
+	var manifest interface{} = map[string]interface{}{}
+	if a.usesManifest {
+		if resource.Manifest == "" {
+			return analyzer.AnalysisDetail{}, false, fmt.Errorf("no manifest was collected to match")
+		}
+		if err := yaml.Unmarshal([]byte(resource.Manifest), &manifest); err != nil {
+			return analyzer.AnalysisDetail{}, false, fmt.Errorf("parsing manifest: %w", err)
+		}
+	}
+
+	for _, field := range a.fields {
+		value, ok, err := field.check(manifest)
+		if err != nil {
+			return analyzer.AnalysisDetail{}, false, err
+		}
+		if !ok {
+			return analyzer.AnalysisDetail{}, false, nil
+		}
+		if field.Name != "" {
+			data.Values[field.Name] = value
+		}
+		if value != "" {
+			evidence = append(evidence, analyzer.Evidence{Kind: analyzer.EvidenceManifest, Key: field.Path, Value: value})
+		}
+	}
+
+	if a.program != nil {
+		status := resource.Status
+		if status == nil {
+			status = map[string]string{}
+		}
+		out, _, err := a.program.Eval(map[string]interface{}{
+			"kind":         resource.Resource.Kind,
+			"name":         resource.Resource.Name,
+			"namespace":    resource.Resource.Namespace,
+			"status":       status,
+			"events":       nonNil(resource.Events),
+			"eventReasons": reasons,
+			"logs":         nonNil(resource.Logs),
+			"manifest":     manifest,
+		})
+		if err != nil {
+			return analyzer.AnalysisDetail{}, false, fmt.Errorf("evaluating condition: %w", err)
+		}
+		fires, ok := out.Value().(bool)
+		if !ok {
+			return analyzer.AnalysisDetail{}, false, fmt.Errorf("condition returned %v, not bool", out.Value())
+		}
+		if !fires {
+			return analyzer.AnalysisDetail{}, false, nil
+		}
+	}
+
+	detail, err := a.finding(resource, data)
+	if err != nil {
+		return analyzer.AnalysisDetail{}, false, err
+	}
+	detail.Evidence = evidence
+	return detail, true, nil
+}
+
+// finding renders the rule's finding for a matched resource
+func (a *RuleAnalyzer) finding(resource collector.ResourceData, data templateData) (analyzer.AnalysisDetail, error) {
+	description, err := render(a.description, data)
+	if err != nil {
+		return analyzer.AnalysisDetail{}, err
+	}
+	detail := analyzer.AnalysisDetail{
+		ID:          a.id,
+		Analyzer:    a.rule.Name,
+		Severity:    a.severity,
+		Confidence:  a.confidence,
+		Title:       a.rule.Finding.Title,
+		Description: description,
+		Resource:    resource.Resource,
+	}
+	for _, tmpl := range a.remediation {
+		text, err := render(tmpl, data)
+		if err != nil {
+			return analyzer.AnalysisDetail{}, err
+		}
+		detail.Remediation = append(detail.Remediation, text)
+	}
+	for _, tmpl := range a.commands {
+		text, err := render(tmpl, data)
+		if err != nil {
+			return analyzer.AnalysisDetail{}, err
+		}
+		detail.RemediationCommands = append(detail.RemediationCommands, text)
+	}
+	return detail, nil
+}
+
+// render executes a finding template
+func render(tmpl *template.Template, data templateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("rendering finding: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// match returns the first status key, in key order, that matches the pattern and
+// has a matching value
+func (m statusMatcher) match(status map[string]string) (string, bool) {
+	keys := make([]string, 0)
+	for key, value := range status {
+		if m.key.MatchString(key) && m.value.MatchString(value) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", false
+	}
+	sort.Strings(keys)
+	return keys[0], true
+}
+
+// matchLogs returns the first capture group, or the whole match, of the first
+// log line matching the rule, along with the line
+func (a *RuleAnalyzer) matchLogs(logs []string) (string, string, bool) {
+	for _, entry := range logs {
+		for _, line := range strings.Split(entry, "\n") {
+			m := a.logs.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			if len(m) > 1 {
+				return m[1], line, true
+			}
+			return m[0], line, true
+		}
+	}
+	return "", "", false
+}
+
+// check evaluates the field against a manifest, returning its value
+func (f fieldMatcher) check(manifest interface{}) (string, bool, error) {
+	results, err := f.path.FindResults(manifest)
+	if err != nil {
+		return "", false, fmt.Errorf("manifest path %s: %w", f.Path, err)
+	}
+	exists := len(results) > 0 && len(results[0]) > 0
+
+	value := ""
+	if exists {
+		var buf bytes.Buffer
+		if err := f.path.Execute(&buf, manifest); err != nil {
+			return "", false, fmt.Errorf("manifest path %s: %w", f.Path, err)
+		}
+		value = buf.String()
+	}
+
+	if f.Exists != nil && *f.Exists != exists {
+		return value, false, nil
+	}
+	if f.Equals != nil && (!exists || value != *f.Equals) {
+		return value, false, nil
+	}
+	if f.regex != nil && (!exists || !f.regex.MatchString(value)) {
+		return value, false, nil
+	}
+	return value, true, nil
+}
+
+// eventReasons extracts the reasons of collected events
+func eventReasons(events []string) []string {
+	reasons := make([]string, 0, len(events))
+	for _, event := range events {
+		if m := eventReasonRegex.FindStringSubmatch(event); m != nil {
+			reasons = append(reasons, m[1])
+		}
+	}
+	return reasons
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// findEvent returns the first event with one of the given reasons
+func findEvent(events, reasons []string) (string, bool) {
+	for _, event := range events {
+		m := eventReasonRegex.FindStringSubmatch(event)
+		if m == nil {
+			continue
+		}
+		for _, reason := range reasons {
+			if m[1] == reason {
+				return event, true
+			}
+		}
+	}
+	return "", false
+}
+
+// nonNil returns an empty list instead of nil so CEL sees a list
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,63 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// TestResult is the outcome of one rule test
+type TestResult struct {
+	Rule    string
+	Test    string
+	Passed  bool
+	Message string
+}
+
+// RunTests compiles a rule and runs its test fixtures
+func RunTests(rule Rule) ([]TestResult, error) {
+	compiled, err := Compile(rule)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]TestResult, 0, len(rule.Tests))
+	for i, test := range rule.Tests {
+		name := test.Name
+		if name == "" {
+			name = fmt.Sprintf("test %d", i+1)
+		}
+		result := TestResult{Rule: rule.Name, Test: name, Passed: true}
+
+		detail, fired, err := compiled.evaluate(test.Resource.resourceData())
+		switch {
+		case err != nil:
+			result.Passed = false
+			result.Message = err.Error()
+		case fired != test.Fires:
+			result.Passed = false
+			result.Message = fmt.Sprintf("expected fires=%t, got %t", test.Fires, fired)
+		case fired && !strings.Contains(detail.Description, test.DescriptionContains):
+			result.Passed = false
+			result.Message = fmt.Sprintf("description %q does not contain %q", detail.Description, test.DescriptionContains)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// resourceData converts a fixture into collected resource data
+func (r TestResource) resourceData() collector.ResourceData {
+	return collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      r.Kind,
+			Name:      r.Name,
+			Namespace: r.Namespace,
+		},
+		Manifest: r.Manifest,
+		Events:   r.Events,
+		Logs:     r.Logs,
+		Status:   r.Status,
+	}
+}
@@ -0,0 +1,165 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k8smed/k8smed/pkg/analyzer"
+)
+
+// documentSeparator splits multi-document YAML
+var documentSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+// Parse reads rules from YAML. Each document holds either a single rule or a list
+// of rules under a "rules" key.
+func Parse(data []byte) ([]Rule, error) {
+	rules := make([]Rule, 0)
+	for _, document := range documentSeparator.Split(string(data), -1) {
+		if strings.TrimSpace(document) == "" {
+			continue
+		}
+
+		var fields map[string]interface{}
+		if err := yaml.Unmarshal([]byte(document), &fields); err != nil {
+			return nil, err
+		}
+		if len(fields) == 0 {
+			continue
+		}
+
+		if _, ok := fields["rules"]; ok {
+			var file struct {
+				Rules []Rule `json:"rules"`
+			}
+			if err := yaml.UnmarshalStrict([]byte(document), &file); err != nil {
+				return nil, err
+			}
+			rules = append(rules, file.Rules...)
+			continue
+		}
+
+		var rule Rule
+		if err := yaml.UnmarshalStrict([]byte(document), &rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// LoadFile reads the rules in a YAML file
+func LoadFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// LoadDir reads the rules in every .yaml and .yml file of a directory, in name order
+func LoadDir(dir string) ([]Rule, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := make([]Rule, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !isYAML(entry.Name()) {
+			continue
+		}
+		fileRules, err := LoadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules, nil
+}
+
+// LoadConfigMap reads the rules in every .yaml and .yml key of a ConfigMap, in key order
+func LoadConfigMap(configMap *corev1.ConfigMap) ([]Rule, error) {
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		if isYAML(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	rules := make([]Rule, 0)
+	for _, key := range keys {
+		keyRules, err := Parse([]byte(configMap.Data[key]))
+		if err != nil {
+			return nil, fmt.Errorf("configmap %s/%s key %s: %w", configMap.Namespace, configMap.Name, key, err)
+		}
+		rules = append(rules, keyRules...)
+	}
+	return rules, nil
+}
+
+// LoadFromCluster reads the rules in a ConfigMap of the cluster
+func LoadFromCluster(ctx context.Context, clientset kubernetes.Interface, namespace, name string) ([]Rule, error) {
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rules configmap: %w", err)
+	}
+	return LoadConfigMap(configMap)
+}
+
+// CompileAll compiles rules, rejecting duplicate names
+func CompileAll(rules []Rule) ([]*RuleAnalyzer, error) {
+	analyzers := make([]*RuleAnalyzer, 0, len(rules))
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if seen[rule.Name] {
+			return nil, fmt.Errorf("duplicate rule name %s", rule.Name)
+		}
+		seen[rule.Name] = true
+
+		compiled, err := Compile(rule)
+		if err != nil {
+			return nil, err
+		}
+		analyzers = append(analyzers, compiled)
+	}
+	return analyzers, nil
+}
+
+// Register compiles rules and adds them to a registry. A rule may not replace a
+// registered analyzer.
+func Register(registry *analyzer.Registry, rules []Rule) error {
+	analyzers, err := CompileAll(rules)
+	if err != nil {
+		return err
+	}
+	for _, compiled := range analyzers {
+		if registry.Get(compiled.Name()) != nil {
+			return fmt.Errorf("rule %s conflicts with a registered analyzer", compiled.Name())
+		}
+	}
+	for _, compiled := range analyzers {
+		registry.Register(compiled)
+	}
+	return nil
+}
+
+// isYAML reports whether a file name has a YAML extension
+func isYAML(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".yaml" || ext == ".yml"
+}
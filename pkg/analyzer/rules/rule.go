@@ -0,0 +1,128 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rule is a declarative check loaded from YAML. A rule fires for a resource when every
+// criterion in Match holds and the Condition, if any, evaluates to true.
+type Rule struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	Match Match `json:"match"`
+
+	// Condition is a CEL expression over kind, name, namespace, status, events,
+	// eventReasons, logs and manifest that must evaluate to a bool. status only holds
+	// the keys that were collected, and reading a missing key is an evaluation error,
+	// so guard optional keys: "'reason' in status && status['reason'] == 'Evicted'".
+	Condition string `json:"condition,omitempty"`
+
+	Finding Finding `json:"finding"`
+
+	// Tests are fixtures checked by RunTests
+	Tests []Test `json:"tests,omitempty"`
+}
+
+// Match selects the resources a rule applies to
+type Match struct {
+	// Kinds limits the rule to these resource kinds
+	Kinds []string `json:"kinds,omitempty"`
+
+	// Namespaces limits the rule to these namespaces
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// Status maps collected status keys to regular expressions their value must match.
+	// A "*" in a key matches one segment, so "container.*.reason" checks every container.
+	Status map[string]string `json:"status,omitempty"`
+
+	// EventReasons requires an event with one of these reasons
+	EventReasons []string `json:"eventReasons,omitempty"`
+
+	// Logs is a regular expression some log line must match; its first capture group
+	// is available to the finding as .LogMatch
+	Logs string `json:"logs,omitempty"`
+
+	// Manifest checks fields of the resource's manifest. A resource collected without a
+	// manifest fails the rule with an error instead of reading every field as missing.
+	Manifest []FieldMatch `json:"manifest,omitempty"`
+}
+
+// FieldMatch checks a manifest field selected with a kubectl-style JSONPath
+type FieldMatch struct {
+	// Path is a JSONPath expression such as "{.spec.replicas}"
+	Path string `json:"path"`
+
+	// Name makes the field's value available to the finding as .Values.<name>
+	Name string `json:"name,omitempty"`
+
+	// Exists requires the field to be present (true) or absent (false)
+	Exists *bool `json:"exists,omitempty"`
+
+	// Equals requires the field to have exactly this value
+	Equals *string `json:"equals,omitempty"`
+
+	// Regex requires the field's value to match this regular expression
+	Regex string `json:"regex,omitempty"`
+}
+
+// Finding describes the analysis detail a rule reports. Description, Remediation and
+// Commands are Go templates over the matched resource.
+type Finding struct {
+	// ID is the finding's stable identifier; it defaults to the rule name in upper snake case
+	ID string `json:"id,omitempty"`
+
+	// Severity is info, warning or error; it defaults to warning
+	Severity string `json:"severity,omitempty"`
+
+	// Confidence is from 0 to 1; it defaults to analyzer.ConfidenceHigh
+	Confidence float64 `json:"confidence,omitempty"`
+
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Remediation []string `json:"remediation,omitempty"`
+	Commands    []string `json:"commands,omitempty"`
+}
+
+// Test is a fixture asserting whether a rule fires for a resource
+type Test struct {
+	Name     string       `json:"name"`
+	Resource TestResource `json:"resource"`
+
+	// Fires is whether the rule is expected to report a finding
+	Fires bool `json:"fires"`
+
+	// DescriptionContains, when set, must appear in the reported description
+	DescriptionContains string `json:"descriptionContains,omitempty"`
+}
+
+// TestResource is the collected data a test runs the rule against
+type TestResource struct {
+	Kind      string            `json:"kind"`
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace,omitempty"`
+	Status    map[string]string `json:"status,omitempty"`
+	Events    []string          `json:"events,omitempty"`
+	Logs      []string          `json:"logs,omitempty"`
+	Manifest  string            `json:"manifest,omitempty"`
+}
+
+// validate checks the fields every rule needs
+func (r *Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("rule has no name")
+	}
+	if r.Finding.Title == "" {
+		return fmt.Errorf("rule %s: finding has no title", r.Name)
+	}
+	if r.Finding.Confidence < 0 || r.Finding.Confidence > 1 {
+		return fmt.Errorf("rule %s: confidence %v is outside [0, 1]", r.Name, r.Finding.Confidence)
+	}
+	for _, field := range r.Match.Manifest {
+		if !strings.HasPrefix(field.Path, "{") {
+			return fmt.Errorf("rule %s: manifest path %q must be a JSONPath such as {.spec.replicas}", r.Name, field.Path)
+		}
+	}
+	return nil
+}
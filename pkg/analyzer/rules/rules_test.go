@@ -0,0 +1,271 @@
+package rules
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k8smed/k8smed/pkg/analyzer"
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestExampleRules(t *testing.T) {
+	rules, err := LoadDir("../../../examples/rules")
+	if err != nil {
+		t.Fatalf("Failed to load example rules: %v", err)
+	}
+	if len(rules) == 0 {
+		t.Fatal("Expected example rules")
+	}
+
+	for _, rule := range rules {
+		results, err := RunTests(rule)
+		if err != nil {
+			t.Errorf("Rule %s does not compile: %v", rule.Name, err)
+			continue
+		}
+		if len(results) == 0 {
+			t.Errorf("Rule %s has no tests", rule.Name)
+		}
+		for _, result := range results {
+			if !result.Passed {
+				t.Errorf("Rule %s, test %s: %s", result.Rule, result.Test, result.Message)
+			}
+		}
+	}
+}
+
+func TestRuleAnalyzer(t *testing.T) {
+	rules, err := Parse([]byte(`
+name: privileged-container
+match:
+  kinds: [Pod]
+  manifest:
+    - path: "{.spec.containers[*].securityContext.privileged}"
+      name: privileged
+      regex: "true"
+finding:
+  severity: error
+  title: Privileged container
+  description: "Pod {{ .Name }} runs privileged containers ({{ .Values.privileged }})"
+  commands:
+    - kubectl get pod {{ .Name }} -n {{ .Namespace }} -o yaml
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse rule: %v", err)
+	}
+
+	registry := analyzer.NewRegistry()
+	if err := Register(registry, rules); err != nil {
+		t.Fatalf("Failed to register rule: %v", err)
+	}
+	ruleAnalyzer := registry.Get("privileged-container")
+	if ruleAnalyzer == nil {
+		t.Fatal("Expected the rule to be registered")
+	}
+
+	analysisCtx := &analyzer.AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "debug", Namespace: "default"},
+				Manifest: "spec:\n  containers:\n  - name: shell\n    securityContext:\n      privileged: true\n",
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "web", Namespace: "default"},
+				Manifest: "spec:\n  containers:\n  - name: nginx\n",
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "Deployment", Name: "debug", Namespace: "default"},
+				Manifest: "spec:\n  containers:\n  - name: shell\n    securityContext:\n      privileged: true\n",
+			},
+		},
+	}
+	details, err := ruleAnalyzer.Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyzer returned error: %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("Expected 1 detail, got %d", len(details))
+	}
+	detail := details[0]
+	if detail.ID != "PRIVILEGED_CONTAINER" || detail.Analyzer != "privileged-container" ||
+		detail.Severity != analyzer.SeverityError || detail.Title != "Privileged container" {
+		t.Errorf("Unexpected detail: %+v", detail)
+	}
+	if len(detail.Evidence) != 1 || detail.Evidence[0].Kind != analyzer.EvidenceManifest || detail.Evidence[0].Value != "true" {
+		t.Errorf("Expected the manifest field as evidence, got %+v", detail.Evidence)
+	}
+	if detail.Description != "Pod debug runs privileged containers (true)" {
+		t.Errorf("Unexpected description: %q", detail.Description)
+	}
+	if len(detail.RemediationCommands) != 1 || detail.RemediationCommands[0] != "kubectl get pod debug -n default -o yaml" {
+		t.Errorf("Unexpected commands: %v", detail.RemediationCommands)
+	}
+}
+
+func TestRuleAnalyzer_EvaluationErrors(t *testing.T) {
+	ruleAnalyzer, err := Compile(Rule{
+		Name:      "evicted-pod",
+		Match:     Match{Kinds: []string{"Pod"}},
+		Condition: "status['reason'] == 'Evicted'",
+		Finding:   Finding{Title: "Evicted pod"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile rule: %v", err)
+	}
+
+	analysisCtx := &analyzer.AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "running", Namespace: "default"},
+				Status:   map[string]string{"phase": "Running"},
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "evicted", Namespace: "default"},
+				Status:   map[string]string{"phase": "Failed", "reason": "Evicted"},
+			},
+		},
+	}
+	details, err := ruleAnalyzer.Analyze(context.Background(), analysisCtx)
+
+	// The pod without a reason fails, which does not hide the finding of the other
+	if err == nil || !strings.Contains(err.Error(), "Pod default/running") {
+		t.Errorf("Expected an error for the pod without a reason, got %v", err)
+	}
+	if len(details) != 1 || details[0].Resource.Name != "evicted" {
+		t.Errorf("Expected the evicted pod's finding, got %+v", details)
+	}
+}
+
+func TestRuleAnalyzer_MissingManifest(t *testing.T) {
+	notExists := false
+	fieldRule, err := Compile(Rule{
+		Name:    "no-resource-limits",
+		Match:   Match{Manifest: []FieldMatch{{Path: "{.spec.containers[0].resources.limits}", Exists: &notExists}}},
+		Finding: Finding{Title: "No resource limits"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile rule: %v", err)
+	}
+	conditionRule, err := Compile(Rule{
+		Name:      "host-network",
+		Condition: "has(manifest.spec) && manifest.spec.hostNetwork == true",
+		Finding:   Finding{Title: "Host network"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to compile rule: %v", err)
+	}
+
+	analysisCtx := &analyzer.AnalysisContext{
+		Resources: []collector.ResourceData{
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "limited", Namespace: "default"},
+				Manifest: "spec:\n  containers:\n  - name: app\n    resources:\n      limits:\n        memory: 1Gi\n",
+			},
+			{
+				Resource: collector.ResourceInfo{Kind: "Pod", Name: "uncollected", Namespace: "default"},
+				Status:   map[string]string{"phase": "Running"},
+			},
+		},
+	}
+
+	// A resource without a manifest is an error rather than a resource without limits
+	for _, ruleAnalyzer := range []*RuleAnalyzer{fieldRule, conditionRule} {
+		details, err := ruleAnalyzer.Analyze(context.Background(), analysisCtx)
+		if err == nil || !strings.Contains(err.Error(), "Pod default/uncollected: no manifest was collected") {
+			t.Errorf("%s: expected an error for the pod without a manifest, got %v", ruleAnalyzer.Name(), err)
+		}
+		if len(details) != 0 {
+			t.Errorf("%s: expected no finding, got %+v", ruleAnalyzer.Name(), details)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		want string
+	}{
+		{
+			name: "missing name",
+			rule: Rule{Finding: Finding{Title: "x"}},
+			want: "no name",
+		},
+		{
+			name: "unknown severity",
+			rule: Rule{Name: "r", Finding: Finding{Title: "x", Severity: "critical"}},
+			want: "unknown severity",
+		},
+		{
+			name: "bad condition",
+			rule: Rule{Name: "r", Condition: "status.phase ==", Finding: Finding{Title: "x"}},
+			want: "condition",
+		},
+		{
+			name: "non-bool condition",
+			rule: Rule{Name: "r", Condition: "name", Finding: Finding{Title: "x"}},
+			want: "not bool",
+		},
+		{
+			name: "bad log regex",
+			rule: Rule{Name: "r", Match: Match{Logs: "("}, Finding: Finding{Title: "x"}},
+			want: "logs",
+		},
+		{
+			name: "bare manifest path",
+			rule: Rule{Name: "r", Match: Match{Manifest: []FieldMatch{{Path: ".spec"}}}, Finding: Finding{Title: "x"}},
+			want: "JSONPath",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.rule)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestRegisterRejectsConflicts(t *testing.T) {
+	rule := Rule{Name: "PodAnalyzer", Finding: Finding{Title: "x"}}
+	if err := Register(analyzer.NewRegistry(), []Rule{rule}); err == nil {
+		t.Error("Expected a rule named like a built-in analyzer to be rejected")
+	}
+
+	if _, err := CompileAll([]Rule{{Name: "a", Finding: Finding{Title: "x"}}, {Name: "a", Finding: Finding{Title: "y"}}}); err == nil {
+		t.Error("Expected duplicate rule names to be rejected")
+	}
+}
+
+func TestLoadConfigMap(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		Data: map[string]string{
+			"b.yaml":    "name: second\nfinding:\n  title: Second\n",
+			"a.yaml":    "name: first\nfinding:\n  title: First\n---\nname: third\nfinding:\n  title: Third\n",
+			"README.md": "not a rule",
+		},
+	}
+
+	rules, err := LoadConfigMap(configMap)
+	if err != nil {
+		t.Fatalf("Failed to load rules: %v", err)
+	}
+	names := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		names = append(names, rule.Name)
+	}
+	if strings.Join(names, ",") != "first,third,second" {
+		t.Errorf("Expected rules in key order, got %v", names)
+	}
+
+	configMap.Data["c.yaml"] = "name: typo\nfindings:\n  title: x\n"
+	if _, err := LoadConfigMap(configMap); err == nil {
+		t.Error("Expected unknown fields to be rejected")
+	}
+}
@@ -0,0 +1,595 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// PredicateType identifies the scheduler filter that rejected a set of nodes
+type PredicateType string
+
+// Scheduler predicates recognized in FailedScheduling messages
+const (
+	PredicateInsufficientResource PredicateType = "InsufficientResource"
+	PredicateTaint                PredicateType = "Taint"
+	PredicateNodeAffinity         PredicateType = "NodeAffinity"
+	PredicateUnschedulable        PredicateType = "Unschedulable"
+	PredicatePodAffinity          PredicateType = "PodAffinity"
+	PredicateTopologySpread       PredicateType = "TopologySpread"
+	PredicateHostPort             PredicateType = "HostPort"
+	PredicateVolumeZone           PredicateType = "VolumeNodeAffinity"
+	PredicateVolumeLimits         PredicateType = "VolumeLimits"
+	PredicateTooManyPods          PredicateType = "TooManyPods"
+	PredicateUnboundPVC           PredicateType = "UnboundPVC"
+	PredicateOther                PredicateType = "Other"
+)
+
+// SchedulingPredicate is one "N node(s) ..." clause of a scheduler message
+type SchedulingPredicate struct {
+	Count    int
+	Type     PredicateType
+	Reason   string
+	Resource string // Set for InsufficientResource
+	TaintKey string // Set for Taint
+	TaintVal string // Set for Taint
+}
+
+// SchedulingFailure is a structured form of a FailedScheduling message
+type SchedulingFailure struct {
+	AvailableNodes int
+	TotalNodes     int
+	Predicates     []SchedulingPredicate
+}
+
+var (
+	// schedulingHeaderRegex matches "0/6 nodes are available: ..."
+	schedulingHeaderRegex = regexp.MustCompile(`(\d+)/(\d+) nodes are available: (.*)`)
+
+	// predicateCountRegex matches the leading node count of a predicate clause
+	predicateCountRegex = regexp.MustCompile(`^(\d+) (.*)$`)
+
+	// eventCountRegex matches the count suffix the collector appends to events
+	eventCountRegex = regexp.MustCompile(`\s*\(count: \d+\)$`)
+
+	// taintRegex matches the {key: value} part of an untolerated taint clause
+	taintRegex = regexp.MustCompile(`\{([^:}]+):\s*([^}]*)\}`)
+)
+
+// ParseSchedulingMessage parses a scheduler FailedScheduling message into per-predicate counts
+func ParseSchedulingMessage(message string) (*SchedulingFailure, bool) {
+	if strings.Contains(message, "unbound immediate PersistentVolumeClaims") {
+		return &SchedulingFailure{
+			Predicates: []SchedulingPredicate{{Type: PredicateUnboundPVC, Reason: strings.TrimSpace(message)}},
+		}, true
+	}
+
+	match := schedulingHeaderRegex.FindStringSubmatch(message)
+	if match == nil {
+		return nil, false
+	}
+
+	failure := &SchedulingFailure{}
+	failure.AvailableNodes, _ = strconv.Atoi(match[1])
+	failure.TotalNodes, _ = strconv.Atoi(match[2])
+
+	// Preemption results are appended after the filter results
+	body, _, _ := strings.Cut(match[3], " preemption:")
+	body = strings.TrimSuffix(strings.TrimSpace(body), ".")
+
+	for _, clause := range splitPredicates(body) {
+		predicate := SchedulingPredicate{Count: 1, Reason: clause}
+		if m := predicateCountRegex.FindStringSubmatch(clause); m != nil {
+			predicate.Count, _ = strconv.Atoi(m[1])
+			predicate.Reason = m[2]
+		}
+		classifyPredicate(&predicate)
+		failure.Predicates = append(failure.Predicates, predicate)
+	}
+
+	return failure, true
+}
+
+// splitPredicates splits the clause list on commas that are not inside taint braces
+func splitPredicates(body string) []string {
+	clauses := make([]string, 0)
+	depth := 0
+	start := 0
+	for i, r := range body {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, strings.TrimSpace(body[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(body[start:]); last != "" {
+		clauses = append(clauses, last)
+	}
+	return clauses
+}
+
+// classifyPredicate sets the predicate type and its details from the reason text
+func classifyPredicate(predicate *SchedulingPredicate) {
+	reason := predicate.Reason
+	lower := strings.ToLower(reason)
+
+	switch {
+	case strings.HasPrefix(reason, "Insufficient "):
+		predicate.Type = PredicateInsufficientResource
+		predicate.Resource = strings.TrimSpace(strings.TrimPrefix(reason, "Insufficient "))
+	case strings.Contains(lower, "node.kubernetes.io/unschedulable") || strings.Contains(lower, "were unschedulable"):
+		predicate.Type = PredicateUnschedulable
+	case strings.Contains(lower, "taint"):
+		predicate.Type = PredicateTaint
+		if m := taintRegex.FindStringSubmatch(reason); m != nil {
+			predicate.TaintKey = strings.TrimSpace(m[1])
+			predicate.TaintVal = strings.TrimSpace(m[2])
+		}
+	case strings.Contains(lower, "node affinity") || strings.Contains(lower, "node selector"):
+		predicate.Type = PredicateNodeAffinity
+	case strings.Contains(lower, "pod affinity") || strings.Contains(lower, "anti-affinity"):
+		predicate.Type = PredicatePodAffinity
+	case strings.Contains(lower, "topology spread"):
+		predicate.Type = PredicateTopologySpread
+	case strings.Contains(lower, "free ports"):
+		predicate.Type = PredicateHostPort
+	case strings.Contains(lower, "volume node affinity"):
+		predicate.Type = PredicateVolumeZone
+	case strings.Contains(lower, "max volume count"):
+		predicate.Type = PredicateVolumeLimits
+	case strings.Contains(lower, "too many pods"):
+		predicate.Type = PredicateTooManyPods
+	default:
+		predicate.Type = PredicateOther
+	}
+}
+
+// schedulingNode is the analyzer's view of a node collected for an unschedulable pod
+type schedulingNode struct {
+	Name          string
+	Unschedulable bool
+	Ready         string
+	Labels        map[string]string
+	Taints        []string
+	Allocatable   map[string]int64
+	Requested     map[string]int64
+}
+
+// free returns the unrequested amount of a resource on the node
+func (n schedulingNode) free(resource string) int64 {
+	return n.Allocatable[resource] - n.Requested[resource]
+}
+
+// hasTaint reports whether the node carries a taint with the given key
+func (n schedulingNode) hasTaint(key string) bool {
+	for _, taint := range n.Taints {
+		if strings.HasPrefix(taint, key+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// SchedulingAnalyzer decodes FailedScheduling messages into per-predicate fixes
+type SchedulingAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *SchedulingAnalyzer) Name() string {
+	return "SchedulingAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *SchedulingAnalyzer) Description() string {
+	return "Analyzes FailedScheduling messages per predicate and correlates them with node capacity, taints and labels"
+}
+
+// Supersedes implements the Superseder interface: each failed predicate explains more
+// than the PodAnalyzer's summary of the scheduler's message
+func (a *SchedulingAnalyzer) Supersedes(detail AnalysisDetail) []string {
+	return []string{"POD_UNSCHEDULABLE"}
+}
+
+// Analyze implements the Analyzer interface
+func (a *SchedulingAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Pod" {
+			continue
+		}
+
+		message, evidence := schedulingMessage(resource)
+		if message == "" {
+			continue
+		}
+
+		failure, ok := ParseSchedulingMessage(message)
+		if !ok {
+			continue
+		}
+
+		nodes := schedulingNodes(resource.Status)
+		for _, predicate := range failure.Predicates {
+			detail := a.predicateDetail(resource, failure, predicate, nodes)
+			detail.Evidence = []Evidence{evidence}
+			details = append(details, detail)
+		}
+	}
+
+	return details, nil
+}
+
+// schedulingMessage returns the scheduler's message from the PodScheduled condition,
+// falling back to the most recent FailedScheduling event, and where it was found
+func schedulingMessage(resource collector.ResourceData) (string, Evidence) {
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("condition.%d.", i)
+		condType, ok := resource.Status[prefix+"type"]
+		if !ok {
+			break
+		}
+		if condType == "PodScheduled" && resource.Status[prefix+"status"] == "False" && resource.Status[prefix+"message"] != "" {
+			message := resource.Status[prefix+"message"]
+			return message, Evidence{Kind: EvidenceStatus, Key: prefix + "message", Value: message}
+		}
+	}
+
+	for i := len(resource.Events) - 1; i >= 0; i-- {
+		if _, message, found := strings.Cut(resource.Events[i], "FailedScheduling: "); found {
+			return eventCountRegex.ReplaceAllString(message, ""), Evidence{Kind: EvidenceEvent, Value: resource.Events[i]}
+		}
+	}
+	return "", Evidence{}
+}
+
+// schedulingNodes reads the collected scheduling.node.N entries
+func schedulingNodes(status map[string]string) []schedulingNode {
+	nodes := make([]schedulingNode, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("scheduling.node.%d.", i)
+		name, ok := status[prefix+"name"]
+		if !ok {
+			break
+		}
+
+		node := schedulingNode{
+			Name:          name,
+			Unschedulable: status[prefix+"unschedulable"] == "true",
+			Ready:         status[prefix+"ready"],
+			Labels:        parseLabels(status[prefix+"labels"]),
+			Taints:        nonEmptyList(status[prefix+"taints"]),
+			Allocatable:   make(map[string]int64),
+			Requested:     make(map[string]int64),
+		}
+
+		for key, value := range status {
+			if resourceName, found := strings.CutPrefix(key, prefix+"allocatable."); found {
+				node.Allocatable[resourceName], _ = strconv.ParseInt(value, 10, 64)
+			}
+			if resourceName, found := strings.CutPrefix(key, prefix+"requested."); found {
+				node.Requested[resourceName], _ = strconv.ParseInt(value, 10, 64)
+			}
+		}
+
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// parseLabels parses comma separated key=value pairs
+func parseLabels(value string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range nonEmptyList(value) {
+		key, val, _ := strings.Cut(pair, "=")
+		labels[key] = val
+	}
+	return labels
+}
+
+// predicateDetail builds the finding and concrete fixes for one predicate
+func (a *SchedulingAnalyzer) predicateDetail(resource collector.ResourceData, failure *SchedulingFailure, predicate SchedulingPredicate, nodes []schedulingNode) AnalysisDetail {
+	detail := AnalysisDetail{
+		Severity:   SeverityError,
+		Confidence: ConfidenceCertain,
+		Resource:   resource.Resource,
+	}
+
+	if failure.TotalNodes > 0 {
+		detail.Description = fmt.Sprintf("%d of %d nodes rejected the pod: %s.", predicate.Count, failure.TotalNodes, predicate.Reason)
+	} else {
+		detail.Description = predicate.Reason + "."
+	}
+
+	switch predicate.Type {
+	case PredicateInsufficientResource:
+		detail.ID = "SCHEDULING_INSUFFICIENT_RESOURCES"
+		detail.Title = "Insufficient " + predicate.Resource + " to schedule pod"
+		a.insufficientResourceFix(&detail, resource.Status, predicate.Resource, nodes)
+
+	case PredicateTaint:
+		detail.ID = "SCHEDULING_UNTOLERATED_TAINT"
+		detail.Title = "Pod does not tolerate node taint"
+		a.taintFix(&detail, predicate, nodes)
+
+	case PredicateUnschedulable:
+		detail.ID = "SCHEDULING_NODES_CORDONED"
+		detail.Title = "Nodes are cordoned"
+		cordoned := make([]string, 0)
+		for _, node := range nodes {
+			if node.Unschedulable {
+				cordoned = append(cordoned, node.Name)
+			}
+		}
+		detail.Remediation = []string{"Uncordon nodes once their maintenance is finished"}
+		if len(cordoned) > 0 {
+			detail.Description += " Cordoned nodes: " + strings.Join(cordoned, ", ") + "."
+			detail.RemediationCommands = []string{"kubectl uncordon " + strings.Join(cordoned, " ")}
+		}
+
+	case PredicateNodeAffinity:
+		detail.ID = "SCHEDULING_NODE_AFFINITY_MISMATCH"
+		detail.Title = "Pod node selector or affinity matches no node"
+		a.nodeSelectorFix(&detail, resource.Status, nodes)
+
+	case PredicatePodAffinity:
+		detail.ID = "SCHEDULING_POD_AFFINITY_UNSATISFIABLE"
+		detail.Title = "Pod affinity or anti-affinity cannot be satisfied"
+		detail.Remediation = []string{
+			"Check which pods the affinity terms select and where they run",
+			"Use preferredDuringSchedulingIgnoredDuringExecution instead of required rules",
+			"Add nodes in the topology domain the anti-affinity requires",
+		}
+		detail.RemediationCommands = []string{"kubectl get pods -n " + resource.Resource.Namespace + " -o wide --show-labels"}
+
+	case PredicateTopologySpread:
+		detail.ID = "SCHEDULING_TOPOLOGY_SPREAD_UNSATISFIABLE"
+		detail.Title = "Topology spread constraints cannot be satisfied"
+		detail.Remediation = []string{
+			"Increase maxSkew or use whenUnsatisfiable: ScheduleAnyway",
+			"Check that every topology domain has schedulable nodes",
+		}
+		detail.RemediationCommands = []string{"kubectl get nodes -L topology.kubernetes.io/zone"}
+
+	case PredicateHostPort:
+		detail.ID = "SCHEDULING_HOST_PORT_CONFLICT"
+		detail.Title = "Requested host port is already in use"
+		detail.Remediation = []string{
+			"Remove hostPort from the container unless it is required",
+			"Ensure at most one pod per node requests the same hostPort",
+		}
+
+	case PredicateVolumeZone:
+		detail.ID = "SCHEDULING_VOLUME_ZONE_CONFLICT"
+		detail.Title = "Persistent volume is bound to another zone"
+		detail.Remediation = []string{
+			"Schedule the pod in the zone of its PersistentVolume",
+			"Use a StorageClass with volumeBindingMode: WaitForFirstConsumer",
+		}
+		detail.RemediationCommands = []string{
+			"kubectl get pv -o custom-columns=NAME:.metadata.name,CLAIM:.spec.claimRef.name,AFFINITY:.spec.nodeAffinity",
+			"kubectl get nodes -L topology.kubernetes.io/zone",
+		}
+
+	case PredicateVolumeLimits:
+		detail.ID = "SCHEDULING_VOLUME_LIMIT_REACHED"
+		detail.Title = "Node volume attach limit reached"
+		detail.Remediation = []string{
+			"Spread volume-heavy pods across more nodes",
+			"Use instance types with a higher volume attach limit",
+		}
+
+	case PredicateTooManyPods:
+		detail.ID = "SCHEDULING_POD_LIMIT_REACHED"
+		detail.Title = "Nodes reached their pod limit"
+		detail.Remediation = []string{
+			"Add nodes or raise the kubelet maxPods setting",
+			"Remove completed or unused pods",
+		}
+		detail.RemediationCommands = []string{"kubectl get nodes -o custom-columns=NAME:.metadata.name,PODS:.status.allocatable.pods"}
+
+	case PredicateUnboundPVC:
+		detail.ID = "SCHEDULING_UNBOUND_PVC"
+		detail.Title = "Pod has unbound PersistentVolumeClaims"
+		detail.Remediation = []string{
+			"Check that the claim's StorageClass exists and can provision volumes",
+			"Check for a matching PersistentVolume if provisioning is static",
+		}
+		detail.RemediationCommands = []string{"kubectl get pvc -n " + resource.Resource.Namespace}
+
+	default:
+		detail.ID = "SCHEDULING_PREDICATE_FAILED"
+		detail.Title = "Pod scheduling predicate failed"
+		detail.Remediation = []string{"Check the pod's scheduling constraints against the nodes"}
+		detail.RemediationCommands = []string{"kubectl describe pod " + resource.Resource.Name + " -n " + resource.Resource.Namespace}
+	}
+
+	return detail
+}
+
+// insufficientResourceFix explains how far each node is from fitting the pod's request
+func (a *SchedulingAnalyzer) insufficientResourceFix(detail *AnalysisDetail, status map[string]string, resourceName string, nodes []schedulingNode) {
+	requestValue, hasRequest := status["scheduling.request."+resourceName]
+	request, _ := strconv.ParseInt(requestValue, 10, 64)
+
+	// Nodes with enough free capacity were rejected by another predicate
+	candidates := make([]schedulingNode, 0)
+	for _, node := range nodes {
+		if !node.Unschedulable && node.Ready == "True" && node.free(resourceName) < request {
+			candidates = append(candidates, node)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].free(resourceName) > candidates[j].free(resourceName)
+	})
+
+	detail.Remediation = []string{
+		"Add nodes or enable the cluster autoscaler for this node pool",
+		"Remove or scale down workloads that over-request " + resourceName,
+	}
+
+	if !hasRequest || len(candidates) == 0 {
+		detail.RemediationCommands = []string{"kubectl describe nodes", "kubectl top nodes"}
+		return
+	}
+
+	detail.Description += fmt.Sprintf(" The pod requests %s.", formatQuantity(resourceName, request))
+
+	shown := candidates
+	if len(shown) > 3 {
+		shown = shown[:3]
+	}
+	frees := make([]string, 0, len(shown))
+	names := make([]string, 0, len(shown))
+	for _, node := range shown {
+		frees = append(frees, fmt.Sprintf("%s has %s free of %s", node.Name,
+			formatQuantity(resourceName, node.free(resourceName)), formatQuantity(resourceName, node.Allocatable[resourceName])))
+		names = append(names, node.Name)
+	}
+	detail.Description += " Most free capacity: " + strings.Join(frees, "; ") + "."
+
+	if best := candidates[0].free(resourceName); best > 0 && best < request {
+		detail.Remediation = append([]string{
+			fmt.Sprintf("Lower the pod's %s request to at most %s to fit on %s", resourceName, formatQuantity(resourceName, best), candidates[0].Name),
+		}, detail.Remediation...)
+	}
+
+	detail.RemediationCommands = []string{
+		"kubectl describe nodes " + strings.Join(names, " "),
+		"kubectl top nodes",
+	}
+}
+
+// taintFix names the tainted nodes and shows the toleration that would admit the pod
+func (a *SchedulingAnalyzer) taintFix(detail *AnalysisDetail, predicate SchedulingPredicate, nodes []schedulingNode) {
+	if predicate.TaintKey == "" {
+		detail.Remediation = []string{"Add a toleration for the node taint or remove the taint"}
+		detail.RemediationCommands = []string{"kubectl get nodes -o custom-columns=NAME:.metadata.name,TAINTS:.spec.taints"}
+		return
+	}
+
+	tainted := make([]string, 0)
+	for _, node := range nodes {
+		if node.hasTaint(predicate.TaintKey) {
+			tainted = append(tainted, node.Name)
+		}
+	}
+	if len(tainted) > 0 {
+		detail.Description += " Tainted nodes: " + strings.Join(tainted, ", ") + "."
+	}
+
+	toleration := fmt.Sprintf("tolerations: [{key: %q, operator: \"Exists\"}]", predicate.TaintKey)
+	if predicate.TaintVal != "" {
+		toleration = fmt.Sprintf("tolerations: [{key: %q, operator: \"Equal\", value: %q}]", predicate.TaintKey, predicate.TaintVal)
+	}
+
+	if strings.HasPrefix(predicate.TaintKey, "node-role.kubernetes.io/") {
+		detail.Severity = SeverityWarning
+		detail.Remediation = []string{
+			"These are control plane nodes; schedule the workload on worker nodes instead of tolerating the taint",
+			"Only if the pod must run there, add " + toleration,
+		}
+		return
+	}
+
+	if strings.HasPrefix(predicate.TaintKey, "node.kubernetes.io/") {
+		detail.Remediation = []string{
+			"The taint is set automatically by Kubernetes; fix the node condition it reflects (" + predicate.TaintKey + ")",
+		}
+		if len(tainted) > 0 {
+			detail.RemediationCommands = []string{"kubectl describe nodes " + strings.Join(tainted, " ")}
+		}
+		return
+	}
+
+	detail.Remediation = []string{
+		"Add " + toleration + " to the pod spec if it is meant to run on these nodes",
+		"Otherwise remove the taint from the nodes",
+	}
+	if len(tainted) > 0 {
+		detail.RemediationCommands = []string{"kubectl taint nodes " + strings.Join(tainted, " ") + " " + predicate.TaintKey + "-"}
+	}
+}
+
+// nodeSelectorFix checks each nodeSelector label against the nodes
+func (a *SchedulingAnalyzer) nodeSelectorFix(detail *AnalysisDetail, status map[string]string, nodes []schedulingNode) {
+	selector := parseLabels(status["scheduling.nodeSelector"])
+
+	detail.Remediation = []string{
+		"Check the pod's nodeSelector and required node affinity against the node labels",
+	}
+	detail.RemediationCommands = []string{"kubectl get nodes --show-labels"}
+
+	if len(selector) == 0 || len(nodes) == 0 {
+		return
+	}
+
+	// Suggest labelling a node that could actually take the pod
+	target := nodes[0].Name
+	for _, node := range nodes {
+		if !node.Unschedulable && node.Ready == "True" {
+			target = node.Name
+			break
+		}
+	}
+
+	keys := make([]string, 0, len(selector))
+	for key := range selector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		matching := make([]string, 0)
+		values := make(map[string]bool)
+		for _, node := range nodes {
+			if value, ok := node.Labels[key]; ok {
+				values[value] = true
+				if value == selector[key] {
+					matching = append(matching, node.Name)
+				}
+			}
+		}
+
+		if len(matching) > 0 {
+			continue
+		}
+
+		if len(values) == 0 {
+			detail.Description += fmt.Sprintf(" No node has label %s.", key)
+		} else {
+			existing := make([]string, 0, len(values))
+			for value := range values {
+				existing = append(existing, value)
+			}
+			sort.Strings(existing)
+			detail.Description += fmt.Sprintf(" No node has %s=%s; existing values: %s.", key, selector[key], strings.Join(existing, ", "))
+		}
+
+		detail.Remediation = append(detail.Remediation,
+			fmt.Sprintf("Fix nodeSelector %s=%s or label a node with it", key, selector[key]))
+		detail.RemediationCommands = append(detail.RemediationCommands,
+			fmt.Sprintf("kubectl label nodes %s %s=%s", target, key, selector[key]))
+	}
+}
+
+// formatQuantity renders millicores and bytes in human readable units
+func formatQuantity(resourceName string, value int64) string {
+	switch resourceName {
+	case "cpu":
+		return fmt.Sprintf("%dm CPU", value)
+	case "memory", "ephemeral-storage":
+		return fmt.Sprintf("%dMi %s", value/(1024*1024), resourceName)
+	default:
+		return fmt.Sprintf("%d %s", value, resourceName)
+	}
+}
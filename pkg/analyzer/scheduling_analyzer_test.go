@@ -0,0 +1,151 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestParseSchedulingMessage(t *testing.T) {
+	message := "0/6 nodes are available: 3 Insufficient cpu, 2 node(s) had untolerated taint {dedicated: gpu}, " +
+		"1 node(s) didn't match Pod's node affinity/selector. preemption: 0/6 nodes are available: 6 Preemption is not helpful for scheduling."
+
+	failure, ok := ParseSchedulingMessage(message)
+	if !ok {
+		t.Fatal("Expected the message to be parsed")
+	}
+
+	if failure.TotalNodes != 6 || failure.AvailableNodes != 0 {
+		t.Errorf("Expected 0/6 nodes, got %d/%d", failure.AvailableNodes, failure.TotalNodes)
+	}
+
+	want := []SchedulingPredicate{
+		{Count: 3, Type: PredicateInsufficientResource, Reason: "Insufficient cpu", Resource: "cpu"},
+		{Count: 2, Type: PredicateTaint, Reason: "node(s) had untolerated taint {dedicated: gpu}", TaintKey: "dedicated", TaintVal: "gpu"},
+		{Count: 1, Type: PredicateNodeAffinity, Reason: "node(s) didn't match Pod's node affinity/selector"},
+	}
+
+	if len(failure.Predicates) != len(want) {
+		t.Fatalf("Expected %d predicates, got %d: %+v", len(want), len(failure.Predicates), failure.Predicates)
+	}
+	for i := range want {
+		if failure.Predicates[i] != want[i] {
+			t.Errorf("Predicate %d = %+v, want %+v", i, failure.Predicates[i], want[i])
+		}
+	}
+}
+
+func TestSchedulingAnalyzer_NodeCorrelation(t *testing.T) {
+	resource := collector.ResourceData{
+		Resource: collector.ResourceInfo{
+			Kind:      "Pod",
+			Name:      "test-pod",
+			Namespace: "default",
+		},
+		Status: map[string]string{
+			"phase":              "Pending",
+			"condition.0.type":   "PodScheduled",
+			"condition.0.status": "False",
+			"condition.0.reason": "Unschedulable",
+			"condition.0.message": "0/3 nodes are available: 2 Insufficient cpu, " +
+				"1 node(s) had untolerated taint {dedicated: gpu}.",
+			"scheduling.request.cpu":            "2000",
+			"scheduling.node.0.name":            "worker-a",
+			"scheduling.node.0.ready":           "True",
+			"scheduling.node.0.unschedulable":   "false",
+			"scheduling.node.0.allocatable.cpu": "4000",
+			"scheduling.node.0.requested.cpu":   "3500",
+			"scheduling.node.1.name":            "worker-b",
+			"scheduling.node.1.ready":           "True",
+			"scheduling.node.1.unschedulable":   "false",
+			"scheduling.node.1.allocatable.cpu": "4000",
+			"scheduling.node.1.requested.cpu":   "2800",
+			"scheduling.node.2.name":            "gpu-a",
+			"scheduling.node.2.ready":           "True",
+			"scheduling.node.2.unschedulable":   "false",
+			"scheduling.node.2.taints":          "dedicated=gpu:NoSchedule",
+			"scheduling.node.2.allocatable.cpu": "8000",
+			"scheduling.node.2.requested.cpu":   "0",
+		},
+	}
+
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{resource},
+		Details:   []AnalysisDetail{},
+	}
+
+	details, err := (&SchedulingAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 2 {
+		t.Fatalf("Expected one detail per predicate, got %d", len(details))
+	}
+
+	cpu := details[0]
+	if !strings.Contains(cpu.Description, "worker-b has 1200m CPU free") {
+		t.Errorf("Expected the CPU finding to name worker-b's free capacity, got '%s'", cpu.Description)
+	}
+	if !strings.Contains(cpu.Remediation[0], "1200m CPU") {
+		t.Errorf("Expected a concrete request suggestion, got %v", cpu.Remediation)
+	}
+
+	taint := details[1]
+	if !strings.Contains(taint.Description, "gpu-a") {
+		t.Errorf("Expected the taint finding to name gpu-a, got '%s'", taint.Description)
+	}
+	for _, cmd := range append(cpu.RemediationCommands, taint.RemediationCommands...) {
+		if strings.Contains(cmd, "<node-name>") {
+			t.Errorf("Expected real node names in commands, got '%s'", cmd)
+		}
+	}
+}
+
+func TestSchedulingAnalyzer_SupersedesPodAnalyzer(t *testing.T) {
+	pending := func(name, message string) collector.ResourceData {
+		return collector.ResourceData{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: name, Namespace: "default"},
+			Status: map[string]string{
+				"phase":               "Pending",
+				"condition.0.type":    "PodScheduled",
+				"condition.0.status":  "False",
+				"condition.0.reason":  "Unschedulable",
+				"condition.0.message": message,
+			},
+		}
+	}
+	analysisCtx := &AnalysisContext{Resources: []collector.ResourceData{
+		pending("explained", "0/3 nodes are available: 3 Insufficient memory."),
+		pending("unexplained", "running PreBind plugin \"VolumeBinding\": binding volumes: timed out waiting for the condition"),
+	}}
+
+	engine := NewEngine(NewRegistry(), EngineOptions{})
+	report, err := engine.Run(context.Background(), analysisCtx, "PodAnalyzer", "SchedulingAnalyzer")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	ids := make(map[string][]string)
+	for _, detail := range report.Details {
+		ids[detail.Resource.Name] = append(ids[detail.Resource.Name], detail.ID)
+	}
+	// The predicate finding replaces the summary; without one, the summary stays
+	if got := strings.Join(ids["explained"], ","); got != "SCHEDULING_INSUFFICIENT_RESOURCES" {
+		t.Errorf("Expected only the predicate finding for the explained pod, got %s", got)
+	}
+	if got := strings.Join(ids["unexplained"], ","); got != "POD_UNSCHEDULABLE" {
+		t.Errorf("Expected the summary for a message without predicates, got %s", got)
+	}
+
+	// Without the SchedulingAnalyzer the summary is reported
+	report, err = engine.Run(context.Background(), analysisCtx, "PodAnalyzer")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Details) != 2 || report.Details[0].ID != "POD_UNSCHEDULABLE" {
+		t.Errorf("Expected the PodAnalyzer's findings, got %+v", report.Details)
+	}
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// ServiceAnalyzer finds Services that route to no pod
+type ServiceAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *ServiceAnalyzer) Name() string {
+	return "ServiceAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *ServiceAnalyzer) Description() string {
+	return "Analyzes Services whose selector matches no pods or only pods that are not ready, and load balancers without an address"
+}
+
+// Analyze implements the Analyzer interface
+func (a *ServiceAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind != "Service" {
+			continue
+		}
+
+		if detail, ok := a.endpointsDetail(resource); ok {
+			details = append(details, detail)
+		}
+
+		if resource.Status["type"] == "LoadBalancer" && resource.Status["loadBalancer"] == "" {
+			details = append(details, AnalysisDetail{
+				ID:         "SERVICE_LOADBALANCER_PENDING",
+				Severity:   SeverityWarning,
+				Confidence: ConfidenceMedium,
+				Title:      "Load balancer has no address",
+				Description: "Service " + resource.Resource.Name + " is of type LoadBalancer but has not been assigned an address; " +
+					"the cloud provider or load balancer controller may be failing to provision it",
+				Evidence: append(statusEvidence(resource.Status, "type", "loadBalancer"),
+					eventEvidence(warningEvents(resource.Events, maxConditionEvents)...)...),
+				Resource: resource.Resource,
+				Remediation: []string{
+					"Check the events of the Service for provisioning errors",
+					"Check that a load balancer controller runs in the cluster",
+				},
+				RemediationCommands: []string{
+					"kubectl describe service " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
+				},
+			})
+		}
+	}
+
+	return details, nil
+}
+
+// endpointsDetail reports a Service with a selector but no ready endpoints
+func (a *ServiceAnalyzer) endpointsDetail(resource collector.ResourceData) (AnalysisDetail, bool) {
+	ready, err := strconv.Atoi(resource.Status["endpoints.ready"])
+	if err != nil || ready > 0 || resource.Status["selector"] == "" {
+		return AnalysisDetail{}, false
+	}
+	matching, _ := strconv.Atoi(resource.Status["pods.matching"])
+
+	detail := AnalysisDetail{
+		ID:         "SERVICE_NO_ENDPOINTS",
+		Severity:   SeverityError,
+		Confidence: ConfidenceCertain,
+		Evidence: statusEvidence(resource.Status, "selector", "endpoints.ready", "endpoints.notReady",
+			"pods.matching", "pods.ready"),
+		Resource: resource.Resource,
+		RemediationCommands: []string{
+			"kubectl get endpoints " + resource.Resource.Name + " -n " + resource.Resource.Namespace,
+			"kubectl get pods -n " + resource.Resource.Namespace + " -l " + resource.Status["selector"],
+		},
+	}
+
+	if matching == 0 {
+		detail.Title = "Service selector matches no pods"
+		detail.Description = fmt.Sprintf("Service %s has no endpoints because its selector %s matches no pods in namespace %s",
+			resource.Resource.Name, resource.Status["selector"], resource.Resource.Namespace)
+		detail.Remediation = []string{
+			"Compare the Service selector with the labels of the pod template",
+			"Check that the workload serving the Service is deployed in the same namespace",
+		}
+		return detail, true
+	}
+
+	detail.Title = "Service has no ready endpoints"
+	detail.Description = fmt.Sprintf("Service %s has no ready endpoints: its selector matches %d pod(s), but none of them is ready",
+		resource.Resource.Name, matching)
+	detail.Remediation = []string{
+		"Check why the selected pods are not ready; their findings are the likely cause",
+		"Check the readiness probes of the selected pods",
+	}
+	return detail, true
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestServiceAnalyzer(t *testing.T) {
+	service := func(name string, status map[string]string) collector.ResourceData {
+		return collector.ResourceData{
+			Resource: collector.ResourceInfo{Kind: "Service", Name: name, Namespace: "shop"},
+			Status:   status,
+		}
+	}
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			service("web", map[string]string{
+				"type": "ClusterIP", "selector": "app=web", "endpoints.ready": "0", "endpoints.notReady": "2",
+				"pods.matching": "2", "pods.ready": "0",
+			}),
+			service("api", map[string]string{
+				"type": "ClusterIP", "selector": "app=api-v2", "endpoints.ready": "0", "pods.matching": "0",
+			}),
+			service("healthy", map[string]string{
+				"type": "ClusterIP", "selector": "app=healthy", "endpoints.ready": "3", "pods.matching": "3",
+			}),
+			service("external", map[string]string{"type": "ExternalName", "externalName": "db.example.com"}),
+			service("edge", map[string]string{
+				"type": "LoadBalancer", "selector": "app=edge", "endpoints.ready": "1", "pods.matching": "1", "loadBalancer": "",
+			}),
+		},
+	}
+
+	details, err := (&ServiceAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	ids := make([]string, 0)
+	for _, detail := range details {
+		ids = append(ids, detail.ID+"/"+detail.Resource.Name)
+	}
+	if got := strings.Join(ids, ","); got != "SERVICE_NO_ENDPOINTS/web,SERVICE_NO_ENDPOINTS/api,SERVICE_LOADBALANCER_PENDING/edge" {
+		t.Fatalf("Unexpected findings: %s", got)
+	}
+	if details[0].Title != "Service has no ready endpoints" || details[1].Title != "Service selector matches no pods" {
+		t.Errorf("Unexpected titles: %q, %q", details[0].Title, details[1].Title)
+	}
+	if !strings.Contains(details[1].Description, "selector app=api-v2 matches no pods") {
+		t.Errorf("Unexpected description: %s", details[1].Description)
+	}
+}
@@ -0,0 +1,162 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Suppression hides a known or accepted finding until it expires
+type Suppression struct {
+	// ID is the finding ID to suppress; it may contain * and ? wildcards
+	ID string `json:"id"`
+
+	// Resource limits the suppression to matching resources; empty matches all
+	Resource ResourceSelector `json:"resource,omitempty"`
+
+	// Expires is the last day (YYYY-MM-DD) or the instant (RFC 3339) the suppression
+	// applies; empty never expires
+	Expires string `json:"expires,omitempty"`
+
+	// Reason explains why the finding is accepted
+	Reason string `json:"reason"`
+}
+
+// ResourceSelector selects the resources a suppression applies to. Namespace and
+// Name may contain * and ? wildcards.
+type ResourceSelector struct {
+	Kind      string            `json:"kind,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// LoadSuppressions reads a YAML suppression file with a "suppressions" list
+func LoadSuppressions(file string) ([]Suppression, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	suppressions, err := ParseSuppressions(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return suppressions, nil
+}
+
+// ParseSuppressions reads suppressions from YAML and validates them
+func ParseSuppressions(data []byte) ([]Suppression, error) {
+	var file struct {
+		Suppressions []Suppression `json:"suppressions"`
+	}
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, err
+	}
+
+	for i := range file.Suppressions {
+		if err := file.Suppressions[i].validate(); err != nil {
+			return nil, fmt.Errorf("suppression %d: %w", i+1, err)
+		}
+	}
+	return file.Suppressions, nil
+}
+
+// validate checks the suppression's fields
+func (s *Suppression) validate() error {
+	if s.ID == "" {
+		return fmt.Errorf("missing id")
+	}
+	if s.Reason == "" {
+		return fmt.Errorf("%s: missing reason", s.ID)
+	}
+	for _, pattern := range []string{s.ID, s.Resource.Namespace, s.Resource.Name} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: invalid pattern %q", s.ID, pattern)
+		}
+	}
+	if _, err := s.expiresAt(); err != nil {
+		return fmt.Errorf("%s: %w", s.ID, err)
+	}
+	return nil
+}
+
+// expiresAt returns when the suppression stops applying, or zero if it never expires
+func (s Suppression) expiresAt() (time.Time, error) {
+	if s.Expires == "" {
+		return time.Time{}, nil
+	}
+	if day, err := time.Parse(time.DateOnly, s.Expires); err == nil {
+		// A date suppresses through the end of that day
+		return day.AddDate(0, 0, 1), nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, s.Expires)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expires %q is neither YYYY-MM-DD nor RFC 3339", s.Expires)
+	}
+	return expiresAt, nil
+}
+
+// Expired reports whether the suppression no longer applies at the given time.
+// A suppression with an invalid expiry counts as expired.
+func (s Suppression) Expired(now time.Time) bool {
+	expiresAt, err := s.expiresAt()
+	if err != nil {
+		return true
+	}
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+// Matches reports whether the suppression hides the finding at the given time
+func (s Suppression) Matches(detail AnalysisDetail, now time.Time) bool {
+	if s.Expired(now) || !globMatch(s.ID, detail.ID) {
+		return false
+	}
+
+	resource := detail.Resource
+	if s.Resource.Kind != "" && !strings.EqualFold(s.Resource.Kind, resource.Kind) {
+		return false
+	}
+	if s.Resource.Namespace != "" && !globMatch(s.Resource.Namespace, resource.Namespace) {
+		return false
+	}
+	if s.Resource.Name != "" && !globMatch(s.Resource.Name, resource.Name) {
+		return false
+	}
+	for key, value := range s.Resource.Labels {
+		if resource.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Suppress splits details into those to report and those hidden by a suppression
+func Suppress(details []AnalysisDetail, suppressions []Suppression, now time.Time) (kept, suppressed []AnalysisDetail) {
+	kept = make([]AnalysisDetail, 0, len(details))
+	suppressed = make([]AnalysisDetail, 0)
+	for _, detail := range details {
+		hidden := false
+		for _, suppression := range suppressions {
+			if suppression.Matches(detail, now) {
+				hidden = true
+				break
+			}
+		}
+		if hidden {
+			suppressed = append(suppressed, detail)
+		} else {
+			kept = append(kept, detail)
+		}
+	}
+	return kept, suppressed
+}
+
+// globMatch matches a value against a pattern validated by Suppression.validate
+func globMatch(pattern, value string) bool {
+	matched, _ := path.Match(pattern, value)
+	return matched
+}
@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+const suppressionFile = `
+suppressions:
+  - id: POD_CRASHLOOP
+    resource:
+      kind: Pod
+      namespace: batch
+      name: report-*
+    expires: 2026-06-30
+    reason: Nightly report job is known to crash until OPS-123 ships
+  - id: TLS_*
+    resource:
+      labels:
+        env: dev
+    reason: Dev ingresses use self-signed certificates
+`
+
+func TestParseSuppressions(t *testing.T) {
+	suppressions, err := ParseSuppressions([]byte(suppressionFile))
+	if err != nil {
+		t.Fatalf("ParseSuppressions() error = %v", err)
+	}
+	if len(suppressions) != 2 {
+		t.Fatalf("Expected 2 suppressions, got %d", len(suppressions))
+	}
+
+	invalid := map[string]string{
+		"missing reason": "suppressions:\n  - id: POD_CRASHLOOP\n",
+		"missing id":     "suppressions:\n  - reason: x\n",
+		"bad expiry":     "suppressions:\n  - id: X\n    reason: x\n    expires: next week\n",
+		"bad pattern":    "suppressions:\n  - id: \"[\"\n    reason: x\n",
+		"unknown field":  "suppressions:\n  - id: X\n    reason: x\n    namespace: y\n",
+	}
+	for name, data := range invalid {
+		if _, err := ParseSuppressions([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	suppressions, err := ParseSuppressions([]byte(suppressionFile))
+	if err != nil {
+		t.Fatalf("ParseSuppressions() error = %v", err)
+	}
+
+	details := []AnalysisDetail{
+		{ID: "POD_CRASHLOOP", Resource: collector.ResourceInfo{Kind: "Pod", Namespace: "batch", Name: "report-29871-abcde"}},
+		{ID: "POD_CRASHLOOP", Resource: collector.ResourceInfo{Kind: "Pod", Namespace: "shop", Name: "report-1"}},
+		{ID: "TLS_CERTIFICATE_EXPIRED", Resource: collector.ResourceInfo{Kind: "Ingress", Name: "web", Labels: map[string]string{"env": "dev"}}},
+		{ID: "TLS_CERTIFICATE_EXPIRED", Resource: collector.ResourceInfo{Kind: "Ingress", Name: "web", Labels: map[string]string{"env": "prod"}}},
+	}
+
+	// The date is inclusive
+	lastDay := time.Date(2026, 6, 30, 23, 0, 0, 0, time.UTC)
+	kept, suppressed := Suppress(details, suppressions, lastDay)
+	if len(suppressed) != 2 || suppressed[0].Resource.Namespace != "batch" || suppressed[1].Resource.Labels["env"] != "dev" {
+		t.Errorf("Unexpected suppressed findings: %+v", suppressed)
+	}
+	if len(kept) != 2 {
+		t.Errorf("Expected 2 findings to be kept, got %+v", kept)
+	}
+
+	// Once expired, the finding shows up again
+	kept, _ = Suppress(details, suppressions, lastDay.Add(2*time.Hour))
+	if len(kept) != 3 {
+		t.Errorf("Expected the expired suppression to stop applying, got %d kept", len(kept))
+	}
+	if !suppressions[0].Expired(lastDay.Add(2*time.Hour)) || suppressions[1].Expired(lastDay.AddDate(10, 0, 0)) {
+		t.Error("Unexpected expiry state")
+	}
+}
+
+func TestRegistry_Select(t *testing.T) {
+	registry := NewRegistry()
+
+	selected, err := registry.Select([]string{"TLSAnalyzer", "PodAnalyzer"}, nil)
+	if err != nil || strings.Join(selected, ",") != "PodAnalyzer,TLSAnalyzer" {
+		t.Errorf("Expected the selection in registration order, got %v, %v", selected, err)
+	}
+
+	selected, err = registry.Select(nil, []string{"DeploymentAnalyzer"})
+	if err != nil || len(selected) != len(registry.Names())-1 || containsName(selected, "DeploymentAnalyzer") {
+		t.Errorf("Expected every analyzer but the skipped one, got %v, %v", selected, err)
+	}
+
+	if _, err := registry.Select(nil, []string{"PodAnalyser"}); err == nil || !strings.Contains(err.Error(), `did you mean "PodAnalyzer"`) {
+		t.Errorf("Expected an unknown analyzer error with a suggestion, got %v", err)
+	}
+	if _, err := registry.Select([]string{"PodAnalyzer"}, []string{"PodAnalyzer"}); err == nil {
+		t.Error("Expected an empty selection to be rejected")
+	}
+}
+
+func TestEngine_Suppressions(t *testing.T) {
+	registry := &Registry{analyzers: make(map[string]Analyzer)}
+	registry.Register(findingAnalyzer("crash", 0))
+
+	suppressions := []Suppression{{ID: "CRASH", Resource: ResourceSelector{Name: "a"}, Reason: "accepted"}}
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{
+			{Resource: collector.ResourceInfo{Name: "a"}},
+			{Resource: collector.ResourceInfo{Name: "b"}},
+		},
+	}
+
+	report, err := NewEngine(registry, EngineOptions{Suppressions: suppressions}).Run(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Details) != 1 || report.Details[0].Resource.Name != "b" {
+		t.Errorf("Expected only the unsuppressed finding, got %+v", report.Details)
+	}
+	if len(report.Suppressed) != 1 || len(analysisCtx.Details) != 1 {
+		t.Errorf("Expected one suppressed finding, got %+v", report.Suppressed)
+	}
+}
@@ -0,0 +1,581 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/k8smed/k8smed/internal/logscan"
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// defaultExpiryWarningDays is how far ahead certificate expiry is reported by default
+const defaultExpiryWarningDays = 30
+
+// certificateInfo is the analyzer's view of a certificate summarized by the collector
+type certificateInfo struct {
+	Prefix               string // Status key prefix the fields were read from
+	Source               string // Where the certificate comes from, for messages
+	Secret               string // Secret holding the certificate, if any
+	Namespace            string
+	Key                  string
+	Mounted              bool // Mounted by the pod whose logs are analyzed
+	Type                 string
+	ParseError           string
+	KeyError             string
+	Subject              string
+	NotAfter             time.Time
+	ChainNotAfter        time.Time
+	ChainNotAfterSubject string
+	DNSNames             []string
+	IPAddresses          []string
+	Hosts                []string // Hosts the certificate is expected to serve
+	ChainError           string
+}
+
+// readCertificate reads the certificate fields recorded under prefix
+func readCertificate(status map[string]string, prefix string) (certificateInfo, bool) {
+	if _, ok := status[prefix+"certificates"]; !ok {
+		return certificateInfo{}, false
+	}
+	return certificateFields(status, prefix), true
+}
+
+// readBundle reads the CAs of a bundle recorded under prefix+"ca.N.". A bundle that
+// could not be parsed is returned as a single certificate carrying the parse error.
+func readBundle(status map[string]string, prefix string) ([]certificateInfo, bool) {
+	bundle, ok := readCertificate(status, prefix)
+	if !ok {
+		return nil, false
+	}
+	if bundle.ParseError != "" {
+		return []certificateInfo{bundle}, true
+	}
+
+	cas := make([]certificateInfo, 0)
+	for i := 0; ; i++ {
+		caPrefix := fmt.Sprintf("%sca.%d.", prefix, i)
+		if _, ok := status[caPrefix+"subject"]; !ok {
+			break
+		}
+		cas = append(cas, certificateFields(status, caPrefix))
+	}
+	return cas, true
+}
+
+// certificateFields reads the fields of a certificate recorded under prefix
+func certificateFields(status map[string]string, prefix string) certificateInfo {
+	cert := certificateInfo{
+		Prefix:               prefix,
+		Type:                 status[prefix+"type"],
+		ParseError:           status[prefix+"parseError"],
+		KeyError:             status[prefix+"keyError"],
+		Subject:              status[prefix+"subject"],
+		ChainNotAfterSubject: status[prefix+"chainNotAfterSubject"],
+		DNSNames:             nonEmptyList(status[prefix+"dnsNames"]),
+		IPAddresses:          nonEmptyList(status[prefix+"ipAddresses"]),
+		ChainError:           status[prefix+"chainError"],
+	}
+	cert.NotAfter, _ = time.Parse(time.RFC3339, status[prefix+"notAfter"])
+	cert.ChainNotAfter, _ = time.Parse(time.RFC3339, status[prefix+"chainNotAfter"])
+	return cert
+}
+
+// evidence returns the certificate's status entries for the given fields
+func (c certificateInfo) evidence(status map[string]string, fields ...string) []Evidence {
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, c.Prefix+field)
+	}
+	return statusEvidence(status, keys...)
+}
+
+// expiry returns the earliest expiry in the chain and the subject of that certificate
+func (c certificateInfo) expiry() (time.Time, string) {
+	if !c.ChainNotAfter.IsZero() && c.ChainNotAfter.Before(c.NotAfter) {
+		return c.ChainNotAfter, c.ChainNotAfterSubject
+	}
+	return c.NotAfter, c.Subject
+}
+
+// covers reports whether the certificate is valid for the host
+func (c certificateInfo) covers(host string) bool {
+	if net.ParseIP(host) != nil {
+		return matchesAny(c.IPAddresses, host)
+	}
+	for _, name := range c.DNSNames {
+		if hostMatches(name, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// TLSAnalyzer finds expired, expiring and mismatched certificates in Ingress TLS
+// secrets, webhook CA bundles and secrets mounted by pods with x509 errors in their logs
+type TLSAnalyzer struct {
+	// ExpiryWarningDays is how many days ahead of expiry to warn; 30 when unset
+	ExpiryWarningDays int
+}
+
+// Name implements the Analyzer interface
+func (a *TLSAnalyzer) Name() string {
+	return "TLSAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *TLSAnalyzer) Description() string {
+	return "Analyzes TLS certificates in Ingress secrets and webhook CA bundles for expiry, host coverage and broken chains"
+}
+
+// Analyze implements the Analyzer interface
+func (a *TLSAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	now := time.Now()
+	ingressCerts := make([]certificateInfo, 0)
+
+	for _, resource := range analysisCtx.Resources {
+		switch resource.Resource.Kind {
+		case "Ingress":
+			certs, ingressDetails := a.analyzeIngress(resource, now)
+			ingressCerts = append(ingressCerts, certs...)
+			details = append(details, ingressDetails...)
+		case "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration":
+			details = append(details, a.analyzeWebhooks(resource, now)...)
+		}
+	}
+
+	// Log failures are matched last so they can point at Ingress secrets too
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind == "Pod" && len(resource.Logs) > 0 {
+			details = append(details, a.analyzePodLogs(resource, ingressCerts, now)...)
+		}
+	}
+
+	return details, nil
+}
+
+// warningWindow returns how far ahead of expiry certificates are reported
+func (a *TLSAnalyzer) warningWindow() time.Duration {
+	days := a.ExpiryWarningDays
+	if days <= 0 {
+		days = defaultExpiryWarningDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// analyzeIngress checks the secrets of each TLS entry and returns their certificates
+// along with the problems found
+func (a *TLSAnalyzer) analyzeIngress(resource collector.ResourceData, now time.Time) ([]certificateInfo, []AnalysisDetail) {
+	namespace := resource.Resource.Namespace
+	certs := make([]certificateInfo, 0)
+	details := make([]AnalysisDetail, 0)
+
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("tls.%d.", i)
+		secret, ok := resource.Status[prefix+"secret"]
+		if !ok {
+			break
+		}
+		hosts := nonEmptyList(resource.Status[prefix+"hosts"])
+
+		if resource.Status[prefix+"exists"] == "false" {
+			details = append(details, AnalysisDetail{
+				ID:         "TLS_SECRET_NOT_FOUND",
+				Severity:   SeverityError,
+				Confidence: ConfidenceCertain,
+				Title:      "Ingress TLS secret not found",
+				Description: fmt.Sprintf("Ingress %s references TLS secret %s, which does not exist in namespace %s; the controller falls back to its default certificate",
+					resource.Resource.Name, secret, namespace),
+				Evidence: statusEvidence(resource.Status, prefix+"secret", prefix+"hosts", prefix+"exists"),
+				Resource: resource.Resource,
+				Cause:    &collector.ResourceInfo{Kind: "Secret", Name: secret, Namespace: namespace},
+				Remediation: []string{
+					"Create the secret with the certificate and key for " + strings.Join(hosts, ", "),
+					"If cert-manager issues the certificate, check the Certificate resource for errors",
+				},
+				RemediationCommands: []string{
+					"kubectl create secret tls " + secret + " -n " + namespace + " --cert=tls.crt --key=tls.key",
+					"kubectl get certificates -n " + namespace,
+				},
+			})
+			continue
+		}
+
+		cert, ok := readCertificate(resource.Status, prefix)
+		if !ok {
+			continue
+		}
+		cert.Source = fmt.Sprintf("TLS secret %s of Ingress %s", secret, resource.Resource.Name)
+		cert.Secret = secret
+		cert.Namespace = namespace
+		cert.Hosts = hosts
+		certs = append(certs, cert)
+
+		details = append(details, a.certificateDetails(resource, cert, now)...)
+	}
+
+	return certs, details
+}
+
+// analyzeWebhooks checks the CA bundle of each webhook in a configuration
+func (a *TLSAnalyzer) analyzeWebhooks(resource collector.ResourceData, now time.Time) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("webhook.%d.", i)
+		name, ok := resource.Status[prefix+"name"]
+		if !ok {
+			break
+		}
+
+		cas, ok := readBundle(resource.Status, prefix+"caBundle.")
+		if !ok {
+			continue
+		}
+
+		// While a CA is rotated the bundle holds the old and the new one; certificates
+		// signed by the CA that stays valid keep being trusted
+		var valid *certificateInfo
+		for i := range cas {
+			if !cas[i].NotAfter.IsZero() && cas[i].NotAfter.Sub(now) > a.warningWindow() {
+				valid = &cas[i]
+				break
+			}
+		}
+
+		for _, ca := range cas {
+			ca.Source = fmt.Sprintf("caBundle of webhook %s", name)
+			if len(cas) > 1 {
+				ca.Source = fmt.Sprintf("CA %s in the caBundle of webhook %s", ca.Subject, name)
+			}
+			for _, detail := range a.certificateDetails(resource, ca, now) {
+				if valid != nil && (detail.ID == "TLS_CERTIFICATE_EXPIRED" || detail.ID == "TLS_CERTIFICATE_EXPIRING") {
+					detail.Severity = SeverityInfo
+					detail.Confidence = ConfidenceMedium
+					detail.Description += fmt.Sprintf("; the bundle also holds CA %s, valid until %s, which keeps verifying certificates it signed",
+						valid.Subject, valid.NotAfter.Format("2006-01-02"))
+					detail.Remediation = []string{
+						"Once no serving certificate is signed by the old CA, remove it from the bundle",
+					}
+				}
+				details = append(details, detail)
+			}
+		}
+	}
+
+	return details
+}
+
+// certificateDetails reports the problems of a single certificate
+func (a *TLSAnalyzer) certificateDetails(resource collector.ResourceData, cert certificateInfo, now time.Time) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+
+	if cert.ParseError != "" {
+		return append(details, AnalysisDetail{
+			ID:          "TLS_CERTIFICATE_INVALID",
+			Severity:    SeverityError,
+			Confidence:  ConfidenceCertain,
+			Title:       "Invalid TLS certificate",
+			Description: fmt.Sprintf("The %s cannot be parsed: %s", cert.Source, cert.ParseError),
+			Evidence:    cert.evidence(resource.Status, "parseError"),
+			Resource:    resource.Resource,
+			Remediation: []string{
+				"Make sure the certificate is PEM encoded and base64 encoded once in the object",
+			},
+		})
+	}
+
+	if cert.Secret != "" && cert.Type != "" && cert.Type != "kubernetes.io/tls" {
+		details = append(details, AnalysisDetail{
+			ID:          "TLS_SECRET_WRONG_TYPE",
+			Severity:    SeverityWarning,
+			Confidence:  ConfidenceCertain,
+			Title:       "TLS secret has unexpected type",
+			Description: fmt.Sprintf("The %s has type %s instead of kubernetes.io/tls", cert.Source, cert.Type),
+			Evidence:    cert.evidence(resource.Status, "type"),
+			Resource:    resource.Resource,
+			Remediation: []string{
+				"Recreate the secret with kubectl create secret tls so ingress controllers accept it",
+			},
+		})
+	}
+
+	if cert.KeyError != "" {
+		details = append(details, AnalysisDetail{
+			ID:          "TLS_KEY_MISMATCH",
+			Severity:    SeverityError,
+			Confidence:  ConfidenceCertain,
+			Title:       "TLS private key does not match certificate",
+			Description: fmt.Sprintf("The %s has a key that does not belong to its certificate: %s", cert.Source, cert.KeyError),
+			Evidence:    cert.evidence(resource.Status, "keyError"),
+			Resource:    resource.Resource,
+			Remediation: []string{
+				"Store the private key that was used to request the certificate in tls.key",
+			},
+		})
+	}
+
+	if detail, ok := a.expiryDetail(resource, cert, now); ok {
+		details = append(details, detail)
+	}
+
+	uncovered := make([]string, 0)
+	for _, host := range cert.Hosts {
+		if !cert.covers(host) {
+			uncovered = append(uncovered, host)
+		}
+	}
+	if len(uncovered) > 0 {
+		details = append(details, AnalysisDetail{
+			ID:         "TLS_HOST_NOT_COVERED",
+			Severity:   SeverityError,
+			Confidence: ConfidenceCertain,
+			Title:      "Certificate does not cover host",
+			Description: fmt.Sprintf("The %s is valid for %s but serves %s; clients will reject the connection with a hostname mismatch",
+				cert.Source, strings.Join(append(cert.DNSNames, cert.IPAddresses...), ", "), strings.Join(uncovered, ", ")),
+			Evidence: cert.evidence(resource.Status, "hosts", "dnsNames", "ipAddresses"),
+			Resource: resource.Resource,
+			Remediation: []string{
+				"Reissue the certificate with " + strings.Join(uncovered, ", ") + " in its subject alternative names",
+				"Or move the hosts to a TLS entry whose secret covers them",
+			},
+		})
+	}
+
+	if cert.ChainError != "" {
+		details = append(details, AnalysisDetail{
+			ID:          "TLS_CHAIN_BROKEN",
+			Severity:    SeverityWarning,
+			Confidence:  ConfidenceCertain,
+			Title:       "Broken certificate chain",
+			Description: fmt.Sprintf("The %s has a chain in the wrong order or with unrelated certificates: %s", cert.Source, cert.ChainError),
+			Evidence:    cert.evidence(resource.Status, "chainError"),
+			Resource:    resource.Resource,
+			Remediation: []string{
+				"Order the bundle leaf first, followed by each intermediate up to the root",
+				"Remove certificates that do not belong to the chain",
+			},
+		})
+	}
+
+	return details
+}
+
+// expiryDetail reports a certificate that has expired or expires within the warning window
+func (a *TLSAnalyzer) expiryDetail(resource collector.ResourceData, cert certificateInfo, now time.Time) (AnalysisDetail, bool) {
+	notAfter, subject := cert.expiry()
+	if notAfter.IsZero() || notAfter.Sub(now) > a.warningWindow() {
+		return AnalysisDetail{}, false
+	}
+
+	which := "certificate"
+	if subject != cert.Subject {
+		which = "chain certificate " + subject
+	}
+
+	detail := AnalysisDetail{
+		Confidence: ConfidenceCertain,
+		Evidence:   cert.evidence(resource.Status, "subject", "notAfter", "chainNotAfterSubject", "chainNotAfter"),
+		Resource:   resource.Resource,
+		Remediation: []string{
+			"Renew the certificate and update the secret or bundle",
+			"If cert-manager manages it, check why the renewal did not happen",
+		},
+	}
+	if cert.Secret != "" {
+		detail.RemediationCommands = []string{
+			"kubectl get certificates -n " + cert.Namespace,
+			"kubectl create secret tls " + cert.Secret + " -n " + cert.Namespace +
+				" --cert=tls.crt --key=tls.key --dry-run=client -o yaml | kubectl apply -f -",
+		}
+	}
+
+	if !notAfter.After(now) {
+		detail.ID = "TLS_CERTIFICATE_EXPIRED"
+		detail.Severity = SeverityError
+		detail.Title = "TLS certificate expired"
+		detail.Description = fmt.Sprintf("The %s of the %s expired on %s",
+			which, cert.Source, notAfter.Format("2006-01-02"))
+	} else {
+		detail.ID = "TLS_CERTIFICATE_EXPIRING"
+		detail.Severity = SeverityWarning
+		detail.Title = "TLS certificate expiring soon"
+		detail.Description = fmt.Sprintf("The %s of the %s expires on %s, in %d days",
+			which, cert.Source, notAfter.Format("2006-01-02"), int(notAfter.Sub(now).Hours()/24))
+	}
+	return detail, true
+}
+
+// analyzePodLogs traces x509 errors in the pod's logs to the secret that causes them
+func (a *TLSAnalyzer) analyzePodLogs(resource collector.ResourceData, ingressCerts []certificateInfo, now time.Time) []AnalysisDetail {
+	details := make([]AnalysisDetail, 0)
+	failures := logscan.ExtractX509Failures(strings.Join(resource.Logs, "\n"))
+	if len(failures) == 0 {
+		return details
+	}
+
+	// Certificates mounted by the pod are candidates alongside the Ingress secrets
+	candidates := make([]certificateInfo, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("tlsSecret.%d.", i)
+		name, ok := resource.Status[prefix+"name"]
+		if !ok {
+			break
+		}
+		cert, ok := readCertificate(resource.Status, prefix)
+		if !ok {
+			continue
+		}
+		cert.Source = fmt.Sprintf("%s in secret %s mounted by the pod", resource.Status[prefix+"key"], name)
+		cert.Secret = name
+		cert.Namespace = resource.Resource.Namespace
+		cert.Key = resource.Status[prefix+"key"]
+		cert.Mounted = true
+		candidates = append(candidates, cert)
+	}
+	candidates = append(candidates, ingressCerts...)
+
+	seen := make(map[string]bool)
+	for _, failure := range failures {
+		key := string(failure.Kind) + "/" + failure.Host
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		details = append(details, a.logFailureDetail(resource, failure, candidates, now))
+	}
+
+	return details
+}
+
+// logFailureDetail builds the finding for an x509 error, naming the secret it comes from
+// when one of the candidates explains it
+func (a *TLSAnalyzer) logFailureDetail(resource collector.ResourceData, failure logscan.X509Failure, candidates []certificateInfo, now time.Time) AnalysisDetail {
+	detail := AnalysisDetail{
+		Severity:   SeverityError,
+		Confidence: ConfidenceHigh,
+		Evidence:   []Evidence{logEvidence("", failure.Line)},
+		Resource:   resource.Resource,
+	}
+
+	target := "a server"
+	if failure.Host != "" {
+		target = failure.Host
+	}
+
+	var culprit *certificateInfo
+	culpritServesHost := false
+	for i := range candidates {
+		cert := &candidates[i]
+		servesHost := failure.Host != "" && (cert.covers(failure.Host) || matchesAny(cert.Hosts, failure.Host))
+
+		switch failure.Kind {
+		case logscan.X509Expired:
+			// Only a certificate for the host or one the pod mounts can explain the failure;
+			// one for the host is preferred over a mounted one
+			notAfter, _ := cert.expiry()
+			if notAfter.IsZero() || notAfter.After(now) || !(servesHost || cert.Mounted) {
+				continue
+			}
+			if culprit == nil || (servesHost && !culpritServesHost) {
+				culprit = cert
+				culpritServesHost = servesHost
+			}
+		case logscan.X509HostnameMismatch:
+			if len(failure.ValidFor) > 0 && sameNames(cert.DNSNames, failure.ValidFor) {
+				culprit = cert
+			}
+		case logscan.X509UnknownAuthority:
+			if cert.Key == "ca.crt" && culprit == nil {
+				culprit = cert
+			}
+		}
+	}
+
+	switch failure.Kind {
+	case logscan.X509Expired:
+		detail.ID = "TLS_CONNECTION_CERTIFICATE_EXPIRED"
+		detail.Title = "TLS connection failed: certificate expired"
+		detail.Description = fmt.Sprintf("Pod %s rejected the certificate of %s because it has expired or is not yet valid",
+			resource.Resource.Name, target)
+		detail.Remediation = []string{
+			"Renew the certificate served by " + target,
+			"Check that the node clocks are synchronized",
+		}
+	case logscan.X509HostnameMismatch:
+		detail.ID = "TLS_CONNECTION_HOSTNAME_MISMATCH"
+		detail.Title = "TLS connection failed: hostname mismatch"
+		detail.Description = fmt.Sprintf("Pod %s connected to %s, but the certificate is only valid for %s",
+			resource.Resource.Name, target, strings.Join(failure.ValidFor, ", "))
+		detail.Remediation = []string{
+			"Connect using a name the certificate covers",
+			"Or reissue the certificate with " + target + " in its subject alternative names",
+		}
+	default:
+		detail.ID = "TLS_CONNECTION_UNKNOWN_AUTHORITY"
+		detail.Title = "TLS connection failed: unknown certificate authority"
+		detail.Description = fmt.Sprintf("Pod %s does not trust the certificate authority that signed the certificate of %s",
+			resource.Resource.Name, target)
+		detail.Remediation = []string{
+			"Mount the issuing CA certificate and point the client at it",
+			"Make sure the server sends its intermediate certificates",
+		}
+	}
+
+	if culprit != nil {
+		// A mounted certificate may be used for other connections than the failing one
+		if failure.Kind == logscan.X509Expired && !culpritServesHost {
+			detail.Confidence = ConfidenceMedium
+		}
+		detail.Cause = &collector.ResourceInfo{Kind: "Secret", Name: culprit.Secret, Namespace: culprit.Namespace}
+		notAfter, _ := culprit.expiry()
+		detail.Description += fmt.Sprintf(". The %s matches this failure", culprit.Source)
+		if failure.Kind == logscan.X509Expired {
+			detail.Description += fmt.Sprintf(" (expired %s)", notAfter.Format("2006-01-02"))
+		}
+		detail.RemediationCommands = []string{
+			"kubectl describe secret " + culprit.Secret + " -n " + culprit.Namespace,
+		}
+	}
+	detail.Description += ": " + failure.Line
+
+	return detail
+}
+
+// hostMatches reports whether a certificate name, possibly a wildcard, matches the host
+func hostMatches(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSuffix(pattern, "."))
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if pattern == host {
+		return true
+	}
+
+	// A wildcard covers exactly one label
+	if strings.HasPrefix(pattern, "*.") {
+		dot := strings.Index(host, ".")
+		return dot > 0 && host[dot:] == pattern[1:]
+	}
+	return false
+}
+
+// sameNames reports whether two name lists contain the same names in any order
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[string]int)
+	for _, name := range a {
+		counts[strings.ToLower(name)]++
+	}
+	for _, name := range b {
+		key := strings.ToLower(name)
+		if counts[key] == 0 {
+			return false
+		}
+		counts[key]--
+	}
+	return true
+}
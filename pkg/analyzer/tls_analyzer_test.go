@@ -0,0 +1,206 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestTLSAnalyzer_IngressCertificate(t *testing.T) {
+	now := time.Now()
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Ingress", Name: "shop", Namespace: "web"},
+			Status: map[string]string{
+				"hosts":                      "shop.example.com,api.example.com",
+				"tls.0.secret":               "shop-tls",
+				"tls.0.hosts":                "shop.example.com,api.example.com",
+				"tls.0.exists":               "true",
+				"tls.0.type":                 "kubernetes.io/tls",
+				"tls.0.certificates":         "2",
+				"tls.0.subject":              "CN=shop.example.com",
+				"tls.0.notAfter":             now.Add(10 * 24 * time.Hour).UTC().Format(time.RFC3339),
+				"tls.0.chainNotAfter":        now.Add(10 * 24 * time.Hour).UTC().Format(time.RFC3339),
+				"tls.0.chainNotAfterSubject": "CN=shop.example.com",
+				"tls.0.dnsNames":             "shop.example.com,*.shop.example.com",
+				"tls.1.secret":               "legacy-tls",
+				"tls.1.hosts":                "legacy.example.com",
+				"tls.1.exists":               "false",
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&TLSAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	titles := make(map[string]AnalysisDetail)
+	for _, detail := range details {
+		titles[detail.Title] = detail
+	}
+
+	if _, ok := titles["TLS certificate expiring soon"]; !ok {
+		t.Errorf("Expected an expiry warning, got %v", details)
+	}
+	uncovered, ok := titles["Certificate does not cover host"]
+	if !ok {
+		t.Fatalf("Expected a host coverage finding, got %v", details)
+	}
+	if !strings.Contains(uncovered.Description, "serves api.example.com") {
+		t.Errorf("Expected api.example.com to be reported, got '%s'", uncovered.Description)
+	}
+	if _, ok := titles["Ingress TLS secret not found"]; !ok {
+		t.Errorf("Expected a missing secret finding, got %v", details)
+	}
+
+	// A shorter warning window leaves the certificate alone
+	details, err = (&TLSAnalyzer{ExpiryWarningDays: 7}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	for _, detail := range details {
+		if detail.Title == "TLS certificate expiring soon" {
+			t.Errorf("Expected no expiry warning with a 7 day window")
+		}
+	}
+}
+
+func TestTLSAnalyzer_LogCorrelation(t *testing.T) {
+	expired := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "client-1", Namespace: "web"},
+			Status: map[string]string{
+				"tlsSecret.0.name":          "upstream-ca",
+				"tlsSecret.0.key":           "ca.crt",
+				"tlsSecret.0.certificates":  "1",
+				"tlsSecret.0.subject":       "CN=Internal CA",
+				"tlsSecret.0.notAfter":      expired,
+				"tlsSecret.0.chainNotAfter": expired,
+			},
+			Logs: []string{`Get "https://orders.internal:8443/v1": x509: certificate has expired or is not yet valid`},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&TLSAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 1 {
+		t.Fatalf("Expected 1 detail, got %+v", details)
+	}
+	detail := details[0]
+	if detail.Title != "TLS connection failed: certificate expired" {
+		t.Errorf("Unexpected title '%s'", detail.Title)
+	}
+	if !strings.Contains(detail.Description, "secret upstream-ca") || !strings.Contains(detail.Description, "orders.internal") {
+		t.Errorf("Expected the host and secret in the description, got '%s'", detail.Description)
+	}
+	if detail.Cause == nil || detail.Cause.Kind != "Secret" || detail.Cause.Name != "upstream-ca" || detail.Cause.Namespace != "web" {
+		t.Errorf("Expected the mounted secret as the cause, got %+v", detail.Cause)
+	}
+}
+
+func TestTLSAnalyzer_LogCorrelationIngressCertificate(t *testing.T) {
+	expired := time.Now().Add(-48 * time.Hour).UTC().Format(time.RFC3339)
+	ingress := func(name, namespace, host string) collector.ResourceData {
+		return collector.ResourceData{
+			Resource: collector.ResourceInfo{Kind: "Ingress", Name: name, Namespace: namespace},
+			Status: map[string]string{
+				"tls.0.secret":       name + "-tls",
+				"tls.0.hosts":        host,
+				"tls.0.exists":       "true",
+				"tls.0.type":         "kubernetes.io/tls",
+				"tls.0.certificates": "1",
+				"tls.0.subject":      "CN=" + host,
+				"tls.0.notAfter":     expired,
+				"tls.0.dnsNames":     host,
+			},
+		}
+	}
+	analyze := func(line string) AnalysisDetail {
+		details, err := (&TLSAnalyzer{}).Analyze(context.Background(), &AnalysisContext{
+			Resources: []collector.ResourceData{
+				ingress("blog", "marketing", "blog.example.com"),
+				ingress("shop", "web", "shop.example.com"),
+				{
+					Resource: collector.ResourceInfo{Kind: "Pod", Name: "client-1", Namespace: "web"},
+					Logs:     []string{line},
+				},
+			},
+		})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		for _, detail := range details {
+			if detail.ID == "TLS_CONNECTION_CERTIFICATE_EXPIRED" {
+				return detail
+			}
+		}
+		t.Fatalf("Expected a connection failure, got %+v", details)
+		return AnalysisDetail{}
+	}
+
+	// The certificate serving the host is blamed, not the first expired one
+	detail := analyze(`Get "https://shop.example.com/api": x509: certificate has expired or is not yet valid`)
+	if detail.Cause == nil || detail.Cause.Name != "shop-tls" || detail.Confidence != ConfidenceHigh {
+		t.Errorf("Expected shop-tls to be blamed, got %+v", detail.Cause)
+	}
+
+	// Without a host, an unrelated Ingress certificate explains nothing
+	detail = analyze(`x509: certificate has expired or is not yet valid`)
+	if detail.Cause != nil || strings.Contains(detail.Description, "matches this failure") {
+		t.Errorf("Expected no certificate to be blamed, got %+v", detail)
+	}
+}
+
+func TestTLSAnalyzer_WebhookBundleRotation(t *testing.T) {
+	now := time.Now()
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "ValidatingWebhookConfiguration", Name: "policy"},
+			Status: map[string]string{
+				"webhook.0.name":                   "validate.policy.example.com",
+				"webhook.0.caBundle.certificates":  "2",
+				"webhook.0.caBundle.ca.0.subject":  "CN=policy-ca-2024",
+				"webhook.0.caBundle.ca.0.notAfter": now.Add(-24 * time.Hour).UTC().Format(time.RFC3339),
+				"webhook.0.caBundle.ca.1.subject":  "CN=policy-ca-2025",
+				"webhook.0.caBundle.ca.1.notAfter": now.Add(365 * 24 * time.Hour).UTC().Format(time.RFC3339),
+				"webhook.1.name":                   "mutate.policy.example.com",
+				"webhook.1.caBundle.certificates":  "1",
+				"webhook.1.caBundle.ca.0.subject":  "CN=policy-ca-2024",
+				"webhook.1.caBundle.ca.0.notAfter": now.Add(-24 * time.Hour).UTC().Format(time.RFC3339),
+			},
+		}},
+	}
+
+	details, err := (&TLSAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	// Two independent CAs are not a broken chain, and the old one only matters where it
+	// is the only CA
+	if len(details) != 2 {
+		t.Fatalf("Expected the expired CA of each webhook, got %+v", details)
+	}
+	for _, detail := range details {
+		if detail.ID != "TLS_CERTIFICATE_EXPIRED" {
+			t.Errorf("Unexpected finding %s: %s", detail.ID, detail.Description)
+		}
+	}
+	if !strings.Contains(details[0].Description, "CA CN=policy-ca-2024 in the caBundle of webhook validate.policy.example.com") ||
+		!strings.Contains(details[0].Description, "also holds CA CN=policy-ca-2025") || details[0].Severity != SeverityInfo {
+		t.Errorf("Expected the rotated CA to be reported as informational, got %+v", details[0])
+	}
+	if details[1].Severity != SeverityError || !strings.Contains(details[1].Description, "caBundle of webhook mutate.policy.example.com") {
+		t.Errorf("Expected the only CA of the bundle to be an error, got %+v", details[1])
+	}
+}
@@ -0,0 +1,134 @@
+package analyzer
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// FindingTracker follows the findings of resources that are analyzed again as they
+// change, so that only the findings that appeared or were resolved since the previous
+// analysis are reported. A finding is identified by its ID, its resource and what it is
+// about: the container and cause it names and its first piece of evidence. A finding
+// that stays but changes its description, such as a growing restart count, is not
+// reported again.
+type FindingTracker struct {
+	findings map[string]AnalysisDetail
+}
+
+// NewFindingTracker creates a tracker without findings
+func NewFindingTracker() *FindingTracker {
+	return &FindingTracker{
+		findings: make(map[string]AnalysisDetail),
+	}
+}
+
+// Update replaces the findings of the analyzed resources with the new details and
+// returns the findings that were not there before and those that are gone. Resources
+// that were deleted are passed with no details, which resolves all of their findings.
+// Details about resources outside the analyzed ones count as analyzed as well.
+func (t *FindingTracker) Update(analyzed []collector.ResourceInfo, details []AnalysisDetail) (appeared, resolved []AnalysisDetail) {
+	scope := make(map[string]bool)
+	for _, resource := range analyzed {
+		scope[resourceKey(resource)] = true
+	}
+	for _, detail := range details {
+		scope[resourceKey(detail.Resource)] = true
+	}
+
+	current := make(map[string]AnalysisDetail)
+	appeared = make([]AnalysisDetail, 0)
+	for _, detail := range details {
+		key := findingKey(detail)
+		if _, ok := current[key]; ok {
+			continue
+		}
+		current[key] = detail
+		if _, ok := t.findings[key]; !ok {
+			appeared = append(appeared, detail)
+		}
+	}
+
+	resolved = make([]AnalysisDetail, 0)
+	for key, detail := range t.findings {
+		if !scope[resourceKey(detail.Resource)] {
+			continue
+		}
+		if _, ok := current[key]; !ok {
+			resolved = append(resolved, detail)
+			delete(t.findings, key)
+		}
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return findingKey(resolved[i]) < findingKey(resolved[j])
+	})
+
+	for key, detail := range current {
+		t.findings[key] = detail
+	}
+	return appeared, resolved
+}
+
+// Count returns the number of findings currently tracked
+func (t *FindingTracker) Count() int {
+	return len(t.findings)
+}
+
+// containerKeyRegex matches the container a status key is about, such as "container.1"
+// in "container.1.state"
+var containerKeyRegex = regexp.MustCompile(`^((?:init|ephemeral)?[cC]ontainer\.\d+)\.`)
+
+// digitsRegex matches the numbers masked in evidence, such as times, counts and ports
+var digitsRegex = regexp.MustCompile(`[0-9]+`)
+
+// findingKey identifies a finding across analyses. The container, cause and evidence
+// tell apart findings with one ID about several containers, references, hosts or
+// requests of a resource.
+func findingKey(detail AnalysisDetail) string {
+	key := resourceKey(detail.Resource) + "/" + detail.ID
+	if container := findingContainer(detail); container != "" {
+		key += "/" + container
+	}
+	if detail.Cause != nil {
+		key += "/" + resourceKey(*detail.Cause)
+	}
+	if subject := findingSubject(detail); subject != "" {
+		key += "/" + subject
+	}
+	return key
+}
+
+// findingSubject returns what the first piece of evidence identifies: the status key or
+// manifest path, or the event or log line with its numbers masked so the same line
+// seen at another time or count keeps its identity
+func findingSubject(detail AnalysisDetail) string {
+	if len(detail.Evidence) == 0 {
+		return ""
+	}
+	evidence := detail.Evidence[0]
+	switch evidence.Kind {
+	case EvidenceStatus, EvidenceManifest:
+		return evidence.Key
+	default:
+		return digitsRegex.ReplaceAllString(evidence.Value, "#")
+	}
+}
+
+// findingContainer returns the container the finding's evidence names: the container
+// of a log excerpt or the container.N prefix of a status key
+func findingContainer(detail AnalysisDetail) string {
+	for _, evidence := range detail.Evidence {
+		switch evidence.Kind {
+		case EvidenceLog:
+			if evidence.Key != "" {
+				return evidence.Key
+			}
+		case EvidenceStatus:
+			if m := containerKeyRegex.FindStringSubmatch(evidence.Key); m != nil {
+				return m[1]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestFindingTracker(t *testing.T) {
+	web := collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "prod"}
+	api := collector.ResourceInfo{Kind: "Pod", Name: "api-1", Namespace: "prod"}
+	finding := func(id string, resource collector.ResourceInfo, description string) AnalysisDetail {
+		return AnalysisDetail{ID: id, Resource: resource, Description: description}
+	}
+	ids := func(details []AnalysisDetail) string {
+		names := make([]string, 0, len(details))
+		for _, detail := range details {
+			names = append(names, detail.ID+"/"+detail.Resource.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	tracker := NewFindingTracker()
+
+	appeared, resolved := tracker.Update([]collector.ResourceInfo{web, api}, []AnalysisDetail{
+		finding("POD_CRASHLOOP", web, "restarted 3 times"),
+		finding("POD_OOMKILLED", web, "killed"),
+		finding("POD_IMAGE_PULL", api, "not found"),
+	})
+	if ids(appeared) != "POD_CRASHLOOP/web-1,POD_OOMKILLED/web-1,POD_IMAGE_PULL/api-1" || len(resolved) != 0 {
+		t.Fatalf("Unexpected first update: appeared %s, resolved %s", ids(appeared), ids(resolved))
+	}
+
+	// Only web is analyzed again, so the finding of api stays
+	appeared, resolved = tracker.Update([]collector.ResourceInfo{web}, []AnalysisDetail{
+		finding("POD_CRASHLOOP", web, "restarted 4 times"),
+		finding("POD_NOT_READY", web, "not ready"),
+	})
+	if ids(appeared) != "POD_NOT_READY/web-1" || ids(resolved) != "POD_OOMKILLED/web-1" {
+		t.Errorf("Unexpected second update: appeared %s, resolved %s", ids(appeared), ids(resolved))
+	}
+	if tracker.Count() != 3 {
+		t.Errorf("Expected 3 tracked findings, got %d", tracker.Count())
+	}
+
+	// A deleted resource resolves all of its findings
+	appeared, resolved = tracker.Update([]collector.ResourceInfo{web}, nil)
+	if len(appeared) != 0 || ids(resolved) != "POD_CRASHLOOP/web-1,POD_NOT_READY/web-1" {
+		t.Errorf("Unexpected update after deletion: appeared %s, resolved %s", ids(appeared), ids(resolved))
+	}
+
+	// Findings about resources that were not analyzed still count as analyzed
+	service := collector.ResourceInfo{Kind: "Service", Name: "web", Namespace: "prod"}
+	appeared, _ = tracker.Update([]collector.ResourceInfo{web}, []AnalysisDetail{finding("SERVICE_NO_ENDPOINTS", service, "")})
+	if ids(appeared) != "SERVICE_NO_ENDPOINTS/web" {
+		t.Errorf("Unexpected findings: %s", ids(appeared))
+	}
+	_, resolved = tracker.Update([]collector.ResourceInfo{service}, nil)
+	if ids(resolved) != "SERVICE_NO_ENDPOINTS/web" {
+		t.Errorf("Expected the service finding to be resolved, got %s", ids(resolved))
+	}
+}
+
+func TestFindingTracker_Containers(t *testing.T) {
+	web := collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "prod"}
+	crashLoop := func(container string) AnalysisDetail {
+		return AnalysisDetail{
+			ID:       "POD_CRASHLOOP",
+			Resource: web,
+			Evidence: []Evidence{{Kind: EvidenceStatus, Key: container + ".reason", Value: "CrashLoopBackOff"}},
+		}
+	}
+	logMatch := func(container string) AnalysisDetail {
+		return AnalysisDetail{ID: "LOG_GO_PANIC", Resource: web, Evidence: []Evidence{logEvidence(container, "panic: boom")}}
+	}
+
+	tracker := NewFindingTracker()
+
+	// The same finding in two containers is tracked twice
+	appeared, _ := tracker.Update([]collector.ResourceInfo{web}, []AnalysisDetail{
+		crashLoop("container.0"), crashLoop("container.1"), logMatch("app"), logMatch("proxy"),
+	})
+	if len(appeared) != 4 || tracker.Count() != 4 {
+		t.Fatalf("Expected 4 findings, got %d appeared and %d tracked", len(appeared), tracker.Count())
+	}
+
+	// One container recovers
+	appeared, resolved := tracker.Update([]collector.ResourceInfo{web}, []AnalysisDetail{
+		crashLoop("container.0"), logMatch("app"),
+	})
+	if len(appeared) != 0 || len(resolved) != 2 ||
+		findingContainer(resolved[0]) != "proxy" || findingContainer(resolved[1]) != "container.1" {
+		t.Errorf("Expected the findings of the second container to be resolved, got appeared %+v, resolved %+v", appeared, resolved)
+	}
+}
+
+func TestFindingTracker_References(t *testing.T) {
+	pod := func(configExists, secretExists string) []collector.ResourceData {
+		return []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "prod"},
+			Status: map[string]string{
+				"phase":                 "Pending",
+				"configRef.0.kind":      "ConfigMap",
+				"configRef.0.name":      "web-config",
+				"configRef.0.source":    "envFrom",
+				"configRef.0.container": "app",
+				"configRef.0.exists":    configExists,
+				"configRef.1.kind":      "ConfigMap",
+				"configRef.1.name":      "feature-flags",
+				"configRef.1.source":    "envFrom",
+				"configRef.1.container": "app",
+				"configRef.1.exists":    secretExists,
+			},
+		}}
+	}
+	analyze := func(resources []collector.ResourceData) []AnalysisDetail {
+		details, err := (&ConfigReferenceAnalyzer{}).Analyze(context.Background(), &AnalysisContext{Resources: resources})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		return details
+	}
+	causes := func(details []AnalysisDetail) string {
+		names := make([]string, 0, len(details))
+		for _, detail := range details {
+			if detail.Cause != nil {
+				names = append(names, detail.ID+"/"+detail.Cause.Name)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	analyzed := []collector.ResourceInfo{{Kind: "Pod", Name: "web-1", Namespace: "prod"}}
+
+	tracker := NewFindingTracker()
+
+	// Both references are missing
+	appeared, _ := tracker.Update(analyzed, analyze(pod("false", "false")))
+	if causes(appeared) != "CONFIG_REF_MISSING/web-config,CONFIG_REF_MISSING/feature-flags" {
+		t.Fatalf("Expected both missing references, got %s", causes(appeared))
+	}
+
+	// One is created
+	appeared, resolved := tracker.Update(analyzed, analyze(pod("true", "false")))
+	if len(appeared) != 0 || causes(resolved) != "CONFIG_REF_MISSING/web-config" {
+		t.Errorf("Expected web-config to be resolved, got appeared %s, resolved %s", causes(appeared), causes(resolved))
+	}
+
+	// Then the other
+	appeared, resolved = tracker.Update(analyzed, analyze(pod("true", "true")))
+	if len(appeared) != 0 || causes(resolved) != "CONFIG_REF_MISSING/feature-flags" || tracker.Count() != 0 {
+		t.Errorf("Expected feature-flags to be resolved, got appeared %s, resolved %s", causes(appeared), causes(resolved))
+	}
+}
+
+func TestFindingTracker_MaskedEvidence(t *testing.T) {
+	web := collector.ResourceInfo{Kind: "Pod", Name: "web-1", Namespace: "prod"}
+	forbidden := func(line string) AnalysisDetail {
+		return AnalysisDetail{ID: "RBAC_PERMISSION_MISSING", Resource: web, Evidence: []Evidence{logEvidence("", line)}}
+	}
+
+	tracker := NewFindingTracker()
+	appeared, _ := tracker.Update([]collector.ResourceInfo{web}, []AnalysisDetail{
+		forbidden(`10:00:01 pods is forbidden: cannot list resource "pods"`),
+		forbidden(`10:00:02 secrets "db" is forbidden: cannot get resource "secrets"`),
+	})
+	if len(appeared) != 2 {
+		t.Fatalf("Expected two requests to be told apart, got %d", len(appeared))
+	}
+
+	// The same lines at a later time are the same findings
+	appeared, resolved := tracker.Update([]collector.ResourceInfo{web}, []AnalysisDetail{
+		forbidden(`10:05:41 pods is forbidden: cannot list resource "pods"`),
+		forbidden(`10:05:42 secrets "db" is forbidden: cannot get resource "secrets"`),
+	})
+	if len(appeared) != 0 || len(resolved) != 0 {
+		t.Errorf("Expected no change, got %d appeared and %d resolved", len(appeared), len(resolved))
+	}
+}
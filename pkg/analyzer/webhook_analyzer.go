@@ -0,0 +1,448 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// WebhookFailureKind classifies a failed admission webhook call
+type WebhookFailureKind string
+
+// Kinds of admission webhook failures
+const (
+	WebhookUnreachable     WebhookFailureKind = "Unreachable"
+	WebhookTimeout         WebhookFailureKind = "Timeout"
+	WebhookNoEndpoints     WebhookFailureKind = "NoEndpoints"
+	WebhookServiceNotFound WebhookFailureKind = "ServiceNotFound"
+	WebhookTLS             WebhookFailureKind = "TLS"
+	WebhookDenied          WebhookFailureKind = "Denied"
+	WebhookOther           WebhookFailureKind = "Other"
+)
+
+// WebhookCallFailure is an admission webhook error found in an event or log line
+type WebhookCallFailure struct {
+	Webhook string
+	Kind    WebhookFailureKind
+	Message string
+	// Object is the "Kind namespace/name" whose create was rejected, when known
+	Object string
+	// Line is the event or log line the failure was found in
+	Line string
+	// Source says whether Line is an event or a log line; set by the analyzer
+	Source EvidenceKind
+}
+
+// maxWebhookEvidence caps the failure lines attached to a finding
+const maxWebhookEvidence = 5
+
+var (
+	// webhookCallRegex matches `failed calling webhook "name": reason`
+	webhookCallRegex = regexp.MustCompile(`failed calling webhook "([^"]+)": (.*)`)
+
+	// webhookDeniedRegex matches `admission webhook "name" denied the request: reason`
+	webhookDeniedRegex = regexp.MustCompile(`admission webhook "([^"]+)" denied the request:? (.*)`)
+
+	// failedCreateObjectRegex extracts the object from events formatted by the webhook collector
+	failedCreateObjectRegex = regexp.MustCompile(`FailedCreate (\w+ [^:\s]+):`)
+)
+
+// ParseWebhookFailures finds admission webhook errors in the text, one per line at most
+func ParseWebhookFailures(text string) []WebhookCallFailure {
+	failures := make([]WebhookCallFailure, 0)
+	for _, line := range strings.Split(text, "\n") {
+		var failure WebhookCallFailure
+		if m := webhookCallRegex.FindStringSubmatch(line); m != nil {
+			failure = WebhookCallFailure{Webhook: m[1], Message: eventCountRegex.ReplaceAllString(m[2], "")}
+			failure.Kind = classifyWebhookFailure(failure.Message)
+		} else if m := webhookDeniedRegex.FindStringSubmatch(line); m != nil {
+			failure = WebhookCallFailure{Webhook: m[1], Kind: WebhookDenied, Message: eventCountRegex.ReplaceAllString(m[2], "")}
+		} else {
+			continue
+		}
+
+		if m := failedCreateObjectRegex.FindStringSubmatch(line); m != nil {
+			failure.Object = m[1]
+		}
+		failure.Line = strings.TrimSpace(line)
+		failures = append(failures, failure)
+	}
+	return failures
+}
+
+// classifyWebhookFailure maps the error the API server got from the webhook to a kind
+func classifyWebhookFailure(message string) WebhookFailureKind {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "no endpoints available"):
+		return WebhookNoEndpoints
+	case strings.Contains(lower, "service") && strings.Contains(lower, "not found"):
+		return WebhookServiceNotFound
+	case strings.Contains(lower, "x509") || strings.Contains(lower, "certificate"):
+		return WebhookTLS
+	case strings.Contains(lower, "connection refused") || strings.Contains(lower, "no route to host"):
+		return WebhookUnreachable
+	// The request URL carries a ?timeout= parameter, so match the error text only
+	case strings.Contains(lower, "deadline exceeded") || strings.Contains(lower, "i/o timeout") ||
+		strings.Contains(lower, "client.timeout"):
+		return WebhookTimeout
+	default:
+		return WebhookOther
+	}
+}
+
+// webhookInfo is the analyzer's view of a collected webhook.N entry
+type webhookInfo struct {
+	Name              string
+	Index             int
+	Configuration     collector.ResourceData
+	FailurePolicy     string
+	Service           string
+	ServiceExists     string
+	ReadyEndpoints    string
+	NotReadyEndpoints string
+	NamespaceSelector string
+	MatchesKubeSystem bool
+	Rules             string
+}
+
+// backendDown reports whether the collector found no Service or no ready endpoints
+func (w webhookInfo) backendDown() bool {
+	return w.ServiceExists == "false" || w.ReadyEndpoints == "0"
+}
+
+// WebhookAnalyzer maps admission webhook errors to the webhook and its backend
+type WebhookAnalyzer struct{}
+
+// Name implements the Analyzer interface
+func (a *WebhookAnalyzer) Name() string {
+	return "WebhookAnalyzer"
+}
+
+// Description implements the Analyzer interface
+func (a *WebhookAnalyzer) Description() string {
+	return "Analyzes admission webhook call failures, unavailable webhook backends and webhooks that can block kube-system"
+}
+
+// Analyze implements the Analyzer interface
+func (a *WebhookAnalyzer) Analyze(ctx context.Context, analysisCtx *AnalysisContext) ([]AnalysisDetail, error) {
+	details := make([]AnalysisDetail, 0)
+	webhooks := make([]webhookInfo, 0)
+	for _, resource := range analysisCtx.Resources {
+		if resource.Resource.Kind == "ValidatingWebhookConfiguration" || resource.Resource.Kind == "MutatingWebhookConfiguration" {
+			webhooks = append(webhooks, collectedWebhooks(resource)...)
+		}
+	}
+
+	// Failures can surface in any resource's events or logs; group them per webhook
+	failures := make(map[string][]WebhookCallFailure)
+	failureSources := make(map[string]collector.ResourceData)
+	names := make([]string, 0)
+	for _, resource := range analysisCtx.Resources {
+		found := ParseWebhookFailures(strings.Join(resource.Events, "\n"))
+		for i := range found {
+			found[i].Source = EvidenceEvent
+		}
+		for _, failure := range ParseWebhookFailures(strings.Join(resource.Logs, "\n")) {
+			failure.Source = EvidenceLog
+			found = append(found, failure)
+		}
+
+		for _, failure := range found {
+			if _, ok := failures[failure.Webhook]; !ok {
+				names = append(names, failure.Webhook)
+				failureSources[failure.Webhook] = resource
+			}
+			failures[failure.Webhook] = append(failures[failure.Webhook], failure)
+		}
+	}
+
+	collected := make(map[string]bool)
+	for _, webhook := range webhooks {
+		collected[webhook.Name] = true
+
+		calls := failures[webhook.Name]
+		if webhook.backendDown() || len(callFailures(calls)) > 0 {
+			details = append(details, a.failureDetail(webhook, calls))
+		}
+		if denials := deniedFailures(calls); len(denials) > 0 {
+			details = append(details, a.deniedDetail(webhook.Configuration, webhook.Name, denials))
+		}
+		if webhook.MatchesKubeSystem && webhook.FailurePolicy == "Fail" {
+			details = append(details, a.kubeSystemDetail(webhook))
+		}
+	}
+
+	// Failures of webhooks whose configuration was not collected
+	sort.Strings(names)
+	for _, name := range names {
+		if collected[name] {
+			continue
+		}
+		source := failureSources[name]
+		if calls := callFailures(failures[name]); len(calls) > 0 {
+			details = append(details, a.uncollectedDetail(source, name, calls))
+		}
+		if denials := deniedFailures(failures[name]); len(denials) > 0 {
+			details = append(details, a.deniedDetail(source, name, denials))
+		}
+	}
+
+	return details, nil
+}
+
+// collectedWebhooks reads the webhook.N entries of a webhook configuration
+func collectedWebhooks(resource collector.ResourceData) []webhookInfo {
+	webhooks := make([]webhookInfo, 0)
+	for i := 0; ; i++ {
+		prefix := fmt.Sprintf("webhook.%d.", i)
+		name, ok := resource.Status[prefix+"name"]
+		if !ok {
+			break
+		}
+		webhooks = append(webhooks, webhookInfo{
+			Name:              name,
+			Index:             i,
+			Configuration:     resource,
+			FailurePolicy:     resource.Status[prefix+"failurePolicy"],
+			Service:           resource.Status[prefix+"service"],
+			ServiceExists:     resource.Status[prefix+"serviceExists"],
+			ReadyEndpoints:    resource.Status[prefix+"readyEndpoints"],
+			NotReadyEndpoints: resource.Status[prefix+"notReadyEndpoints"],
+			NamespaceSelector: resource.Status[prefix+"namespaceSelector"],
+			MatchesKubeSystem: resource.Status[prefix+"matchesKubeSystem"] == "true",
+			Rules:             resource.Status[prefix+"rules"],
+		})
+	}
+	return webhooks
+}
+
+// callFailures returns the failures where the API server could not get an answer
+func callFailures(failures []WebhookCallFailure) []WebhookCallFailure {
+	calls := make([]WebhookCallFailure, 0)
+	for _, failure := range failures {
+		if failure.Kind != WebhookDenied {
+			calls = append(calls, failure)
+		}
+	}
+	return calls
+}
+
+// deniedFailures returns the failures where the webhook rejected the request
+func deniedFailures(failures []WebhookCallFailure) []WebhookCallFailure {
+	denials := make([]WebhookCallFailure, 0)
+	for _, failure := range failures {
+		if failure.Kind == WebhookDenied {
+			denials = append(denials, failure)
+		}
+	}
+	return denials
+}
+
+// failureEvidence returns the distinct lines the failures were found in
+func failureEvidence(failures []WebhookCallFailure) []Evidence {
+	seen := make(map[string]bool)
+	evidence := make([]Evidence, 0)
+	for _, failure := range failures {
+		if seen[failure.Line] || len(evidence) == maxWebhookEvidence {
+			continue
+		}
+		seen[failure.Line] = true
+		evidence = append(evidence, Evidence{Kind: failure.Source, Value: failure.Line})
+	}
+	return evidence
+}
+
+// affectedObjects lists the distinct objects whose creation the failures blocked
+func affectedObjects(failures []WebhookCallFailure) []string {
+	seen := make(map[string]bool)
+	objects := make([]string, 0)
+	for _, failure := range failures {
+		if failure.Object != "" && !seen[failure.Object] {
+			seen[failure.Object] = true
+			objects = append(objects, failure.Object)
+		}
+	}
+	return objects
+}
+
+// failureDetail reports a webhook whose backend is down or whose calls fail
+func (a *WebhookAnalyzer) failureDetail(webhook webhookInfo, failures []WebhookCallFailure) AnalysisDetail {
+	calls := callFailures(failures)
+
+	prefix := fmt.Sprintf("webhook.%d.", webhook.Index)
+	detail := AnalysisDetail{
+		ID:         "WEBHOOK_CALL_FAILED",
+		Severity:   SeverityError,
+		Confidence: ConfidenceHigh,
+		Title:      "Admission webhook call failed",
+		Evidence:   failureEvidence(calls),
+		Resource:   webhook.Configuration.Resource,
+		RemediationCommands: []string{
+			"kubectl get " + strings.ToLower(webhook.Configuration.Resource.Kind) + " " + webhook.Configuration.Resource.Name + " -o yaml",
+		},
+	}
+
+	description := fmt.Sprintf("Webhook %s (%s %s, failurePolicy %s)", webhook.Name,
+		webhook.Configuration.Resource.Kind, webhook.Configuration.Resource.Name, webhook.FailurePolicy)
+	if webhook.backendDown() {
+		detail.ID = "WEBHOOK_BACKEND_UNAVAILABLE"
+		detail.Confidence = ConfidenceCertain
+		detail.Title = "Admission webhook backend unavailable"
+		detail.Evidence = append(statusEvidence(webhook.Configuration.Status, prefix+"service", prefix+"serviceExists",
+			prefix+"readyEndpoints", prefix+"notReadyEndpoints", prefix+"failurePolicy"), detail.Evidence...)
+		switch {
+		case webhook.ServiceExists == "false":
+			description += fmt.Sprintf(" points at Service %s, which does not exist", webhook.Service)
+		default:
+			description += fmt.Sprintf(" points at Service %s, which has no ready endpoints", webhook.Service)
+			if webhook.NotReadyEndpoints != "" && webhook.NotReadyEndpoints != "0" {
+				description += fmt.Sprintf(" (%s not ready)", webhook.NotReadyEndpoints)
+			}
+		}
+
+		if webhook.FailurePolicy == "Fail" {
+			description += "; every matching request is rejected"
+			if webhook.Rules != "" {
+				description += " (" + webhook.Rules + ")"
+			}
+		} else {
+			// With failurePolicy Ignore requests pass unchecked, after the call times out
+			detail.Severity = SeverityWarning
+			description += "; matching requests are admitted without the webhook after waiting for it"
+		}
+	} else {
+		description += " could not be called: " + calls[0].Message
+	}
+
+	if objects := affectedObjects(calls); len(objects) > 0 {
+		description += ". Blocked creates: " + strings.Join(objects, ", ")
+	}
+	detail.Description = description
+
+	if webhook.Service != "" {
+		namespace, name, _ := strings.Cut(webhook.Service, "/")
+		detail.RemediationCommands = append(detail.RemediationCommands,
+			"kubectl get endpoints "+name+" -n "+namespace,
+			"kubectl get pods -n "+namespace)
+	}
+
+	detail.Remediation = append(detail.Remediation, webhookKindRemediation(webhook, calls)...)
+	if webhook.FailurePolicy == "Fail" {
+		detail.Remediation = append(detail.Remediation,
+			"If the webhook is no longer needed, delete its configuration to unblock requests")
+	}
+
+	return detail
+}
+
+// webhookKindRemediation suggests fixes for the observed failure
+func webhookKindRemediation(webhook webhookInfo, calls []WebhookCallFailure) []string {
+	kind := WebhookNoEndpoints
+	if len(calls) > 0 {
+		kind = calls[0].Kind
+	}
+	if webhook.ServiceExists == "false" {
+		kind = WebhookServiceNotFound
+	}
+
+	switch kind {
+	case WebhookServiceNotFound:
+		return []string{"Reinstall the component that provides the webhook, or fix the service reference"}
+	case WebhookTLS:
+		return []string{"Make sure the caBundle matches the CA that signed the webhook's serving certificate"}
+	case WebhookTimeout:
+		return []string{
+			"Check the webhook pods for slow responses and resource starvation",
+			"Check that NetworkPolicies allow traffic from the API server to the webhook",
+		}
+	default:
+		return []string{"Check why the webhook pods are not running or not ready"}
+	}
+}
+
+// deniedDetail reports requests the webhook itself rejected
+func (a *WebhookAnalyzer) deniedDetail(resource collector.ResourceData, name string, denials []WebhookCallFailure) AnalysisDetail {
+	description := fmt.Sprintf("Webhook %s denied %d request(s): %s", name, len(denials), denials[0].Message)
+	if objects := affectedObjects(denials); len(objects) > 0 {
+		description += ". Affected: " + strings.Join(objects, ", ")
+	}
+
+	return AnalysisDetail{
+		ID:          "WEBHOOK_REQUEST_DENIED",
+		Severity:    SeverityWarning,
+		Confidence:  ConfidenceCertain,
+		Title:       "Request denied by admission webhook",
+		Description: description,
+		Evidence:    failureEvidence(denials),
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Change the object so it satisfies the policy enforced by the webhook",
+			"Ask the owner of the policy for an exception if the object is legitimate",
+		},
+	}
+}
+
+// uncollectedDetail reports webhook failures seen in events when the configuration
+// itself was not collected
+func (a *WebhookAnalyzer) uncollectedDetail(resource collector.ResourceData, name string, calls []WebhookCallFailure) AnalysisDetail {
+	description := fmt.Sprintf("Calls to admission webhook %s fail: %s", name, calls[0].Message)
+	if objects := affectedObjects(calls); len(objects) > 0 {
+		description += ". Blocked creates: " + strings.Join(objects, ", ")
+	}
+
+	return AnalysisDetail{
+		ID:          "WEBHOOK_CALL_FAILED",
+		Severity:    SeverityError,
+		Confidence:  ConfidenceMedium,
+		Title:       "Admission webhook call failed",
+		Description: description,
+		Evidence:    failureEvidence(calls),
+		Resource:    resource.Resource,
+		Remediation: []string{
+			"Find the webhook configuration and check the Service it calls",
+			"Check that the webhook pods are running and ready",
+		},
+		RemediationCommands: []string{
+			"kubectl get validatingwebhookconfigurations,mutatingwebhookconfigurations",
+		},
+	}
+}
+
+// kubeSystemDetail reports a failing-closed webhook whose namespaceSelector includes kube-system
+func (a *WebhookAnalyzer) kubeSystemDetail(webhook webhookInfo) AnalysisDetail {
+	resource := webhook.Configuration.Resource
+	selector := webhook.NamespaceSelector
+	if selector == "" {
+		selector = "<none>"
+	}
+
+	prefix := fmt.Sprintf("webhook.%d.", webhook.Index)
+	detail := AnalysisDetail{
+		ID:         "WEBHOOK_BLOCKS_KUBE_SYSTEM",
+		Severity:   SeverityWarning,
+		Confidence: ConfidenceCertain,
+		Title:      "Admission webhook can block kube-system",
+		Description: fmt.Sprintf("Webhook %s in %s uses failurePolicy Fail and namespaceSelector %s, which matches kube-system. "+
+			"If its backend goes down, system components there cannot be created or updated, including the webhook's own recovery",
+			webhook.Name, resource.Name, selector),
+		Evidence: statusEvidence(webhook.Configuration.Status, prefix+"failurePolicy", prefix+"namespaceSelector", prefix+"matchesKubeSystem"),
+		Resource: resource,
+		Remediation: []string{
+			"Exclude kube-system with a kubernetes.io/metadata.name NotIn [kube-system] namespaceSelector expression",
+		},
+	}
+
+	// Only an unset selector can be added in one patch without knowing its structure
+	if webhook.NamespaceSelector == "" {
+		detail.RemediationCommands = []string{fmt.Sprintf(
+			`kubectl patch %s %s --type=json -p '[{"op":"add","path":"/webhooks/%d/namespaceSelector","value":{"matchExpressions":[{"key":"kubernetes.io/metadata.name","operator":"NotIn","values":["kube-system"]}]}}]'`,
+			strings.ToLower(resource.Kind), resource.Name, webhook.Index)}
+	}
+
+	return detail
+}
@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestParseWebhookFailures(t *testing.T) {
+	text := `[2024-05-01 10:00:00] Warning FailedCreate ReplicaSet shop/web-7d9f: Error creating: Internal error occurred: failed calling webhook "validate.policy.example.com": failed to call webhook: Post "https://policy-webhook.policy.svc:443/validate?timeout=10s": dial tcp 10.96.12.4:443: connect: connection refused (count: 12)
+[2024-05-01 10:01:00] Warning FailedCreate ReplicaSet shop/api-5c4b: Error creating: admission webhook "validate.policy.example.com" denied the request: image registry not allowed (count: 1)`
+
+	failures := ParseWebhookFailures(text)
+	if len(failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %+v", failures)
+	}
+	if failures[0].Kind != WebhookUnreachable || failures[0].Object != "ReplicaSet shop/web-7d9f" {
+		t.Errorf("Unexpected first failure %+v", failures[0])
+	}
+	if strings.HasSuffix(failures[0].Message, "(count: 12)") {
+		t.Errorf("Expected the event count to be stripped, got '%s'", failures[0].Message)
+	}
+	if failures[1].Kind != WebhookDenied || failures[1].Message != "image registry not allowed" {
+		t.Errorf("Unexpected second failure %+v", failures[1])
+	}
+}
+
+func TestWebhookAnalyzer_BackendDown(t *testing.T) {
+	analysisCtx := &AnalysisContext{
+		Resources: []collector.ResourceData{{
+			Resource: collector.ResourceInfo{Kind: "ValidatingWebhookConfiguration", Name: "policy"},
+			Status: map[string]string{
+				"webhook.0.name":              "validate.policy.example.com",
+				"webhook.0.failurePolicy":     "Fail",
+				"webhook.0.service":           "policy/policy-webhook",
+				"webhook.0.serviceExists":     "true",
+				"webhook.0.readyEndpoints":    "0",
+				"webhook.0.notReadyEndpoints": "2",
+				"webhook.0.matchesKubeSystem": "true",
+				"webhook.0.rules":             "CREATE,UPDATE:pods",
+			},
+			Events: []string{
+				`[2024-05-01 10:00:00] Warning FailedCreate ReplicaSet shop/web-7d9f: Error creating: Internal error occurred: failed calling webhook "validate.policy.example.com": failed to call webhook: dial tcp 10.96.12.4:443: connect: connection refused (count: 12)`,
+			},
+		}},
+		Details: []AnalysisDetail{},
+	}
+
+	details, err := (&WebhookAnalyzer{}).Analyze(context.Background(), analysisCtx)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	if len(details) != 2 {
+		t.Fatalf("Expected 2 details, got %+v", details)
+	}
+
+	backend := details[0]
+	if backend.Title != "Admission webhook backend unavailable" || backend.Severity != SeverityError {
+		t.Errorf("Unexpected backend finding %s (%s)", backend.Title, backend.Severity)
+	}
+	if !strings.Contains(backend.Description, "ReplicaSet shop/web-7d9f") || !strings.Contains(backend.Description, "2 not ready") {
+		t.Errorf("Expected the blocked object and endpoint state in the description, got '%s'", backend.Description)
+	}
+
+	kubeSystem := details[1]
+	if kubeSystem.Title != "Admission webhook can block kube-system" {
+		t.Errorf("Unexpected second finding '%s'", kubeSystem.Title)
+	}
+	if len(kubeSystem.RemediationCommands) != 1 || !strings.Contains(kubeSystem.RemediationCommands[0], "/webhooks/0/namespaceSelector") {
+		t.Errorf("Expected a patch adding a namespaceSelector, got %v", kubeSystem.RemediationCommands)
+	}
+}
@@ -3,19 +3,34 @@ package collector
 import (
 	"context"
 	"fmt"
+	"time"
 
 	internalcollector "github.com/k8smed/k8smed/internal/collector"
+	internalcached "github.com/k8smed/k8smed/internal/collector/cached"
+	internaldump "github.com/k8smed/k8smed/internal/collector/dump"
+	internalgeneric "github.com/k8smed/k8smed/internal/collector/generic"
+	internalhealth "github.com/k8smed/k8smed/internal/collector/health"
+	internalingress "github.com/k8smed/k8smed/internal/collector/ingress"
+	internalpdb "github.com/k8smed/k8smed/internal/collector/pdb"
 	internalpod "github.com/k8smed/k8smed/internal/collector/pod"
+	internalservice "github.com/k8smed/k8smed/internal/collector/service"
+	internalwebhook "github.com/k8smed/k8smed/internal/collector/webhook"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 // ResourceType represents the type of Kubernetes resource
 type ResourceType string
 
-// Define common resource types
+// Define common resource types. Any other resource, including custom resources, is
+// collected through the dynamic client when the discovery API knows it, using the
+// name kubectl accepts, such as "rollout", "certificates.cert-manager.io" or "cert".
 const (
 	ResourceTypePod         ResourceType = "pod"
 	ResourceTypeDeployment  ResourceType = "deployment"
@@ -30,6 +45,8 @@ const (
 	ResourceTypePVC         ResourceType = "persistentvolumeclaim"
 	ResourceTypePV          ResourceType = "persistentvolume"
 	ResourceTypeEvent       ResourceType = "event"
+	ResourceTypePDB         ResourceType = "poddisruptionbudget"
+	ResourceTypeWebhook     ResourceType = "webhookconfiguration"
 )
 
 // CollectionOptions provides options for resource collection
@@ -39,9 +56,11 @@ type CollectionOptions struct {
 	LabelSelector string
 	IncludeEvents bool
 	IncludeLogs   bool
-	SinceSeconds  int64
-	TailLines     int64
-	Limit         int64
+	// IncludeMetrics queries metrics-server for the live usage of pods and their node
+	IncludeMetrics bool
+	SinceSeconds   int64
+	TailLines      int64
+	Limit          int64
 }
 
 // ResourceInfo contains basic information about a Kubernetes resource
@@ -53,6 +72,26 @@ type ResourceInfo struct {
 	// Additional fields can be added as needed
 }
 
+// Relation is the type of the edge from a collected resource to a related one
+type Relation string
+
+// Relation types; each reads "<collected resource> <relation> <related resource>",
+// except RelationOwner, where the related resource owns the collected one
+const (
+	RelationOwner       Relation = "owner"       // ReplicaSet and Deployment of a pod
+	RelationMounts      Relation = "mounts"      // ConfigMap, Secret or PVC volume of a pod
+	RelationReferences  Relation = "references"  // env source, service account, TLS secret, webhook service
+	RelationSelects     Relation = "selects"     // pods and workloads of a PodDisruptionBudget
+	RelationSelectedBy  Relation = "selectedBy"  // Service selecting a pod
+	RelationScheduledOn Relation = "scheduledOn" // node of a pod
+)
+
+// RelatedResource is a resource connected to the collected resource by a typed edge
+type RelatedResource struct {
+	ResourceInfo
+	Relation Relation `json:"relation,omitempty"`
+}
+
 // ResourceData represents the collected data for a Kubernetes resource
 type ResourceData struct {
 	Resource ResourceInfo      `json:"resource"`
@@ -60,16 +99,157 @@ type ResourceData struct {
 	Events   []string          `json:"events,omitempty"`
 	Logs     []string          `json:"logs,omitempty"`
 	Status   map[string]string `json:"status,omitempty"`
-	Related  []ResourceInfo    `json:"related,omitempty"`
+	Related  []RelatedResource `json:"related,omitempty"`
 }
 
 // Collector provides methods to collect information from a Kubernetes cluster
 type Collector struct {
-	clientset *kubernetes.Clientset
+	clientset     kubernetes.Interface
+	dynamicClient dynamic.Interface
+	mapper        meta.RESTMapper
+	cache         *internalcached.Cache // set for cached collectors
+	// pods is shared by pod collections, so unschedulable pods reuse the node lists
+	pods *internalpod.Collector
+}
+
+// CacheOptions configures the informer cache of a cached collector
+type CacheOptions struct {
+	// Namespace limits the cache to one namespace; empty caches all namespaces
+	Namespace string
+	// Resync is how often the informers replay their cache; zero disables it
+	Resync time.Duration
 }
 
-// NewCollector creates a new Collector instance
+// NewCollector creates a new Collector instance that reads from the API server on
+// every collection
 func NewCollector(kubeConfigPath string) (*Collector, error) {
+	config, err := buildConfig(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the clientset
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	return newCollector(config, clientset)
+}
+
+// NewCachedCollector creates a Collector that serves pods, events, nodes, services and
+// workloads from shared informers. It blocks until the informers have synced; they
+// keep watching the cluster until ctx is done. Other resources are read from the API
+// server as with NewCollector.
+func NewCachedCollector(ctx context.Context, kubeConfigPath string, options CacheOptions) (*Collector, error) {
+	config, err := buildConfig(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	informerCache := internalcached.New(clientset, options.Resync, options.Namespace)
+	if err := informerCache.Start(ctx); err != nil {
+		return nil, fmt.Errorf("failed to start the resource cache: %w", err)
+	}
+
+	k8sCollector, err := newCollector(config, informerCache.Client())
+	if err != nil {
+		return nil, err
+	}
+	k8sCollector.cache = informerCache
+	return k8sCollector, nil
+}
+
+// ResourceChange is a change to a pod seen by a cached collector
+type ResourceChange struct {
+	ResourceInfo
+	Deleted bool
+	// Healthy is whether the pod is healthy the way Summarize judges it
+	Healthy bool
+}
+
+// WatchPods calls handler for every change to a pod in the cache of a cached collector,
+// and for every event recorded for one, starting with each pod already cached. The
+// handler is called from the informers' goroutines, so it should return quickly.
+func (c *Collector) WatchPods(handler func(ResourceChange)) error {
+	if c.cache == nil {
+		return fmt.Errorf("watching pods requires a cached collector")
+	}
+	return c.cache.OnPodChange(func(change internalcached.Change) {
+		handler(ResourceChange{
+			ResourceInfo: ResourceInfo{
+				Kind:      change.Kind,
+				Name:      change.Name,
+				Namespace: change.Namespace,
+				Labels:    change.Labels,
+			},
+			Deleted: change.Deleted,
+			Healthy: change.Healthy,
+		})
+	})
+}
+
+// NewClusterInfoDumpCollector creates a Collector that reads the directory written by
+// `kubectl cluster-info dump --output-directory` instead of a cluster. The dump holds
+// nodes, pods with their logs, events, services and workloads; reads of anything else,
+// including custom resources, fail.
+func NewClusterInfoDumpCollector(dir string) (*Collector, error) {
+	clusterDump, err := internaldump.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := clusterDump.Client()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster-info dump: %w", err)
+	}
+	dynamicClient, err := clusterDump.DynamicClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the cluster-info dump: %w", err)
+	}
+
+	return &Collector{
+		clientset:     clientset,
+		dynamicClient: dynamicClient,
+		mapper:        clusterDump.RESTMapper(),
+		pods:          internalpod.NewCollector(clientset),
+	}, nil
+}
+
+// ContextName returns the name of the kubeconfig's current context, which identifies
+// the cluster collected from, or "in-cluster" without a kubeconfig
+func ContextName(kubeConfigPath string) string {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeConfigPath != "" {
+		rules.ExplicitPath = kubeConfigPath
+	}
+	rawConfig, err := rules.Load()
+	if err != nil || rawConfig.CurrentContext == "" {
+		return "in-cluster"
+	}
+	return rawConfig.CurrentContext
+}
+
+// NewClientset creates a client for reads the collectors do not cover, such as custom
+// rule ConfigMaps, falling back to the in-cluster config without a kubeconfig
+func NewClientset(kubeConfigPath string) (kubernetes.Interface, error) {
+	config, err := buildConfig(kubeConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return clientset, nil
+}
+
+// buildConfig loads the kubeconfig, falling back to the in-cluster config
+func buildConfig(kubeConfigPath string) (*rest.Config, error) {
 	// Try to build config from the provided kubeconfig path
 	config, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
 	if err != nil {
@@ -79,56 +259,200 @@ func NewCollector(kubeConfigPath string) (*Collector, error) {
 			return nil, fmt.Errorf("failed to create kubernetes client config: %w", err)
 		}
 	}
+	return config, nil
+}
 
-	// Create the clientset
-	clientset, err := kubernetes.NewForConfig(config)
+// newCollector creates a Collector reading typed resources through the clientset
+func newCollector(config *rest.Config, clientset kubernetes.Interface) (*Collector, error) {
+	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		return nil, fmt.Errorf("failed to create dynamic kubernetes client: %w", err)
 	}
 
 	return &Collector{
-		clientset: clientset,
+		clientset:     clientset,
+		dynamicClient: dynamicClient,
+		mapper:        newMapper(clientset),
+		pods:          internalpod.NewCollector(clientset),
 	}, nil
 }
 
+// newMapper creates the mapper resolving resource names the way kubectl does.
+// Discovery is deferred until the first resource type outside the built-in ones.
+func newMapper(clientset kubernetes.Interface) meta.RESTMapper {
+	cachedDiscovery := memory.NewMemCacheClient(clientset.Discovery())
+	return restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cachedDiscovery), clientset.Discovery(), nil)
+}
+
 // CollectResource collects data for the specified resource
 func (c *Collector) CollectResource(ctx context.Context, resourceType ResourceType, options CollectionOptions) (*ResourceData, error) {
 	// Convert our options to internal options
-	internalOptions := internalcollector.CollectionOptions{
-		Namespace:     options.Namespace,
-		ResourceName:  options.ResourceName,
-		LabelSelector: options.LabelSelector,
-		IncludeEvents: options.IncludeEvents,
-		IncludeLogs:   options.IncludeLogs,
-		SinceSeconds:  options.SinceSeconds,
-		TailLines:     options.TailLines,
-		Limit:         options.Limit,
-	}
+	internalOptions := convertOptions(options)
 
 	// Use the appropriate collector
 	switch resourceType {
 	case ResourceTypePod:
 		return c.collectPod(ctx, internalOptions)
-	case ResourceTypeDeployment:
-		return c.collectDeployment(ctx, internalOptions)
 	case ResourceTypeService:
 		return c.collectService(ctx, internalOptions)
 	case ResourceTypeEvent:
 		return c.collectEvents(ctx, internalOptions)
+	case ResourceTypePDB:
+		return c.collectPDB(ctx, internalOptions)
+	case ResourceTypeIngress:
+		return c.collectIngress(ctx, internalOptions)
+	case ResourceTypeWebhook:
+		return c.collectWebhook(ctx, internalOptions)
+	default:
+		return c.collectGeneric(ctx, resourceType, internalOptions)
+	}
+}
+
+// CollectResources collects data for every resource of the given type in the
+// namespace, or in all namespaces when no namespace is set
+func (c *Collector) CollectResources(ctx context.Context, resourceType ResourceType, options CollectionOptions) ([]*ResourceData, error) {
+	internalOptions := convertOptions(options)
+
+	var internalData []*internalcollector.ResourceData
+	var err error
+
+	switch resourceType {
+	case ResourceTypePod:
+		internalData, err = c.pods.CollectAll(ctx, internalOptions)
+	case ResourceTypeService:
+		internalData, err = internalservice.NewCollector(c.clientset).CollectAll(ctx, internalOptions)
+	case ResourceTypePDB:
+		internalData, err = internalpdb.NewCollector(c.clientset).CollectAll(ctx, internalOptions)
+	case ResourceTypeIngress:
+		internalData, err = internalingress.NewCollector(c.clientset).CollectAll(ctx, internalOptions)
+	case ResourceTypeWebhook:
+		internalData, err = internalwebhook.NewCollector(c.clientset).CollectAll(ctx, internalOptions)
 	default:
-		return nil, fmt.Errorf("unsupported resource type: %s", resourceType)
+		internalData, err = c.genericCollector().CollectAll(ctx, string(resourceType), internalOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResourceDataList(internalData), nil
+}
+
+// ResourceSummary is a listed resource with a verdict on its health, judged without
+// collecting it
+type ResourceSummary struct {
+	ResourceInfo
+	Healthy bool
+}
+
+// SummaryKinds are the kinds Summarize can list
+var SummaryKinds = []string{"Pod", "Deployment", "StatefulSet", "DaemonSet", "Service", "PersistentVolumeClaim", "Node"}
+
+// Summarize lists the resources of a kind in the namespace, or in all namespaces when no
+// namespace is set, and judges whether each is healthy. It is cheap enough to run over a
+// whole cluster, so only unhealthy resources need to be collected.
+func (c *Collector) Summarize(ctx context.Context, kind, namespace string) ([]ResourceSummary, error) {
+	internalSummaries, err := internalhealth.NewSummarizer(c.clientset).Summarize(ctx, kind, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ResourceSummary, len(internalSummaries))
+	for i, summary := range internalSummaries {
+		summaries[i] = ResourceSummary{
+			ResourceInfo: ResourceInfo{
+				Kind:      summary.Kind,
+				Name:      summary.Name,
+				Namespace: summary.Namespace,
+				Labels:    summary.Labels,
+			},
+			Healthy: summary.Healthy,
+		}
+	}
+	return summaries, nil
+}
+
+// convertOptions converts our options to internal options
+func convertOptions(options CollectionOptions) internalcollector.CollectionOptions {
+	return internalcollector.CollectionOptions{
+		Namespace:      options.Namespace,
+		ResourceName:   options.ResourceName,
+		LabelSelector:  options.LabelSelector,
+		IncludeEvents:  options.IncludeEvents,
+		IncludeLogs:    options.IncludeLogs,
+		IncludeMetrics: options.IncludeMetrics,
+		SinceSeconds:   options.SinceSeconds,
+		TailLines:      options.TailLines,
+		Limit:          options.Limit,
 	}
 }
 
 // collectPod collects data for the specified pod
 func (c *Collector) collectPod(ctx context.Context, options internalcollector.CollectionOptions) (*ResourceData, error) {
-	podCollector := internalpod.NewCollector(c.clientset)
-	internalData, err := podCollector.Collect(ctx, options)
+	internalData, err := c.pods.Collect(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResourceData(internalData), nil
+}
+
+// collectService collects data for the specified service
+func (c *Collector) collectService(ctx context.Context, options internalcollector.CollectionOptions) (*ResourceData, error) {
+	internalData, err := internalservice.NewCollector(c.clientset).Collect(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResourceData(internalData), nil
+}
+
+// collectPDB collects data for the specified pod disruption budget
+func (c *Collector) collectPDB(ctx context.Context, options internalcollector.CollectionOptions) (*ResourceData, error) {
+	internalData, err := internalpdb.NewCollector(c.clientset).Collect(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResourceData(internalData), nil
+}
+
+// collectIngress collects data for the specified ingress
+func (c *Collector) collectIngress(ctx context.Context, options internalcollector.CollectionOptions) (*ResourceData, error) {
+	internalData, err := internalingress.NewCollector(c.clientset).Collect(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResourceData(internalData), nil
+}
+
+// collectWebhook collects data for the specified admission webhook configuration
+func (c *Collector) collectWebhook(ctx context.Context, options internalcollector.CollectionOptions) (*ResourceData, error) {
+	internalData, err := internalwebhook.NewCollector(c.clientset).Collect(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResourceData(internalData), nil
+}
+
+// collectGeneric collects data for a resource without a dedicated collector
+func (c *Collector) collectGeneric(ctx context.Context, resourceType ResourceType, options internalcollector.CollectionOptions) (*ResourceData, error) {
+	internalData, err := c.genericCollector().Collect(ctx, string(resourceType), options)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert internal data to our format
+	return convertResourceData(internalData), nil
+}
+
+// genericCollector returns the dynamic client based collector
+func (c *Collector) genericCollector() *internalgeneric.Collector {
+	return internalgeneric.NewCollector(c.dynamicClient, c.clientset, c.mapper)
+}
+
+// convertResourceData converts internal data to our format
+func convertResourceData(internalData *internalcollector.ResourceData) *ResourceData {
 	return &ResourceData{
 		Resource: ResourceInfo{
 			Kind:      internalData.Resource.Kind,
@@ -141,33 +465,35 @@ func (c *Collector) collectPod(ctx context.Context, options internalcollector.Co
 		Logs:     internalData.Logs,
 		Status:   internalData.Status,
 		Related:  convertRelatedResources(internalData.Related),
-	}, nil
+	}
+}
+
+// convertResourceDataList converts a list of internal data to our format
+func convertResourceDataList(internalData []*internalcollector.ResourceData) []*ResourceData {
+	resources := make([]*ResourceData, len(internalData))
+	for i, data := range internalData {
+		resources[i] = convertResourceData(data)
+	}
+	return resources
 }
 
 // convertRelatedResources converts internal resource info to our format
-func convertRelatedResources(internalResources []internalcollector.ResourceInfo) []ResourceInfo {
-	resources := make([]ResourceInfo, len(internalResources))
+func convertRelatedResources(internalResources []internalcollector.RelatedResource) []RelatedResource {
+	resources := make([]RelatedResource, len(internalResources))
 	for i, res := range internalResources {
-		resources[i] = ResourceInfo{
-			Kind:      res.Kind,
-			Name:      res.Name,
-			Namespace: res.Namespace,
-			Labels:    res.Labels,
+		resources[i] = RelatedResource{
+			ResourceInfo: ResourceInfo{
+				Kind:      res.Kind,
+				Name:      res.Name,
+				Namespace: res.Namespace,
+				Labels:    res.Labels,
+			},
+			Relation: Relation(res.Relation),
 		}
 	}
 	return resources
 }
 
-func (c *Collector) collectDeployment(_ context.Context, _ internalcollector.CollectionOptions) (*ResourceData, error) {
-	// This will be implemented in a dedicated package
-	return nil, fmt.Errorf("not implemented")
-}
-
-func (c *Collector) collectService(_ context.Context, _ internalcollector.CollectionOptions) (*ResourceData, error) {
-	// This will be implemented in a dedicated package
-	return nil, fmt.Errorf("not implemented")
-}
-
 func (c *Collector) collectEvents(_ context.Context, _ internalcollector.CollectionOptions) (*ResourceData, error) {
 	// This will be implemented in a dedicated package
 	return nil, fmt.Errorf("not implemented")
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElectionOptions configures the Lease that replicas of a long-running command
+// compete for
+type LeaderElectionOptions struct {
+	// Namespace and Name identify the Lease
+	Namespace string
+	Name      string
+	// Identity names this replica in the Lease, such as its pod name
+	Identity string
+	// LeaseDuration is how long the other replicas wait before taking over a Lease that
+	// was not renewed; zero uses 15s
+	LeaseDuration time.Duration
+}
+
+// RunWithLeaderElection blocks until ctx is done, calling lead each time this replica
+// acquires the Lease. The context passed to lead is canceled when the Lease is lost, and
+// lead is not called again before the previous call returns. The Lease is released when
+// ctx is done, so another replica can take over at once.
+func RunWithLeaderElection(ctx context.Context, kubeConfigPath string, options LeaderElectionOptions, lead func(ctx context.Context)) error {
+	config, err := buildConfig(kubeConfigPath)
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+
+	leaseDuration := options.LeaseDuration
+	if leaseDuration <= 0 {
+		leaseDuration = 15 * time.Second
+	}
+
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Namespace: options.Namespace, Name: options.Name},
+		Client:     clientset.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: options.Identity},
+	}
+	// The elector starts lead in a goroutine and does not wait for it once the Lease is
+	// lost, so each term waits for the previous one to return, and a term whose Lease was
+	// lost before it got to run is skipped
+	var leading sync.Mutex
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   leaseDuration * 2 / 3,
+		RetryPeriod:     leaseDuration / 7,
+		ReleaseOnCancel: true,
+		Name:            options.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leadCtx context.Context) {
+				leading.Lock()
+				defer leading.Unlock()
+				if leadCtx.Err() == nil {
+					lead(leadCtx)
+				}
+			},
+			OnStoppedLeading: func() {},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set up leader election: %w", err)
+	}
+
+	// Run returns when the Lease is lost, so it is run again until ctx is done
+	for ctx.Err() == nil {
+		elector.Run(ctx)
+	}
+	leading.Lock()
+	defer leading.Unlock()
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 )
 
 // Config holds the application configuration
@@ -20,6 +21,10 @@ type Config struct {
 	// Application settings
 	AnonymizeByDefault bool   `json:"anonymizeByDefault"` // Whether to anonymize sensitive data by default
 	OutputFormat       string `json:"outputFormat"`       // e.g., "text", "json", "yaml"
+	SuppressionsFile   string `json:"suppressionsFile"`   // YAML file of accepted findings to hide
+
+	// Analyzer settings
+	CertExpiryWarningDays int `json:"certExpiryWarningDays"` // Days ahead of expiry that certificates are reported
 }
 
 // DefaultConfig returns a config with default values
@@ -43,11 +48,26 @@ func DefaultConfig() *Config {
 		CurrentContext:     "",
 		AnonymizeByDefault: false,
 		OutputFormat:       "text",
+
+		CertExpiryWarningDays: 30,
 	}
 }
 
 // LoadConfig loads the configuration from environment variables or defaults
 func LoadConfig() (*Config, error) {
+	config := ReadConfig()
+
+	// Validate configuration
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// ReadConfig loads the configuration from environment variables or defaults without
+// validating the AI provider, for commands that only call it on request
+func ReadConfig() *Config {
 	config := DefaultConfig()
 
 	// Override with environment variables if present
@@ -71,12 +91,15 @@ func LoadConfig() (*Config, error) {
 		config.OutputFormat = format
 	}
 
-	// Validate configuration
-	if err := validateConfig(config); err != nil {
-		return nil, err
+	if suppressions := os.Getenv("K8SMED_SUPPRESSIONS_FILE"); suppressions != "" {
+		config.SuppressionsFile = suppressions
 	}
 
-	return config, nil
+	if days, err := strconv.Atoi(os.Getenv("K8SMED_CERT_EXPIRY_DAYS")); err == nil && days > 0 {
+		config.CertExpiryWarningDays = days
+	}
+
+	return config
 }
 
 // validateConfig ensures the configuration is valid
@@ -48,6 +48,9 @@ type Plan struct {
 
 	// Analysis details that led to this remediation
 	AnalysisDetails []analyzer.AnalysisDetail `json:"analysisDetails"`
+
+	// The analysis details with the same issue on several replicas merged
+	Groups []analyzer.FindingGroup `json:"groups,omitempty"`
 }
 
 // Generator generates remediation plans based on analysis results
@@ -98,40 +101,58 @@ func (g *Generator) GeneratePlan(details []analyzer.AnalysisDetail, resources []
 		Steps:           []string{},
 		Commands:        []Command{},
 		AnalysisDetails: details,
+		Groups:          analyzer.GroupFindings(resources, details),
 	}
 
-	// Group details by type for better organization
-	errorDetails := filterDetailsByType(details, "error")
-	warningDetails := filterDetailsByType(details, "warning")
-	infoDetails := filterDetailsByType(details, "info")
+	// Group details by severity for better organization
+	errorGroups := filterGroupsBySeverity(plan.Groups, analyzer.SeverityError)
+	warningGroups := filterGroupsBySeverity(plan.Groups, analyzer.SeverityWarning)
+	infoGroups := filterGroupsBySeverity(plan.Groups, analyzer.SeverityInfo)
 
 	// Add error remediation first (highest priority)
-	if len(errorDetails) > 0 {
+	if len(errorGroups) > 0 {
 		plan.Steps = append(plan.Steps, "Fix critical issues:")
-		for _, detail := range errorDetails {
-			g.addRemediationForDetail(plan, detail, resources)
+		for _, group := range errorGroups {
+			g.addRemediationForGroup(plan, group, resources)
 		}
 	}
 
 	// Add warning remediation next
-	if len(warningDetails) > 0 {
+	if len(warningGroups) > 0 {
 		plan.Steps = append(plan.Steps, "Address warnings:")
-		for _, detail := range warningDetails {
-			g.addRemediationForDetail(plan, detail, resources)
+		for _, group := range warningGroups {
+			g.addRemediationForGroup(plan, group, resources)
 		}
 	}
 
 	// Add info remediation last
-	if len(infoDetails) > 0 {
+	if len(infoGroups) > 0 {
 		plan.Steps = append(plan.Steps, "Consider improvements:")
-		for _, detail := range infoDetails {
-			g.addRemediationForDetail(plan, detail, resources)
+		for _, group := range infoGroups {
+			g.addRemediationForGroup(plan, group, resources)
 		}
 	}
 
 	return plan
 }
 
+// addRemediationForGroup adds the remediation of an issue once, however many replicas it affects
+func (g *Generator) addRemediationForGroup(plan *Plan, group analyzer.FindingGroup, resources []collector.ResourceData) {
+	if group.Count() > 1 {
+		names := make([]string, 0, group.Count())
+		for _, resource := range group.Resources {
+			names = append(names, resource.Name)
+		}
+		affected := fmt.Sprintf("%d %ss", group.Count(), group.Detail.Resource.Kind)
+		if group.Owner != nil {
+			affected += fmt.Sprintf(" of %s %s", group.Owner.Kind, group.Owner.Name)
+		}
+		plan.Steps = append(plan.Steps, fmt.Sprintf("  %s (affects %s: %s)", group.Detail.Title, affected, strings.Join(names, ", ")))
+	}
+
+	g.addRemediationForDetail(plan, group.Detail, resources)
+}
+
 // addRemediationForDetail adds remediation steps for a specific detail
 func (g *Generator) addRemediationForDetail(plan *Plan, detail analyzer.AnalysisDetail, resources []collector.ResourceData) {
 	// Add the remediation steps from the detail
@@ -167,6 +188,9 @@ func (g *Generator) addRemediationForDetail(plan *Plan, detail analyzer.Analysis
 					}
 				}
 			}
+		} else if determineCommandType(cmdStr) == CommandTypeYAML {
+			// Manifests are applied rather than executed
+			plan.YAMLSnippets = append(plan.YAMLSnippets, cmdStr)
 		} else {
 			// This is a direct command
 			plan.Commands = append(plan.Commands, Command{
@@ -178,12 +202,12 @@ func (g *Generator) addRemediationForDetail(plan *Plan, detail analyzer.Analysis
 	}
 }
 
-// filterDetailsByType filters analysis details by their type
-func filterDetailsByType(details []analyzer.AnalysisDetail, detailType string) []analyzer.AnalysisDetail {
-	filtered := make([]analyzer.AnalysisDetail, 0)
-	for _, detail := range details {
-		if detail.Type == detailType {
-			filtered = append(filtered, detail)
+// filterGroupsBySeverity filters finding groups by their severity
+func filterGroupsBySeverity(groups []analyzer.FindingGroup, severity analyzer.Severity) []analyzer.FindingGroup {
+	filtered := make([]analyzer.FindingGroup, 0)
+	for _, group := range groups {
+		if group.Detail.Severity == severity {
+			filtered = append(filtered, group)
 		}
 	}
 	return filtered
@@ -0,0 +1,220 @@
+package snapshot
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+// FormatVersion is the version of the snapshot layout written by Write
+const FormatVersion = 1
+
+// metadataFile is the archive entry holding the snapshot's metadata
+const metadataFile = "snapshot.json"
+
+// resourcesDir is the archive directory holding one <kind>/<namespace>/<name>.json
+// file per collected resource
+const resourcesDir = "resources/"
+
+// clusterScope is the namespace directory of cluster-scoped resources
+const clusterScope = "_cluster"
+
+// Snapshot is a set of collected resources saved so they can be analyzed without a
+// connection to their cluster
+type Snapshot struct {
+	Version   int                      `json:"version"`
+	CreatedAt time.Time                `json:"createdAt"`
+	Source    string                   `json:"source,omitempty"` // kubeconfig context or dump directory
+	Resources []collector.ResourceData `json:"-"`
+}
+
+// New creates a snapshot of the resources
+func New(source string, resources []collector.ResourceData) *Snapshot {
+	return &Snapshot{
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Source:    source,
+		Resources: resources,
+	}
+}
+
+// Write saves the snapshot as a gzipped tarball
+func Write(file string, snapshot *Snapshot) (err error) {
+	out, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write snapshot: %w", closeErr)
+		}
+	}()
+
+	gzipWriter := gzip.NewWriter(out)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	if err := writeEntry(tarWriter, metadataFile, snapshot, snapshot.CreatedAt); err != nil {
+		return err
+	}
+	written := make(map[string]bool)
+	for _, resource := range snapshot.Resources {
+		name := resourceEntry(resource.Resource)
+		// The same resource may be collected twice, such as by name and by selector
+		if written[name] {
+			continue
+		}
+		written[name] = true
+		if err := writeEntry(tarWriter, name, resource, snapshot.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// Read loads a snapshot written by Write
+func Read(file string) (*Snapshot, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	defer in.Close()
+
+	gzipReader, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a k8smed snapshot: %w", file, err)
+	}
+	tarReader := tar.NewReader(gzipReader)
+
+	var snapshot *Snapshot
+	resources := make([]collector.ResourceData, 0)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot: %w", err)
+		}
+
+		switch {
+		case header.Name == metadataFile:
+			snapshot = &Snapshot{}
+			if err := json.NewDecoder(tarReader).Decode(snapshot); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+			}
+		case strings.HasPrefix(header.Name, resourcesDir) && strings.HasSuffix(header.Name, ".json"):
+			var resource collector.ResourceData
+			if err := json.NewDecoder(tarReader).Decode(&resource); err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", header.Name, err)
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	if snapshot == nil {
+		return nil, fmt.Errorf("%s is not a k8smed snapshot: %s is missing", file, metadataFile)
+	}
+	if snapshot.Version > FormatVersion {
+		return nil, fmt.Errorf("snapshot version %d is newer than the supported version %d", snapshot.Version, FormatVersion)
+	}
+	snapshot.Resources = resources
+	return snapshot, nil
+}
+
+// Load loads a snapshot written by Write or, given a directory, the output of
+// `kubectl cluster-info dump --output-directory`
+func Load(ctx context.Context, file string) (*Snapshot, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot: %w", err)
+	}
+	if info.IsDir() {
+		return LoadClusterInfoDump(ctx, file)
+	}
+	return Read(file)
+}
+
+// dumpResourceTypes are the resource types a cluster-info dump holds, which are the
+// kinds a scan lists
+var dumpResourceTypes = []collector.ResourceType{
+	collector.ResourceTypePod,
+	collector.ResourceTypeDeployment,
+	collector.ResourceTypeStatefulSet,
+	collector.ResourceTypeDaemonSet,
+	collector.ResourceTypeService,
+	collector.ResourceTypeNode,
+}
+
+// LoadClusterInfoDump collects every pod, workload, service and node of a cluster-info
+// dump directory, with their events and the logs of the pods, through the regular
+// collectors
+func LoadClusterInfoDump(ctx context.Context, dir string) (*Snapshot, error) {
+	dumpCollector, err := collector.NewClusterInfoDumpCollector(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]collector.ResourceData, 0)
+	for _, resourceType := range dumpResourceTypes {
+		collected, err := dumpCollector.CollectResources(ctx, resourceType, collector.CollectionOptions{
+			IncludeEvents: true,
+			IncludeLogs:   resourceType == collector.ResourceTypePod,
+			TailLines:     200,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect the %ss of the dump: %w", resourceType, err)
+		}
+		for _, data := range collected {
+			resources = append(resources, *data)
+		}
+	}
+	return New("cluster-info dump "+dir, resources), nil
+}
+
+// resourceEntry returns the archive entry of a resource
+func resourceEntry(resource collector.ResourceInfo) string {
+	namespace := resource.Namespace
+	if namespace == "" {
+		namespace = clusterScope
+	}
+	return resourcesDir + path.Join(strings.ToLower(resource.Kind), namespace, resource.Name+".json")
+}
+
+// writeEntry writes value as an indented JSON file to the archive
+func writeEntry(tarWriter *tar.Writer, name string, value interface{}, modTime time.Time) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if _, err := tarWriter.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
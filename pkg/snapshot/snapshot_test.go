@@ -0,0 +1,87 @@
+package snapshot
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/k8smed/k8smed/pkg/collector"
+)
+
+func TestWriteRead(t *testing.T) {
+	pod := collector.ResourceData{
+		Resource: collector.ResourceInfo{Kind: "Pod", Name: "web-7d9f", Namespace: "shop", Labels: map[string]string{"app": "web"}},
+		Status:   map[string]string{"phase": "Running", "container.0.reason": "CrashLoopBackOff"},
+		Events:   []string{"[2024-05-01 10:00:00] Warning BackOff: Back-off restarting failed container (count: 14)"},
+		Logs:     []string{"=== Logs for container: app ===\nFATAL: password authentication failed"},
+		Manifest: "kind: Pod\n",
+	}
+	node := collector.ResourceData{
+		Resource: collector.ResourceInfo{Kind: "Node", Name: "node-1"},
+		Status:   map[string]string{"condition.Ready": "True"},
+	}
+	written := New("kind-dev", []collector.ResourceData{pod, node, pod})
+
+	file := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	if err := Write(file, written); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	read, err := Read(file)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	if read.Version != FormatVersion || read.Source != "kind-dev" || !read.CreatedAt.Equal(written.CreatedAt) {
+		t.Errorf("Unexpected metadata: %+v", read)
+	}
+	// The pod collected twice is written once; entries are read back in archive order
+	if len(read.Resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d", len(read.Resources))
+	}
+	if !reflect.DeepEqual(read.Resources[0], pod) || !reflect.DeepEqual(read.Resources[1], node) {
+		t.Errorf("Resources changed in the round trip: %+v", read.Resources)
+	}
+
+	if _, err := Read(filepath.Join("..", "..", "go.mod")); err == nil || !strings.Contains(err.Error(), "is not a k8smed snapshot") {
+		t.Errorf("Expected a file that is not a snapshot to be rejected, got %v", err)
+	}
+}
+
+func TestLoadClusterInfoDump(t *testing.T) {
+	loaded, err := Load(context.Background(), "../../internal/collector/dump/testdata/cluster-info")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !strings.HasPrefix(loaded.Source, "cluster-info dump ") {
+		t.Errorf("Unexpected source: %s", loaded.Source)
+	}
+	kinds := make([]string, 0, len(loaded.Resources))
+	for _, resource := range loaded.Resources {
+		kinds = append(kinds, resource.Resource.Kind+"/"+resource.Resource.Name)
+	}
+	if got := strings.Join(kinds, ","); got != "Pod/web-7d9f,Deployment/web,Service/web,Node/node-1" {
+		t.Fatalf("Expected the dumped pod, deployment, service and node, got %s", got)
+	}
+
+	deployment := loaded.Resources[1]
+	if deployment.Status["unavailableReplicas"] != "1" || deployment.Status["condition.0.reason"] != "MinimumReplicasUnavailable" {
+		t.Errorf("Unexpected deployment status: %v", deployment.Status)
+	}
+	if service := loaded.Resources[2]; service.Resource.Namespace != "shop" || service.Manifest == "" {
+		t.Errorf("Unexpected service: %+v", service)
+	}
+
+	pod := loaded.Resources[0]
+	if pod.Resource.Name != "web-7d9f" || pod.Status["container.0.reason"] != "CrashLoopBackOff" {
+		t.Errorf("Unexpected pod: %+v", pod.Resource)
+	}
+	if len(pod.Events) != 1 || !strings.Contains(pod.Events[0], "Warning BackOff") {
+		t.Errorf("Expected the pod's BackOff event, got %v", pod.Events)
+	}
+	logs := strings.Join(pod.Logs, "\n")
+	if !strings.Contains(logs, "password authentication failed") || !strings.Contains(logs, "envoy started") {
+		t.Errorf("Expected the logs of both containers, got %q", logs)
+	}
+}